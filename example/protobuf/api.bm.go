@@ -52,3 +52,38 @@ func RegisterUserBMServer(e *bm.Engine, server UserBMServer) {
 	e.GET("/user.api.User/Info", userInfo)
 	e.GET("/user.api.User/Card", userCard)
 }
+
+// UserBMClient is the client API for User service.
+type UserBMClient interface {
+	Info(ctx context.Context, req *UserReq) (resp *InfoReply, err error)
+
+	Card(ctx context.Context, req *UserReq) (resp *google_protobuf1.Empty, err error)
+}
+
+type userBMClient struct {
+	cc   *bm.Client
+	host string
+}
+
+// NewUserBMClient new a blademaster client of User service, host is like http://127.0.0.1:8000
+func NewUserBMClient(cc *bm.Client, host string) UserBMClient {
+	return &userBMClient{cc: cc, host: host}
+}
+
+func (c *userBMClient) Info(ctx context.Context, req *UserReq) (resp *InfoReply, err error) {
+	out := new(InfoReply)
+	if err = c.cc.Invoke(ctx, c.host, &bm.ClientMethod{Method: "GET", Path: PathUserInfo}, req, out); err != nil {
+		return
+	}
+	resp = out
+	return
+}
+
+func (c *userBMClient) Card(ctx context.Context, req *UserReq) (resp *google_protobuf1.Empty, err error) {
+	out := new(google_protobuf1.Empty)
+	if err = c.cc.Invoke(ctx, c.host, &bm.ClientMethod{Method: "GET", Path: PathUserCard}, req, out); err != nil {
+		return
+	}
+	resp = out
+	return
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"kratos/pkg/container/pool"
+	"kratos/pkg/net/netutil/breaker"
 	xtime "kratos/pkg/time"
 )
 
@@ -192,11 +193,17 @@ type Config struct {
 	DialTimeout  xtime.Duration
 	ReadTimeout  xtime.Duration
 	WriteTimeout xtime.Duration
+
+	// Nodes are the weighted nodes of memcache fleet, keys are routed by ketama
+	// consistent hashing if it is not empty, and Addr is ignored.
+	Nodes []*Node
+	// Breaker is the breaker config of each node, the rejected node is ejected from the ring temporarily.
+	Breaker *breaker.Config
 }
 
 // Memcache memcache client
 type Memcache struct {
-	pool *Pool
+	pool connPool
 }
 
 // Reply is the result of Get
@@ -216,8 +223,11 @@ type Replies struct {
 	closed    bool
 }
 
-// New get a memcache client
+// New get a memcache client, it is a client of consistent hash ring if cfg.Nodes is set.
 func New(cfg *Config) *Memcache {
+	if len(cfg.Nodes) > 0 {
+		return &Memcache{pool: newRingPool(cfg)}
+	}
 	return &Memcache{pool: NewPool(cfg)}
 }
 
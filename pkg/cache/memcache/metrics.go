@@ -48,4 +48,11 @@ var (
 		Help:      "memcache client misses total.",
 		Labels:    []string{"name", "addr"},
 	})
+	_metricRingEjected = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: namespace,
+		Subsystem: "ring",
+		Name:      "ejected_total",
+		Help:      "memcache client requests rerouted from the ejected node total.",
+		Labels:    []string{"name", "addr"},
+	})
 )
@@ -0,0 +1,331 @@
+package memcache
+
+import (
+	"context"
+	"crypto/md5"
+	"sort"
+	"strconv"
+	"sync"
+
+	pkgerr "github.com/pkg/errors"
+
+	"kratos/pkg/ecode"
+	"kratos/pkg/net/netutil/breaker"
+)
+
+// _ketamaPoints is the hash points of a node per weight, every md5 digest makes 4 points.
+const _ketamaPoints = 160
+
+// Node is a weighted memcache node of the consistent hash ring.
+type Node struct {
+	Addr string
+	// Weight is the relative weight of node, default 1. the node has 160 points on the ring per weight.
+	Weight int
+}
+
+// connPool is the connection source of Memcache, it is a Pool of the single node or a ring of nodes.
+type connPool interface {
+	Get(ctx context.Context) Conn
+	Close() error
+}
+
+type ringPoint struct {
+	hash uint32
+	addr string
+}
+
+// ringPool routes keys to nodes by ketama consistent hashing, a node rejected by its
+// breaker is ejected temporarily and its keys are routed to the next node on the ring.
+// every node has its own Pool, so the trace and metrics of Pool work for each node.
+type ringPool struct {
+	c      *Config
+	points []ringPoint
+	pools  map[string]*Pool
+	brks   *breaker.Group
+}
+
+func newRingPool(c *Config) *ringPool {
+	p := &ringPool{
+		c:     c,
+		pools: make(map[string]*Pool, len(c.Nodes)),
+		brks:  breaker.NewGroup(c.Breaker),
+	}
+	for _, n := range c.Nodes {
+		if _, ok := p.pools[n.Addr]; ok {
+			continue
+		}
+		nc := *c
+		nc.Addr = n.Addr
+		p.pools[n.Addr] = NewPool(&nc)
+		// NOTE: unlike libketama, points depend on the weight of node only,
+		// so adding or removing a node does not move keys between other nodes.
+		count := _ketamaPoints * nodeWeight(n) / 4
+		for i := 0; i < count; i++ {
+			digest := md5.Sum([]byte(n.Addr + "-" + strconv.Itoa(i)))
+			for j := 0; j < 4; j++ {
+				p.points = append(p.points, ringPoint{hash: ketamaHash(digest, j), addr: n.Addr})
+			}
+		}
+	}
+	sort.Slice(p.points, func(i, j int) bool { return p.points[i].hash < p.points[j].hash })
+	return p
+}
+
+func nodeWeight(n *Node) int {
+	if n.Weight <= 0 {
+		return 1
+	}
+	return n.Weight
+}
+
+func ketamaHash(digest [md5.Size]byte, i int) uint32 {
+	return uint32(digest[3+i*4])<<24 | uint32(digest[2+i*4])<<16 | uint32(digest[1+i*4])<<8 | uint32(digest[i*4])
+}
+
+// node returns the node of key, nodes rejected by breakers are skipped.
+func (p *ringPool) node(key string) (addr string, err error) {
+	if len(p.points) == 0 {
+		return "", ErrPoolClosed
+	}
+	hash := ketamaHash(md5.Sum([]byte(key)), 0)
+	i := sort.Search(len(p.points), func(i int) bool { return p.points[i].hash >= hash })
+	var ejected map[string]bool
+	for n := 0; n < len(p.points); n++ {
+		addr = p.points[(i+n)%len(p.points)].addr
+		if ejected[addr] {
+			continue
+		}
+		brk := p.brks.Get(addr)
+		if brk.Allow() == nil {
+			return addr, nil
+		}
+		// NOTE: the rejected request is counted as failure, so the node keeps ejected until probes succeed.
+		brk.MarkFailed()
+		_metricRingEjected.Inc(p.c.Name, addr)
+		if ejected == nil {
+			ejected = make(map[string]bool)
+		}
+		if ejected[addr] = true; len(ejected) == len(p.pools) {
+			break
+		}
+	}
+	return "", pkgerr.WithStack(ecode.ServiceUnavailable)
+}
+
+// Get gets a ring connection. The application must close the returned connection.
+func (p *ringPool) Get(ctx context.Context) Conn {
+	return &ringConn{p: p, ctx: ctx, ed: newEncodeDecoder(), conns: make(map[string]Conn)}
+}
+
+// Close closes pools of all nodes.
+func (p *ringPool) Close() (err error) {
+	for _, pool := range p.pools {
+		if e := pool.Close(); e != nil {
+			err = e
+		}
+	}
+	return
+}
+
+// ringConn routes commands to node connections by key, connections of nodes
+// are got on demand and closed together.
+type ringConn struct {
+	p     *ringPool
+	ctx   context.Context
+	ed    *encodeDecode
+	conns map[string]Conn
+}
+
+func (rc *ringConn) conn(addr string) Conn {
+	c, ok := rc.conns[addr]
+	if !ok {
+		c = rc.p.pools[addr].Get(rc.ctx)
+		rc.conns[addr] = c
+	}
+	return c
+}
+
+// do calls fn with the connection of key node, broken connections are marked as failures of node.
+func (rc *ringConn) do(key string, fn func(c Conn) error) error {
+	addr, err := rc.p.node(key)
+	if err != nil {
+		return err
+	}
+	c := rc.conn(addr)
+	err = fn(c)
+	rc.mark(addr, c)
+	return err
+}
+
+func (rc *ringConn) mark(addr string, c Conn) {
+	if c.Err() != nil {
+		rc.p.brks.Get(addr).MarkFailed()
+	} else {
+		rc.p.brks.Get(addr).MarkSuccess()
+	}
+}
+
+func (rc *ringConn) Close() (err error) {
+	for addr, c := range rc.conns {
+		if e := c.Close(); e != nil {
+			err = e
+		}
+		delete(rc.conns, addr)
+	}
+	return
+}
+
+func (rc *ringConn) Err() error {
+	for _, c := range rc.conns {
+		if err := c.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rc *ringConn) Add(item *Item) error {
+	return rc.AddContext(rc.ctx, item)
+}
+
+func (rc *ringConn) Set(item *Item) error {
+	return rc.SetContext(rc.ctx, item)
+}
+
+func (rc *ringConn) Replace(item *Item) error {
+	return rc.ReplaceContext(rc.ctx, item)
+}
+
+func (rc *ringConn) Get(key string) (*Item, error) {
+	return rc.GetContext(rc.ctx, key)
+}
+
+func (rc *ringConn) GetMulti(keys []string) (map[string]*Item, error) {
+	return rc.GetMultiContext(rc.ctx, keys)
+}
+
+func (rc *ringConn) Delete(key string) error {
+	return rc.DeleteContext(rc.ctx, key)
+}
+
+func (rc *ringConn) Increment(key string, delta uint64) (uint64, error) {
+	return rc.IncrementContext(rc.ctx, key, delta)
+}
+
+func (rc *ringConn) Decrement(key string, delta uint64) (uint64, error) {
+	return rc.DecrementContext(rc.ctx, key, delta)
+}
+
+func (rc *ringConn) CompareAndSwap(item *Item) error {
+	return rc.CompareAndSwapContext(rc.ctx, item)
+}
+
+func (rc *ringConn) Touch(key string, seconds int32) error {
+	return rc.TouchContext(rc.ctx, key, seconds)
+}
+
+func (rc *ringConn) Scan(item *Item, v interface{}) error {
+	return pkgerr.WithStack(rc.ed.decode(item, v))
+}
+
+func (rc *ringConn) AddContext(ctx context.Context, item *Item) error {
+	return rc.do(item.Key, func(c Conn) error { return c.AddContext(ctx, item) })
+}
+
+func (rc *ringConn) SetContext(ctx context.Context, item *Item) error {
+	return rc.do(item.Key, func(c Conn) error { return c.SetContext(ctx, item) })
+}
+
+func (rc *ringConn) ReplaceContext(ctx context.Context, item *Item) error {
+	return rc.do(item.Key, func(c Conn) error { return c.ReplaceContext(ctx, item) })
+}
+
+func (rc *ringConn) GetContext(ctx context.Context, key string) (item *Item, err error) {
+	err = rc.do(key, func(c Conn) (err error) {
+		item, err = c.GetContext(ctx, key)
+		return
+	})
+	return
+}
+
+// GetMultiContext splits keys by node and gets them from nodes in parallel,
+// the items got are returned with the first error if some nodes failed.
+func (rc *ringConn) GetMultiContext(ctx context.Context, keys []string) (map[string]*Item, error) {
+	if len(keys) == 0 {
+		return make(map[string]*Item), nil
+	}
+	var (
+		groups   = make(map[string][]string)
+		firstErr error
+	)
+	for _, key := range keys {
+		addr, err := rc.p.node(key)
+		if err != nil {
+			firstErr = err
+			continue
+		}
+		groups[addr] = append(groups[addr], key)
+	}
+	type result struct {
+		items map[string]*Item
+		err   error
+	}
+	var (
+		wg      sync.WaitGroup
+		results = make(map[string]*result, len(groups))
+	)
+	for addr, ks := range groups {
+		// NOTE: connections are got before goroutines, conns map is not thread safe.
+		c, r := rc.conn(addr), &result{}
+		results[addr] = r
+		if len(groups) == 1 {
+			r.items, r.err = c.GetMultiContext(ctx, ks)
+			break
+		}
+		wg.Add(1)
+		go func(c Conn, ks []string) {
+			defer wg.Done()
+			r.items, r.err = c.GetMultiContext(ctx, ks)
+		}(c, ks)
+	}
+	wg.Wait()
+	items := make(map[string]*Item, len(keys))
+	for addr, r := range results {
+		rc.mark(addr, rc.conns[addr])
+		if r.err != nil && firstErr == nil {
+			firstErr = r.err
+		}
+		for k, item := range r.items {
+			items[k] = item
+		}
+	}
+	return items, firstErr
+}
+
+func (rc *ringConn) DeleteContext(ctx context.Context, key string) error {
+	return rc.do(key, func(c Conn) error { return c.DeleteContext(ctx, key) })
+}
+
+func (rc *ringConn) IncrementContext(ctx context.Context, key string, delta uint64) (newValue uint64, err error) {
+	err = rc.do(key, func(c Conn) (err error) {
+		newValue, err = c.IncrementContext(ctx, key, delta)
+		return
+	})
+	return
+}
+
+func (rc *ringConn) DecrementContext(ctx context.Context, key string, delta uint64) (newValue uint64, err error) {
+	err = rc.do(key, func(c Conn) (err error) {
+		newValue, err = c.DecrementContext(ctx, key, delta)
+		return
+	})
+	return
+}
+
+func (rc *ringConn) CompareAndSwapContext(ctx context.Context, item *Item) error {
+	return rc.do(item.Key, func(c Conn) error { return c.CompareAndSwapContext(ctx, item) })
+}
+
+func (rc *ringConn) TouchContext(ctx context.Context, key string, seconds int32) error {
+	return rc.do(key, func(c Conn) error { return c.TouchContext(ctx, key, seconds) })
+}
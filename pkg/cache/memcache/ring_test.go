@@ -0,0 +1,253 @@
+package memcache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"kratos/pkg/container/pool"
+	"kratos/pkg/net/netutil/breaker"
+	xtime "kratos/pkg/time"
+)
+
+// fakeServer is an in-process memcached speaks set and gets.
+type fakeServer struct {
+	ln   net.Listener
+	addr string
+
+	mu    sync.Mutex
+	data  map[string][]byte
+	calls map[string]int
+	conns []net.Conn
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen error, %v", err)
+	}
+	s := &fakeServer{ln: ln, addr: ln.Addr().String(), data: make(map[string][]byte), calls: make(map[string]int)}
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns = append(s.conns, c)
+			s.mu.Unlock()
+			go s.handle(c)
+		}
+	}()
+	return s
+}
+
+// Close stops the server and closes active connections.
+func (s *fakeServer) Close() {
+	s.ln.Close()
+	s.mu.Lock()
+	for _, c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+}
+
+func (s *fakeServer) Calls(cmd string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[cmd]
+}
+
+func (s *fakeServer) Has(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.data[key]
+	return ok
+}
+
+func (s *fakeServer) handle(c net.Conn) {
+	defer c.Close()
+	r, w := bufio.NewReader(c), bufio.NewWriter(c)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			return
+		}
+		s.mu.Lock()
+		s.calls[fields[0]]++
+		switch fields[0] {
+		case "set":
+			size, _ := strconv.Atoi(fields[4])
+			buf := make([]byte, size+2)
+			if _, err = io.ReadFull(r, buf); err != nil {
+				s.mu.Unlock()
+				return
+			}
+			s.data[fields[1]] = buf[:size]
+			w.WriteString("STORED\r\n")
+		case "gets":
+			for _, key := range fields[1:] {
+				if v, ok := s.data[key]; ok {
+					fmt.Fprintf(w, "VALUE %s 0 %d 1\r\n%s\r\n", key, len(v), v)
+				}
+			}
+			w.WriteString("END\r\n")
+		default:
+			w.WriteString("ERROR\r\n")
+		}
+		s.mu.Unlock()
+		w.Flush()
+	}
+}
+
+func ringConfig(nodes ...*Node) *Config {
+	return &Config{
+		Config: &pool.Config{
+			Active:      10,
+			Idle:        2,
+			IdleTimeout: xtime.Duration(time.Minute),
+		},
+		Name:         "ring",
+		Proto:        "tcp",
+		DialTimeout:  xtime.Duration(time.Second),
+		ReadTimeout:  xtime.Duration(time.Second),
+		WriteTimeout: xtime.Duration(time.Second),
+		Nodes:        nodes,
+		Breaker: &breaker.Config{
+			Window:  xtime.Duration(time.Second),
+			Bucket:  10,
+			Request: 10,
+			K:       1.5,
+		},
+	}
+}
+
+func TestRingDistribution(t *testing.T) {
+	p := newRingPool(ringConfig(&Node{Addr: "a:11211"}, &Node{Addr: "b:11211", Weight: 2}, &Node{Addr: "c:11211"}))
+	defer p.Close()
+	count := make(map[string]int)
+	owners := make(map[string]string)
+	for i := 0; i < 10000; i++ {
+		key := "key" + strconv.Itoa(i)
+		addr, err := p.node(key)
+		if err != nil {
+			t.Fatalf("node of %s error, %v", key, err)
+		}
+		count[addr]++
+		owners[key] = addr
+	}
+	if count["b:11211"] < count["a:11211"]*3/2 || count["b:11211"] < count["c:11211"]*3/2 {
+		t.Fatalf("keys should be distributed by weight, got %v", count)
+	}
+
+	// adding a node only moves keys to it.
+	p2 := newRingPool(ringConfig(&Node{Addr: "a:11211"}, &Node{Addr: "b:11211", Weight: 2}, &Node{Addr: "c:11211"}, &Node{Addr: "d:11211"}))
+	defer p2.Close()
+	var moved int
+	for key, owner := range owners {
+		addr, _ := p2.node(key)
+		if addr != owner {
+			if addr != "d:11211" {
+				t.Fatalf("key %s moved from %s to %s", key, owner, addr)
+			}
+			moved++
+		}
+	}
+	if moved == 0 || moved > 4000 {
+		t.Fatalf("about a quarter of keys should be moved, got %d", moved)
+	}
+}
+
+func TestRing(t *testing.T) {
+	var (
+		servers []*fakeServer
+		nodes   []*Node
+	)
+	for i := 0; i < 3; i++ {
+		s := newFakeServer(t)
+		defer s.Close()
+		servers = append(servers, s)
+		nodes = append(nodes, &Node{Addr: s.addr})
+	}
+	mc := New(ringConfig(nodes...))
+	defer mc.Close()
+	ctx := context.TODO()
+
+	var keys []string
+	for i := 0; i < 30; i++ {
+		key := "key" + strconv.Itoa(i)
+		keys = append(keys, key)
+		if err := mc.Set(ctx, &Item{Key: key, Value: []byte(strconv.Itoa(i))}); err != nil {
+			t.Fatalf("set %s error, %v", key, err)
+		}
+	}
+	for _, s := range servers {
+		if s.Calls("set") == 0 {
+			t.Fatalf("keys should be spread over nodes")
+		}
+	}
+	var v string
+	if err := mc.Get(ctx, keys[1]).Scan(&v); err != nil || v != "1" {
+		t.Fatalf("get got %s, %v", v, err)
+	}
+
+	calls := make([]int, len(servers))
+	for i, s := range servers {
+		calls[i] = s.Calls("gets")
+	}
+	rs, err := mc.GetMulti(ctx, append(keys, "missing"))
+	if err != nil {
+		t.Fatalf("get multi error, %v", err)
+	}
+	if len(rs.Keys()) != len(keys) {
+		t.Fatalf("get multi got %d keys", len(rs.Keys()))
+	}
+	for i, key := range keys {
+		if err = rs.Scan(key, &v); err != nil || v != strconv.Itoa(i) {
+			t.Fatalf("get multi %s got %s, %v", key, v, err)
+		}
+	}
+	for i, s := range servers {
+		if s.Calls("gets") != calls[i]+1 {
+			t.Fatalf("get multi should be split to all nodes")
+		}
+	}
+
+	// the failing node is ejected and its keys are routed to other nodes.
+	down := servers[0]
+	down.Close()
+	var key string
+	for _, k := range keys {
+		if down.Has(k) {
+			key = k
+			break
+		}
+	}
+	for i := 0; i < 100; i++ {
+		mc.Set(ctx, &Item{Key: key, Value: []byte("v")})
+	}
+	// NOTE: the breaker lets a few requests through to probe the node.
+	var failed int
+	for i := 0; i < 100; i++ {
+		if err = mc.Set(ctx, &Item{Key: key, Value: []byte("v")}); err != nil {
+			failed++
+		}
+	}
+	if failed > 50 {
+		t.Fatalf("set to ejected node should be rerouted, %d failed", failed)
+	}
+	if !servers[1].Has(key) && !servers[2].Has(key) {
+		t.Fatalf("key %s should be stored in other nodes", key)
+	}
+}
@@ -0,0 +1,41 @@
+package multicache
+
+import "kratos/pkg/stat/metric"
+
+const _metricNamespace = "cache"
+
+const (
+	_tierLocal  = "local"
+	_tierRemote = "remote"
+)
+
+var (
+	_metricHits = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: _metricNamespace,
+		Subsystem: "multi",
+		Name:      "hits_total",
+		Help:      "multi level cache hits total by tier.",
+		Labels:    []string{"name", "tier"},
+	})
+	_metricMisses = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: _metricNamespace,
+		Subsystem: "multi",
+		Name:      "misses_total",
+		Help:      "multi level cache misses total by tier.",
+		Labels:    []string{"name", "tier"},
+	})
+	_metricLoads = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: _metricNamespace,
+		Subsystem: "multi",
+		Name:      "loads_total",
+		Help:      "multi level cache loads total of misses.",
+		Labels:    []string{"name", "state"},
+	})
+	_metricInvalidations = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: _metricNamespace,
+		Subsystem: "multi",
+		Name:      "invalidations_total",
+		Help:      "multi level cache invalidations received from other instances.",
+		Labels:    []string{"name"},
+	})
+)
@@ -0,0 +1,260 @@
+// Package multicache is a two level cache, it reads through a per process
+// lrucache.SyncCache to a shared Remote (redis or memcache).
+//
+// Misses of both tiers are loaded once per key by singleflight, and writes
+// are broadcasted by redis Pub/Sub so other instances evict their local copy.
+// NOTE: invalidations published while an instance is disconnected from redis
+// are lost, the local copy of that instance is stale until LocalExpire.
+package multicache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"golang.org/x/sync/singleflight"
+
+	"kratos/pkg/cache/lrucache"
+	"kratos/pkg/cache/xredis"
+	"kratos/pkg/conf/env"
+	"kratos/pkg/log"
+	xtime "kratos/pkg/time"
+)
+
+var (
+	// ErrNotFound is returned when the key is missing in both tiers.
+	ErrNotFound = errors.New("multicache: key not found")
+	// ErrClosed is returned after the cache is closed.
+	ErrClosed = errors.New("multicache: closed")
+)
+
+// Config is the multi level cache config.
+type Config struct {
+	Name string
+	// LocalCapacity is the lru capacity of each local bucket, default 1024.
+	LocalCapacity int
+	// LocalBucket is the bucket count of local cache, default 16.
+	LocalBucket int
+	// LocalExpire is the expiration of local items in seconds granularity, default 1m.
+	LocalExpire xtime.Duration
+	// RemoteExpire is the expiration of remote items, zero means never expire.
+	RemoteExpire xtime.Duration
+	// Channel is the redis Pub/Sub channel of invalidations, default "multicache:{Name}".
+	Channel string
+}
+
+func (c *Config) fix() {
+	if c.LocalCapacity <= 0 {
+		c.LocalCapacity = 1024
+	}
+	if c.LocalBucket <= 0 {
+		c.LocalBucket = 16
+	}
+	if c.LocalExpire < xtime.Duration(time.Second) {
+		c.LocalExpire = xtime.Duration(time.Minute)
+	}
+	if c.Channel == "" {
+		c.Channel = "multicache:" + c.Name
+	}
+}
+
+// Cache is the multi level cache, it's safe for concurrent use.
+type Cache struct {
+	c      *Config
+	id     string
+	local  *lrucache.SyncCache
+	remote Remote
+	pubsub *xredis.Client
+	sub    *xredis.PubSub
+	sf     singleflight.Group
+
+	wg     sync.WaitGroup
+	mu     sync.RWMutex
+	closed bool
+}
+
+// New new a multi level cache on the remote tier, invalidations are broadcasted
+// through pubsub, nil pubsub disables the broadcast.
+func New(c *Config, remote Remote, pubsub *xredis.Client) *Cache {
+	if c == nil || remote == nil {
+		panic("multicache: config and remote can not be nil")
+	}
+	nc := *c
+	nc.fix()
+	cc := &Cache{
+		c:      &nc,
+		id:     fmt.Sprintf("%s-%d-%d", env.Hostname, os.Getpid(), time.Now().UnixNano()),
+		local:  lrucache.NewSyncCache(nc.LocalCapacity, nc.LocalBucket, int64(time.Duration(nc.LocalExpire)/time.Second)),
+		remote: remote,
+		pubsub: pubsub,
+	}
+	if pubsub != nil {
+		cc.sub = pubsub.Subscribe(context.Background(), nc.Channel)
+		cc.wg.Add(1)
+		go cc.invalidateproc(cc.sub.Channel())
+	}
+	return cc
+}
+
+// invalidateproc evicts local copies written by other instances, the payload is "{id} {key}".
+func (c *Cache) invalidateproc(ch <-chan *xredis.Message) {
+	defer c.wg.Done()
+	for msg := range ch {
+		idx := strings.IndexByte(msg.Payload, ' ')
+		if idx < 0 || msg.Payload[:idx] == c.id {
+			continue
+		}
+		c.local.Delete(msg.Payload[idx+1:])
+		_metricInvalidations.Inc(c.c.Name)
+	}
+}
+
+func (c *Cache) broadcast(ctx context.Context, key string) {
+	if c.pubsub == nil {
+		return
+	}
+	if err := c.pubsub.Publish(ctx, c.c.Channel, c.id+" "+key).Err(); err != nil {
+		log.Errorc(ctx, "multicache: publish invalidation key(%s) error(%v)", key, err)
+	}
+}
+
+// Get gets the value of key into v, the local tier is read first and remote
+// hits are cached locally. ErrNotFound is returned if both tiers miss.
+func (c *Cache) Get(ctx context.Context, key string, v interface{}) error {
+	bs, err := c.get(ctx, key)
+	if err != nil {
+		return err
+	}
+	return decode(bs, v)
+}
+
+func (c *Cache) get(ctx context.Context, key string) ([]byte, error) {
+	if c.isClosed() {
+		return nil, ErrClosed
+	}
+	if bs, ok := c.local.Get(key); ok {
+		_metricHits.Inc(c.c.Name, _tierLocal)
+		return bs.([]byte), nil
+	}
+	_metricMisses.Inc(c.c.Name, _tierLocal)
+	bs, err := c.remote.Get(ctx, key)
+	if err != nil {
+		if err == ErrNotFound {
+			_metricMisses.Inc(c.c.Name, _tierRemote)
+		}
+		return nil, err
+	}
+	_metricHits.Inc(c.c.Name, _tierRemote)
+	c.local.Put(key, bs)
+	return bs, nil
+}
+
+// Fetch gets the value of key into v like Get, misses of both tiers are loaded
+// by load once per key across concurrent callers and stored into both tiers.
+func (c *Cache) Fetch(ctx context.Context, key string, v interface{}, load func(ctx context.Context) (interface{}, error)) error {
+	bs, err := c.get(ctx, key)
+	if err == ErrNotFound {
+		var res interface{}
+		res, err, _ = c.sf.Do(key, func() (interface{}, error) {
+			val, err := load(ctx)
+			if err != nil {
+				_metricLoads.Inc(c.c.Name, "error")
+				return nil, err
+			}
+			_metricLoads.Inc(c.c.Name, "ok")
+			bs, err := encode(val)
+			if err != nil {
+				return nil, err
+			}
+			if err = c.set(ctx, key, bs); err != nil {
+				log.Errorc(ctx, "multicache: set loaded key(%s) error(%v)", key, err)
+			}
+			return bs, nil
+		})
+		if err == nil {
+			bs = res.([]byte)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return decode(bs, v)
+}
+
+// Set sets the value of key into both tiers, and the local copies of other instances are evicted.
+func (c *Cache) Set(ctx context.Context, key string, v interface{}) error {
+	if c.isClosed() {
+		return ErrClosed
+	}
+	bs, err := encode(v)
+	if err != nil {
+		return err
+	}
+	return c.set(ctx, key, bs)
+}
+
+func (c *Cache) set(ctx context.Context, key string, bs []byte) error {
+	if err := c.remote.Set(ctx, key, bs, time.Duration(c.c.RemoteExpire)); err != nil {
+		c.local.Delete(key)
+		return err
+	}
+	c.local.Put(key, bs)
+	c.broadcast(ctx, key)
+	return nil
+}
+
+// Delete deletes key from both tiers, and the local copies of other instances are evicted.
+func (c *Cache) Delete(ctx context.Context, key string) error {
+	if c.isClosed() {
+		return ErrClosed
+	}
+	c.local.Delete(key)
+	if err := c.remote.Delete(ctx, key); err != nil {
+		return err
+	}
+	c.broadcast(ctx, key)
+	return nil
+}
+
+func (c *Cache) isClosed() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.closed
+}
+
+// Close stops receiving invalidations, the remote and pubsub clients are not closed.
+func (c *Cache) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	c.closed = true
+	c.mu.Unlock()
+	if c.sub != nil {
+		c.sub.Close()
+	}
+	c.wg.Wait()
+	return nil
+}
+
+// encode encodes v by protobuf if v is a proto.Message, otherwise by json.
+func encode(v interface{}) ([]byte, error) {
+	if pb, ok := v.(proto.Message); ok {
+		return proto.Marshal(pb)
+	}
+	return json.Marshal(v)
+}
+
+func decode(bs []byte, v interface{}) error {
+	if pb, ok := v.(proto.Message); ok {
+		return proto.Unmarshal(bs, pb)
+	}
+	return json.Unmarshal(bs, v)
+}
@@ -0,0 +1,146 @@
+package multicache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"kratos/pkg/cache/redis"
+	"kratos/pkg/cache/redis/redistest"
+	"kratos/pkg/cache/xredis"
+	xtime "kratos/pkg/time"
+)
+
+type user struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+func newTestCache(t *testing.T, conf *redis.Config) *Cache {
+	r := redis.NewRedis(conf)
+	ps := xredis.New(conf)
+	c := New(&Config{Name: "test", RemoteExpire: xtime.Duration(time.Minute)}, NewRedisRemote(r), ps)
+	t.Cleanup(func() {
+		c.Close()
+		ps.Close()
+		r.Close()
+	})
+	return c
+}
+
+func TestCache(t *testing.T) {
+	s, conf := redistest.New(t)
+	c := newTestCache(t, conf)
+	ctx := context.TODO()
+
+	var (
+		u   user
+		err error
+	)
+	if err = c.Get(ctx, "u1", &u); err != ErrNotFound {
+		t.Fatalf("get missing key should be ErrNotFound, %v", err)
+	}
+	if err = c.Set(ctx, "u1", &user{ID: 1, Name: "kratos"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Get(ctx, "u1", &u); err != nil || u.Name != "kratos" {
+		t.Fatalf("get got %+v, %v", u, err)
+	}
+	if ttl := s.TTL("u1"); ttl != time.Minute {
+		t.Fatalf("remote ttl should be 1m, got %v", ttl)
+	}
+	// the local tier serves the key even if the remote is changed behind the cache.
+	s.Set("u1", `{"id":1,"name":"remote"}`)
+	if err = c.Get(ctx, "u1", &u); err != nil || u.Name != "kratos" {
+		t.Fatalf("get from local got %+v, %v", u, err)
+	}
+	if err = c.Delete(ctx, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if s.Exists("u1") {
+		t.Fatalf("remote key should be deleted")
+	}
+	if err = c.Get(ctx, "u1", &u); err != ErrNotFound {
+		t.Fatalf("get deleted key should be ErrNotFound, %v", err)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	s, conf := redistest.New(t)
+	c := newTestCache(t, conf)
+	ctx := context.TODO()
+
+	var (
+		err   error
+		loads int32
+		start = make(chan struct{})
+		wg    sync.WaitGroup
+	)
+	load := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&loads, 1)
+		<-start
+		return &user{ID: 2, Name: "loaded"}, nil
+	}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var u user
+			if err := c.Fetch(ctx, "u2", &u, load); err != nil || u.Name != "loaded" {
+				t.Errorf("fetch got %+v, %v", u, err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(start)
+	wg.Wait()
+	if n := atomic.LoadInt32(&loads); n != 1 {
+		t.Fatalf("concurrent misses should be loaded once, got %d", n)
+	}
+	if !s.Exists("u2") {
+		t.Fatalf("loaded value should be stored in remote")
+	}
+
+	errLoad := errors.New("load error")
+	var u user
+	if err = c.Fetch(ctx, "u3", &u, func(ctx context.Context) (interface{}, error) { return nil, errLoad }); err != errLoad {
+		t.Fatalf("fetch should return load error, %v", err)
+	}
+}
+
+func TestInvalidation(t *testing.T) {
+	s, conf := redistest.New(t)
+	c1 := newTestCache(t, conf)
+	c2 := newTestCache(t, conf)
+	ctx := context.TODO()
+
+	var err error
+	if err = c1.Set(ctx, "u1", &user{ID: 1, Name: "v1"}); err != nil {
+		t.Fatal(err)
+	}
+	var u user
+	if err = c2.Get(ctx, "u1", &u); err != nil || u.Name != "v1" {
+		t.Fatalf("get got %+v, %v", u, err)
+	}
+	if err = c1.Set(ctx, "u1", &user{ID: 1, Name: "v2"}); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if err = c2.Get(ctx, "u1", &u); err == nil && u.Name == "v2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("local copy of other instance should be evicted, got %+v, %v", u, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// the instance does not evict its own write.
+	s.Set("u1", `{"id":1,"name":"remote"}`)
+	if err = c1.Get(ctx, "u1", &u); err != nil || u.Name != "v2" {
+		t.Fatalf("get from local got %+v, %v", u, err)
+	}
+}
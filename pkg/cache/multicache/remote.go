@@ -0,0 +1,75 @@
+package multicache
+
+import (
+	"context"
+	"time"
+
+	"kratos/pkg/cache/memcache"
+	"kratos/pkg/cache/redis"
+)
+
+// Remote is the shared tier of Cache, values are encoded bytes.
+type Remote interface {
+	// Get returns ErrNotFound if the key is missing.
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, expire time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
+
+type redisRemote struct {
+	r *redis.Redis
+}
+
+// NewRedisRemote returns a Remote backed by redis.
+func NewRedisRemote(r *redis.Redis) Remote {
+	return &redisRemote{r: r}
+}
+
+func (r *redisRemote) Get(ctx context.Context, key string) ([]byte, error) {
+	bs, err := redis.Bytes(r.r.Do(ctx, "GET", key))
+	if err == redis.ErrNil {
+		return nil, ErrNotFound
+	}
+	return bs, err
+}
+
+func (r *redisRemote) Set(ctx context.Context, key string, value []byte, expire time.Duration) (err error) {
+	if expire > 0 {
+		_, err = r.r.Do(ctx, "SET", key, value, "PX", int64(expire/time.Millisecond))
+	} else {
+		_, err = r.r.Do(ctx, "SET", key, value)
+	}
+	return
+}
+
+func (r *redisRemote) Delete(ctx context.Context, key string) (err error) {
+	_, err = r.r.Do(ctx, "DEL", key)
+	return
+}
+
+type memcacheRemote struct {
+	mc *memcache.Memcache
+}
+
+// NewMemcacheRemote returns a Remote backed by memcache.
+func NewMemcacheRemote(mc *memcache.Memcache) Remote {
+	return &memcacheRemote{mc: mc}
+}
+
+func (m *memcacheRemote) Get(ctx context.Context, key string) (bs []byte, err error) {
+	if err = m.mc.Get(ctx, key).Scan(&bs); err == memcache.ErrNotFound {
+		err = ErrNotFound
+	}
+	return
+}
+
+func (m *memcacheRemote) Set(ctx context.Context, key string, value []byte, expire time.Duration) error {
+	return m.mc.Set(ctx, &memcache.Item{Key: key, Value: value, Flags: memcache.FlagRAW, Expiration: int32(expire / time.Second)})
+}
+
+func (m *memcacheRemote) Delete(ctx context.Context, key string) (err error) {
+	if err = m.mc.Delete(ctx, key); err == memcache.ErrNotFound {
+		err = nil
+	}
+	return
+}
@@ -0,0 +1,826 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"kratos/pkg/log"
+)
+
+const (
+	_clusterSlots        = 16384
+	_defaultMaxRedirects = 3
+)
+
+var (
+	errClusterReceive = errors.New("redis: cluster conn receive without pending reply")
+	errNoClusterNode  = errors.New("redis: no available cluster node")
+	errCrossSlot      = errors.New("redis: CROSSSLOT keys of transaction don't hash to the same slot")
+	errExecAbort      = errors.New("redis: EXECABORT transaction discarded because of previous errors")
+)
+
+// clusterPool routes commands to the master of the key slot, the slot map is
+// loaded by CLUSTER SLOTS and fixed by MOVED redirects. every node has its own
+// Pool, so the trace, metrics and slowlog hooks of Pool work for each node.
+type clusterPool struct {
+	c       *Config
+	options []DialOption
+	addrs   []string
+
+	mu        sync.RWMutex
+	slots     [_clusterSlots]string
+	pools     map[string]*Pool
+	reloading int32
+}
+
+func newClusterPool(c *Config, options ...DialOption) *clusterPool {
+	addrs := c.Addrs
+	if len(addrs) == 0 && c.Addr != "" {
+		addrs = []string{c.Addr}
+	}
+	p := &clusterPool{
+		c:       c,
+		options: options,
+		addrs:   addrs,
+		pools:   make(map[string]*Pool),
+	}
+	if err := p.reload(); err != nil {
+		log.Error("redis: cluster(%s) load slots error(%v)", c.Name, err)
+	}
+	return p
+}
+
+// Get gets a cluster connection. The application must close the returned connection.
+func (p *clusterPool) Get(ctx context.Context) Conn {
+	return &clusterConn{p: p, ctx: ctx, conns: make(map[string]Conn), txSlot: -1}
+}
+
+// Close closes pools of all nodes.
+func (p *clusterPool) Close() (err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for addr, pool := range p.pools {
+		if e := pool.Close(); e != nil {
+			err = e
+		}
+		delete(p.pools, addr)
+	}
+	return
+}
+
+// node returns the pool of node addr.
+func (p *clusterPool) node(addr string) *Pool {
+	p.mu.RLock()
+	pool, ok := p.pools[addr]
+	p.mu.RUnlock()
+	if ok {
+		return pool
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if pool, ok = p.pools[addr]; ok {
+		return pool
+	}
+	c := *p.c
+	c.Addr = addr
+	pool = NewPool(&c, p.options...)
+	p.pools[addr] = pool
+	return pool
+}
+
+// slotAddr returns the master of slot, any known node if the slot is not loaded.
+func (p *clusterPool) slotAddr(slot int) string {
+	p.mu.RLock()
+	addr := p.slots[slot]
+	p.mu.RUnlock()
+	if addr != "" {
+		return addr
+	}
+	return p.anyAddr()
+}
+
+func (p *clusterPool) anyAddr() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, addr := range p.slots {
+		if addr != "" {
+			return addr
+		}
+	}
+	if len(p.addrs) > 0 {
+		return p.addrs[0]
+	}
+	return ""
+}
+
+func (p *clusterPool) setSlot(slot int, addr string) {
+	p.mu.Lock()
+	p.slots[slot] = addr
+	p.mu.Unlock()
+}
+
+// reload loads the slot map from the first available node.
+func (p *clusterPool) reload() (err error) {
+	seen := make(map[string]bool)
+	var addrs []string
+	p.mu.RLock()
+	for _, addr := range p.slots {
+		if addr != "" && !seen[addr] {
+			seen[addr] = true
+			addrs = append(addrs, addr)
+		}
+	}
+	p.mu.RUnlock()
+	for _, addr := range p.addrs {
+		if !seen[addr] {
+			seen[addr] = true
+			addrs = append(addrs, addr)
+		}
+	}
+	err = errNoClusterNode
+	for _, addr := range addrs {
+		var slots [_clusterSlots]string
+		if slots, err = p.loadSlots(addr); err != nil {
+			continue
+		}
+		p.mu.Lock()
+		p.slots = slots
+		p.mu.Unlock()
+		return
+	}
+	return
+}
+
+func (p *clusterPool) loadSlots(addr string) (slots [_clusterSlots]string, err error) {
+	conn := p.node(addr).Get(context.Background())
+	defer conn.Close()
+	ranges, err := Values(conn.Do("CLUSTER", "SLOTS"))
+	if err != nil {
+		return
+	}
+	for _, r := range ranges {
+		var (
+			rg     []interface{}
+			master []interface{}
+			start  int
+			end    int
+			host   string
+			port   int
+		)
+		if rg, err = Values(r, nil); err != nil || len(rg) < 3 {
+			return slots, fmt.Errorf("redis: unexpected cluster slots reply %v", r)
+		}
+		if start, err = Int(rg[0], nil); err != nil {
+			return
+		}
+		if end, err = Int(rg[1], nil); err != nil {
+			return
+		}
+		if master, err = Values(rg[2], nil); err != nil || len(master) < 2 {
+			return slots, fmt.Errorf("redis: unexpected cluster slots reply %v", r)
+		}
+		if host, err = String(master[0], nil); err != nil {
+			return
+		}
+		if port, err = Int(master[1], nil); err != nil {
+			return
+		}
+		if host == "" {
+			// empty host means the node we asked.
+			host, _, _ = net.SplitHostPort(addr)
+		}
+		node := net.JoinHostPort(host, strconv.Itoa(port))
+		for slot := start; slot <= end && slot < _clusterSlots; slot++ {
+			slots[slot] = node
+		}
+	}
+	return
+}
+
+// reloadAsync reloads the slot map in background, concurrent calls are merged.
+func (p *clusterPool) reloadAsync() {
+	if !atomic.CompareAndSwapInt32(&p.reloading, 0, 1) {
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&p.reloading, 0)
+		if err := p.reload(); err != nil {
+			log.Error("redis: cluster(%s) reload slots error(%v)", p.c.Name, err)
+		}
+	}()
+}
+
+// redirect parses MOVED or ASK error, MOVED updates the slot map.
+func (p *clusterPool) redirect(err error) (addr string, ask bool) {
+	e, ok := err.(Error)
+	if !ok {
+		return
+	}
+	fields := strings.Fields(string(e))
+	if len(fields) != 3 || (fields[0] != "MOVED" && fields[0] != "ASK") {
+		return
+	}
+	slot, serr := strconv.Atoi(fields[1])
+	if serr != nil || slot < 0 || slot >= _clusterSlots {
+		return
+	}
+	if fields[0] == "ASK" {
+		return fields[2], true
+	}
+	p.setSlot(slot, fields[2])
+	p.reloadAsync()
+	return fields[2], false
+}
+
+func (p *clusterPool) maxRedirects() int {
+	if p.c.MaxRedirects > 0 {
+		return p.c.MaxRedirects
+	}
+	return _defaultMaxRedirects
+}
+
+// crc16 is the CRC16-CCITT(XMODEM) checksum used by redis cluster.
+func crc16(key string) (crc uint16) {
+	for i := 0; i < len(key); i++ {
+		crc ^= uint16(key[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return
+}
+
+// keySlot returns the slot of key, only the hash tag is hashed if the key contains one.
+func keySlot(key string) int {
+	if s := strings.IndexByte(key, '{'); s >= 0 {
+		if e := strings.IndexByte(key[s+1:], '}'); e > 0 {
+			key = key[s+1 : s+1+e]
+		}
+	}
+	return int(crc16(key) % _clusterSlots)
+}
+
+func argString(arg interface{}) string {
+	switch arg := arg.(type) {
+	case string:
+		return arg
+	case []byte:
+		return string(arg)
+	}
+	return fmt.Sprint(arg)
+}
+
+// _keylessCommands are sent to the node of the last command.
+var _keylessCommands = map[string]bool{
+	"": true, "ASKING": true, "AUTH": true, "BGSAVE": true, "CLIENT": true, "CLUSTER": true,
+	"COMMAND": true, "CONFIG": true, "DBSIZE": true, "DISCARD": true, "ECHO": true, "EXEC": true,
+	"FLUSHALL": true, "FLUSHDB": true, "INFO": true, "KEYS": true, "LASTSAVE": true, "MULTI": true,
+	"PING": true, "PSUBSCRIBE": true, "PUBLISH": true, "PUNSUBSCRIBE": true, "RANDOMKEY": true,
+	"READONLY": true, "ROLE": true, "SAVE": true, "SCAN": true, "SCRIPT": true, "SELECT": true,
+	"SLOWLOG": true, "SUBSCRIBE": true, "TIME": true, "UNSUBSCRIBE": true, "UNWATCH": true, "WAIT": true,
+}
+
+// commandKey returns the first key of the command, ok is false if the command has no key.
+func commandKey(cmd string, args []interface{}) (key string, ok bool) {
+	switch cmd = strings.ToUpper(cmd); {
+	case _keylessCommands[cmd]:
+		return
+	case cmd == "EVAL" || cmd == "EVALSHA":
+		if len(args) > 2 {
+			if n, err := strconv.Atoi(argString(args[1])); err == nil && n > 0 {
+				return argString(args[2]), true
+			}
+		}
+		return
+	case cmd == "XREAD" || cmd == "XREADGROUP":
+		for i, arg := range args {
+			if strings.ToUpper(argString(arg)) == "STREAMS" && i+1 < len(args) {
+				return argString(args[i+1]), true
+			}
+		}
+		return
+	case cmd == "XGROUP" || cmd == "XINFO" || cmd == "OBJECT":
+		// the key follows the sub command.
+		if len(args) > 1 {
+			return argString(args[1]), true
+		}
+		return
+	}
+	if len(args) == 0 {
+		return
+	}
+	return argString(args[0]), true
+}
+
+// commandKeys returns all keys of the command.
+func commandKeys(cmd string, args []interface{}) []string {
+	upper := strings.ToUpper(cmd)
+	if step, ok := _multiKeyCommands[upper]; ok || upper == "WATCH" {
+		if !ok {
+			step = 1
+		}
+		keys := make([]string, 0, len(args)/step)
+		for i := 0; i < len(args); i += step {
+			keys = append(keys, argString(args[i]))
+		}
+		return keys
+	}
+	if upper == "EVAL" || upper == "EVALSHA" {
+		if len(args) > 1 {
+			if n, err := strconv.Atoi(argString(args[1])); err == nil && n > 0 && len(args) >= n+2 {
+				keys := make([]string, 0, n)
+				for _, arg := range args[2 : n+2] {
+					keys = append(keys, argString(arg))
+				}
+				return keys
+			}
+		}
+		return nil
+	}
+	if key, ok := commandKey(cmd, args); ok {
+		return []string{key}
+	}
+	return nil
+}
+
+// _multiKeyCommands are split by slot when their keys span slots, the value is the step of keys.
+var _multiKeyCommands = map[string]int{
+	"DEL":    1,
+	"EXISTS": 1,
+	"MGET":   1,
+	"MSET":   2,
+	"TOUCH":  1,
+	"UNLINK": 1,
+}
+
+// slotCmd is a sub command of a split multi-key command.
+type slotCmd struct {
+	cmd
+	index []int
+}
+
+// splitCommand splits the multi-key command by slot, it returns nil if all keys are in one slot.
+func splitCommand(cmdName string, args []interface{}) []*slotCmd {
+	step, ok := _multiKeyCommands[strings.ToUpper(cmdName)]
+	if !ok || len(args) < step*2 || len(args)%step != 0 {
+		return nil
+	}
+	var (
+		scs    []*slotCmd
+		bySlot = make(map[int]*slotCmd)
+	)
+	for i := 0; i < len(args); i += step {
+		slot := keySlot(argString(args[i]))
+		sc, ok := bySlot[slot]
+		if !ok {
+			sc = &slotCmd{cmd: cmd{commandName: cmdName}}
+			bySlot[slot] = sc
+			scs = append(scs, sc)
+		}
+		sc.args = append(sc.args, args[i:i+step]...)
+		sc.index = append(sc.index, i/step)
+	}
+	if len(scs) == 1 {
+		return nil
+	}
+	return scs
+}
+
+// mergeReplies merges replies of split sub commands in the order of keys.
+func mergeReplies(cmdName string, scs []*slotCmd, rps []*reply) (interface{}, error) {
+	for _, rp := range rps {
+		if rp.err != nil {
+			return nil, rp.err
+		}
+	}
+	switch strings.ToUpper(cmdName) {
+	case "MGET":
+		var n int
+		for _, sc := range scs {
+			n += len(sc.index)
+		}
+		res := make([]interface{}, n)
+		for i, sc := range scs {
+			vs, err := Values(rps[i].reply, nil)
+			if err != nil {
+				return nil, err
+			}
+			if len(vs) != len(sc.index) {
+				return nil, fmt.Errorf("redis: unexpected mget reply %v", vs)
+			}
+			for j, idx := range sc.index {
+				res[idx] = vs[j]
+			}
+		}
+		return res, nil
+	case "MSET":
+		return okReply, nil
+	}
+	var sum int64
+	for _, rp := range rps {
+		n, err := Int64(rp.reply, nil)
+		if err != nil {
+			return nil, err
+		}
+		sum += n
+	}
+	return sum, nil
+}
+
+// clusterConn is a connection of cluster, it holds a connection to each node it talked to.
+// pipelined commands are grouped by node and sent concurrently on Flush.
+type clusterConn struct {
+	p     *clusterPool
+	ctx   context.Context
+	conns map[string]Conn
+	// addr is the node of the last command, keyless commands like EXEC are sent to it.
+	addr string
+	// multi defers MULTI until the node of the transaction is known, that is
+	// the node of the first keyed command, or the node of WATCH.
+	multi bool
+	// keys of a transaction must be in one slot from WATCH or MULTI until EXEC
+	// or DISCARD, txSlot is -1 until the first key, txAbort is set if a queued
+	// command is rejected or redirected so that EXEC discards the transaction.
+	watching, inMulti, txAbort bool
+	txSlot                     int
+
+	pending []*cmd
+	replies []*reply
+}
+
+func (cc *clusterConn) conn(addr string) Conn {
+	c, ok := cc.conns[addr]
+	if !ok {
+		c = cc.p.node(addr).Get(cc.ctx)
+		cc.conns[addr] = c
+	}
+	return c
+}
+
+// txCheck tracks the transaction of the command, it returns errCrossSlot if the
+// keys are not in the slot of transaction and errExecAbort on EXEC of an
+// aborted transaction.
+func (cc *clusterConn) txCheck(cmdName string, args []interface{}) error {
+	switch strings.ToUpper(cmdName) {
+	case "WATCH":
+		cc.watching = true
+	case "MULTI":
+		cc.inMulti = true
+		return nil
+	case "UNWATCH":
+		if !cc.inMulti {
+			cc.resetTx()
+		}
+		return nil
+	case "EXEC", "DISCARD":
+		abort := cc.txAbort && strings.ToUpper(cmdName) == "EXEC"
+		cc.resetTx()
+		if abort {
+			return errExecAbort
+		}
+		return nil
+	}
+	if !cc.watching && !cc.inMulti {
+		return nil
+	}
+	for _, key := range commandKeys(cmdName, args) {
+		slot := keySlot(key)
+		if cc.txSlot < 0 {
+			cc.txSlot = slot
+		}
+		if slot != cc.txSlot {
+			if cc.inMulti {
+				cc.txAbort = true
+			}
+			return errCrossSlot
+		}
+	}
+	return nil
+}
+
+func (cc *clusterConn) resetTx() {
+	cc.watching, cc.inMulti, cc.txAbort = false, false, false
+	cc.txSlot = -1
+}
+
+// route returns the node of the command, commands of a started transaction
+// are sent to the node of MULTI.
+func (cc *clusterConn) route(cmdName string, args []interface{}) string {
+	if cc.inMulti && !cc.multi && cc.addr != "" {
+		return cc.addr
+	}
+	if key, ok := commandKey(cmdName, args); ok {
+		return cc.p.slotAddr(keySlot(key))
+	}
+	if cc.addr != "" {
+		return cc.addr
+	}
+	return cc.p.anyAddr()
+}
+
+func (cc *clusterConn) Do(commandName string, args ...interface{}) (reply interface{}, err error) {
+	if commandName == "" {
+		if err = cc.Flush(); err != nil || len(cc.replies) == 0 {
+			return
+		}
+		rs := make([]interface{}, 0, len(cc.replies))
+		for _, rp := range cc.replies {
+			rs = append(rs, rp.reply)
+		}
+		cc.replies = nil
+		return rs, nil
+	}
+	if err = cc.txCheck(commandName, args); err == errExecAbort {
+		// the deferred MULTI is not sent yet if no command is queued.
+		if !cc.multi {
+			cc.do(cc.route("DISCARD", nil), "DISCARD", nil)
+		}
+		cc.multi = false
+		return
+	} else if err != nil {
+		return
+	}
+	// WATCH pins the transaction to its node, otherwise MULTI waits for the
+	// first keyed command, the node of previous commands may not own the slot.
+	if strings.ToUpper(commandName) == "MULTI" && !cc.watching {
+		cc.multi = true
+		return okReply, nil
+	}
+	if scs := splitCommand(commandName, args); scs != nil {
+		cmds := make([]*cmd, 0, len(scs))
+		for _, sc := range scs {
+			cmds = append(cmds, &sc.cmd)
+		}
+		return mergeReplies(commandName, scs, cc.exec(cmds, cc.watching, cc.inMulti))
+	}
+	return cc.do(cc.route(commandName, args), commandName, args)
+}
+
+// do sends the command to addr and follows MOVED and ASK redirects, a command
+// queued by MULTI is never redirected but aborts the transaction, the caller
+// should retry the whole transaction.
+func (cc *clusterConn) do(addr string, commandName string, args []interface{}) (reply interface{}, err error) {
+	if addr == "" {
+		return nil, errNoClusterNode
+	}
+	for i := 0; ; i++ {
+		c := cc.conn(addr)
+		if cc.multi {
+			cc.multi = false
+			if _, err = c.Do("MULTI"); err != nil {
+				return
+			}
+		}
+		cc.addr = addr
+		reply, err = c.Do(commandName, args...)
+		next, ask := cc.p.redirect(err)
+		if next != "" && cc.inMulti {
+			cc.txAbort = true
+			return
+		}
+		if next == "" || i >= cc.p.maxRedirects() {
+			return
+		}
+		addr = next
+		if ask {
+			if _, err = cc.conn(addr).Do("ASKING"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// exec pipelines commands to their nodes concurrently, replies keep the order of commands.
+// watching and inMulti are the transaction state before the commands, all
+// commands of a transaction are sent to the node of MULTI.
+func (cc *clusterConn) exec(cmds []*cmd, watching, inMulti bool) []*reply {
+	var (
+		rps    = make([]*reply, len(cmds))
+		addrs  = make([]string, len(cmds))
+		queued = make([]bool, len(cmds))
+		splits = make(map[int][]*slotCmd)
+		nodes  = make(map[string][]int)
+		order  []string
+		last   string
+	)
+	if watching || inMulti {
+		last = cc.addr
+	}
+	for i, c := range cmds {
+		if scs := splitCommand(c.commandName, c.args); scs != nil {
+			splits[i] = scs
+			continue
+		}
+		queued[i] = inMulti
+		switch name := strings.ToUpper(c.commandName); {
+		case name == "MULTI":
+			inMulti = true
+			// without WATCH, MULTI follows the next keyed command.
+			if !watching {
+				last = ""
+			}
+		case name == "EXEC" || name == "DISCARD":
+			watching, inMulti, queued[i] = false, false, false
+		case name == "WATCH":
+			watching = true
+		}
+		if inMulti && last != "" {
+			addrs[i] = last
+		} else if key, ok := commandKey(c.commandName, c.args); ok {
+			addrs[i] = cc.p.slotAddr(keySlot(key))
+			last = addrs[i]
+		} else if last != "" {
+			addrs[i] = last
+		}
+	}
+	// keyless commands before the first keyed one follow the next command, e.g. MULTI.
+	for i := len(cmds) - 1; i >= 0; i-- {
+		if _, ok := splits[i]; ok {
+			continue
+		}
+		if addrs[i] == "" {
+			if i+1 < len(cmds) && addrs[i+1] != "" {
+				addrs[i] = addrs[i+1]
+			} else {
+				addrs[i] = cc.route(cmds[i].commandName, cmds[i].args)
+			}
+		}
+	}
+	for i, addr := range addrs {
+		if _, ok := splits[i]; ok {
+			continue
+		}
+		if _, ok := nodes[addr]; !ok {
+			order = append(order, addr)
+			cc.conn(addr)
+		}
+		nodes[addr] = append(nodes[addr], i)
+	}
+	var wg sync.WaitGroup
+	for _, addr := range order {
+		idx, c := nodes[addr], cc.conns[addr]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pipeline(c, cmds, idx, rps)
+		}()
+	}
+	wg.Wait()
+	for i := len(cmds) - 1; i >= 0; i-- {
+		if _, ok := splits[i]; !ok {
+			cc.addr = addrs[i]
+			break
+		}
+	}
+	for i, rp := range rps {
+		if rp == nil {
+			continue
+		}
+		if next, ask := cc.p.redirect(rp.err); next != "" {
+			if queued[i] {
+				// the node has aborted the transaction, EXEC of later pipeline discards it.
+				if cc.inMulti {
+					cc.txAbort = true
+				}
+				continue
+			}
+			if ask {
+				if _, err := cc.conn(next).Do("ASKING"); err != nil {
+					rps[i] = &reply{err: err}
+					continue
+				}
+			}
+			r, err := cc.do(next, cmds[i].commandName, cmds[i].args)
+			rps[i] = &reply{reply: r, err: err}
+		}
+	}
+	for i, scs := range splits {
+		sub := make([]*cmd, 0, len(scs))
+		for _, sc := range scs {
+			sub = append(sub, &sc.cmd)
+		}
+		r, err := mergeReplies(cmds[i].commandName, scs, cc.exec(sub, false, false))
+		rps[i] = &reply{reply: r, err: err}
+	}
+	return rps
+}
+
+// pipeline sends commands of idx on c and receives their replies into rps.
+func pipeline(c Conn, cmds []*cmd, idx []int, rps []*reply) {
+	var err error
+	for _, i := range idx {
+		if err = c.Send(cmds[i].commandName, cmds[i].args...); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = c.Flush()
+	}
+	for _, i := range idx {
+		if err != nil {
+			rps[i] = &reply{err: err}
+			continue
+		}
+		r, e := c.Receive()
+		rps[i] = &reply{reply: r, err: e}
+	}
+}
+
+func (cc *clusterConn) Send(commandName string, args ...interface{}) error {
+	cc.pending = append(cc.pending, &cmd{commandName: commandName, args: args})
+	return nil
+}
+
+// Flush sends pending commands, their replies are received by Receive.
+func (cc *clusterConn) Flush() error {
+	if len(cc.pending) == 0 {
+		return nil
+	}
+	var (
+		errs = make([]error, len(cc.pending))
+		cmds = make([]*cmd, 0, len(cc.pending))
+		idx  = make([]int, 0, len(cc.pending))
+		// the transaction state before the pending commands.
+		watching, inMulti = cc.watching, cc.inMulti
+	)
+	if cc.multi {
+		// MULTI deferred by Do is sent with the first pending command.
+		cc.multi, inMulti = false, false
+		cmds = append(cmds, &cmd{commandName: "MULTI"})
+		idx = append(idx, -1)
+	}
+	for i, c := range cc.pending {
+		if errs[i] = cc.txCheck(c.commandName, c.args); errs[i] == errExecAbort {
+			c = &cmd{commandName: "DISCARD"}
+		} else if errs[i] != nil {
+			continue
+		}
+		cmds = append(cmds, c)
+		idx = append(idx, i)
+	}
+	replies := make([]*reply, len(cc.pending))
+	for i, rp := range cc.exec(cmds, watching, inMulti) {
+		if idx[i] >= 0 {
+			replies[idx[i]] = rp
+		}
+	}
+	for i, err := range errs {
+		if err != nil {
+			replies[i] = &reply{err: err}
+		}
+	}
+	cc.replies = append(cc.replies, replies...)
+	cc.pending = nil
+	return nil
+}
+
+// Receive returns the reply of pending commands in order, it flushes pending commands if needed.
+// NOTE: pub/sub is not supported by cluster conn.
+func (cc *clusterConn) Receive() (reply interface{}, err error) {
+	if len(cc.replies) == 0 {
+		cc.Flush()
+	}
+	if len(cc.replies) == 0 {
+		return nil, errClusterReceive
+	}
+	rp := cc.replies[0]
+	cc.replies = cc.replies[1:]
+	return rp.reply, rp.err
+}
+
+func (cc *clusterConn) Err() error {
+	for _, c := range cc.conns {
+		if err := c.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cc *clusterConn) Close() (err error) {
+	for addr, c := range cc.conns {
+		if e := c.Close(); e != nil {
+			err = e
+		}
+		delete(cc.conns, addr)
+	}
+	cc.pending, cc.replies = nil, nil
+	cc.multi = false
+	cc.resetTx()
+	return
+}
+
+func (cc *clusterConn) WithContext(ctx context.Context) Conn {
+	cc.ctx = ctx
+	return cc
+}
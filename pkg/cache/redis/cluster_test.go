@@ -0,0 +1,258 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/alicebob/miniredis/v2/server"
+
+	"kratos/pkg/container/pool"
+	xtime "kratos/pkg/time"
+)
+
+// newFakeCluster returns a cluster of two miniredis, slots of "bar" are
+// served by the first one and slots of "foo" by the second one.
+func newFakeCluster(t *testing.T) (*clusterPool, *miniredis.Miniredis, *miniredis.Miniredis) {
+	s1, s2 := miniredis.RunT(t), miniredis.RunT(t)
+	c := getTestConfig("")
+	c.Config = &pool.Config{Active: 10, Idle: 2, IdleTimeout: xtime.Duration(time.Minute)}
+	p := &clusterPool{c: c, pools: make(map[string]*Pool)}
+	for i := range p.slots {
+		p.slots[i] = s1.Addr()
+		if i >= _clusterSlots/2 {
+			p.slots[i] = s2.Addr()
+		}
+	}
+	t.Cleanup(func() { p.Close() })
+	return p, s1, s2
+}
+
+func TestKeySlot(t *testing.T) {
+	if crc16("123456789") != 0x31c3 {
+		t.Fatalf("crc16 got %x", crc16("123456789"))
+	}
+	for key, slot := range map[string]int{
+		"foo":             12182,
+		"bar":             5061,
+		"{user1000}.a":    keySlot("user1000"),
+		"foo{}{bar}":      keySlot("foo{}{bar}"),
+		"{}bar":           keySlot("{}bar"),
+		"foo{bar}{zap}":   keySlot("bar"),
+		"{user1000}.b":    keySlot("{user1000}.a"),
+		"prefix{tag}":     keySlot("tag"),
+		"prefix{tag":      keySlot("prefix{tag"),
+		"prefix{tag}end":  keySlot("{tag}"),
+		"prefix{}tag}end": keySlot("prefix{}tag}end"),
+	} {
+		if got := keySlot(key); got != slot {
+			t.Fatalf("slot of %s got %d, want %d", key, got, slot)
+		}
+	}
+}
+
+func TestCommandKey(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		args []interface{}
+		key  string
+		ok   bool
+	}{
+		{"get", []interface{}{"a"}, "a", true},
+		{"SET", []interface{}{[]byte("b"), 1}, "b", true},
+		{"ping", nil, "", false},
+		{"exec", nil, "", false},
+		{"eval", []interface{}{"return 1", 0}, "", false},
+		{"evalsha", []interface{}{"sha", "1", "c"}, "c", true},
+		{"xreadgroup", []interface{}{"GROUP", "g", "c", "COUNT", 1, "STREAMS", "s", ">"}, "s", true},
+		{"xgroup", []interface{}{"create", "s", "g", "$"}, "s", true},
+	}
+	for _, test := range tests {
+		if key, ok := commandKey(test.cmd, test.args); key != test.key || ok != test.ok {
+			t.Fatalf("key of %s %v got %s %v", test.cmd, test.args, key, ok)
+		}
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	if splitCommand("mget", []interface{}{"{a}1", "{a}2"}) != nil {
+		t.Fatalf("keys in one slot should not be split")
+	}
+	scs := splitCommand("mset", []interface{}{"foo", 1, "bar", 2, "{foo}x", 3})
+	if len(scs) != 2 || len(scs[0].args) != 4 || len(scs[1].args) != 2 {
+		t.Fatalf("mset should be split into 2 slots, got %+v", scs)
+	}
+	rps := []*reply{{reply: okReply}, {reply: okReply}}
+	if r, err := mergeReplies("mset", scs, rps); err != nil || r != okReply {
+		t.Fatalf("merge mset got %v, %v", r, err)
+	}
+	scs = splitCommand("mget", []interface{}{"foo", "bar", "{foo}x"})
+	rps = []*reply{{reply: []interface{}{[]byte("1"), []byte("3")}}, {reply: []interface{}{nil}}}
+	r, err := Values(mergeReplies("mget", scs, rps))
+	if err != nil || len(r) != 3 || string(r[0].([]byte)) != "1" || r[1] != nil || string(r[2].([]byte)) != "3" {
+		t.Fatalf("merge mget got %v, %v", r, err)
+	}
+	scs = splitCommand("del", []interface{}{"foo", "bar"})
+	if n, err := Int64(mergeReplies("del", scs, []*reply{{reply: int64(1)}, {reply: int64(0)}})); err != nil || n != 1 {
+		t.Fatalf("merge del got %d, %v", n, err)
+	}
+}
+
+func TestClusterTxPipeline(t *testing.T) {
+	p, _, s2 := newFakeCluster(t)
+	conn := p.Get(context.TODO())
+	defer conn.Close()
+
+	conn.Send("MULTI")
+	conn.Send("SET", "{foo}a", 1)
+	conn.Send("SET", "{foo}b", 2)
+	conn.Send("EXEC")
+	r, err := Values(conn.Do(""))
+	if err != nil || len(r) != 4 {
+		t.Fatalf("tx of one slot got %v, %v", r, err)
+	}
+	if v, _ := s2.Get("{foo}b"); v != "2" {
+		t.Fatalf("tx of one slot should be executed on the node of slot, got %q", v)
+	}
+
+	conn.Send("MULTI")
+	conn.Send("SET", "foo", 1)
+	conn.Send("SET", "bar", 2)
+	conn.Send("EXEC")
+	conn.Flush()
+	for i, want := range []error{nil, nil, errCrossSlot, errExecAbort} {
+		if _, err = conn.Receive(); err != want {
+			t.Fatalf("reply %d of cross slot tx got %v, want %v", i, err, want)
+		}
+	}
+	if s2.Exists("foo") {
+		t.Fatalf("cross slot tx should be discarded")
+	}
+}
+
+func TestClusterTxDo(t *testing.T) {
+	p, s1, s2 := newFakeCluster(t)
+	conn := p.Get(context.TODO())
+	defer conn.Close()
+
+	if _, err := conn.Do("WATCH", "foo", "bar"); err != errCrossSlot {
+		t.Fatalf("watch keys of slots got %v", err)
+	}
+	conn.Do("UNWATCH")
+	if _, err := conn.Do("WATCH", "foo"); err != nil {
+		t.Fatal(err)
+	}
+	conn.Do("MULTI")
+	if _, err := conn.Do("SET", "foo", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Do("SET", "bar", 2); err != errCrossSlot {
+		t.Fatalf("set key of other slot in tx got %v", err)
+	}
+	if _, err := conn.Do("EXEC"); err != errExecAbort {
+		t.Fatalf("exec of aborted tx got %v", err)
+	}
+	if s1.Exists("bar") || s2.Exists("foo") {
+		t.Fatalf("cross slot tx should be discarded")
+	}
+
+	// the transaction is over, keys of slots are free again.
+	if _, err := conn.Do("MSET", "foo", 1, "bar", 2); err != nil {
+		t.Fatal(err)
+	}
+	if conn.Err() != nil {
+		t.Fatalf("err of healthy conn got %v", conn.Err())
+	}
+	s2.Close()
+	conn.Do("GET", "foo")
+	if conn.Err() == nil {
+		t.Fatalf("err of conn to the closed node should not be nil")
+	}
+}
+
+func TestClusterTxAfterCommand(t *testing.T) {
+	p, s1, s2 := newFakeCluster(t)
+	conn := p.Get(context.TODO())
+	defer conn.Close()
+	s1.Set("bar", "1")
+
+	if _, err := conn.Do("GET", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	conn.Do("MULTI")
+	if r, err := String(conn.Do("SET", "foo", 2)); err != nil || r != "QUEUED" {
+		t.Fatalf("set in tx got %v, %v", r, err)
+	}
+	if r, err := Values(conn.Do("EXEC")); err != nil || len(r) != 1 {
+		t.Fatalf("exec got %v, %v", r, err)
+	}
+	if v, _ := s2.Get("foo"); v != "2" {
+		t.Fatalf("tx should be executed on the node of slot, got %q", v)
+	}
+	// the conn of the first node is not left in MULTI.
+	if v, err := String(conn.Do("GET", "bar")); err != nil || v != "1" {
+		t.Fatalf("get after tx got %v, %v", v, err)
+	}
+
+	conn.Send("GET", "bar")
+	conn.Send("MULTI")
+	conn.Send("SET", "foo", 3)
+	conn.Send("EXEC")
+	r, err := Values(conn.Do(""))
+	if err != nil || len(r) != 4 {
+		t.Fatalf("pipelined tx after command got %v, %v", r, err)
+	}
+	if v, _ := s2.Get("foo"); v != "3" {
+		t.Fatalf("pipelined tx should be executed on the node of slot, got %q", v)
+	}
+}
+
+func TestClusterTxRedirect(t *testing.T) {
+	p, s1, s2 := newFakeCluster(t)
+	conn := p.Get(context.TODO())
+	defer conn.Close()
+	ask := "ASK " + strconv.Itoa(keySlot("foo")) + " " + s1.Addr()
+	s2.Server().SetPreHook(func(c *server.Peer, cmd string, args ...string) bool {
+		if strings.ToUpper(cmd) == "SET" && len(args) > 0 && args[0] == "foo" {
+			c.WriteError(ask)
+			return true
+		}
+		return false
+	})
+
+	conn.Do("MULTI")
+	if _, err := conn.Do("SET", "foo", 1); err == nil || err.Error() != ask {
+		t.Fatalf("redirected command in tx got %v", err)
+	}
+	if _, err := conn.Do("EXEC"); err != errExecAbort {
+		t.Fatalf("exec of redirected tx got %v", err)
+	}
+
+	conn.Send("MULTI")
+	conn.Send("SET", "foo", 1)
+	conn.Flush()
+	conn.Receive()
+	if _, err := conn.Receive(); err == nil || err.Error() != ask {
+		t.Fatalf("pipelined redirected command in tx got %v", err)
+	}
+	if _, err := conn.Do("EXEC"); err != errExecAbort {
+		t.Fatalf("exec of pipelined redirected tx got %v", err)
+	}
+	if s1.Exists("foo") || s2.Exists("foo") {
+		t.Fatalf("redirected command in tx should not be sent out of tx")
+	}
+	// out of transaction redirects are followed.
+	s1.Server().SetPreHook(func(c *server.Peer, cmd string, args ...string) bool {
+		if strings.ToUpper(cmd) == "ASKING" {
+			c.WriteOK()
+			return true
+		}
+		return false
+	})
+	if _, err := conn.Do("SET", "foo", 1); err != nil || !s1.Exists("foo") {
+		t.Fatalf("redirected command got %v", err)
+	}
+}
@@ -27,6 +27,25 @@
 // The application must call the connection Close method when the application
 // is done with the connection.
 //
+// Cluster and Sentinel
+//
+// NewRedis connects to redis cluster or the master monitored by sentinels if
+// Config.Mode is ModeCluster or ModeSentinel, Config.Addrs are the startup nodes
+// or the sentinels:
+//
+//  r := redis.NewRedis(&redis.Config{Mode: redis.ModeCluster, Addrs: []string{"127.0.0.1:7000"}, ...})
+//
+// Commands of cluster are sent to the master of the key slot, MOVED and ASK
+// redirects are followed. MGET, MSET, DEL, EXISTS, TOUCH and UNLINK are split by
+// slot if their keys span slots, pipelined commands are grouped by node.
+// Commands without key are sent to the node of the last command, MULTI is sent
+// to the node of WATCH or of the first keyed command after it. Keys of a
+// transaction must be in one slot from WATCH or MULTI until EXEC, use hash tags
+// for that, a command of other slot fails with CROSSSLOT error and the EXEC
+// fails with EXECABORT error. Redirects of queued commands are not followed but
+// abort the transaction too, retry the whole transaction then. Pub/Sub is not
+// supported by cluster connections.
+//
 // Executing Commands
 //
 // The Conn interface has a generic method for executing Redis commands:
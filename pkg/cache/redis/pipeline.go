@@ -18,7 +18,7 @@ var (
 )
 
 type pipeliner struct {
-	pool *Pool
+	pool connPool
 	cmds []*cmd
 }
 
@@ -26,6 +26,16 @@ type Error string
 
 func (err Error) Error() string { return string(err) }
 
+// Deploy modes of Config.
+const (
+	// ModeSingle connects to the node of Addr.
+	ModeSingle = ""
+	// ModeCluster connects to redis cluster, Addrs are the startup nodes.
+	ModeCluster = "cluster"
+	// ModeSentinel connects to the master of MasterName, Addrs are the sentinels.
+	ModeSentinel = "sentinel"
+)
+
 // Config client settings.
 type Config struct {
 	*pool.Config
@@ -39,16 +49,38 @@ type Config struct {
 	ReadTimeout  xtime.Duration
 	WriteTimeout xtime.Duration
 	SlowLog      xtime.Duration
+
+	Mode         string   // deploy mode, single node of Addr by default
+	Addrs        []string // cluster startup nodes or sentinel addresses
+	MasterName   string   // sentinel master name
+	MaxRedirects int      // cluster max MOVED/ASK redirects, default 3
+}
+
+// connPool gets connections of a single node, a sentinel master or a cluster.
+type connPool interface {
+	Get(ctx context.Context) Conn
+	Close() error
+}
+
+func newConnPool(c *Config, options ...DialOption) connPool {
+	switch c.Mode {
+	case ModeCluster:
+		return newClusterPool(c, options...)
+	case ModeSentinel:
+		return newSentinelPool(c, options...)
+	}
+	return NewPool(c, options...)
 }
 
 type Redis struct {
-	pool *Pool
+	pool connPool
 	conf *Config
 }
 
+// NewRedis new a redis client by the deploy mode of config.
 func NewRedis(c *Config, options ...DialOption) *Redis {
 	return &Redis{
-		pool: NewPool(c, options...),
+		pool: newConnPool(c, options...),
 		conf: c,
 	}
 }
@@ -0,0 +1,43 @@
+// Package redistest provides a miniredis fixture for the tests of packages
+// built on redis.
+package redistest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"kratos/pkg/cache/redis"
+	"kratos/pkg/container/pool"
+	xtime "kratos/pkg/time"
+)
+
+// New runs a miniredis server and returns it with a config dialing it,
+// the server is closed when the test and its subtests complete.
+func New(t testing.TB) (*miniredis.Miniredis, *redis.Config) {
+	t.Helper()
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("run miniredis error, %v", err)
+	}
+	t.Cleanup(s.Close)
+	return s, Config(s.Addr())
+}
+
+// Config returns the config of a small test pool dialing addr.
+func Config(addr string) *redis.Config {
+	return &redis.Config{
+		Config: &pool.Config{
+			Active:      10,
+			Idle:        2,
+			IdleTimeout: xtime.Duration(time.Minute),
+		},
+		Name:         "test",
+		Proto:        "tcp",
+		Addr:         addr,
+		DialTimeout:  xtime.Duration(time.Second),
+		ReadTimeout:  xtime.Duration(time.Second),
+		WriteTimeout: xtime.Duration(time.Second),
+	}
+}
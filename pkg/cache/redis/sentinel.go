@@ -0,0 +1,186 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"kratos/pkg/log"
+)
+
+const _sentinelCheckInterval = 3 * time.Second
+
+var errNoMaster = errors.New("redis: sentinel master not found")
+
+// sentinelPool connects to the master monitored by sentinels, the master is checked
+// periodically and re-resolved on connection errors or READONLY replies after failover.
+type sentinelPool struct {
+	c       *Config
+	options []DialOption
+
+	mu         sync.RWMutex
+	addr       string
+	pool       *Pool
+	refreshing int32
+	closed     bool
+	done       chan struct{}
+}
+
+func newSentinelPool(c *Config, options ...DialOption) *sentinelPool {
+	p := &sentinelPool{
+		c:       c,
+		options: options,
+		done:    make(chan struct{}),
+	}
+	if err := p.refresh(); err != nil {
+		log.Error("redis: sentinel master(%s) resolve error(%v)", c.MasterName, err)
+	}
+	go p.watch()
+	return p
+}
+
+// masterAddr asks sentinels for the master address.
+func (p *sentinelPool) masterAddr() (addr string, err error) {
+	err = errNoMaster
+	for _, sentinel := range p.c.Addrs {
+		var (
+			conn  Conn
+			reply []string
+		)
+		conn, err = Dial(p.c.Proto, sentinel,
+			DialConnectTimeout(time.Duration(p.c.DialTimeout)),
+			DialReadTimeout(time.Duration(p.c.ReadTimeout)),
+			DialWriteTimeout(time.Duration(p.c.WriteTimeout)),
+		)
+		if err != nil {
+			continue
+		}
+		reply, err = Strings(conn.Do("SENTINEL", "get-master-addr-by-name", p.c.MasterName))
+		conn.Close()
+		if err != nil {
+			continue
+		}
+		if len(reply) != 2 {
+			err = fmt.Errorf("redis: unexpected sentinel reply %v", reply)
+			continue
+		}
+		return net.JoinHostPort(reply[0], reply[1]), nil
+	}
+	return
+}
+
+// refresh resolves the master and switches the pool if the master changed.
+func (p *sentinelPool) refresh() error {
+	addr, err := p.masterAddr()
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	if p.closed || addr == p.addr {
+		p.mu.Unlock()
+		return nil
+	}
+	c := *p.c
+	c.Addr = addr
+	old, oldAddr := p.pool, p.addr
+	p.pool, p.addr = NewPool(&c, p.options...), addr
+	p.mu.Unlock()
+	if old != nil {
+		old.Close()
+		log.Info("redis: sentinel master(%s) switched from %s to %s", p.c.MasterName, oldAddr, addr)
+	}
+	return nil
+}
+
+// refreshAsync refreshes the master in background, concurrent calls are merged.
+func (p *sentinelPool) refreshAsync() {
+	if !atomic.CompareAndSwapInt32(&p.refreshing, 0, 1) {
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&p.refreshing, 0)
+		if err := p.refresh(); err != nil {
+			log.Error("redis: sentinel master(%s) resolve error(%v)", p.c.MasterName, err)
+		}
+	}()
+}
+
+func (p *sentinelPool) watch() {
+	ticker := time.NewTicker(_sentinelCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			p.refreshAsync()
+		}
+	}
+}
+
+// Get gets a connection of the current master. The application must close the returned connection.
+func (p *sentinelPool) Get(ctx context.Context) Conn {
+	p.mu.RLock()
+	pool := p.pool
+	p.mu.RUnlock()
+	if pool == nil {
+		p.refreshAsync()
+		return errorConnection{errNoMaster}
+	}
+	return &sentinelConn{Conn: pool.Get(ctx), p: p}
+}
+
+// Close closes the pool of master and stops watching sentinels.
+func (p *sentinelPool) Close() (err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	p.closed = true
+	close(p.done)
+	if p.pool != nil {
+		err = p.pool.Close()
+	}
+	return
+}
+
+// sentinelConn triggers master refresh on connection errors or READONLY replies.
+type sentinelConn struct {
+	Conn
+	p *sentinelPool
+}
+
+func (sc *sentinelConn) check(err error) {
+	if err == nil {
+		return
+	}
+	if e, ok := err.(Error); ok {
+		if msg := string(e); !strings.HasPrefix(msg, "READONLY") && !strings.HasPrefix(msg, "MASTERDOWN") {
+			return
+		}
+	}
+	sc.p.refreshAsync()
+}
+
+func (sc *sentinelConn) Do(commandName string, args ...interface{}) (reply interface{}, err error) {
+	reply, err = sc.Conn.Do(commandName, args...)
+	sc.check(err)
+	return
+}
+
+func (sc *sentinelConn) Receive() (reply interface{}, err error) {
+	reply, err = sc.Conn.Receive()
+	sc.check(err)
+	return
+}
+
+func (sc *sentinelConn) WithContext(ctx context.Context) Conn {
+	sc.Conn = sc.Conn.WithContext(ctx)
+	return sc
+}
@@ -0,0 +1,177 @@
+package xredis
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"kratos/pkg/cache/redis"
+)
+
+func TestCluster(t *testing.T) {
+	fc := newFakeCluster(t, 3)
+	defer fc.Close()
+	conf := fakeConfig()
+	conf.Mode = redis.ModeCluster
+	conf.Addrs = []string{fc.nodes[0].addr}
+	c := New(conf)
+	defer c.Close()
+	ctx := context.TODO()
+
+	var keys []string
+	for i := 0; i < 30; i++ {
+		key := "key" + strconv.Itoa(i)
+		keys = append(keys, key)
+		if err := c.Set(ctx, key, i, 0).Err(); err != nil {
+			t.Fatalf("set %s error, %v", key, err)
+		}
+	}
+	for i, key := range keys {
+		if v, ok := fc.Owner(key).Get(key); !ok || v != strconv.Itoa(i) {
+			t.Fatalf("%s should be stored in the node of its slot, got %s %v", key, v, ok)
+		}
+		if v, err := c.Get(ctx, key).Result(); err != nil || v != strconv.Itoa(i) {
+			t.Fatalf("get %s got %s, %v", key, v, err)
+		}
+	}
+	for _, n := range fc.nodes {
+		if n.Calls("SET") == 0 {
+			t.Fatalf("keys should be spread over nodes")
+		}
+	}
+
+	t.Run("multi keys", func(t *testing.T) {
+		vs, err := c.MGet(ctx, append(keys[:10:10], "missing")...).Result()
+		if err != nil || len(vs) != 11 {
+			t.Fatalf("mget got %v, %v", vs, err)
+		}
+		for i, v := range vs[:10] {
+			if string(v.([]byte)) != strconv.Itoa(i) {
+				t.Fatalf("mget got unexpected value %s of %s", v, keys[i])
+			}
+		}
+		if vs[10] != nil {
+			t.Fatalf("missing key should be nil, got %v", vs[10])
+		}
+		if err = c.MSet(ctx, "a", "1", "b", "2", "c", "3").Err(); err != nil {
+			t.Fatalf("mset error, %v", err)
+		}
+		if n, err := c.Del(ctx, "a", "b", "c", "missing").Result(); err != nil || n != 3 {
+			t.Fatalf("del got %d, %v", n, err)
+		}
+		if n, err := c.Do(ctx, "exists", "{tag}a", "{tag}b").Int64(); err != nil || n != 0 {
+			t.Fatalf("hash tag keys should be in the same slot, got %d, %v", n, err)
+		}
+	})
+
+	t.Run("pipeline", func(t *testing.T) {
+		cmds, err := c.Pipelined(ctx, func(p Pipeliner) error {
+			for _, key := range keys[:10] {
+				p.Incr(ctx, key)
+			}
+			p.MGet(ctx, keys[:3]...)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("pipeline error, %v", err)
+		}
+		for i, cmd := range cmds[:10] {
+			if n, err := cmd.(*IntCmd).Result(); err != nil || n != int64(i+1) {
+				t.Fatalf("incr %s got %d, %v", keys[i], n, err)
+			}
+		}
+		if vs := cmds[10].(*SliceCmd).Val(); len(vs) != 3 || string(vs[2].([]byte)) != "3" {
+			t.Fatalf("mget in pipeline got %v", vs)
+		}
+	})
+
+	t.Run("moved", func(t *testing.T) {
+		key := keys[0]
+		owner := fc.Owner(key)
+		target := fc.nodes[0]
+		if owner == target {
+			target = fc.nodes[1]
+		}
+		fc.Move(key, target)
+		if v, err := c.Get(ctx, key).Result(); err != nil || v != "1" {
+			t.Fatalf("get moved key got %s, %v", v, err)
+		}
+		time.Sleep(time.Millisecond * 100)
+		calls := owner.Calls("GET")
+		c.Get(ctx, key)
+		if owner.Calls("GET") != calls {
+			t.Fatalf("slot map should be updated after MOVED")
+		}
+	})
+
+	t.Run("ask", func(t *testing.T) {
+		key := keys[1]
+		owner := fc.Owner(key)
+		target := fc.nodes[0]
+		if owner == target {
+			target = fc.nodes[1]
+		}
+		fc.Migrate(key, target)
+		if v, err := c.Get(ctx, key).Result(); err != nil || v != "2" {
+			t.Fatalf("get migrating key got %s, %v", v, err)
+		}
+		if target.Calls("ASKING") == 0 {
+			t.Fatalf("ASKING should be sent to the importing node")
+		}
+		if fc.Owner(key) != owner {
+			t.Fatalf("ASK should not change the slot owner")
+		}
+	})
+}
+
+func TestSentinel(t *testing.T) {
+	master, replica := newFakeNode(t), newFakeNode(t)
+	defer master.Close()
+	defer replica.Close()
+	var mu sync.Mutex
+	current := master
+	sentinel := newFakeNode(t)
+	sentinel.master = func() *fakeNode {
+		mu.Lock()
+		defer mu.Unlock()
+		return current
+	}
+	defer sentinel.Close()
+
+	conf := fakeConfig()
+	conf.Mode = redis.ModeSentinel
+	conf.Addrs = []string{"127.0.0.1:1", sentinel.addr}
+	conf.MasterName = "mymaster"
+	c := New(conf)
+	defer c.Close()
+	ctx := context.TODO()
+
+	if err := c.Set(ctx, "key", "1", 0).Err(); err != nil {
+		t.Fatalf("set error, %v", err)
+	}
+	if v, ok := master.Get("key"); !ok || v != "1" {
+		t.Fatalf("key should be written to master")
+	}
+
+	// failover: the old master becomes a replica.
+	master.SetReadonly(true)
+	mu.Lock()
+	current = replica
+	mu.Unlock()
+
+	var err error
+	for i := 0; i < 50; i++ {
+		if err = c.Set(ctx, "key", "2", 0).Err(); err == nil {
+			break
+		}
+		time.Sleep(time.Millisecond * 20)
+	}
+	if err != nil {
+		t.Fatalf("set after failover error, %v", err)
+	}
+	if v, ok := replica.Get("key"); !ok || v != "2" {
+		t.Fatalf("key should be written to new master")
+	}
+}
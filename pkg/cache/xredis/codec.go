@@ -0,0 +1,116 @@
+package xredis
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+
+	"github.com/gogo/protobuf/proto"
+)
+
+const (
+	// Flag, the same bits as memcache so the generated code of genmc and
+	// genredis stay interchangeable.
+
+	// FlagRAW default flag.
+	FlagRAW = uint32(0)
+	// FlagGOB gob encoding.
+	FlagGOB = uint32(1) << 0
+	// FlagJSON json encoding.
+	FlagJSON = uint32(1) << 1
+	// FlagProtobuf protobuf
+	FlagProtobuf = uint32(1) << 2
+	// FlagGzip gzip compress.
+	FlagGzip = uint32(1) << 15
+)
+
+var (
+	// ErrValueObject the value can not be encoded or decoded with the flags.
+	ErrValueObject = errors.New("xredis: value object type error")
+)
+
+// Marshal encodes v with the encoding and compress flags.
+// FlagRAW accepts []byte and string only, FlagProtobuf accepts proto.Message only.
+func Marshal(v interface{}, flags uint32) (data []byte, err error) {
+	switch {
+	case flags&FlagGOB == FlagGOB:
+		var buf bytes.Buffer
+		if err = gob.NewEncoder(&buf).Encode(v); err != nil {
+			return
+		}
+		data = buf.Bytes()
+	case flags&FlagProtobuf == FlagProtobuf:
+		pb, ok := v.(proto.Message)
+		if !ok {
+			return nil, ErrValueObject
+		}
+		if data, err = proto.Marshal(pb); err != nil {
+			return
+		}
+	case flags&FlagJSON == FlagJSON:
+		if data, err = json.Marshal(v); err != nil {
+			return
+		}
+	default:
+		switch d := v.(type) {
+		case []byte:
+			data = d
+		case string:
+			data = []byte(d)
+		default:
+			return nil, ErrValueObject
+		}
+	}
+	if flags&FlagGzip == FlagGzip {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err = w.Write(data); err != nil {
+			return
+		}
+		if err = w.Close(); err != nil {
+			return
+		}
+		data = buf.Bytes()
+	}
+	return
+}
+
+// Unmarshal decodes data encoded by Marshal with the same flags into v,
+// v must be a pointer.
+func Unmarshal(data []byte, v interface{}, flags uint32) (err error) {
+	if flags&FlagGzip == FlagGzip {
+		var r *gzip.Reader
+		if r, err = gzip.NewReader(bytes.NewReader(data)); err != nil {
+			return
+		}
+		defer r.Close()
+		if data, err = ioutil.ReadAll(r); err != nil {
+			return
+		}
+	}
+	switch {
+	case flags&FlagGOB == FlagGOB:
+		err = gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+	case flags&FlagProtobuf == FlagProtobuf:
+		pb, ok := v.(proto.Message)
+		if !ok {
+			return ErrValueObject
+		}
+		err = proto.Unmarshal(data, pb)
+	case flags&FlagJSON == FlagJSON:
+		err = json.Unmarshal(data, v)
+	default:
+		switch d := v.(type) {
+		case *[]byte:
+			*d = data
+		case *string:
+			*d = string(data)
+		default:
+			err = ErrValueObject
+		}
+	}
+	return
+}
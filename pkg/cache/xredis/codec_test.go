@@ -0,0 +1,61 @@
+package xredis
+
+import (
+	"testing"
+
+	pb "kratos/pkg/cache/memcache/test"
+)
+
+type codecItem struct {
+	Name string
+	Age  int
+}
+
+func TestCodec(t *testing.T) {
+	for _, flags := range []uint32{FlagGOB, FlagJSON, FlagGOB | FlagGzip, FlagJSON | FlagGzip} {
+		data, err := Marshal(&codecItem{Name: "kratos", Age: 3}, flags)
+		if err != nil {
+			t.Fatalf("marshal with flags %d error, %v", flags, err)
+		}
+		v := &codecItem{}
+		if err = Unmarshal(data, v, flags); err != nil {
+			t.Fatalf("unmarshal with flags %d error, %v", flags, err)
+		}
+		if v.Name != "kratos" || v.Age != 3 {
+			t.Fatalf("flags %d decoded %+v", flags, v)
+		}
+	}
+}
+
+func TestCodecProtobuf(t *testing.T) {
+	for _, flags := range []uint32{FlagProtobuf, FlagProtobuf | FlagGzip} {
+		data, err := Marshal(&pb.TestItem{Name: "kratos", Age: 3}, flags)
+		if err != nil {
+			t.Fatalf("marshal with flags %d error, %v", flags, err)
+		}
+		v := &pb.TestItem{}
+		if err = Unmarshal(data, v, flags); err != nil {
+			t.Fatalf("unmarshal with flags %d error, %v", flags, err)
+		}
+		if v.Name != "kratos" || v.Age != 3 {
+			t.Fatalf("flags %d decoded %+v", flags, v)
+		}
+	}
+	if _, err := Marshal(&codecItem{}, FlagProtobuf); err != ErrValueObject {
+		t.Fatalf("marshal non proto message should be ErrValueObject, got %v", err)
+	}
+}
+
+func TestCodecRaw(t *testing.T) {
+	data, err := Marshal("kratos", FlagRAW|FlagGzip)
+	if err != nil {
+		t.Fatalf("marshal raw error, %v", err)
+	}
+	var s string
+	if err = Unmarshal(data, &s, FlagRAW|FlagGzip); err != nil || s != "kratos" {
+		t.Fatalf("unmarshal raw got %q, %v", s, err)
+	}
+	if _, err = Marshal(1, FlagRAW); err != ErrValueObject {
+		t.Fatalf("marshal int raw should be ErrValueObject, got %v", err)
+	}
+}
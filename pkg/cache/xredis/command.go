@@ -534,7 +534,473 @@ func (cmd *ZSliceCmd) setReply(val interface{}, err error) {
 	}
 }
 
-// TODO:
-// type ScanCmd struct {
-// 	baseCmd
-// }
+//------------------------------------------------------------------------------
+
+type ScanCmd struct {
+	baseCmd
+
+	page   []string
+	cursor uint64
+
+	process cmdable
+}
+
+var _ Cmder = (*ScanCmd)(nil)
+
+func NewScanCmd(ctx context.Context, process cmdable, args ...interface{}) *ScanCmd {
+	return &ScanCmd{
+		baseCmd: baseCmd{
+			ctx:  ctx,
+			args: args,
+		},
+		process: process,
+	}
+}
+
+func (cmd *ScanCmd) Val() (keys []string, cursor uint64) {
+	return cmd.page, cmd.cursor
+}
+
+func (cmd *ScanCmd) Result() (keys []string, cursor uint64, err error) {
+	return cmd.page, cmd.cursor, cmd.err
+}
+
+func (cmd *ScanCmd) setReply(val interface{}, err error) {
+	var arr []interface{}
+	if arr, cmd.err = redis.Values(val, err); cmd.err != nil {
+		return
+	}
+	if len(arr) != 2 {
+		cmd.err = fmt.Errorf("xredis: unexpected scan reply length %d", len(arr))
+		return
+	}
+	if cmd.cursor, cmd.err = redis.Uint64(arr[0], nil); cmd.err != nil {
+		return
+	}
+	cmd.page, cmd.err = redis.Strings(arr[1], nil)
+}
+
+// Iterator creates a new ScanIterator.
+func (cmd *ScanCmd) Iterator() *ScanIterator {
+	return &ScanIterator{
+		cmd: cmd,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type XMessage struct {
+	ID     string
+	Values map[string]interface{}
+}
+
+type XMessageSliceCmd struct {
+	baseCmd
+
+	val []XMessage
+}
+
+var _ Cmder = (*XMessageSliceCmd)(nil)
+
+func NewXMessageSliceCmd(ctx context.Context, args ...interface{}) *XMessageSliceCmd {
+	return &XMessageSliceCmd{
+		baseCmd: baseCmd{
+			ctx:  ctx,
+			args: args,
+		},
+	}
+}
+
+func (cmd *XMessageSliceCmd) Val() []XMessage {
+	return cmd.val
+}
+
+func (cmd *XMessageSliceCmd) Result() ([]XMessage, error) {
+	return cmd.val, cmd.err
+}
+
+func (cmd *XMessageSliceCmd) setReply(val interface{}, err error) {
+	cmd.val, cmd.err = parseXMessageSlice(val, err)
+}
+
+func parseXMessageSlice(val interface{}, err error) ([]XMessage, error) {
+	arr, err := redis.Values(val, err)
+	if err != nil {
+		return nil, err
+	}
+	msgs := make([]XMessage, 0, len(arr))
+	for _, v := range arr {
+		msg, err := redis.Values(v, nil)
+		if err == redis.ErrNil {
+			// XCLAIM replies nil for the messages deleted.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(msg) != 2 {
+			return nil, fmt.Errorf("xredis: unexpected stream message length %d", len(msg))
+		}
+		id, err := redis.String(msg[0], nil)
+		if err != nil {
+			return nil, err
+		}
+		m := XMessage{ID: id}
+		// the fields are nil if the message is deleted but still pending.
+		if msg[1] != nil {
+			kvs, err := redis.Strings(msg[1], nil)
+			if err != nil {
+				return nil, err
+			}
+			m.Values = make(map[string]interface{}, len(kvs)/2)
+			for i := 0; i+1 < len(kvs); i += 2 {
+				m.Values[kvs[i]] = kvs[i+1]
+			}
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs, nil
+}
+
+//------------------------------------------------------------------------------
+
+type XStream struct {
+	Stream   string
+	Messages []XMessage
+}
+
+type XStreamSliceCmd struct {
+	baseCmd
+
+	val []XStream
+}
+
+var _ Cmder = (*XStreamSliceCmd)(nil)
+
+func NewXStreamSliceCmd(ctx context.Context, args ...interface{}) *XStreamSliceCmd {
+	return &XStreamSliceCmd{
+		baseCmd: baseCmd{
+			ctx:  ctx,
+			args: args,
+		},
+	}
+}
+
+func (cmd *XStreamSliceCmd) Val() []XStream {
+	return cmd.val
+}
+
+func (cmd *XStreamSliceCmd) Result() ([]XStream, error) {
+	return cmd.val, cmd.err
+}
+
+func (cmd *XStreamSliceCmd) setReply(val interface{}, err error) {
+	var arr []interface{}
+	if arr, cmd.err = redis.Values(val, err); cmd.err != nil {
+		return
+	}
+	cmd.val = make([]XStream, len(arr))
+	for i, v := range arr {
+		var stream []interface{}
+		if stream, cmd.err = redis.Values(v, nil); cmd.err != nil {
+			return
+		}
+		if len(stream) != 2 {
+			cmd.err = fmt.Errorf("xredis: unexpected stream reply length %d", len(stream))
+			return
+		}
+		if cmd.val[i].Stream, cmd.err = redis.String(stream[0], nil); cmd.err != nil {
+			return
+		}
+		if cmd.val[i].Messages, cmd.err = parseXMessageSlice(stream[1], nil); cmd.err != nil {
+			return
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type XPending struct {
+	Count     int64
+	Lower     string
+	Higher    string
+	Consumers map[string]int64
+}
+
+type XPendingCmd struct {
+	baseCmd
+
+	val *XPending
+}
+
+var _ Cmder = (*XPendingCmd)(nil)
+
+func NewXPendingCmd(ctx context.Context, args ...interface{}) *XPendingCmd {
+	return &XPendingCmd{
+		baseCmd: baseCmd{
+			ctx:  ctx,
+			args: args,
+		},
+	}
+}
+
+func (cmd *XPendingCmd) Val() *XPending {
+	return cmd.val
+}
+
+func (cmd *XPendingCmd) Result() (*XPending, error) {
+	return cmd.val, cmd.err
+}
+
+func (cmd *XPendingCmd) setReply(val interface{}, err error) {
+	var arr []interface{}
+	if arr, cmd.err = redis.Values(val, err); cmd.err != nil {
+		return
+	}
+	if len(arr) != 4 {
+		cmd.err = fmt.Errorf("xredis: unexpected xpending reply length %d", len(arr))
+		return
+	}
+	p := &XPending{Consumers: make(map[string]int64)}
+	if p.Count, cmd.err = redis.Int64(arr[0], nil); cmd.err != nil {
+		return
+	}
+	// lower, higher and consumers are nil if there is no pending message.
+	p.Lower, _ = redis.String(arr[1], nil)
+	p.Higher, _ = redis.String(arr[2], nil)
+	consumers, _ := redis.Values(arr[3], nil)
+	for _, v := range consumers {
+		var c []string
+		if c, cmd.err = redis.Strings(v, nil); cmd.err != nil {
+			return
+		}
+		if len(c) != 2 {
+			cmd.err = fmt.Errorf("xredis: unexpected xpending consumer length %d", len(c))
+			return
+		}
+		if p.Consumers[c[0]], cmd.err = strconv.ParseInt(c[1], 10, 64); cmd.err != nil {
+			return
+		}
+	}
+	cmd.val = p
+}
+
+//------------------------------------------------------------------------------
+
+type XPendingExt struct {
+	ID         string
+	Consumer   string
+	Idle       time.Duration
+	RetryCount int64
+}
+
+type XPendingExtCmd struct {
+	baseCmd
+
+	val []XPendingExt
+}
+
+var _ Cmder = (*XPendingExtCmd)(nil)
+
+func NewXPendingExtCmd(ctx context.Context, args ...interface{}) *XPendingExtCmd {
+	return &XPendingExtCmd{
+		baseCmd: baseCmd{
+			ctx:  ctx,
+			args: args,
+		},
+	}
+}
+
+func (cmd *XPendingExtCmd) Val() []XPendingExt {
+	return cmd.val
+}
+
+func (cmd *XPendingExtCmd) Result() ([]XPendingExt, error) {
+	return cmd.val, cmd.err
+}
+
+func (cmd *XPendingExtCmd) setReply(val interface{}, err error) {
+	var arr []interface{}
+	if arr, cmd.err = redis.Values(val, err); cmd.err != nil {
+		return
+	}
+	cmd.val = make([]XPendingExt, len(arr))
+	for i, v := range arr {
+		var (
+			ext  []interface{}
+			idle int64
+		)
+		if ext, cmd.err = redis.Values(v, nil); cmd.err != nil {
+			return
+		}
+		if len(ext) != 4 {
+			cmd.err = fmt.Errorf("xredis: unexpected xpending reply length %d", len(ext))
+			return
+		}
+		if cmd.val[i].ID, cmd.err = redis.String(ext[0], nil); cmd.err != nil {
+			return
+		}
+		if cmd.val[i].Consumer, cmd.err = redis.String(ext[1], nil); cmd.err != nil {
+			return
+		}
+		if idle, cmd.err = redis.Int64(ext[2], nil); cmd.err != nil {
+			return
+		}
+		cmd.val[i].Idle = time.Duration(idle) * time.Millisecond
+		if cmd.val[i].RetryCount, cmd.err = redis.Int64(ext[3], nil); cmd.err != nil {
+			return
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// GeoLocation is used with GeoAdd to add geospatial location.
+type GeoLocation struct {
+	Name                      string
+	Longitude, Latitude, Dist float64
+	GeoHash                   int64
+}
+
+type GeoLocationCmd struct {
+	baseCmd
+
+	q   *GeoRadiusQuery
+	val []GeoLocation
+}
+
+var _ Cmder = (*GeoLocationCmd)(nil)
+
+func NewGeoLocationCmd(ctx context.Context, q *GeoRadiusQuery, args ...interface{}) *GeoLocationCmd {
+	return &GeoLocationCmd{
+		baseCmd: baseCmd{
+			ctx:  ctx,
+			args: geoLocationArgs(q, args...),
+		},
+		q: q,
+	}
+}
+
+func (cmd *GeoLocationCmd) Val() []GeoLocation {
+	return cmd.val
+}
+
+func (cmd *GeoLocationCmd) Result() ([]GeoLocation, error) {
+	return cmd.val, cmd.err
+}
+
+func (cmd *GeoLocationCmd) setReply(val interface{}, err error) {
+	var arr []interface{}
+	if arr, cmd.err = redis.Values(val, err); cmd.err != nil {
+		return
+	}
+	cmd.val = make([]GeoLocation, len(arr))
+	for i, v := range arr {
+		if !cmd.q.WithCoord && !cmd.q.WithDist && !cmd.q.WithGeoHash {
+			if cmd.val[i].Name, cmd.err = redis.String(v, nil); cmd.err != nil {
+				return
+			}
+			continue
+		}
+		// the reply is [name, dist?, hash?, [longitude, latitude]?].
+		var loc []interface{}
+		if loc, cmd.err = redis.Values(v, nil); cmd.err != nil {
+			return
+		}
+		if len(loc) == 0 {
+			cmd.err = fmt.Errorf("xredis: unexpected geo reply %v", loc)
+			return
+		}
+		if cmd.val[i].Name, cmd.err = redis.String(loc[0], nil); cmd.err != nil {
+			return
+		}
+		loc = loc[1:]
+		if cmd.q.WithDist && len(loc) > 0 {
+			if cmd.val[i].Dist, cmd.err = redis.Float64(loc[0], nil); cmd.err != nil {
+				return
+			}
+			loc = loc[1:]
+		}
+		if cmd.q.WithGeoHash && len(loc) > 0 {
+			if cmd.val[i].GeoHash, cmd.err = redis.Int64(loc[0], nil); cmd.err != nil {
+				return
+			}
+			loc = loc[1:]
+		}
+		if cmd.q.WithCoord && len(loc) > 0 {
+			var pos *GeoPos
+			if pos, cmd.err = parseGeoPos(loc[0]); cmd.err != nil {
+				return
+			}
+			if pos != nil {
+				cmd.val[i].Longitude, cmd.val[i].Latitude = pos.Longitude, pos.Latitude
+			}
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type GeoPos struct {
+	Longitude, Latitude float64
+}
+
+type GeoPosCmd struct {
+	baseCmd
+
+	val []*GeoPos
+}
+
+var _ Cmder = (*GeoPosCmd)(nil)
+
+func NewGeoPosCmd(ctx context.Context, args ...interface{}) *GeoPosCmd {
+	return &GeoPosCmd{
+		baseCmd: baseCmd{
+			ctx:  ctx,
+			args: args,
+		},
+	}
+}
+
+// Val returns positions of members, the position is nil if the member is not found.
+func (cmd *GeoPosCmd) Val() []*GeoPos {
+	return cmd.val
+}
+
+func (cmd *GeoPosCmd) Result() ([]*GeoPos, error) {
+	return cmd.val, cmd.err
+}
+
+func (cmd *GeoPosCmd) setReply(val interface{}, err error) {
+	var arr []interface{}
+	if arr, cmd.err = redis.Values(val, err); cmd.err != nil {
+		return
+	}
+	cmd.val = make([]*GeoPos, len(arr))
+	for i, v := range arr {
+		if cmd.val[i], cmd.err = parseGeoPos(v); cmd.err != nil {
+			return
+		}
+	}
+}
+
+func parseGeoPos(val interface{}) (*GeoPos, error) {
+	if val == nil {
+		return nil, nil
+	}
+	pos, err := redis.Values(val, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(pos) != 2 {
+		return nil, fmt.Errorf("xredis: unexpected geo position length %d", len(pos))
+	}
+	p := new(GeoPos)
+	if p.Longitude, err = redis.Float64(pos[0], nil); err != nil {
+		return nil, err
+	}
+	if p.Latitude, err = redis.Float64(pos[1], nil); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,170 @@
+package xredis
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"kratos/pkg/cache/redis/redistest"
+)
+
+func newMiniClient(t *testing.T) (*miniredis.Miniredis, *Client) {
+	s, conf := redistest.New(t)
+	return s, New(conf)
+}
+
+func TestScan(t *testing.T) {
+	s, c := newMiniClient(t)
+	defer s.Close()
+	defer c.Close()
+	ctx := context.TODO()
+
+	for i := 0; i < 25; i++ {
+		s.Set("scan:"+strconv.Itoa(i), "v")
+		s.HSet("hash", "f"+strconv.Itoa(i), "v")
+		s.SAdd("set", "m"+strconv.Itoa(i))
+		s.ZAdd("zset", float64(i), "z"+strconv.Itoa(i))
+	}
+	s.Set("other", "v")
+
+	keys, cursor, err := c.Scan(ctx, 0, "scan:*", 10).Result()
+	if err != nil || len(keys) == 0 {
+		t.Fatalf("scan got %v %d, %v", keys, cursor, err)
+	}
+
+	tests := []struct {
+		cmd  *ScanCmd
+		want int
+	}{
+		{c.Scan(ctx, 0, "scan:*", 10), 25},
+		{c.HScan(ctx, "hash", 0, "", 10), 50},
+		{c.SScan(ctx, "set", 0, "m1*", 10), 11},
+		{c.ZScan(ctx, "zset", 0, "", 0), 50},
+	}
+	for _, test := range tests {
+		var vals []string
+		it := test.cmd.Iterator()
+		for it.Next(ctx) {
+			vals = append(vals, it.Val())
+		}
+		if err := it.Err(); err != nil {
+			t.Fatalf("%s iterate error, %v", test.cmd.Name(), err)
+		}
+		if len(vals) != test.want {
+			t.Fatalf("%s got %d values, want %d: %v", test.cmd.Name(), len(vals), test.want, vals)
+		}
+	}
+}
+
+func TestStreams(t *testing.T) {
+	s, c := newMiniClient(t)
+	defer s.Close()
+	defer c.Close()
+	ctx := context.TODO()
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		id, err := c.XAdd(ctx, &XAddArgs{
+			Stream: "stream",
+			Values: map[string]interface{}{"n": i},
+		}).Result()
+		if err != nil {
+			t.Fatalf("xadd error, %v", err)
+		}
+		ids = append(ids, id)
+	}
+	if n, err := c.XLen(ctx, "stream").Result(); err != nil || n != 3 {
+		t.Fatalf("xlen got %d, %v", n, err)
+	}
+	msgs, err := c.XRange(ctx, "stream", "-", "+").Result()
+	if err != nil || len(msgs) != 3 || msgs[2].ID != ids[2] || msgs[2].Values["n"] != "2" {
+		t.Fatalf("xrange got %+v, %v", msgs, err)
+	}
+	if msgs, err = c.XRevRangeN(ctx, "stream", "+", "-", 1).Result(); err != nil || len(msgs) != 1 || msgs[0].ID != ids[2] {
+		t.Fatalf("xrevrange got %+v, %v", msgs, err)
+	}
+	streams, err := c.XRead(ctx, &XReadArgs{Streams: []string{"stream", ids[0]}}).Result()
+	if err != nil || len(streams) != 1 || streams[0].Stream != "stream" || len(streams[0].Messages) != 2 {
+		t.Fatalf("xread got %+v, %v", streams, err)
+	}
+	if _, err = c.XRead(ctx, &XReadArgs{Streams: []string{"stream", "$"}, Block: time.Millisecond * 10}).Result(); err != ErrNil {
+		t.Fatalf("xread timeout should be ErrNil, got %v", err)
+	}
+
+	if err = c.XGroupCreate(ctx, "stream", "group", "0").Err(); err != nil {
+		t.Fatalf("xgroup create error, %v", err)
+	}
+	streams, err = c.XReadGroup(ctx, &XReadGroupArgs{
+		Group:    "group",
+		Consumer: "c1",
+		Streams:  []string{"stream", ">"},
+		Count:    2,
+	}).Result()
+	if err != nil || len(streams) != 1 || len(streams[0].Messages) != 2 {
+		t.Fatalf("xreadgroup got %+v, %v", streams, err)
+	}
+	if n, err := c.XAck(ctx, "stream", "group", ids[0]).Result(); err != nil || n != 1 {
+		t.Fatalf("xack got %d, %v", n, err)
+	}
+	pending, err := c.XPending(ctx, "stream", "group").Result()
+	if err != nil || pending.Count != 1 || pending.Lower != ids[1] || pending.Consumers["c1"] != 1 {
+		t.Fatalf("xpending got %+v, %v", pending, err)
+	}
+	exts, err := c.XPendingExt(ctx, &XPendingExtArgs{Stream: "stream", Group: "group", Count: 10}).Result()
+	if err != nil || len(exts) != 1 || exts[0].ID != ids[1] || exts[0].Consumer != "c1" || exts[0].RetryCount != 1 {
+		t.Fatalf("xpending ext got %+v, %v", exts, err)
+	}
+	msgs, err = c.XClaim(ctx, &XClaimArgs{
+		Stream:   "stream",
+		Group:    "group",
+		Consumer: "c2",
+		Messages: []string{ids[1]},
+	}).Result()
+	if err != nil || len(msgs) != 1 || msgs[0].Values["n"] != "1" {
+		t.Fatalf("xclaim got %+v, %v", msgs, err)
+	}
+	if pending, err = c.XPending(ctx, "stream", "group").Result(); err != nil || pending.Consumers["c2"] != 1 {
+		t.Fatalf("xpending after xclaim got %+v, %v", pending, err)
+	}
+	if n, err := c.XDel(ctx, "stream", ids[0]).Result(); err != nil || n != 1 {
+		t.Fatalf("xdel got %d, %v", n, err)
+	}
+	if n, err := c.XTrim(ctx, "stream", 1).Result(); err != nil || n != 1 {
+		t.Fatalf("xtrim got %d, %v", n, err)
+	}
+}
+
+func TestGeo(t *testing.T) {
+	s, c := newMiniClient(t)
+	defer s.Close()
+	defer c.Close()
+	ctx := context.TODO()
+
+	n, err := c.GeoAdd(ctx, "Sicily",
+		&GeoLocation{Longitude: 13.361389, Latitude: 38.115556, Name: "Palermo"},
+		&GeoLocation{Longitude: 15.087269, Latitude: 37.502669, Name: "Catania"},
+	).Result()
+	if err != nil || n != 2 {
+		t.Fatalf("geoadd got %d, %v", n, err)
+	}
+	pos, err := c.GeoPos(ctx, "Sicily", "Palermo", "missing").Result()
+	if err != nil || len(pos) != 2 || pos[1] != nil || math.Abs(pos[0].Longitude-13.361389) > 0.001 {
+		t.Fatalf("geopos got %+v, %v", pos, err)
+	}
+	dist, err := c.GeoDist(ctx, "Sicily", "Palermo", "Catania", "km").Result()
+	if err != nil || math.Abs(dist-166.27) > 1 {
+		t.Fatalf("geodist got %f, %v", dist, err)
+	}
+	locs, err := c.GeoRadius(ctx, "Sicily", 15, 37, &GeoRadiusQuery{Radius: 200, WithDist: true, WithCoord: true, Sort: "ASC"}).Result()
+	if err != nil || len(locs) != 2 || locs[0].Name != "Catania" || locs[0].Dist == 0 || locs[0].Latitude == 0 {
+		t.Fatalf("georadius got %+v, %v", locs, err)
+	}
+	locs, err = c.GeoRadiusByMember(ctx, "Sicily", "Palermo", &GeoRadiusQuery{Radius: 100}).Result()
+	if err != nil || len(locs) != 1 || locs[0].Name != "Palermo" {
+		t.Fatalf("georadiusbymember got %+v, %v", locs, err)
+	}
+}
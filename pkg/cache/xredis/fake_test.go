@@ -0,0 +1,391 @@
+package xredis
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"kratos/pkg/cache/redis"
+	xpool "kratos/pkg/container/pool"
+	xtime "kratos/pkg/time"
+)
+
+const _fakeSlots = 16384
+
+func fakeCRC16(key string) (crc uint16) {
+	for i := 0; i < len(key); i++ {
+		crc ^= uint16(key[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return
+}
+
+func fakeSlot(key string) int {
+	if s := strings.IndexByte(key, '{'); s >= 0 {
+		if e := strings.IndexByte(key[s+1:], '}'); e > 0 {
+			key = key[s+1 : s+1+e]
+		}
+	}
+	return int(fakeCRC16(key) % _fakeSlots)
+}
+
+// fakeNode is an in-process redis server speaks a subset of RESP commands.
+type fakeNode struct {
+	t    *testing.T
+	ln   net.Listener
+	addr string
+
+	// cluster is set if the node is a member of fake cluster.
+	cluster *fakeCluster
+	// master is set if the node is a sentinel.
+	master func() *fakeNode
+
+	mu       sync.Mutex
+	data     map[string]string
+	readonly bool
+	calls    map[string]int
+}
+
+func newFakeNode(t *testing.T) *fakeNode {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen error, %v", err)
+	}
+	n := &fakeNode{t: t, ln: ln, addr: ln.Addr().String(), data: make(map[string]string), calls: make(map[string]int)}
+	go n.serve()
+	return n
+}
+
+func (n *fakeNode) Close() {
+	n.ln.Close()
+}
+
+func (n *fakeNode) Set(key, value string) {
+	n.mu.Lock()
+	n.data[key] = value
+	n.mu.Unlock()
+}
+
+func (n *fakeNode) Get(key string) (value string, ok bool) {
+	n.mu.Lock()
+	value, ok = n.data[key]
+	n.mu.Unlock()
+	return
+}
+
+// Calls returns the count of command received.
+func (n *fakeNode) Calls(cmd string) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.calls[strings.ToUpper(cmd)]
+}
+
+func (n *fakeNode) SetReadonly(readonly bool) {
+	n.mu.Lock()
+	n.readonly = readonly
+	n.mu.Unlock()
+}
+
+func (n *fakeNode) serve() {
+	for {
+		c, err := n.ln.Accept()
+		if err != nil {
+			return
+		}
+		go n.handle(c)
+	}
+}
+
+func readCommand(r *bufio.Reader) (args []string, err error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("unexpected line %q", line)
+	}
+	count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	for i := 0; i < count; i++ {
+		if line, err = r.ReadString('\n'); err != nil {
+			return
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		buf := make([]byte, size+2)
+		if _, err = io.ReadFull(r, buf); err != nil {
+			return
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return
+}
+
+// writeReply writes v in RESP: string is status, error is error reply, []byte is bulk,
+// nil is nil bulk, int is integer and []interface{} is array.
+func writeReply(w *bufio.Writer, v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		w.WriteString("$-1\r\n")
+	case string:
+		w.WriteString("+" + v + "\r\n")
+	case error:
+		w.WriteString("-" + v.Error() + "\r\n")
+	case int:
+		w.WriteString(":" + strconv.Itoa(v) + "\r\n")
+	case []byte:
+		w.WriteString("$" + strconv.Itoa(len(v)) + "\r\n")
+		w.Write(v)
+		w.WriteString("\r\n")
+	case []interface{}:
+		w.WriteString("*" + strconv.Itoa(len(v)) + "\r\n")
+		for _, e := range v {
+			writeReply(w, e)
+		}
+	default:
+		panic(fmt.Sprintf("unexpected reply type %T", v))
+	}
+}
+
+func (n *fakeNode) handle(c net.Conn) {
+	defer c.Close()
+	r, w := bufio.NewReader(c), bufio.NewWriter(c)
+	var asking bool
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(args[0])
+		n.mu.Lock()
+		n.calls[cmd]++
+		n.mu.Unlock()
+		if cmd == "ASKING" {
+			asking = true
+			writeReply(w, "OK")
+		} else {
+			writeReply(w, n.exec(cmd, args[1:], asking))
+			asking = false
+		}
+		if r.Buffered() == 0 {
+			w.Flush()
+		}
+	}
+}
+
+// route checks keys of command in cluster mode, it returns MOVED, ASK or CROSSSLOT error
+// if the node can not serve the keys.
+func (n *fakeNode) route(keys []string, asking bool) error {
+	if n.cluster == nil || len(keys) == 0 {
+		return nil
+	}
+	slot := fakeSlot(keys[0])
+	for _, key := range keys[1:] {
+		if fakeSlot(key) != slot {
+			return fmt.Errorf("CROSSSLOT Keys in request don't hash to the same slot")
+		}
+	}
+	fc := n.cluster
+	fc.mu.Lock()
+	owner, target := fc.slots[slot], fc.migrating[slot]
+	fc.mu.Unlock()
+	if owner != n {
+		if asking && target == n {
+			return nil
+		}
+		return fmt.Errorf("MOVED %d %s", slot, owner.addr)
+	}
+	if target != nil {
+		if _, ok := n.Get(keys[0]); !ok {
+			return fmt.Errorf("ASK %d %s", slot, target.addr)
+		}
+	}
+	return nil
+}
+
+func (n *fakeNode) exec(cmd string, args []string, asking bool) interface{} {
+	var keys []string
+	switch cmd {
+	case "GET", "SET", "INCR":
+		keys = args[:1]
+	case "DEL", "EXISTS", "MGET":
+		keys = args
+	case "MSET":
+		for i := 0; i < len(args); i += 2 {
+			keys = append(keys, args[i])
+		}
+	}
+	if err := n.route(keys, asking); err != nil {
+		return err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	switch cmd {
+	case "SET", "DEL", "INCR", "MSET":
+		if n.readonly {
+			return fmt.Errorf("READONLY You can't write against a read only replica.")
+		}
+	}
+	switch cmd {
+	case "PING":
+		return "PONG"
+	case "GET":
+		if v, ok := n.data[args[0]]; ok {
+			return []byte(v)
+		}
+		return nil
+	case "SET":
+		n.data[args[0]] = args[1]
+		return "OK"
+	case "INCR":
+		i, _ := strconv.Atoi(n.data[args[0]])
+		n.data[args[0]] = strconv.Itoa(i + 1)
+		return i + 1
+	case "DEL", "EXISTS":
+		var count int
+		for _, key := range args {
+			if _, ok := n.data[key]; ok {
+				count++
+				if cmd == "DEL" {
+					delete(n.data, key)
+				}
+			}
+		}
+		return count
+	case "MGET":
+		var vs []interface{}
+		for _, key := range args {
+			if v, ok := n.data[key]; ok {
+				vs = append(vs, []byte(v))
+			} else {
+				vs = append(vs, nil)
+			}
+		}
+		return vs
+	case "MSET":
+		for i := 0; i+1 < len(args); i += 2 {
+			n.data[args[i]] = args[i+1]
+		}
+		return "OK"
+	case "CLUSTER":
+		if n.cluster != nil && len(args) > 0 && strings.ToUpper(args[0]) == "SLOTS" {
+			return n.cluster.slotsReply()
+		}
+	case "SENTINEL":
+		if n.master != nil && len(args) == 2 && strings.ToLower(args[0]) == "get-master-addr-by-name" {
+			host, port, _ := net.SplitHostPort(n.master().addr)
+			return []interface{}{[]byte(host), []byte(port)}
+		}
+	}
+	return fmt.Errorf("ERR unknown command '%s'", cmd)
+}
+
+// fakeCluster is a redis cluster of fake nodes, slots are evenly assigned to nodes.
+type fakeCluster struct {
+	nodes []*fakeNode
+
+	mu        sync.Mutex
+	slots     [_fakeSlots]*fakeNode
+	migrating map[int]*fakeNode
+}
+
+func newFakeCluster(t *testing.T, size int) *fakeCluster {
+	fc := &fakeCluster{migrating: make(map[int]*fakeNode)}
+	for i := 0; i < size; i++ {
+		n := newFakeNode(t)
+		n.cluster = fc
+		fc.nodes = append(fc.nodes, n)
+	}
+	for slot := range fc.slots {
+		fc.slots[slot] = fc.nodes[slot*size/_fakeSlots]
+	}
+	return fc
+}
+
+func (fc *fakeCluster) Close() {
+	for _, n := range fc.nodes {
+		n.Close()
+	}
+}
+
+// Owner returns the node of key slot.
+func (fc *fakeCluster) Owner(key string) *fakeNode {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return fc.slots[fakeSlot(key)]
+}
+
+// Move moves the slot of key with its keys to node n.
+func (fc *fakeCluster) Move(key string, n *fakeNode) {
+	fc.mu.Lock()
+	slot := fakeSlot(key)
+	owner := fc.slots[slot]
+	fc.slots[slot] = n
+	delete(fc.migrating, slot)
+	fc.mu.Unlock()
+	owner.mu.Lock()
+	defer owner.mu.Unlock()
+	for k, v := range owner.data {
+		if fakeSlot(k) == slot {
+			n.Set(k, v)
+			delete(owner.data, k)
+		}
+	}
+}
+
+// Migrate marks the slot of key migrating to node n, the key is moved at once.
+func (fc *fakeCluster) Migrate(key string, n *fakeNode) {
+	fc.mu.Lock()
+	slot := fakeSlot(key)
+	owner := fc.slots[slot]
+	fc.migrating[slot] = n
+	fc.mu.Unlock()
+	owner.mu.Lock()
+	defer owner.mu.Unlock()
+	if v, ok := owner.data[key]; ok {
+		n.Set(key, v)
+		delete(owner.data, key)
+	}
+}
+
+func (fc *fakeCluster) slotsReply() interface{} {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	var ranges []interface{}
+	for start := 0; start < _fakeSlots; {
+		end := start
+		for end+1 < _fakeSlots && fc.slots[end+1] == fc.slots[start] {
+			end++
+		}
+		host, port, _ := net.SplitHostPort(fc.slots[start].addr)
+		p, _ := strconv.Atoi(port)
+		ranges = append(ranges, []interface{}{start, end, []interface{}{[]byte(host), p, []byte("id")}})
+		start = end + 1
+	}
+	return ranges
+}
+
+func fakeConfig() *redis.Config {
+	return &redis.Config{
+		Config: &xpool.Config{
+			Active:      10,
+			Idle:        2,
+			IdleTimeout: xtime.Duration(time.Minute),
+		},
+		Name:         "fake",
+		Proto:        "tcp",
+		DialTimeout:  xtime.Duration(time.Second),
+		ReadTimeout:  xtime.Duration(time.Second),
+		WriteTimeout: xtime.Duration(time.Second),
+	}
+}
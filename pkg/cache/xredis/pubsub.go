@@ -0,0 +1,362 @@
+package xredis
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"kratos/pkg/cache/redis"
+	"kratos/pkg/log"
+)
+
+var errPubSubClosed = errors.New("xredis: pubsub is closed")
+
+// Subscription received after a successful subscription to channel.
+type Subscription = redis.Subscription
+
+// Pong received as result of a PING command issued by client.
+type Pong = redis.Pong
+
+// Message received as result of a PUBLISH command issued by another client,
+// Pattern is set if the message is received by a pattern subscription.
+type Message struct {
+	Channel string
+	Pattern string
+	Payload string
+}
+
+// PubSub implements Pub/Sub commands as described in
+// http://redis.io/topics/pubsub. Message receiving is NOT safe
+// for concurrent use by multiple goroutines.
+//
+// PubSub holds a connection of the pool until it is closed, the connection
+// is redialed and channels are resubscribed automatically if it is broken.
+// Receive blocks at most the read timeout of the connection once, so
+// subscribing or closing may wait for the pending Receive for that long.
+type PubSub struct {
+	newConn func(ctx context.Context) redis.Conn
+
+	mu       sync.Mutex
+	conn     redis.Conn
+	channels map[string]struct{}
+	patterns map[string]struct{}
+	closed   bool
+	done     chan struct{}
+
+	chOnce sync.Once
+	ch     chan *Message
+}
+
+func (c *Client) newPubSub() *PubSub {
+	return &PubSub{
+		newConn:  c.p.Get,
+		channels: make(map[string]struct{}),
+		patterns: make(map[string]struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Subscribe subscribes the client to the specified channels.
+// Channels can be omitted to create empty subscription.
+// Note that this method does not wait on a response from Redis, so the
+// subscription may not be active immediately. To force the connection to wait,
+// you may call the Receive() method on the returned *PubSub like so:
+//
+//    sub := client.Subscribe(ctx, queryResp)
+//    iface, err := sub.Receive(ctx)
+//    if err != nil {
+//        // handle error
+//    }
+//
+//    // Should be *Subscription, but others are possible if other actions have been
+//    // taken on sub since it was created.
+//    switch iface.(type) {
+//    case *Subscription:
+//        // subscribe succeeded
+//    case *Message:
+//        // received first message
+//    case *Pong:
+//        // pong received
+//    default:
+//        // handle error
+//    }
+//
+//    ch := sub.Channel()
+func (c *Client) Subscribe(ctx context.Context, channels ...string) *PubSub {
+	ps := c.newPubSub()
+	if len(channels) > 0 {
+		if err := ps.Subscribe(ctx, channels...); err != nil {
+			log.Errorc(ctx, "xredis: subscribe channels(%v) error(%v)", channels, err)
+		}
+	}
+	return ps
+}
+
+// PSubscribe subscribes the client to the given patterns.
+// Patterns can be omitted to create empty subscription.
+func (c *Client) PSubscribe(ctx context.Context, patterns ...string) *PubSub {
+	ps := c.newPubSub()
+	if len(patterns) > 0 {
+		if err := ps.PSubscribe(ctx, patterns...); err != nil {
+			log.Errorc(ctx, "xredis: psubscribe patterns(%v) error(%v)", patterns, err)
+		}
+	}
+	return ps
+}
+
+// connLocked returns the connection, a new one is dialed and resubscribed if there is not.
+func (ps *PubSub) connLocked(ctx context.Context) (redis.Conn, error) {
+	if ps.closed {
+		return nil, errPubSubClosed
+	}
+	if ps.conn != nil {
+		return ps.conn, nil
+	}
+	conn := ps.newConn(ctx)
+	if err := ps.resubscribe(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	ps.conn = conn
+	return conn, nil
+}
+
+func (ps *PubSub) resubscribe(conn redis.Conn) (err error) {
+	if len(ps.channels) > 0 {
+		if err = conn.Send("SUBSCRIBE", setArgs(ps.channels)...); err != nil {
+			return
+		}
+	}
+	if len(ps.patterns) > 0 {
+		if err = conn.Send("PSUBSCRIBE", setArgs(ps.patterns)...); err != nil {
+			return
+		}
+	}
+	return conn.Flush()
+}
+
+func setArgs(set map[string]struct{}) []interface{} {
+	args := make([]interface{}, 0, len(set))
+	for s := range set {
+		args = append(args, s)
+	}
+	return args
+}
+
+// releaseConnLocked closes the broken connection, the next call dials a new one.
+func (ps *PubSub) releaseConnLocked() {
+	if ps.conn != nil {
+		ps.conn.Close()
+		ps.conn = nil
+	}
+}
+
+// command sends the pubsub command, channels are recorded to be resubscribed on reconnection.
+func (ps *PubSub) command(ctx context.Context, name string, set map[string]struct{}, add bool, channels ...string) (err error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if ps.closed {
+		return errPubSubClosed
+	}
+	// unsubscribing all.
+	if !add && len(channels) == 0 {
+		for ch := range set {
+			delete(set, ch)
+		}
+	}
+	args := make([]interface{}, len(channels))
+	for i, ch := range channels {
+		args[i] = ch
+		if add {
+			set[ch] = struct{}{}
+		} else {
+			delete(set, ch)
+		}
+	}
+	if ps.conn == nil {
+		// a new connection subscribes all the channels.
+		if add {
+			_, err = ps.connLocked(ctx)
+		}
+		return
+	}
+	if err = ps.conn.Send(name, args...); err == nil {
+		err = ps.conn.Flush()
+	}
+	if err != nil {
+		ps.releaseConnLocked()
+	}
+	return
+}
+
+// Subscribe the client to the specified channels. It returns
+// empty subscription if there are no channels.
+func (ps *PubSub) Subscribe(ctx context.Context, channels ...string) error {
+	return ps.command(ctx, "SUBSCRIBE", ps.channels, true, channels...)
+}
+
+// PSubscribe the client to the given patterns. It returns
+// empty subscription if there are no patterns.
+func (ps *PubSub) PSubscribe(ctx context.Context, patterns ...string) error {
+	return ps.command(ctx, "PSUBSCRIBE", ps.patterns, true, patterns...)
+}
+
+// Unsubscribe the client from the given channels, or from all of
+// them if none is given.
+func (ps *PubSub) Unsubscribe(ctx context.Context, channels ...string) error {
+	return ps.command(ctx, "UNSUBSCRIBE", ps.channels, false, channels...)
+}
+
+// PUnsubscribe the client from the given patterns, or from all of
+// them if none is given.
+func (ps *PubSub) PUnsubscribe(ctx context.Context, patterns ...string) error {
+	return ps.command(ctx, "PUNSUBSCRIBE", ps.patterns, false, patterns...)
+}
+
+// Ping sends a PING to the server, the Pong is received by Receive.
+func (ps *PubSub) Ping(ctx context.Context, payload ...string) (err error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	conn, err := ps.connLocked(ctx)
+	if err != nil {
+		return
+	}
+	args := make([]interface{}, len(payload))
+	for i, p := range payload {
+		args[i] = p
+	}
+	if err = conn.Send("PING", args...); err == nil {
+		err = conn.Flush()
+	}
+	if err != nil {
+		ps.releaseConnLocked()
+	}
+	return
+}
+
+// Receive returns a message as a *Subscription, *Message, *Pong or error.
+// See PubSub example for details. This is low-level API and in most cases
+// Channel should be used instead.
+//
+// Receive waits until a message is received or ctx is done, read timeouts
+// of the idle connection are retried transparently.
+func (ps *PubSub) Receive(ctx context.Context) (interface{}, error) {
+	for {
+		msg, err := ps.receive(ctx)
+		if err == nil {
+			return msg, nil
+		}
+		if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ps.done:
+			return nil, errPubSubClosed
+		default:
+		}
+	}
+}
+
+func (ps *PubSub) receive(ctx context.Context) (interface{}, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	conn, err := ps.connLocked(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch v := (redis.PubSubConn{Conn: conn}).Receive().(type) {
+	case redis.Message:
+		return &Message{Channel: v.Channel, Payload: string(v.Data)}, nil
+	case redis.PMessage:
+		return &Message{Channel: v.Channel, Pattern: v.Pattern, Payload: string(v.Data)}, nil
+	case redis.Subscription:
+		return &v, nil
+	case redis.Pong:
+		return &v, nil
+	case redis.Error:
+		return nil, v
+	case error:
+		// the connection is broken or timed out while idle.
+		ps.releaseConnLocked()
+		return nil, unwrapNetError(v)
+	default:
+		return nil, errors.New("xredis: unexpected pubsub reply")
+	}
+}
+
+// unwrapNetError returns the net.Error wrapped with stack by the connection.
+func unwrapNetError(err error) error {
+	var ne net.Error
+	if errors.As(err, &ne) {
+		return ne
+	}
+	return err
+}
+
+// ReceiveMessage returns a Message or error ignoring Subscription and Pong
+// messages. This is low-level API and in most cases Channel should be used
+// instead.
+func (ps *PubSub) ReceiveMessage(ctx context.Context) (*Message, error) {
+	for {
+		msg, err := ps.Receive(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if m, ok := msg.(*Message); ok {
+			return m, nil
+		}
+	}
+}
+
+// Channel returns a Go channel for concurrently receiving messages.
+// The channel is closed together with the PubSub. Receive* APIs can
+// not be used after channel is created.
+func (ps *PubSub) Channel() <-chan *Message {
+	ps.chOnce.Do(func() {
+		ps.ch = make(chan *Message, 100)
+		go ps.deliver()
+	})
+	return ps.ch
+}
+
+func (ps *PubSub) deliver() {
+	defer close(ps.ch)
+	ctx := context.Background()
+	for {
+		msg, err := ps.ReceiveMessage(ctx)
+		if err != nil {
+			if err == errPubSubClosed {
+				return
+			}
+			log.Error("xredis: pubsub receive error(%v)", err)
+			select {
+			case <-ps.done:
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+		select {
+		case ps.ch <- msg:
+		case <-ps.done:
+			return
+		}
+	}
+}
+
+// Close unsubscribes all the channels and returns the connection to the pool.
+func (ps *PubSub) Close() error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if ps.closed {
+		return errPubSubClosed
+	}
+	ps.closed = true
+	close(ps.done)
+	ps.releaseConnLocked()
+	return nil
+}
@@ -0,0 +1,72 @@
+package xredis
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPubSub(t *testing.T) {
+	s, c := newMiniClient(t)
+	defer s.Close()
+	defer c.Close()
+	ctx := context.TODO()
+
+	ps := c.Subscribe(ctx, "news")
+	msg, err := ps.Receive(ctx)
+	if sub, ok := msg.(*Subscription); err != nil || !ok || sub.Channel != "news" {
+		t.Fatalf("receive subscription got %v, %v", msg, err)
+	}
+	if err = ps.PSubscribe(ctx, "sport.*"); err != nil {
+		t.Fatalf("psubscribe error, %v", err)
+	}
+	if msg, err = ps.Receive(ctx); err != nil {
+		t.Fatalf("receive psubscription error, %v", err)
+	}
+	if err = c.Publish(ctx, "news", "hello").Err(); err != nil {
+		t.Fatalf("publish error, %v", err)
+	}
+	m, err := ps.ReceiveMessage(ctx)
+	if err != nil || m.Channel != "news" || m.Payload != "hello" {
+		t.Fatalf("receive message got %+v, %v", m, err)
+	}
+
+	ch := ps.Channel()
+	c.Publish(ctx, "sport.ball", "goal")
+	select {
+	case m = <-ch:
+		if m.Pattern != "sport.*" || m.Channel != "sport.ball" || m.Payload != "goal" {
+			t.Fatalf("pattern message got %+v", m)
+		}
+	case <-time.After(time.Second * 3):
+		t.Fatalf("pattern message should be received")
+	}
+
+	// the connection is redialed and channels are resubscribed.
+	s.Restart()
+	deadline := time.Now().Add(time.Second * 5)
+	for time.Now().Before(deadline) {
+		c.Publish(ctx, "news", "again")
+		select {
+		case m = <-ch:
+		case <-time.After(time.Millisecond * 100):
+			continue
+		}
+		break
+	}
+	if m.Payload != "again" {
+		t.Fatalf("message should be received after reconnection, got %+v", m)
+	}
+
+	if err = ps.Close(); err != nil {
+		t.Fatalf("close error, %v", err)
+	}
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Fatalf("channel should be closed")
+		}
+	case <-time.After(time.Second * 3):
+		t.Fatalf("channel should be closed after Close")
+	}
+}
@@ -22,9 +22,10 @@ type Client struct {
 
 type Config = redis.Config
 
+// New new a client by the deploy mode of config, see redis.Config.
 func New(conf *Config, options ...redis.DialOption) (c *Client) {
 	c = &Client{
-		p:        redis.NewPool(conf, options...),
+		p:        poolWrap{redis.NewRedis(conf, options...)},
 		selfInit: true,
 	}
 	c.cmdable = c.Process
@@ -89,15 +90,26 @@ func (c *Client) Process(ctx context.Context, cmd Cmder) error {
 
 func (c *Client) processPipeline(ctx context.Context, cmds []Cmder) (err error) {
 	// 验证参数构建问题
-	for _, cmd := range cmds {
-		if err = cmd.Err(); err != nil {
-			return
-		}
+	if err = cmdsFirstErr(cmds); err != nil {
+		return
 	}
 
 	conn := c.p.Get(ctx)
 	defer conn.Close()
+	return processPipeline(conn, cmds)
+}
+
+// cmdsFirstErr returns the error of the first failed command if any.
+func cmdsFirstErr(cmds []Cmder) error {
+	for _, cmd := range cmds {
+		if err := cmd.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
+func processPipeline(conn redis.Conn, cmds []Cmder) (err error) {
 	for _, cmd := range cmds {
 		err = conn.Send(cmd.Name(), cmd.Args()[1:]...)
 		if err != nil {
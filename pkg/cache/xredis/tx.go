@@ -0,0 +1,219 @@
+package xredis
+
+import (
+	"context"
+	"errors"
+
+	"kratos/pkg/cache/redis"
+)
+
+// TxFailedErr transaction redis failed.
+var TxFailedErr = errors.New("xredis: transaction failed")
+
+// Tx implements Redis transactions as described in
+// http://redis.io/topics/transactions. It's NOT safe for concurrent use
+// by multiple goroutines, because Exec resets list of watched keys.
+//
+// If you don't need WATCH, use TxPipelined of Client instead.
+type Tx struct {
+	cmdable
+
+	conn redis.Conn
+}
+
+func newTx(conn redis.Conn) *Tx {
+	tx := &Tx{conn: conn}
+	tx.cmdable = tx.Process
+	return tx
+}
+
+// Watch prepares a transaction and marks the keys to be watched
+// for conditional execution if there are any keys.
+//
+// The transaction is automatically closed when fn exits.
+func (c *Client) Watch(ctx context.Context, fn func(*Tx) error, keys ...string) error {
+	conn := c.p.Get(ctx)
+	// closing a watching conn sends UNWATCH before putting it back to the pool.
+	defer conn.Close()
+
+	tx := newTx(conn)
+	if len(keys) > 0 {
+		if err := tx.Watch(ctx, keys...).Err(); err != nil {
+			return err
+		}
+	}
+	return fn(tx)
+}
+
+// Process processes the cmd with the connection of transaction.
+func (tx *Tx) Process(ctx context.Context, cmd Cmder) error {
+	if cmd.Err() != nil {
+		return cmd.Err()
+	}
+	cmd.setReply(tx.conn.Do(cmd.Name(), cmd.Args()[1:]...))
+	return cmd.Err()
+}
+
+// Do creates a Cmd from the args and processes the cmd.
+func (tx *Tx) Do(ctx context.Context, args ...interface{}) *Cmd {
+	cmd := NewCmd(ctx, args...)
+	_ = tx.Process(ctx, cmd)
+	return cmd
+}
+
+// Watch marks the keys to be watched for conditional execution
+// of a transaction.
+func (tx *Tx) Watch(ctx context.Context, keys ...string) *StatusCmd {
+	args := make([]interface{}, 1+len(keys))
+	args[0] = "watch"
+	for i, key := range keys {
+		args[1+i] = key
+	}
+	cmd := NewStatusCmd(ctx, args...)
+	_ = tx.Process(ctx, cmd)
+	return cmd
+}
+
+// Unwatch flushes all the previously watched keys for a transaction.
+func (tx *Tx) Unwatch(ctx context.Context, keys ...string) *StatusCmd {
+	args := make([]interface{}, 1+len(keys))
+	args[0] = "unwatch"
+	for i, key := range keys {
+		args[1+i] = key
+	}
+	cmd := NewStatusCmd(ctx, args...)
+	_ = tx.Process(ctx, cmd)
+	return cmd
+}
+
+// Pipeline creates a pipeline on the connection of transaction.
+func (tx *Tx) Pipeline() Pipeliner {
+	pipe := Pipeline{
+		exec: func(ctx context.Context, cmds []Cmder) error {
+			if err := cmdsFirstErr(cmds); err != nil {
+				return err
+			}
+			return processPipeline(tx.conn, cmds)
+		},
+	}
+	pipe.init()
+	return &pipe
+}
+
+func (tx *Tx) Pipelined(ctx context.Context, fn func(Pipeliner) error) ([]Cmder, error) {
+	return tx.Pipeline().Pipelined(ctx, fn)
+}
+
+// TxPipeline creates a pipeline which wraps queued commands with MULTI/EXEC,
+// the commands are executed only if the watched keys are not modified.
+func (tx *Tx) TxPipeline() Pipeliner {
+	pipe := Pipeline{
+		exec: func(ctx context.Context, cmds []Cmder) error {
+			return processTxPipeline(tx.conn, cmds)
+		},
+	}
+	pipe.init()
+	return &pipe
+}
+
+// TxPipelined executes commands queued in the fn in a transaction.
+//
+// When using WATCH, EXEC will execute commands only if the watched keys
+// were not modified, allowing for a check-and-set mechanism.
+//
+// Exec always returns list of commands. If transaction fails
+// TxFailedErr is returned. Otherwise Exec returns an error of the first
+// failed command or nil.
+func (tx *Tx) TxPipelined(ctx context.Context, fn func(Pipeliner) error) ([]Cmder, error) {
+	return tx.TxPipeline().Pipelined(ctx, fn)
+}
+
+// TxPipeline creates a pipeline which wraps queued commands with MULTI/EXEC.
+func (c *Client) TxPipeline() Pipeliner {
+	pipe := Pipeline{
+		exec: func(ctx context.Context, cmds []Cmder) error {
+			conn := c.p.Get(ctx)
+			defer conn.Close()
+			return processTxPipeline(conn, cmds)
+		},
+	}
+	pipe.init()
+	return &pipe
+}
+
+// TxPipelined executes commands queued in the fn in a transaction, see Tx.TxPipelined.
+func (c *Client) TxPipelined(ctx context.Context, fn func(Pipeliner) error) ([]Cmder, error) {
+	return c.TxPipeline().Pipelined(ctx, fn)
+}
+
+func processTxPipeline(conn redis.Conn, cmds []Cmder) (err error) {
+	// 验证参数构建问题
+	if err = cmdsFirstErr(cmds); err != nil {
+		return
+	}
+
+	if err = conn.Send("MULTI"); err != nil {
+		return
+	}
+	for _, cmd := range cmds {
+		if err = conn.Send(cmd.Name(), cmd.Args()[1:]...); err != nil {
+			return
+		}
+	}
+	if err = conn.Send("EXEC"); err != nil {
+		return
+	}
+	if err = conn.Flush(); err != nil {
+		return
+	}
+
+	// replies of MULTI and queued commands are OK and QUEUED, or errors
+	// if the command is rejected, the whole transaction is aborted then.
+	if _, err = conn.Receive(); err != nil {
+		setCmdsErr(cmds, err)
+		return
+	}
+	for _, cmd := range cmds {
+		if _, qerr := conn.Receive(); qerr != nil {
+			cmd.setErr(qerr)
+		}
+	}
+	reply, err := conn.Receive()
+	if err != nil {
+		for _, cmd := range cmds {
+			if cmd.Err() == nil {
+				cmd.setErr(err)
+			}
+		}
+		return
+	}
+	if reply == nil {
+		setCmdsErr(cmds, TxFailedErr)
+		return TxFailedErr
+	}
+	replies, err := redis.Values(reply, nil)
+	if err != nil {
+		setCmdsErr(cmds, err)
+		return
+	}
+	if len(replies) != len(cmds) {
+		err = errors.New("xredis: unexpected exec reply length")
+		setCmdsErr(cmds, err)
+		return
+	}
+	for i, cmd := range cmds {
+		// errors of executed commands are elements of the EXEC reply.
+		if e, ok := replies[i].(redis.Error); ok {
+			cmd.setReply(nil, e)
+		} else {
+			cmd.setReply(replies[i], nil)
+		}
+	}
+	return cmdsFirstErr(cmds)
+}
+
+func setCmdsErr(cmds []Cmder, e error) {
+	for _, cmd := range cmds {
+		cmd.setErr(e)
+	}
+}
@@ -0,0 +1,76 @@
+package xredis
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTxPipelined(t *testing.T) {
+	s, c := newMiniClient(t)
+	defer s.Close()
+	defer c.Close()
+	ctx := context.TODO()
+
+	cmds, err := c.TxPipelined(ctx, func(p Pipeliner) error {
+		p.Incr(ctx, "counter")
+		p.Incr(ctx, "counter")
+		p.Get(ctx, "counter")
+		return nil
+	})
+	if err != nil || len(cmds) != 3 {
+		t.Fatalf("tx pipelined got %v, %v", cmds, err)
+	}
+	if v := cmds[2].(*StringCmd).Val(); v != "2" {
+		t.Fatalf("get in tx got %s", v)
+	}
+
+	s.Set("str", "v")
+	cmds, err = c.TxPipelined(ctx, func(p Pipeliner) error {
+		p.Incr(ctx, "str")
+		p.Incr(ctx, "counter")
+		return nil
+	})
+	if err == nil || cmds[0].Err() == nil || cmds[1].Err() != nil {
+		t.Fatalf("error of the failed command should be returned, got %v, %v", cmds, err)
+	}
+	if v, _ := s.Get("counter"); v != "3" {
+		t.Fatalf("other commands should be executed, got %s", v)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	s, c := newMiniClient(t)
+	defer s.Close()
+	defer c.Close()
+	ctx := context.TODO()
+
+	incr := func(tx *Tx) error {
+		n, err := tx.Get(ctx, "key").Int64()
+		if err != nil && err != ErrNil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(p Pipeliner) error {
+			p.Set(ctx, "key", n+1, 0)
+			return nil
+		})
+		return err
+	}
+	if err := c.Watch(ctx, incr, "key"); err != nil {
+		t.Fatalf("watch error, %v", err)
+	}
+	if v, _ := s.Get("key"); v != "1" {
+		t.Fatalf("key should be incremented, got %s", v)
+	}
+
+	err := c.Watch(ctx, func(tx *Tx) error {
+		// modified by others after watching.
+		s.Set("key", "10")
+		return incr(tx)
+	}, "key")
+	if err != TxFailedErr {
+		t.Fatalf("watch should fail if the key is modified, got %v", err)
+	}
+	if v, _ := s.Get("key"); v != "10" {
+		t.Fatalf("key should not be changed by failed transaction, got %s", v)
+	}
+}
@@ -0,0 +1,6 @@
+package etcd
+
+const (
+	// PaladinDriverEtcd ...
+	PaladinDriverEtcd = "etcd"
+)
@@ -0,0 +1,369 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc"
+
+	"kratos/pkg/conf/paladin"
+	"kratos/pkg/net/netutil"
+)
+
+var (
+	_ paladin.Client = &etcd{}
+
+	_defaultBackoff = netutil.BackoffConfig{
+		MaxDelay:  10 * time.Second,
+		BaseDelay: 100 * time.Millisecond,
+		Factor:    1.6,
+		Jitter:    0.2,
+	}
+)
+
+type etcdWatcher struct {
+	keys []string // in etcd, they're keys under the prefix
+	C    chan paladin.Event
+}
+
+func newEtcdWatcher(keys []string) *etcdWatcher {
+	return &etcdWatcher{keys: keys, C: make(chan paladin.Event, 5)}
+}
+
+func (ew *etcdWatcher) HasKey(key string) bool {
+	if len(ew.keys) == 0 {
+		return true
+	}
+	for _, k := range ew.keys {
+		if paladin.KeyNamed(k) == paladin.KeyNamed(key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ew *etcdWatcher) Handle(event paladin.Event) {
+	select {
+	case ew.C <- event:
+	default:
+		log.Printf("paladin: event channel full discard key %s update event", event.Key)
+	}
+}
+
+// etcd is etcd config client.
+type etcd struct {
+	kv      clientv3.KV
+	watcher clientv3.Watcher
+	closer  io.Closer
+	prefix  string
+	backoff netutil.BackoffConfig
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	values   *paladin.Map
+	wmu      sync.RWMutex
+	watchers map[*etcdWatcher]struct{}
+}
+
+// Config is etcd config client config.
+type Config struct {
+	Endpoints   []string      `json:"endpoints"`
+	Prefix      string        `json:"prefix"`
+	Username    string        `json:"username"`
+	Password    string        `json:"password"`
+	DialTimeout time.Duration `json:"dial_timeout"`
+}
+
+type etcdDriver struct{}
+
+var (
+	confEndpoints, confPrefix, confUsername, confPassword string
+)
+
+func init() {
+	addEtcdFlags()
+	paladin.Register(PaladinDriverEtcd, &etcdDriver{})
+}
+
+func addEtcdFlags() {
+	// NOTE: etcd.* flags are used by naming/etcd, so paladin.etcd.* is used here.
+	flag.StringVar(&confEndpoints, "paladin.etcd.endpoints", "", "etcd endpoints, comma separated, e.g. 127.0.0.1:2379,127.0.0.2:2379")
+	flag.StringVar(&confPrefix, "paladin.etcd.prefix", "", "etcd key prefix of configs, e.g. /kratos/config/app")
+	flag.StringVar(&confUsername, "paladin.etcd.username", "", "etcd username")
+	flag.StringVar(&confPassword, "paladin.etcd.password", "", "etcd password")
+}
+
+func buildConfigForEtcd() (c *Config, err error) {
+	if endpointsFromEnv := os.Getenv("PALADIN_ETCD_ENDPOINTS"); endpointsFromEnv != "" {
+		confEndpoints = endpointsFromEnv
+	}
+	if confEndpoints == "" {
+		confEndpoints = os.Getenv("ETCD_ENDPOINTS")
+	}
+	if confEndpoints == "" {
+		err = errors.New("invalid etcd endpoints, pass it via PALADIN_ETCD_ENDPOINTS=xxx with env or --paladin.etcd.endpoints=xxx with flag")
+		return
+	}
+	if prefixFromEnv := os.Getenv("PALADIN_ETCD_PREFIX"); prefixFromEnv != "" {
+		confPrefix = prefixFromEnv
+	}
+	if confPrefix == "" {
+		err = errors.New("invalid etcd prefix, pass it via PALADIN_ETCD_PREFIX=xxx with env or --paladin.etcd.prefix=xxx with flag")
+		return
+	}
+	if usernameFromEnv := os.Getenv("PALADIN_ETCD_USERNAME"); usernameFromEnv != "" {
+		confUsername = usernameFromEnv
+	}
+	if passwordFromEnv := os.Getenv("PALADIN_ETCD_PASSWORD"); passwordFromEnv != "" {
+		confPassword = passwordFromEnv
+	}
+	c = &Config{
+		Endpoints: strings.Split(confEndpoints, ","),
+		Prefix:    confPrefix,
+		Username:  confUsername,
+		Password:  confPassword,
+	}
+	return
+}
+
+// New new an etcd config client.
+// it watches keys under the prefix and updates local cache.
+// BTW, in our context, keys in etcd without prefix means keys in paladin.
+func (ed *etcdDriver) New() (paladin.Client, error) {
+	c, err := buildConfigForEtcd()
+	if err != nil {
+		return nil, err
+	}
+	return ed.new(c)
+}
+
+func (ed *etcdDriver) new(conf *Config) (paladin.Client, error) {
+	if conf == nil || len(conf.Endpoints) == 0 {
+		return nil, errors.New("invalid etcd conf")
+	}
+	if conf.DialTimeout <= 0 {
+		conf.DialTimeout = 5 * time.Second
+	}
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints:   conf.Endpoints,
+		Username:    conf.Username,
+		Password:    conf.Password,
+		DialTimeout: conf.DialTimeout,
+		DialOptions: []grpc.DialOption{grpc.WithBlock()},
+	})
+	if err != nil {
+		return nil, err
+	}
+	e, err := newEtcd(cli.KV, cli.Watcher, conf.Prefix)
+	if err != nil {
+		cli.Close()
+		return nil, err
+	}
+	e.closer = cli
+	return e, nil
+}
+
+// newEtcd loads values under prefix and starts a watch daemon.
+func newEtcd(kv clientv3.KV, watcher clientv3.Watcher, prefix string) (*etcd, error) {
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &etcd{
+		kv:       kv,
+		watcher:  watcher,
+		prefix:   prefix,
+		backoff:  _defaultBackoff,
+		ctx:      ctx,
+		cancel:   cancel,
+		values:   new(paladin.Map),
+		watchers: make(map[*etcdWatcher]struct{}),
+	}
+	raws, rev, err := e.loadValues()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	e.values.Store(raws)
+	go e.watchproc(rev)
+	return e, nil
+}
+
+// key returns paladin key of etcd key.
+func (e *etcd) key(k []byte) string {
+	return strings.TrimPrefix(string(k), e.prefix)
+}
+
+// loadValues load values under prefix, returns values and the revision of them.
+func (e *etcd) loadValues() (values map[string]*paladin.Value, rev int64, err error) {
+	resp, err := e.kv.Get(e.ctx, e.prefix, clientv3.WithPrefix())
+	if err != nil {
+		return
+	}
+	values = make(map[string]*paladin.Value, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		values[e.key(kv.Key)] = paladin.NewValue(string(kv.Value), string(kv.Value))
+	}
+	rev = resp.Header.Revision
+	return
+}
+
+// reloadValues reload all values and send events of the differences,
+// it is used when watch can not be resumed, e.g. revision compacted.
+func (e *etcd) reloadValues() (rev int64, err error) {
+	raws, rev, err := e.loadValues()
+	if err != nil {
+		return
+	}
+	e.mu.Lock()
+	olds := e.values.Load()
+	e.values.Store(raws)
+	e.mu.Unlock()
+	news := make(map[string]struct{}, len(raws))
+	for key, value := range raws {
+		news[paladin.KeyNamed(key)] = struct{}{}
+		content, _ := value.Raw()
+		old, ok := olds[paladin.KeyNamed(key)]
+		if !ok {
+			e.notify(paladin.Event{Event: paladin.EventAdd, Key: key, Value: content})
+			continue
+		}
+		if raw, _ := old.Raw(); raw != content {
+			e.notify(paladin.Event{Event: paladin.EventUpdate, Key: key, Value: content})
+		}
+	}
+	for key := range olds {
+		if _, ok := news[key]; !ok {
+			e.notify(paladin.Event{Event: paladin.EventRemove, Key: key})
+		}
+	}
+	return
+}
+
+// applyEvent apply etcd watch event to values and send event.
+func (e *etcd) applyEvent(ev *clientv3.Event) {
+	key := e.key(ev.Kv.Key)
+	e.mu.Lock()
+	raws := e.values.Load()
+	event := paladin.Event{Key: key}
+	switch ev.Type {
+	case mvccpb.PUT:
+		event.Value = string(ev.Kv.Value)
+		event.Event = paladin.EventUpdate
+		if _, ok := raws[paladin.KeyNamed(key)]; !ok {
+			event.Event = paladin.EventAdd
+		}
+		raws[key] = paladin.NewValue(event.Value, event.Value)
+	case mvccpb.DELETE:
+		event.Event = paladin.EventRemove
+		delete(raws, paladin.KeyNamed(key))
+	}
+	e.values.Store(raws)
+	e.mu.Unlock()
+	e.notify(event)
+}
+
+func (e *etcd) notify(event paladin.Event) {
+	e.wmu.RLock()
+	n := 0
+	for w := range e.watchers {
+		if w.HasKey(event.Key) {
+			n++
+			w.Handle(event)
+		}
+	}
+	e.wmu.RUnlock()
+	log.Printf("paladin: reload config: %s events: %d\n", event.Key, n)
+}
+
+// etcd config daemon to watch keys under prefix since rev.
+// it resumes watching after leader loss and reloads all values after compaction.
+func (e *etcd) watchproc(rev int64) {
+	var retries int
+	for {
+		ctx, cancel := context.WithCancel(clientv3.WithRequireLeader(e.ctx))
+		wch := e.watcher.Watch(ctx, e.prefix, clientv3.WithPrefix(), clientv3.WithRev(rev+1))
+		var compacted bool
+		for resp := range wch {
+			if resp.CompactRevision != 0 {
+				log.Printf("paladin: etcd watch %s revision %d compacted by %d", e.prefix, rev+1, resp.CompactRevision)
+				compacted = true
+				break
+			}
+			if err := resp.Err(); err != nil {
+				log.Printf("paladin: etcd watch %s error: %s", e.prefix, err)
+				break
+			}
+			retries = 0
+			for _, ev := range resp.Events {
+				e.applyEvent(ev)
+			}
+			if resp.Header.Revision > rev {
+				rev = resp.Header.Revision
+			}
+		}
+		cancel()
+		if e.ctx.Err() != nil {
+			return
+		}
+		select {
+		case <-time.After(e.backoff.Backoff(retries)):
+		case <-e.ctx.Done():
+			return
+		}
+		retries++
+		if compacted {
+			newRev, err := e.reloadValues()
+			if err != nil {
+				log.Printf("paladin: etcd reload %s error: %s, retry", e.prefix, err)
+				continue
+			}
+			rev = newRev
+		}
+	}
+}
+
+// Get return value by key.
+func (e *etcd) Get(key string) *paladin.Value {
+	return e.values.Get(key)
+}
+
+// GetAll return value map.
+func (e *etcd) GetAll() *paladin.Map {
+	return e.values
+}
+
+// WatchEvent watch with the specified keys.
+func (e *etcd) WatchEvent(ctx context.Context, keys ...string) <-chan paladin.Event {
+	ew := newEtcdWatcher(keys)
+	e.wmu.Lock()
+	e.watchers[ew] = struct{}{}
+	e.wmu.Unlock()
+	return ew.C
+}
+
+// Close close watcher.
+func (e *etcd) Close() (err error) {
+	e.cancel()
+	if e.closer != nil {
+		err = e.closer.Close()
+	}
+	e.wmu.Lock()
+	for w := range e.watchers {
+		close(w.C)
+		delete(e.watchers, w)
+	}
+	e.wmu.Unlock()
+	return
+}
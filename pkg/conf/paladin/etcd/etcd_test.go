@@ -0,0 +1,369 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"kratos/pkg/conf/paladin"
+)
+
+// fakeEtcd is an in-memory etcd stand-in implements clientv3.KV (only Get) and clientv3.Watcher.
+// NOTE: go.etcd.io/etcd/server/v3/embed requires a newer grpc than the one
+// replaced in go.mod, TestEtcdServer covers the real server by TEST_ETCD_ADDR.
+type fakeEtcd struct {
+	clientv3.KV
+
+	mu        sync.Mutex
+	rev       int64
+	compacted int64
+	kvs       map[string]*mvccpb.KeyValue
+	history   []*clientv3.Event
+	watches   map[chan clientv3.WatchResponse]string
+}
+
+func newFakeEtcd() *fakeEtcd {
+	return &fakeEtcd{
+		rev:     1,
+		kvs:     make(map[string]*mvccpb.KeyValue),
+		watches: make(map[chan clientv3.WatchResponse]string),
+	}
+}
+
+func (f *fakeEtcd) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	resp := &clientv3.GetResponse{Header: &pb.ResponseHeader{Revision: f.rev}}
+	for k, kv := range f.kvs {
+		if strings.HasPrefix(k, key) {
+			resp.Kvs = append(resp.Kvs, kv)
+		}
+	}
+	return resp, nil
+}
+
+func (f *fakeEtcd) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch := make(chan clientv3.WatchResponse, 64)
+	rev := clientv3.OpGet(key, opts...).Rev()
+	if rev > 0 && rev <= f.compacted {
+		ch <- clientv3.WatchResponse{Header: pb.ResponseHeader{Revision: f.rev}, CompactRevision: f.compacted, Canceled: true}
+		close(ch)
+		return ch
+	}
+	for _, ev := range f.history {
+		if ev.Kv.ModRevision >= rev && strings.HasPrefix(string(ev.Kv.Key), key) {
+			ch <- clientv3.WatchResponse{Header: pb.ResponseHeader{Revision: ev.Kv.ModRevision}, Events: []*clientv3.Event{ev}}
+		}
+	}
+	f.watches[ch] = key
+	go func() {
+		<-ctx.Done()
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if _, ok := f.watches[ch]; ok {
+			delete(f.watches, ch)
+			close(ch)
+		}
+	}()
+	return ch
+}
+
+func (f *fakeEtcd) RequestProgress(ctx context.Context) error { return nil }
+
+func (f *fakeEtcd) Close() error { return nil }
+
+func (f *fakeEtcd) Set(key, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.putLocked(key, value)
+}
+
+func (f *fakeEtcd) Remove(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleteLocked(key)
+}
+
+// Disconnect cancels all watches like leader lost, applies fn, then compacts history if compact is true.
+func (f *fakeEtcd) Disconnect(compact bool, fn func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch := range f.watches {
+		ch <- clientv3.WatchResponse{Header: pb.ResponseHeader{Revision: f.rev}, Canceled: true}
+		delete(f.watches, ch)
+		close(ch)
+	}
+	if fn != nil {
+		fn()
+	}
+	if compact {
+		f.compacted = f.rev
+		f.history = nil
+	}
+}
+
+func (f *fakeEtcd) putLocked(key, value string) {
+	f.rev++
+	kv := &mvccpb.KeyValue{Key: []byte(key), Value: []byte(value), ModRevision: f.rev, CreateRevision: f.rev, Version: 1}
+	if old, ok := f.kvs[key]; ok {
+		kv.CreateRevision = old.CreateRevision
+		kv.Version = old.Version + 1
+	}
+	f.kvs[key] = kv
+	f.appendLocked(&clientv3.Event{Type: mvccpb.PUT, Kv: kv})
+}
+
+func (f *fakeEtcd) deleteLocked(key string) {
+	if _, ok := f.kvs[key]; !ok {
+		return
+	}
+	f.rev++
+	delete(f.kvs, key)
+	f.appendLocked(&clientv3.Event{Type: mvccpb.DELETE, Kv: &mvccpb.KeyValue{Key: []byte(key), ModRevision: f.rev}})
+}
+
+func (f *fakeEtcd) appendLocked(ev *clientv3.Event) {
+	f.history = append(f.history, ev)
+	for ch, prefix := range f.watches {
+		if strings.HasPrefix(string(ev.Kv.Key), prefix) {
+			ch <- clientv3.WatchResponse{Header: pb.ResponseHeader{Revision: f.rev}, Events: []*clientv3.Event{ev}}
+		}
+	}
+}
+
+func waitEvent(t *testing.T, events <-chan paladin.Event) paladin.Event {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second * 5):
+		t.Fatalf("wait event timeout")
+	}
+	return paladin.Event{}
+}
+
+// gateWatcher wraps the watcher of a real etcd client, Disconnect cancels running
+// watches and blocks new ones until Connect, like a client lost the connection.
+type gateWatcher struct {
+	clientv3.Watcher
+
+	mu      sync.Mutex
+	open    chan struct{}
+	cancels []context.CancelFunc
+}
+
+func newGateWatcher(w clientv3.Watcher) *gateWatcher {
+	g := &gateWatcher{Watcher: w, open: make(chan struct{})}
+	close(g.open)
+	return g
+}
+
+func (g *gateWatcher) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	g.mu.Lock()
+	open := g.open
+	g.mu.Unlock()
+	select {
+	case <-open:
+	case <-ctx.Done():
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	g.mu.Lock()
+	g.cancels = append(g.cancels, cancel)
+	g.mu.Unlock()
+	return g.Watcher.Watch(ctx, key, opts...)
+}
+
+func (g *gateWatcher) Disconnect() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.open = make(chan struct{})
+	for _, cancel := range g.cancels {
+		cancel()
+	}
+	g.cancels = nil
+}
+
+func (g *gateWatcher) Connect() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	close(g.open)
+}
+
+// TestEtcdServer runs against a real etcd server, e.g. the one of
+// test/docker-compose.yaml: TEST_ETCD_ADDR=127.0.0.1:2379 go test
+// It is gated by env on purpose instead of embed.Etcd, see the NOTE of
+// fakeEtcd, so the runs without etcd only cover the fake.
+func TestEtcdServer(t *testing.T) {
+	addr := os.Getenv("TEST_ETCD_ADDR")
+	if addr == "" || testing.Short() {
+		t.Skip("TEST_ETCD_ADDR not provide skip test.")
+	}
+	_defaultBackoff.BaseDelay = time.Millisecond * 10
+	cli, err := clientv3.New(clientv3.Config{Endpoints: strings.Split(addr, ","), DialTimeout: time.Second * 5})
+	if err != nil {
+		t.Fatalf("new etcd client error, %v", err)
+	}
+	defer cli.Close()
+	ctx := context.TODO()
+	prefix := fmt.Sprintf("/kratos/test/%d/", time.Now().UnixNano())
+	defer cli.Delete(ctx, prefix, clientv3.WithPrefix())
+	put := func(key, value string) int64 {
+		t.Helper()
+		resp, err := cli.Put(ctx, prefix+key, value)
+		if err != nil {
+			t.Fatalf("put %s error, %v", key, err)
+		}
+		return resp.Header.Revision
+	}
+	del := func(key string) int64 {
+		t.Helper()
+		resp, err := cli.Delete(ctx, prefix+key)
+		if err != nil {
+			t.Fatalf("delete %s error, %v", key, err)
+		}
+		return resp.Header.Revision
+	}
+
+	put("app.toml", "a = 1")
+	put("client.json", `{"name":"etcd"}`)
+	w := newGateWatcher(cli.Watcher)
+	client, err := newEtcd(cli.KV, w, prefix)
+	if err != nil {
+		t.Fatalf("new etcd error, %v", err)
+	}
+	defer client.Close()
+	if content, _ := client.Get("app.toml").String(); content != "a = 1" {
+		t.Fatalf("got app.toml unexpected value %s", content)
+	}
+	events := client.WatchEvent(ctx)
+	expect := func(typ paladin.EventType, key, value string) {
+		t.Helper()
+		event := waitEvent(t, events)
+		if event.Event != typ || event.Key != key || event.Value != value {
+			t.Fatalf("got unexpected event %+v, expect %v %s %s", event, typ, key, value)
+		}
+	}
+
+	put("app.toml", "a = 2")
+	expect(paladin.EventUpdate, "app.toml", "a = 2")
+
+	// changes during reconnect are resumed from the watch revision.
+	w.Disconnect()
+	put("db.toml", "dsn = 1")
+	w.Connect()
+	expect(paladin.EventAdd, "db.toml", "dsn = 1")
+
+	// the watch revision is compacted during reconnect, values are resynced.
+	w.Disconnect()
+	del("client.json")
+	rev := put("db.toml", "dsn = 2")
+	if _, err = cli.Compact(ctx, rev); err != nil {
+		t.Fatalf("compact error, %v", err)
+	}
+	w.Connect()
+	got := map[string]paladin.Event{}
+	for i := 0; i < 2; i++ {
+		event := waitEvent(t, events)
+		got[event.Key] = event
+	}
+	if got["client.json"].Event != paladin.EventRemove {
+		t.Fatalf("client.json should be removed after compaction, got %+v", got)
+	}
+	if got["db.toml"].Event != paladin.EventUpdate || got["db.toml"].Value != "dsn = 2" {
+		t.Fatalf("db.toml should be updated after compaction, got %+v", got)
+	}
+	put("app.toml", "a = 3")
+	expect(paladin.EventUpdate, "app.toml", "a = 3")
+	if client.GetAll().Exist("client.json") {
+		t.Fatalf("client.json should be removed")
+	}
+}
+
+func TestEtcd(t *testing.T) {
+	const prefix = "/kratos/config/app/"
+	_defaultBackoff.BaseDelay = time.Millisecond * 10
+
+	f := newFakeEtcd()
+	f.Set(prefix+"app.toml", "a = 1")
+	f.Set(prefix+"client.json", `{"name":"etcd"}`)
+	f.Set("/kratos/config/other/app.toml", "other = 1")
+	client, err := newEtcd(f, f, "/kratos/config/app")
+	if err != nil {
+		t.Fatalf("new etcd error, %v", err)
+	}
+	defer client.Close()
+	if content, _ := client.Get("app.toml").String(); content != "a = 1" {
+		t.Fatalf("got app.toml unexpected value %s", content)
+	}
+	if content, _ := client.Get("client.json").String(); content != `{"name":"etcd"}` {
+		t.Fatalf("got client.json unexpected value %s", content)
+	}
+	if len(client.GetAll().Keys()) != 2 {
+		t.Fatalf("keys out of prefix should not be loaded: %v", client.GetAll().Keys())
+	}
+	events := client.WatchEvent(context.TODO())
+
+	expect := func(typ paladin.EventType, key, value string) {
+		t.Helper()
+		event := waitEvent(t, events)
+		if event.Event != typ || event.Key != key || event.Value != value {
+			t.Fatalf("got unexpected event %+v, expect %v %s %s", event, typ, key, value)
+		}
+		if typ == paladin.EventRemove {
+			if client.GetAll().Exist(key) {
+				t.Fatalf("%s should be removed", key)
+			}
+			return
+		}
+		if content, _ := client.Get(key).String(); content != value {
+			t.Fatalf("got %s unexpected value %s", key, content)
+		}
+	}
+
+	t.Run("watch", func(t *testing.T) {
+		f.Set(prefix+"app.toml", "a = 2")
+		expect(paladin.EventUpdate, "app.toml", "a = 2")
+		f.Set(prefix+"db.toml", "dsn = 1")
+		expect(paladin.EventAdd, "db.toml", "dsn = 1")
+		f.Remove(prefix + "client.json")
+		expect(paladin.EventRemove, "client.json", "")
+	})
+
+	t.Run("leader lost", func(t *testing.T) {
+		f.Disconnect(false, func() {
+			f.putLocked(prefix+"app.toml", "a = 3")
+		})
+		expect(paladin.EventUpdate, "app.toml", "a = 3")
+		f.Set(prefix+"db.toml", "dsn = 2")
+		expect(paladin.EventUpdate, "db.toml", "dsn = 2")
+	})
+
+	t.Run("compacted", func(t *testing.T) {
+		f.Disconnect(true, func() {
+			f.deleteLocked(prefix + "app.toml")
+			f.putLocked(prefix+"db.toml", "dsn = 3")
+		})
+		got := map[string]paladin.Event{}
+		for i := 0; i < 2; i++ {
+			event := waitEvent(t, events)
+			got[event.Key] = event
+		}
+		if got["app.toml"].Event != paladin.EventRemove {
+			t.Fatalf("app.toml should be removed after compaction, got %+v", got)
+		}
+		if got["db.toml"].Event != paladin.EventUpdate || got["db.toml"].Value != "dsn = 3" {
+			t.Fatalf("db.toml should be updated after compaction, got %+v", got)
+		}
+		f.Set(prefix+"app.toml", "a = 4")
+		expect(paladin.EventAdd, "app.toml", "a = 4")
+	})
+}
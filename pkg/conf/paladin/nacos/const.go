@@ -0,0 +1,11 @@
+package nacos
+
+const (
+	// PaladinDriverNacos ...
+	PaladinDriverNacos = "nacos"
+
+	// DefaultGroup is the nacos default group.
+	DefaultGroup = "DEFAULT_GROUP"
+	// DefaultPort is the nacos default port.
+	DefaultPort = 8848
+)
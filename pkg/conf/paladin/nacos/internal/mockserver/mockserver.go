@@ -0,0 +1,167 @@
+package mockserver
+
+import (
+	"context"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	_splitConfig      = "\u0001"
+	_splitConfigInner = "\u0002"
+	// _holdTimeout is the max duration the mock server holds a long polling request.
+	_holdTimeout = time.Second
+)
+
+type mockServer struct {
+	server http.Server
+
+	lock   sync.Mutex
+	config map[string]string // group/dataId -> content
+	notify chan struct{}
+}
+
+func configKey(group, dataID string) string {
+	return group + "/" + dataID
+}
+
+func md5Sum(content string) string {
+	h := md5.New()
+	io.WriteString(h, content)
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// ConfigHandler handles nacos get config api: GET /nacos/v1/cs/configs.
+func (s *mockServer) ConfigHandler(rw http.ResponseWriter, req *http.Request) {
+	req.ParseForm()
+	content, ok := s.Get(req.FormValue("group"), req.FormValue("dataId"))
+	if !ok {
+		rw.WriteHeader(http.StatusNotFound)
+		rw.Write([]byte("config data not exist"))
+		return
+	}
+	rw.Write([]byte(content))
+}
+
+// ListenerHandler handles nacos long polling api: POST /nacos/v1/cs/configs/listener.
+func (s *mockServer) ListenerHandler(rw http.ResponseWriter, req *http.Request) {
+	req.ParseForm()
+	listening := req.FormValue("Listening-Configs")
+	timer := time.NewTimer(_holdTimeout)
+	defer timer.Stop()
+	for {
+		s.lock.Lock()
+		notify := s.notify
+		changes := s.changes(listening)
+		s.lock.Unlock()
+		if len(changes) > 0 || req.Header.Get("Long-Pulling-Timeout-No-Hangup") == "true" {
+			rw.Write([]byte(url.QueryEscape(strings.Join(changes, ""))))
+			return
+		}
+		select {
+		case <-notify:
+		case <-timer.C:
+			return
+		case <-req.Context().Done():
+			return
+		}
+	}
+}
+
+// changes returns changed configs of listening configs, it must be called with lock held.
+func (s *mockServer) changes(listening string) (changes []string) {
+	for _, item := range strings.Split(listening, _splitConfig) {
+		attrs := strings.Split(item, _splitConfigInner)
+		if len(attrs) < 3 {
+			continue
+		}
+		dataID, group, clientMd5 := attrs[0], attrs[1], attrs[2]
+		var serverMd5 string
+		if content, ok := s.config[configKey(group, dataID)]; ok {
+			serverMd5 = md5Sum(content)
+		}
+		if serverMd5 == clientMd5 || (serverMd5 == "" && clientMd5 == md5Sum("")) {
+			continue
+		}
+		change := dataID + _splitConfigInner + group
+		if len(attrs) > 3 {
+			change += _splitConfigInner + attrs[3]
+		}
+		changes = append(changes, change+_splitConfig)
+	}
+	return
+}
+
+var server *mockServer
+
+func (s *mockServer) Set(group, dataID, content string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.config[configKey(group, dataID)] = content
+	s.broadcast()
+}
+
+func (s *mockServer) Get(group, dataID string) (string, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	content, ok := s.config[configKey(group, dataID)]
+	return content, ok
+}
+
+func (s *mockServer) Delete(group, dataID string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.config, configKey(group, dataID))
+	s.broadcast()
+}
+
+// broadcast wakes up all holding long polling requests, it must be called with lock held.
+func (s *mockServer) broadcast() {
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+// Set group's dataId content
+func Set(group, dataID, content string) {
+	server.Set(group, dataID, content)
+}
+
+// Delete group's dataId
+func Delete(group, dataID string) {
+	server.Delete(group, dataID)
+}
+
+// Run mock server
+func Run() error {
+	initServer()
+	return server.server.ListenAndServe()
+}
+
+func initServer() {
+	server = &mockServer{
+		config: map[string]string{},
+		notify: make(chan struct{}),
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/nacos/v1/cs/configs", http.HandlerFunc(server.ConfigHandler))
+	mux.Handle("/nacos/v1/cs/configs/listener", http.HandlerFunc(server.ListenerHandler))
+	server.server.Handler = mux
+	server.server.Addr = ":18848"
+}
+
+// Close mock server
+func Close() error {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(time.Second))
+	defer cancel()
+
+	return server.server.Shutdown(ctx)
+}
@@ -0,0 +1,363 @@
+package nacos
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nacos-group/nacos-sdk-go/clients"
+	"github.com/nacos-group/nacos-sdk-go/clients/config_client"
+	"github.com/nacos-group/nacos-sdk-go/common/constant"
+	"github.com/nacos-group/nacos-sdk-go/vo"
+
+	"kratos/pkg/conf/paladin"
+)
+
+var (
+	_ paladin.Client = &nacos{}
+)
+
+type nacosWatcher struct {
+	keys []string // in nacos, they're dataIds, optional with group prefix
+	C    chan paladin.Event
+}
+
+func newNacosWatcher(keys []string) *nacosWatcher {
+	return &nacosWatcher{keys: keys, C: make(chan paladin.Event, 5)}
+}
+
+func (nw *nacosWatcher) HasKey(key string) bool {
+	if len(nw.keys) == 0 {
+		return true
+	}
+	for _, k := range nw.keys {
+		if paladin.KeyNamed(k) == paladin.KeyNamed(key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (nw *nacosWatcher) Handle(event paladin.Event) {
+	select {
+	case nw.C <- event:
+	default:
+		log.Printf("paladin: event channel full discard dataId %s update event", event.Key)
+	}
+}
+
+// nacos is nacos config client.
+type nacos struct {
+	client   config_client.IConfigClient
+	group    string
+	keys     []string
+	values   *paladin.Map
+	mu       sync.Mutex
+	wmu      sync.RWMutex
+	watchers map[*nacosWatcher]struct{}
+}
+
+// Config is nacos config client config.
+type Config struct {
+	// Addrs is nacos server addrs, e.g. 127.0.0.1:8848
+	Addrs       []string `json:"addrs"`
+	ContextPath string   `json:"context_path"`
+	NamespaceID string   `json:"namespace_id"`
+	// Group is the default group of dataIds without group prefix.
+	Group string `json:"group"`
+	// DataIDs is subscribed dataIds, use group/dataId to subscribe dataId in other group.
+	DataIDs   []string `json:"data_ids"`
+	AccessKey string   `json:"access_key"`
+	SecretKey string   `json:"secret_key"`
+	Username  string   `json:"username"`
+	Password  string   `json:"password"`
+	CacheDir  string   `json:"cache_dir"`
+	LogDir    string   `json:"log_dir"`
+	TimeoutMs uint64   `json:"timeout_ms"`
+}
+
+type nacosDriver struct{}
+
+var (
+	confAddrs, confContextPath, confNamespaceID, confGroup, confDataIDs string
+	confAccessKey, confSecretKey, confUsername, confPassword            string
+	confCacheDir, confLogDir                                            string
+)
+
+func init() {
+	addNacosFlags()
+	paladin.Register(PaladinDriverNacos, &nacosDriver{})
+}
+
+func addNacosFlags() {
+	flag.StringVar(&confAddrs, "nacos.addrs", "", "nacos server addrs, comma separated, e.g. 127.0.0.1:8848,127.0.0.2:8848")
+	flag.StringVar(&confContextPath, "nacos.contextpath", "", "nacos server context path, default /nacos")
+	flag.StringVar(&confNamespaceID, "nacos.namespace", "", "nacos namespace id")
+	flag.StringVar(&confGroup, "nacos.group", DefaultGroup, "nacos default group")
+	flag.StringVar(&confDataIDs, "nacos.dataids", "", "subscribed nacos dataIds, comma separated, e.g. app.toml,OTHER_GROUP/mysql.toml")
+	flag.StringVar(&confAccessKey, "nacos.accesskey", "", "nacos access key")
+	flag.StringVar(&confSecretKey, "nacos.secretkey", "", "nacos secret key")
+	flag.StringVar(&confUsername, "nacos.username", "", "nacos username")
+	flag.StringVar(&confPassword, "nacos.password", "", "nacos password")
+	flag.StringVar(&confCacheDir, "nacos.cachedir", "/tmp/nacos/cache", "nacos cache dir")
+	flag.StringVar(&confLogDir, "nacos.logdir", "/tmp/nacos/log", "nacos log dir")
+}
+
+func buildConfigForNacos() (c *Config, err error) {
+	if addrsFromEnv := os.Getenv("NACOS_ADDRS"); addrsFromEnv != "" {
+		confAddrs = addrsFromEnv
+	}
+	if confAddrs == "" {
+		err = errors.New("invalid nacos addrs, pass it via NACOS_ADDRS=xxx with env or --nacos.addrs=xxx with flag")
+		return
+	}
+	if contextPathFromEnv := os.Getenv("NACOS_CONTEXT_PATH"); contextPathFromEnv != "" {
+		confContextPath = contextPathFromEnv
+	}
+	if namespaceFromEnv := os.Getenv("NACOS_NAMESPACE"); namespaceFromEnv != "" {
+		confNamespaceID = namespaceFromEnv
+	}
+	if groupFromEnv := os.Getenv("NACOS_GROUP"); groupFromEnv != "" {
+		confGroup = groupFromEnv
+	}
+	if dataIDsFromEnv := os.Getenv("NACOS_DATA_IDS"); dataIDsFromEnv != "" {
+		confDataIDs = dataIDsFromEnv
+	}
+	if confDataIDs == "" {
+		err = errors.New("invalid nacos dataIds, pass it via NACOS_DATA_IDS=xxx with env or --nacos.dataids=xxx with flag")
+		return
+	}
+	if accessKeyFromEnv := os.Getenv("NACOS_ACCESS_KEY"); accessKeyFromEnv != "" {
+		confAccessKey = accessKeyFromEnv
+	}
+	if secretKeyFromEnv := os.Getenv("NACOS_SECRET_KEY"); secretKeyFromEnv != "" {
+		confSecretKey = secretKeyFromEnv
+	}
+	if usernameFromEnv := os.Getenv("NACOS_USERNAME"); usernameFromEnv != "" {
+		confUsername = usernameFromEnv
+	}
+	if passwordFromEnv := os.Getenv("NACOS_PASSWORD"); passwordFromEnv != "" {
+		confPassword = passwordFromEnv
+	}
+	if cacheDirFromEnv := os.Getenv("NACOS_CACHE_DIR"); cacheDirFromEnv != "" {
+		confCacheDir = cacheDirFromEnv
+	}
+	if logDirFromEnv := os.Getenv("NACOS_LOG_DIR"); logDirFromEnv != "" {
+		confLogDir = logDirFromEnv
+	}
+	c = &Config{
+		Addrs:       strings.Split(confAddrs, ","),
+		ContextPath: confContextPath,
+		NamespaceID: confNamespaceID,
+		Group:       confGroup,
+		DataIDs:     strings.Split(confDataIDs, ","),
+		AccessKey:   confAccessKey,
+		SecretKey:   confSecretKey,
+		Username:    confUsername,
+		Password:    confPassword,
+		CacheDir:    confCacheDir,
+		LogDir:      confLogDir,
+	}
+	return
+}
+
+// New new a nacos config client.
+// it watches nacos dataIds changes and updates local cache.
+// BTW, in our context, dataIds in nacos means keys in paladin,
+// dataIds out of the default group are named as group/dataId.
+func (nd *nacosDriver) New() (paladin.Client, error) {
+	c, err := buildConfigForNacos()
+	if err != nil {
+		return nil, err
+	}
+	return nd.new(c)
+}
+
+func (nd *nacosDriver) new(conf *Config) (paladin.Client, error) {
+	if conf == nil || len(conf.Addrs) == 0 {
+		return nil, errors.New("invalid nacos conf")
+	}
+	if conf.Group == "" {
+		conf.Group = DefaultGroup
+	}
+	if conf.TimeoutMs == 0 {
+		conf.TimeoutMs = 5 * 1000
+	}
+	servers := make([]constant.ServerConfig, 0, len(conf.Addrs))
+	for _, addr := range conf.Addrs {
+		sc, err := parseAddr(addr)
+		if err != nil {
+			return nil, err
+		}
+		sc.ContextPath = conf.ContextPath
+		servers = append(servers, sc)
+	}
+	client, err := clients.NewConfigClient(vo.NacosClientParam{
+		ClientConfig: &constant.ClientConfig{
+			NamespaceId:         conf.NamespaceID,
+			AccessKey:           conf.AccessKey,
+			SecretKey:           conf.SecretKey,
+			Username:            conf.Username,
+			Password:            conf.Password,
+			TimeoutMs:           conf.TimeoutMs,
+			NotLoadCacheAtStart: true,
+			CacheDir:            conf.CacheDir,
+			LogDir:              conf.LogDir,
+			LogLevel:            "error",
+		},
+		ServerConfigs: servers,
+	})
+	if err != nil {
+		return nil, err
+	}
+	n := &nacos{
+		client:   client,
+		group:    conf.Group,
+		keys:     conf.DataIDs,
+		values:   new(paladin.Map),
+		watchers: make(map[*nacosWatcher]struct{}),
+	}
+	raws, err := n.loadValues(conf.DataIDs)
+	if err != nil {
+		return nil, err
+	}
+	n.values.Store(raws)
+	if err = n.watchproc(conf.DataIDs); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func parseAddr(addr string) (sc constant.ServerConfig, err error) {
+	host, port := addr, DefaultPort
+	if strings.Contains(addr, ":") {
+		var p string
+		if host, p, err = net.SplitHostPort(addr); err != nil {
+			return
+		}
+		if port, err = strconv.Atoi(p); err != nil {
+			return
+		}
+	}
+	sc = constant.ServerConfig{IpAddr: host, Port: uint64(port)}
+	return
+}
+
+// param returns the nacos config param of paladin key.
+func (n *nacos) param(key string) vo.ConfigParam {
+	if idx := strings.Index(key, "/"); idx > 0 {
+		return vo.ConfigParam{Group: key[:idx], DataId: key[idx+1:]}
+	}
+	return vo.ConfigParam{Group: n.group, DataId: key}
+}
+
+// loadValues load values from nacos dataIds to values
+func (n *nacos) loadValues(keys []string) (values map[string]*paladin.Value, err error) {
+	values = make(map[string]*paladin.Value, len(keys))
+	for _, k := range keys {
+		var content string
+		if content, err = n.client.GetConfig(n.param(k)); err != nil {
+			return
+		}
+		// NOTE: nacos returns empty content if dataId does not exist.
+		if content == "" {
+			continue
+		}
+		values[k] = paladin.NewValue(content, content)
+	}
+	return
+}
+
+// reloadValue reload value by key and send event
+func (n *nacos) reloadValue(key, content string) {
+	n.mu.Lock()
+	raws := n.values.Load()
+	event := paladin.EventUpdate
+	old, ok := raws[paladin.KeyNamed(key)]
+	switch {
+	case content == "" && !ok:
+		n.mu.Unlock()
+		return
+	case content == "":
+		event = paladin.EventRemove
+		delete(raws, paladin.KeyNamed(key))
+	case !ok:
+		event = paladin.EventAdd
+		raws[key] = paladin.NewValue(content, content)
+	default:
+		if raw, _ := old.Raw(); raw == content {
+			n.mu.Unlock()
+			return
+		}
+		raws[key] = paladin.NewValue(content, content)
+	}
+	n.values.Store(raws)
+	n.mu.Unlock()
+
+	n.wmu.RLock()
+	count := 0
+	for w := range n.watchers {
+		if w.HasKey(key) {
+			count++
+			w.Handle(paladin.Event{Event: event, Key: key, Value: content})
+		}
+	}
+	n.wmu.RUnlock()
+	log.Printf("paladin: reload config: %s events: %d\n", key, count)
+}
+
+// nacos config daemon to listen remote nacos changes
+func (n *nacos) watchproc(keys []string) error {
+	for _, k := range keys {
+		key := k
+		param := n.param(key)
+		param.OnChange = func(namespace, group, dataId, data string) {
+			n.reloadValue(key, data)
+		}
+		if err := n.client.ListenConfig(param); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get return value by key.
+func (n *nacos) Get(key string) *paladin.Value {
+	return n.values.Get(key)
+}
+
+// GetAll return value map.
+func (n *nacos) GetAll() *paladin.Map {
+	return n.values
+}
+
+// WatchEvent watch with the specified keys.
+func (n *nacos) WatchEvent(ctx context.Context, keys ...string) <-chan paladin.Event {
+	nw := newNacosWatcher(keys)
+	n.wmu.Lock()
+	n.watchers[nw] = struct{}{}
+	n.wmu.Unlock()
+	return nw.C
+}
+
+// Close close watcher.
+func (n *nacos) Close() (err error) {
+	for _, k := range n.keys {
+		if err = n.client.CancelListenConfig(n.param(k)); err != nil {
+			return
+		}
+	}
+	n.wmu.Lock()
+	for w := range n.watchers {
+		close(w.C)
+		delete(n.watchers, w)
+	}
+	n.wmu.Unlock()
+	return
+}
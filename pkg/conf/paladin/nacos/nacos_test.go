@@ -0,0 +1,110 @@
+package nacos
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"kratos/pkg/conf/paladin"
+	"kratos/pkg/conf/paladin/nacos/internal/mockserver"
+)
+
+func TestMain(m *testing.M) {
+	setup()
+	code := m.Run()
+	teardown()
+	os.Exit(code)
+}
+
+func setup() {
+	go func() {
+		if err := mockserver.Run(); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+	// wait for mock server to run
+	time.Sleep(time.Millisecond * 500)
+}
+
+func teardown() {
+	mockserver.Close()
+}
+
+func waitEvent(t *testing.T, events <-chan paladin.Event) paladin.Event {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second * 10):
+		t.Fatalf("wait event timeout")
+	}
+	return paladin.Event{}
+}
+
+func TestNacosMock(t *testing.T) {
+	var (
+		testAppTOML            = "app.toml"
+		testAppTOMLContent1    = "test = \"test12234\"\ntest2 = \"test333\""
+		testAppTOMLContent2    = "test = 1111"
+		testClientJSON         = "OTHER_GROUP/client.json"
+		testClientJSONContent  = `{"name":"nacos"}`
+		testMissingYAML        = "missing.yml"
+		testMissingYAMLContent = "name: nacos"
+	)
+	dir, err := ioutil.TempDir("", "paladin-nacos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("NACOS_ADDRS", "127.0.0.1:18848")
+	os.Setenv("NACOS_DATA_IDS", testAppTOML+","+testClientJSON+","+testMissingYAML)
+	os.Setenv("NACOS_CACHE_DIR", dir)
+	os.Setenv("NACOS_LOG_DIR", dir)
+	mockserver.Set(DefaultGroup, testAppTOML, testAppTOMLContent1)
+	mockserver.Set("OTHER_GROUP", "client.json", testClientJSONContent)
+	nd := &nacosDriver{}
+	client, err := nd.New()
+	if err != nil {
+		t.Fatalf("new nacos error, %v", err)
+	}
+	defer client.Close()
+	if content, _ := client.Get(testAppTOML).String(); content != testAppTOMLContent1 {
+		t.Fatalf("got app.toml unexpected value %s", content)
+	}
+	if content, _ := client.Get(testClientJSON).String(); content != testClientJSONContent {
+		t.Fatalf("got client.json unexpected value %s", content)
+	}
+	if client.GetAll().Exist(testMissingYAML) {
+		t.Fatalf("missing.yml should not exist")
+	}
+
+	updates := client.WatchEvent(context.TODO(), testAppTOML)
+	mockserver.Set(DefaultGroup, testAppTOML, testAppTOMLContent2)
+	event := waitEvent(t, updates)
+	if event.Event != paladin.EventUpdate || event.Key != testAppTOML || event.Value != testAppTOMLContent2 {
+		t.Fatalf("got app.toml unexpected event %+v", event)
+	}
+	if content, _ := client.Get(testAppTOML).String(); content != testAppTOMLContent2 {
+		t.Fatalf("got app.toml unexpected updated value %s", content)
+	}
+
+	adds := client.WatchEvent(context.TODO(), testMissingYAML)
+	mockserver.Set(DefaultGroup, testMissingYAML, testMissingYAMLContent)
+	event = waitEvent(t, adds)
+	if event.Event != paladin.EventAdd || event.Value != testMissingYAMLContent {
+		t.Fatalf("got missing.yml unexpected event %+v", event)
+	}
+
+	removes := client.WatchEvent(context.TODO(), testClientJSON)
+	mockserver.Delete("OTHER_GROUP", "client.json")
+	event = waitEvent(t, removes)
+	if event.Event != paladin.EventRemove || event.Key != testClientJSON {
+		t.Fatalf("got client.json unexpected event %+v", event)
+	}
+	if client.GetAll().Exist(testClientJSON) {
+		t.Fatalf("client.json should be removed")
+	}
+}
@@ -7,13 +7,32 @@ import (
 	"time"
 
 	"kratos/pkg/ecode"
+	"kratos/pkg/net/criticality"
 )
 
 // Config codel config.
 type Config struct {
-	Target   int64 // target queue delay (default 20 ms).
-	Internal int64 // sliding minimum time window width (default 500 ms)
+	Target         int64 // target queue delay (default 20 ms).
+	Internal       int64 // sliding minimum time window width (default 500 ms)
 	MaxOutstanding int64 //max num of concurrent acquires
+	// Targets are the target queue delays of criticality, the less critical requests are dropped
+	// with shorter delay. The default target of CRITICAL is Target.
+	Targets map[criticality.Criticality]int64
+}
+
+// the default target ratios of criticality to Target.
+var defaultTargets = map[criticality.Criticality]float64{
+	criticality.CriticalPlus:  2,
+	criticality.Critical:      1,
+	criticality.SheddablePlus: 0.5,
+	criticality.Sheddable:     0.25,
+}
+
+func (c *Config) target(crtl criticality.Criticality) int64 {
+	if t, ok := c.Targets[crtl]; ok && t > 0 {
+		return t
+	}
+	return int64(float64(c.Target) * defaultTargets[crtl])
 }
 
 // Stat is the Statistics of codel.
@@ -25,13 +44,23 @@ type Stat struct {
 }
 
 type packet struct {
-	ch chan bool
-	ts int64
+	ch   chan bool
+	ts   int64
+	crtl criticality.Criticality
+}
+
+// codel is the codel state of a criticality, every criticality is dropped by its own target.
+type codel struct {
+	target   int64
+	count    int64
+	dropping bool  // 	Equal to 1 if in drop state
+	faTime   int64 // Time when we'll declare we're above target (0 if below)
+	dropNext int64 // Packets dropped since going into drop state
 }
 
 var defaultConf = &Config{
-	Target:   50,
-	Internal: 500,
+	Target:         50,
+	Internal:       500,
 	MaxOutstanding: 40,
 }
 
@@ -40,13 +69,10 @@ type Queue struct {
 	pool    sync.Pool
 	packets chan packet
 
-	mux      sync.RWMutex
-	conf     *Config
-	count    int64
-	dropping bool  // 	Equal to 1 if in drop state
-	faTime   int64 // Time when we'll declare we're above target (0 if below)
-	dropNext int64 // Packets dropped since going into drop state
-	outstanding    int64
+	mux         sync.RWMutex
+	conf        *Config
+	codels      map[criticality.Criticality]*codel
+	outstanding int64
 }
 
 // Default new a default codel queue.
@@ -62,6 +88,10 @@ func New(conf *Config) *Queue {
 	q := &Queue{
 		packets: make(chan packet, 2048),
 		conf:    conf,
+		codels:  make(map[criticality.Criticality]*codel, len(defaultTargets)),
+	}
+	for crtl := range defaultTargets {
+		q.codels[crtl] = &codel{target: conf.target(crtl)}
 	}
 	q.pool.New = func() interface{} {
 		return make(chan bool)
@@ -77,34 +107,48 @@ func (q *Queue) Reload(c *Config) {
 	// TODO codel queue size
 	q.mux.Lock()
 	q.conf = c
+	for crtl, cd := range q.codels {
+		cd.target = c.target(crtl)
+	}
 	q.mux.Unlock()
 }
 
-// Stat return the statistics of codel
+// Stat return the statistics of codel of CRITICAL requests.
 func (q *Queue) Stat() Stat {
+	return q.StatOf(criticality.Critical)
+}
+
+// StatOf return the statistics of codel of the criticality.
+func (q *Queue) StatOf(crtl criticality.Criticality) Stat {
 	q.mux.Lock()
 	defer q.mux.Unlock()
+	cd, ok := q.codels[crtl]
+	if !ok {
+		cd = q.codels[criticality.Critical]
+	}
 	return Stat{
-		Dropping: q.dropping,
-		FaTime:   q.faTime,
-		DropNext: q.dropNext,
+		Dropping: cd.dropping,
+		FaTime:   cd.faTime,
+		DropNext: cd.dropNext,
 		Packets:  len(q.packets),
 	}
 }
 
 // Push req into CoDel request buffer queue.
 // if return error is nil,the caller must call q.Done() after finish request handling
+// the less critical requests of the criticality in metadata are dropped first.
 func (q *Queue) Push(ctx context.Context) (err error) {
 	q.mux.Lock()
 	if q.outstanding < q.conf.MaxOutstanding && len(q.packets) == 0 {
-		q.outstanding ++
+		q.outstanding++
 		q.mux.Unlock()
 		return
 	}
 	q.mux.Unlock()
 	r := packet{
-		ch: q.pool.Get().(chan bool),
-		ts: time.Now().UnixNano() / int64(time.Millisecond),
+		ch:   q.pool.Get().(chan bool),
+		ts:   time.Now().UnixNano() / int64(time.Millisecond),
+		crtl: criticality.FromContext(ctx),
 	}
 	select {
 	case q.packets <- r:
@@ -123,13 +167,16 @@ func (q *Queue) Push(ctx context.Context) (err error) {
 			err = ecode.Deadline
 		}
 	}
+	if err == ecode.LimitExceed {
+		_metricDropped.Inc(string(r.crtl))
+	}
 	return
 }
 
 // Pop req from CoDel request buffer queue.
 func (q *Queue) Pop() {
 	q.mux.Lock()
-	q.outstanding --
+	q.outstanding--
 	if q.outstanding < 0 {
 		q.outstanding = 0
 		q.mux.Unlock()
@@ -154,48 +201,49 @@ func (q *Queue) Pop() {
 	}
 }
 
-func (q *Queue) controlLaw(now int64) int64 {
-	q.dropNext = now + int64(float64(q.conf.Internal)/math.Sqrt(float64(q.count)))
-	return q.dropNext
+func (q *Queue) controlLaw(cd *codel, now int64) int64 {
+	cd.dropNext = now + int64(float64(q.conf.Internal)/math.Sqrt(float64(cd.count)))
+	return cd.dropNext
 }
 
 // judge decide if the packet should drop or not.
 func (q *Queue) judge(p packet) (drop bool) {
+	cd := q.codels[p.crtl]
 	now := time.Now().UnixNano() / int64(time.Millisecond)
 	sojurn := now - p.ts
-	if sojurn < q.conf.Target {
-		q.faTime = 0
-	} else if q.faTime == 0 {
-		q.faTime = now + q.conf.Internal
-	} else if now >= q.faTime {
+	if sojurn < cd.target {
+		cd.faTime = 0
+	} else if cd.faTime == 0 {
+		cd.faTime = now + q.conf.Internal
+	} else if now >= cd.faTime {
 		drop = true
 	}
-	if q.dropping {
+	if cd.dropping {
 		if !drop {
 			// sojourn time below target - leave dropping state
-			q.dropping = false
-		} else if now > q.dropNext {
-			q.count++
-			q.dropNext = q.controlLaw(q.dropNext)
+			cd.dropping = false
+		} else if now > cd.dropNext {
+			cd.count++
+			cd.dropNext = q.controlLaw(cd, cd.dropNext)
 			return
 		}
-	} else if drop && (now-q.dropNext < q.conf.Internal || now-q.faTime >= q.conf.Internal) {
-		q.dropping = true
+	} else if drop && (now-cd.dropNext < q.conf.Internal || now-cd.faTime >= q.conf.Internal) {
+		cd.dropping = true
 		// If we're in a drop cycle, the drop rate that controlled the queue
 		// on the last cycle is a good starting point to control it now.
-		if now-q.dropNext < q.conf.Internal {
-			if q.count > 2 {
-				q.count = q.count - 2
+		if now-cd.dropNext < q.conf.Internal {
+			if cd.count > 2 {
+				cd.count = cd.count - 2
 			} else {
-				q.count = 1
+				cd.count = 1
 			}
 		} else {
-			q.count = 1
+			cd.count = 1
 		}
-		q.dropNext = q.controlLaw(now)
+		cd.dropNext = q.controlLaw(cd, now)
 		return
 	}
-	q.outstanding ++
+	q.outstanding++
 	drop = false
 	return
 }
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"kratos/pkg/ecode"
+	"kratos/pkg/net/criticality"
 )
 
 var testConf = &Config{
@@ -61,6 +62,30 @@ func TestCoDel50(t *testing.T) {
 	fmt.Printf("qps %v process time %v drop %d timeout %d accept %d \n", int64(time.Second/qps), delay, *drop, *tm, *accept)
 }
 
+func TestCoDelCriticality(t *testing.T) {
+	q := New(&Config{Target: 40, Internal: 20, MaxOutstanding: 1})
+	packetOf := func(crtl criticality.Criticality) packet {
+		// the sojourn time is above the targets of sheddable requests only.
+		return packet{ts: time.Now().UnixNano()/int64(time.Millisecond) - 30, crtl: crtl}
+	}
+	all := []criticality.Criticality{criticality.Sheddable, criticality.SheddablePlus, criticality.Critical, criticality.CriticalPlus}
+	for _, crtl := range all {
+		if q.judge(packetOf(crtl)) {
+			t.Fatalf("%s should not be dropped before the interval", crtl)
+		}
+	}
+	time.Sleep(time.Millisecond * 45)
+	for _, crtl := range all {
+		drop := q.judge(packetOf(crtl))
+		if want := crtl == criticality.Sheddable || crtl == criticality.SheddablePlus; drop != want {
+			t.Fatalf("%s drop should be %v", crtl, want)
+		}
+		if stat := q.StatOf(crtl); stat.Dropping != drop {
+			t.Fatalf("%s dropping should be %v", crtl, drop)
+		}
+	}
+}
+
 func testPush(q *Queue, sleep time.Duration, delay time.Duration, drop *int64, tm *int64, accept *int64) {
 	var group sync.WaitGroup
 	for i := 0; i < 5000; i++ {
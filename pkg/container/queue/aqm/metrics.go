@@ -0,0 +1,11 @@
+package aqm
+
+import "kratos/pkg/stat/metric"
+
+var _metricDropped = metric.NewCounterVec(&metric.CounterVecOpts{
+	Namespace: "queue",
+	Subsystem: "aqm",
+	Name:      "dropped_total",
+	Help:      "aqm dropped requests total.",
+	Labels:    []string{"criticality"},
+})
@@ -0,0 +1,511 @@
+package nacos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nacos-group/nacos-sdk-go/clients"
+	"github.com/nacos-group/nacos-sdk-go/clients/naming_client"
+	"github.com/nacos-group/nacos-sdk-go/common/constant"
+	"github.com/nacos-group/nacos-sdk-go/model"
+	"github.com/nacos-group/nacos-sdk-go/vo"
+
+	"kratos/pkg/log"
+	"kratos/pkg/naming"
+	xtime "kratos/pkg/time"
+)
+
+// nacos instance metadata keys of naming.Instance fields.
+const (
+	metaRegion   = "kratos.region"
+	metaZone     = "kratos.zone"
+	metaEnv      = "kratos.env"
+	metaHostname = "kratos.hostname"
+	metaVersion  = "kratos.version"
+	metaAddrs    = "kratos.addrs"
+	metaStatus   = "kratos.status"
+
+	_defaultWeight = 10
+)
+
+var (
+	_once    sync.Once
+	_builder naming.Builder
+
+	_ naming.Builder  = &Nacos{}
+	_ naming.Registry = &Nacos{}
+
+	// ErrDuplication is a register duplication err
+	ErrDuplication = errors.New("nacos: instance duplicate registration")
+)
+
+// Config is nacos naming config.
+type Config struct {
+	// Addrs is nacos server addrs, e.g. 127.0.0.1:8848
+	Addrs       []string       `json:"addrs"`
+	NamespaceID string         `json:"namespace_id"`
+	Group       string         `json:"group"`
+	Username    string         `json:"username"`
+	Password    string         `json:"password"`
+	CacheDir    string         `json:"cache_dir"`
+	LogDir      string         `json:"log_dir"`
+	Timeout     xtime.Duration `json:"timeout"`
+	// BeatInterval is heartbeat interval of registered instances.
+	BeatInterval xtime.Duration `json:"beat_interval"`
+}
+
+// Builder return default nacos resolver builder, it panics if c is invalid.
+func Builder(c *Config) naming.Builder {
+	_once.Do(func() {
+		b, err := New(c)
+		if err != nil {
+			log.Error("nacos: new builder error(%v)", err)
+			panic(fmt.Sprintf("nacos: new builder error(%v)", err))
+		}
+		_builder = b
+	})
+	return _builder
+}
+
+// Build register resolver into default nacos.
+func Build(c *Config, id string) naming.Resolver {
+	return Builder(c).Build(id)
+}
+
+type appInfo struct {
+	resolver map[*Resolve]struct{}
+	ins      atomic.Value
+	param    *vo.SubscribeParam
+	once     sync.Once
+}
+
+// Resolve nacos resolver.
+type Resolve struct {
+	id    string
+	event chan struct{}
+	n     *Nacos
+	opt   *naming.BuildOptions
+}
+
+// Nacos is a nacos client Builder and Registry.
+// service: {appid} in group, instance metadata carries naming.Instance fields.
+type Nacos struct {
+	c          *Config
+	cli        naming_client.INamingClient
+	ctx        context.Context
+	cancelFunc context.CancelFunc
+
+	mutex    sync.RWMutex
+	apps     map[string]*appInfo
+	registry map[string]*vo.RegisterInstanceParam
+}
+
+// NOTE: flags of nacos.* are used by paladin nacos driver, so only env is supported here.
+func configFromEnv() *Config {
+	return &Config{
+		Addrs:       strings.FieldsFunc(os.Getenv("NACOS_SERVERS"), func(r rune) bool { return r == ',' || r == ' ' }),
+		NamespaceID: os.Getenv("NACOS_NAMESPACE"),
+		Group:       os.Getenv("NACOS_GROUP"),
+		Username:    os.Getenv("NACOS_USERNAME"),
+		Password:    os.Getenv("NACOS_PASSWORD"),
+		CacheDir:    os.Getenv("NACOS_CACHE_DIR"),
+		LogDir:      os.Getenv("NACOS_LOG_DIR"),
+	}
+}
+
+// New is new a nacos builder.
+// if c is nil, config is built from env NACOS_SERVERS, NACOS_NAMESPACE and NACOS_GROUP etc.
+func New(c *Config) (n *Nacos, err error) {
+	if c == nil {
+		c = configFromEnv()
+	}
+	if len(c.Addrs) == 0 {
+		return nil, errors.New("nacos: invalid config addrs")
+	}
+	if c.Timeout == 0 {
+		c.Timeout = xtime.Duration(10 * time.Second)
+	}
+	if c.BeatInterval == 0 {
+		c.BeatInterval = xtime.Duration(5 * time.Second)
+	}
+	servers := make([]constant.ServerConfig, 0, len(c.Addrs))
+	for _, addr := range c.Addrs {
+		host, port := addr, 8848
+		if h, p, e := net.SplitHostPort(addr); e == nil {
+			host = h
+			if port, err = strconv.Atoi(p); err != nil {
+				return
+			}
+		}
+		servers = append(servers, constant.ServerConfig{IpAddr: host, Port: uint64(port)})
+	}
+	cli, err := clients.NewNamingClient(vo.NacosClientParam{
+		ClientConfig: &constant.ClientConfig{
+			NamespaceId:         c.NamespaceID,
+			Username:            c.Username,
+			Password:            c.Password,
+			TimeoutMs:           uint64(time.Duration(c.Timeout) / time.Millisecond),
+			BeatInterval:        int64(time.Duration(c.BeatInterval) / time.Millisecond),
+			NotLoadCacheAtStart: true,
+			CacheDir:            c.CacheDir,
+			LogDir:              c.LogDir,
+			LogLevel:            "error",
+		},
+		ServerConfigs: servers,
+	})
+	if err != nil {
+		return
+	}
+	return newNacos(c, cli), nil
+}
+
+func newNacos(c *Config, cli naming_client.INamingClient) *Nacos {
+	if c.Group == "" {
+		c.Group = DefaultGroupName
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Nacos{
+		c:          c,
+		cli:        cli,
+		ctx:        ctx,
+		cancelFunc: cancel,
+		apps:       map[string]*appInfo{},
+		registry:   map[string]*vo.RegisterInstanceParam{},
+	}
+}
+
+// Build nacos resovler builder.
+func (n *Nacos) Build(appid string, opts ...naming.BuildOpt) naming.Resolver {
+	r := &Resolve{
+		id:    appid,
+		n:     n,
+		event: make(chan struct{}, 1),
+		opt:   new(naming.BuildOptions),
+	}
+	for _, opt := range opts {
+		opt.Apply(r.opt)
+	}
+	n.mutex.Lock()
+	app, ok := n.apps[appid]
+	if !ok {
+		app = &appInfo{resolver: make(map[*Resolve]struct{})}
+		n.apps[appid] = app
+	}
+	app.resolver[r] = struct{}{}
+	n.mutex.Unlock()
+	if ok {
+		select {
+		case r.event <- struct{}{}:
+		default:
+		}
+	}
+
+	app.once.Do(func() {
+		go n.watch(appid, app)
+		log.Info("nacos: AddWatch(%s) already watch(%v)", appid, ok)
+	})
+	return r
+}
+
+// Scheme return nacos's scheme
+func (n *Nacos) Scheme() string {
+	return "nacos"
+}
+
+// Register is register instance, nacos client keeps the heartbeat of ephemeral instance.
+func (n *Nacos) Register(ctx context.Context, ins *naming.Instance) (cancelFunc context.CancelFunc, err error) {
+	param, err := registerParam(n.c.Group, ins)
+	if err != nil {
+		return
+	}
+	n.mutex.Lock()
+	if _, ok := n.registry[ins.AppID]; ok {
+		err = ErrDuplication
+	} else {
+		n.registry[ins.AppID] = param
+	}
+	n.mutex.Unlock()
+	if err != nil {
+		return
+	}
+	if _, err = n.cli.RegisterInstance(*param); err != nil {
+		log.Error("nacos: register client.RegisterInstance(%s,%s:%d) error(%v)", param.ServiceName, param.Ip, param.Port, err)
+		n.mutex.Lock()
+		delete(n.registry, ins.AppID)
+		n.mutex.Unlock()
+		return
+	}
+	var once sync.Once
+	cancelFunc = context.CancelFunc(func() {
+		once.Do(func() {
+			n.mutex.Lock()
+			delete(n.registry, ins.AppID)
+			n.mutex.Unlock()
+			n.unregister(param)
+		})
+	})
+	return
+}
+
+func (n *Nacos) unregister(param *vo.RegisterInstanceParam) (err error) {
+	if _, err = n.cli.DeregisterInstance(vo.DeregisterInstanceParam{
+		Ip:          param.Ip,
+		Port:        param.Port,
+		Cluster:     param.ClusterName,
+		ServiceName: param.ServiceName,
+		GroupName:   param.GroupName,
+		Ephemeral:   param.Ephemeral,
+	}); err != nil {
+		log.Error("nacos: unregister client.DeregisterInstance(%s,%s:%d) error(%v)", param.ServiceName, param.Ip, param.Port, err)
+		return
+	}
+	log.Info("nacos: unregister client.DeregisterInstance(%s,%s:%d) success", param.ServiceName, param.Ip, param.Port)
+	return
+}
+
+// Close stop all running process including subscribe and register
+func (n *Nacos) Close() error {
+	n.cancelFunc()
+	n.mutex.Lock()
+	registry := n.registry
+	n.registry = map[string]*vo.RegisterInstanceParam{}
+	var params []*vo.SubscribeParam
+	for _, app := range n.apps {
+		if app.param != nil {
+			params = append(params, app.param)
+		}
+	}
+	n.mutex.Unlock()
+	for _, param := range registry {
+		n.unregister(param)
+	}
+	for _, param := range params {
+		n.cli.Unsubscribe(param)
+	}
+	return nil
+}
+
+func (n *Nacos) watch(appID string, app *appInfo) {
+	instances, err := n.cli.SelectInstances(vo.SelectInstancesParam{
+		ServiceName: appID,
+		GroupName:   n.c.Group,
+		HealthyOnly: true,
+	})
+	if err != nil {
+		log.Error("nacos: fetch client.SelectInstances(%s) error(%v)", appID, err)
+	} else {
+		services := make([]model.SubscribeService, 0, len(instances))
+		for _, in := range instances {
+			services = append(services, model.SubscribeService{
+				ClusterName: in.ClusterName,
+				Enable:      in.Enable,
+				Healthy:     in.Healthy,
+				Ip:          in.Ip,
+				Port:        in.Port,
+				Weight:      in.Weight,
+				Metadata:    in.Metadata,
+			})
+		}
+		n.store(appID, app, services)
+	}
+	param := &vo.SubscribeParam{
+		ServiceName: appID,
+		GroupName:   n.c.Group,
+		SubscribeCallback: func(services []model.SubscribeService, err error) {
+			if err != nil {
+				log.Error("nacos: subscribe service(%s) error(%v)", appID, err)
+				return
+			}
+			n.store(appID, app, services)
+		},
+	}
+	if err = n.cli.Subscribe(param); err != nil {
+		log.Error("nacos: client.Subscribe(%s) error(%v)", appID, err)
+		return
+	}
+	n.mutex.Lock()
+	app.param = param
+	n.mutex.Unlock()
+}
+
+func (n *Nacos) store(appID string, app *appInfo, services []model.SubscribeService) {
+	ins := &naming.InstancesInfo{
+		Instances: make(map[string][]*naming.Instance),
+		LastTs:    time.Now().UnixNano(),
+	}
+	for _, s := range services {
+		if !s.Enable || !s.Healthy {
+			continue
+		}
+		in := toInstance(appID, s)
+		ins.Instances[in.Zone] = append(ins.Instances[in.Zone], in)
+	}
+	app.ins.Store(ins)
+	n.mutex.RLock()
+	for rs := range app.resolver {
+		select {
+		case rs.event <- struct{}{}:
+		default:
+		}
+	}
+	n.mutex.RUnlock()
+}
+
+// registerParam converts naming.Instance to nacos register param,
+// the grpc address is used as nacos instance address, and other fields are kept in metadata.
+func registerParam(group string, ins *naming.Instance) (*vo.RegisterInstanceParam, error) {
+	if ins == nil || ins.AppID == "" || len(ins.Addrs) == 0 {
+		return nil, errors.New("nacos: invalid instance, appid and addrs are required")
+	}
+	var host string
+	for _, a := range ins.Addrs {
+		u, err := url.Parse(a)
+		if err != nil {
+			continue
+		}
+		if host == "" || u.Scheme == "grpc" {
+			host = u.Host
+		}
+	}
+	ip, p, err := net.SplitHostPort(host)
+	if err != nil {
+		return nil, fmt.Errorf("nacos: invalid instance addrs(%v): %v", ins.Addrs, err)
+	}
+	port, err := strconv.ParseUint(p, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("nacos: invalid instance addrs(%v): %v", ins.Addrs, err)
+	}
+	md := make(map[string]string, len(ins.Metadata)+7)
+	for k, v := range ins.Metadata {
+		md[k] = v
+	}
+	md[metaRegion] = ins.Region
+	md[metaZone] = ins.Zone
+	md[metaEnv] = ins.Env
+	md[metaHostname] = ins.Hostname
+	md[metaVersion] = ins.Version
+	md[metaAddrs] = strings.Join(ins.Addrs, ",")
+	md[metaStatus] = strconv.FormatInt(ins.Status, 10)
+	weight, _ := strconv.ParseFloat(ins.Metadata[naming.MetaWeight], 64)
+	if weight <= 0 {
+		weight = _defaultWeight
+	}
+	cluster := ins.Metadata[naming.MetaCluster]
+	if cluster == "" {
+		cluster = DefaultClusterName
+	}
+	return &vo.RegisterInstanceParam{
+		Ip:          ip,
+		Port:        port,
+		Weight:      weight,
+		Enable:      true,
+		Healthy:     true,
+		Metadata:    md,
+		ClusterName: cluster,
+		ServiceName: ins.AppID,
+		GroupName:   group,
+		Ephemeral:   true,
+	}, nil
+}
+
+// toInstance converts nacos instance to naming.Instance.
+func toInstance(appID string, s model.SubscribeService) *naming.Instance {
+	in := &naming.Instance{
+		AppID:    appID,
+		Metadata: make(map[string]string, len(s.Metadata)),
+	}
+	for k, v := range s.Metadata {
+		switch k {
+		case metaRegion:
+			in.Region = v
+		case metaZone:
+			in.Zone = v
+		case metaEnv:
+			in.Env = v
+		case metaHostname:
+			in.Hostname = v
+		case metaVersion:
+			in.Version = v
+		case metaAddrs:
+			if v != "" {
+				in.Addrs = strings.Split(v, ",")
+			}
+		case metaStatus:
+			in.Status, _ = strconv.ParseInt(v, 10, 64)
+		default:
+			in.Metadata[k] = v
+		}
+	}
+	// NOTE: instances registered by other sdk have no kratos metadata.
+	if len(in.Addrs) == 0 {
+		in.Addrs = []string{"grpc://" + net.JoinHostPort(s.Ip, strconv.FormatUint(s.Port, 10))}
+	}
+	if in.Hostname == "" {
+		in.Hostname = s.Ip
+	}
+	if _, ok := in.Metadata[naming.MetaWeight]; !ok && s.Weight > 0 {
+		in.Metadata[naming.MetaWeight] = strconv.FormatInt(int64(s.Weight), 10)
+	}
+	if _, ok := in.Metadata[naming.MetaCluster]; !ok && s.ClusterName != "" && s.ClusterName != DefaultClusterName {
+		in.Metadata[naming.MetaCluster] = s.ClusterName
+	}
+	return in
+}
+
+// Watch watch instance.
+func (r *Resolve) Watch() <-chan struct{} {
+	return r.event
+}
+
+// Fetch fetch resolver instance.
+func (r *Resolve) Fetch(ctx context.Context) (ins *naming.InstancesInfo, ok bool) {
+	r.n.mutex.RLock()
+	app, ok := r.n.apps[r.id]
+	r.n.mutex.RUnlock()
+	if ok {
+		var appIns *naming.InstancesInfo
+		appIns, ok = app.ins.Load().(*naming.InstancesInfo)
+		if !ok {
+			return
+		}
+		ins = new(naming.InstancesInfo)
+		ins.LastTs = appIns.LastTs
+		ins.Scheduler = appIns.Scheduler
+		if r.opt.Filter != nil {
+			ins.Instances = r.opt.Filter(appIns.Instances)
+		} else {
+			ins.Instances = make(map[string][]*naming.Instance)
+			for zone, in := range appIns.Instances {
+				ins.Instances[zone] = in
+			}
+		}
+		if r.opt.Scheduler != nil {
+			ins.Instances[r.opt.ClientZone] = r.opt.Scheduler(ins)
+		}
+		if r.opt.Subset != nil && r.opt.SubsetSize != 0 {
+			for zone, inss := range ins.Instances {
+				ins.Instances[zone] = r.opt.Subset(inss, r.opt.SubsetSize)
+			}
+		}
+	}
+	return
+}
+
+// Close close resolver.
+func (r *Resolve) Close() error {
+	r.n.mutex.Lock()
+	if app, ok := r.n.apps[r.id]; ok && len(app.resolver) != 0 {
+		delete(app.resolver, r)
+	}
+	r.n.mutex.Unlock()
+	return nil
+}
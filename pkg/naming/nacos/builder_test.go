@@ -0,0 +1,181 @@
+package nacos
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nacos-group/nacos-sdk-go/clients/naming_client"
+	"github.com/nacos-group/nacos-sdk-go/model"
+	"github.com/nacos-group/nacos-sdk-go/vo"
+
+	"kratos/pkg/naming"
+)
+
+// fakeNaming is an in-memory nacos naming client.
+type fakeNaming struct {
+	naming_client.INamingClient
+
+	mu        sync.Mutex
+	instances map[string][]model.Instance
+	subs      map[string]*vo.SubscribeParam
+}
+
+func newFakeNaming() *fakeNaming {
+	return &fakeNaming{instances: map[string][]model.Instance{}, subs: map[string]*vo.SubscribeParam{}}
+}
+
+func (f *fakeNaming) RegisterInstance(p vo.RegisterInstanceParam) (bool, error) {
+	f.mu.Lock()
+	f.instances[p.ServiceName] = append(f.instances[p.ServiceName], model.Instance{
+		Ip: p.Ip, Port: p.Port, Weight: p.Weight, Enable: p.Enable, Healthy: p.Healthy,
+		Metadata: p.Metadata, ClusterName: p.ClusterName, ServiceName: p.ServiceName,
+	})
+	f.mu.Unlock()
+	f.notify(p.ServiceName)
+	return true, nil
+}
+
+func (f *fakeNaming) DeregisterInstance(p vo.DeregisterInstanceParam) (bool, error) {
+	f.mu.Lock()
+	var ins []model.Instance
+	for _, in := range f.instances[p.ServiceName] {
+		if in.Ip != p.Ip || in.Port != p.Port {
+			ins = append(ins, in)
+		}
+	}
+	f.instances[p.ServiceName] = ins
+	f.mu.Unlock()
+	f.notify(p.ServiceName)
+	return true, nil
+}
+
+func (f *fakeNaming) SelectInstances(p vo.SelectInstancesParam) ([]model.Instance, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]model.Instance(nil), f.instances[p.ServiceName]...), nil
+}
+
+func (f *fakeNaming) Subscribe(p *vo.SubscribeParam) error {
+	f.mu.Lock()
+	f.subs[p.ServiceName] = p
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *fakeNaming) Unsubscribe(p *vo.SubscribeParam) error {
+	f.mu.Lock()
+	delete(f.subs, p.ServiceName)
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *fakeNaming) notify(service string) {
+	f.mu.Lock()
+	sub := f.subs[service]
+	var services []model.SubscribeService
+	for _, in := range f.instances[service] {
+		services = append(services, model.SubscribeService{
+			Ip: in.Ip, Port: in.Port, Weight: in.Weight, Enable: in.Enable, Healthy: in.Healthy,
+			Metadata: in.Metadata, ClusterName: in.ClusterName,
+		})
+	}
+	f.mu.Unlock()
+	if sub != nil {
+		sub.SubscribeCallback(services, nil)
+	}
+}
+
+func waitFetch(t *testing.T, r naming.Resolver, n int) *naming.InstancesInfo {
+	t.Helper()
+	for {
+		select {
+		case <-r.Watch():
+			ins, ok := r.Fetch(context.TODO())
+			if !ok {
+				continue
+			}
+			var count int
+			for _, in := range ins.Instances {
+				count += len(in)
+			}
+			if count == n {
+				return ins
+			}
+		case <-time.After(time.Second * 5):
+			t.Fatalf("wait %d instances timeout", n)
+		}
+	}
+}
+
+func TestNacos(t *testing.T) {
+	f := newFakeNaming()
+	n := newNacos(&Config{}, f)
+	defer n.Close()
+
+	sh := &naming.Instance{
+		Region:   "sh",
+		Zone:     "sh001",
+		Env:      "prod",
+		AppID:    "demo.service",
+		Hostname: "host1",
+		Version:  "v1",
+		Addrs:    []string{"http://10.0.0.1:8000", "grpc://10.0.0.1:9000"},
+		Metadata: map[string]string{naming.MetaColor: "red", naming.MetaCluster: "c1", naming.MetaWeight: "20"},
+	}
+	cancel, err := n.Register(context.TODO(), sh)
+	if err != nil {
+		t.Fatalf("register error, %v", err)
+	}
+	if _, err = n.Register(context.TODO(), sh); err != ErrDuplication {
+		t.Fatalf("register duplicate instance should return ErrDuplication, got %v", err)
+	}
+	if ins := f.instances["demo.service"]; len(ins) != 1 || ins[0].Ip != "10.0.0.1" || ins[0].Port != 9000 || ins[0].ClusterName != "c1" || ins[0].Weight != 20 {
+		t.Fatalf("got unexpected nacos instances %+v", ins)
+	}
+
+	r := n.Build("demo.service", naming.Filter("grpc", map[string]struct{}{"c1": {}}))
+	defer r.Close()
+	ins := waitFetch(t, r, 1)
+	got := ins.Instances["sh001"][0]
+	if got.Region != "sh" || got.Env != "prod" || got.Hostname != "host1" || got.Version != "v1" || len(got.Addrs) != 2 {
+		t.Fatalf("got unexpected instance %+v", got)
+	}
+	if got.Metadata[naming.MetaColor] != "red" || got.Metadata[naming.MetaCluster] != "c1" || got.Metadata[naming.MetaWeight] != "20" {
+		t.Fatalf("got unexpected instance metadata %+v", got.Metadata)
+	}
+	if _, ok := got.Metadata[metaZone]; ok {
+		t.Fatalf("reserved metadata should not be exposed %+v", got.Metadata)
+	}
+
+	bj := &naming.Instance{Zone: "bj001", AppID: "demo.service", Addrs: []string{"grpc://10.0.0.2:9000"}, Metadata: map[string]string{naming.MetaCluster: "c2"}}
+	if _, err = n.Register(context.TODO(), &naming.Instance{AppID: "other.service", Addrs: bj.Addrs}); err != nil {
+		t.Fatalf("register error, %v", err)
+	}
+	n2 := newNacos(&Config{}, f)
+	defer n2.Close()
+	if _, err = n2.Register(context.TODO(), bj); err != nil {
+		t.Fatalf("register error, %v", err)
+	}
+	all := n.Build("demo.service")
+	defer all.Close()
+	if ins = waitFetch(t, all, 2); len(ins.Instances["bj001"]) != 1 {
+		t.Fatalf("got unexpected instances %+v", ins.Instances)
+	}
+	if ins, _ = r.Fetch(context.TODO()); len(ins.Instances["bj001"]) != 0 {
+		t.Fatalf("instance of other cluster should be filtered %+v", ins.Instances)
+	}
+
+	cancel()
+	waitFetch(t, all, 1)
+}
+
+func TestBuilderInvalidConfig(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("builder of invalid config should panic")
+		}
+	}()
+	Builder(&Config{})
+}
@@ -1,5 +1,11 @@
 package criticality
 
+import (
+	"context"
+
+	"kratos/pkg/net/metadata"
+)
+
 // Criticality is
 type Criticality string
 
@@ -55,3 +61,12 @@ func Exist(c Criticality) bool {
 	_, ok := _criticalityEnum[c]
 	return ok
 }
+
+// FromContext returns the criticality of request in the metadata of ctx,
+// the default criticality is returned if it is absent or invalid.
+func FromContext(ctx context.Context) Criticality {
+	if crtl := Parse(metadata.String(ctx, metadata.Criticality)); crtl != EmptyCriticality {
+		return crtl
+	}
+	return _defaultCriticality
+}
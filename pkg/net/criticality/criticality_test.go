@@ -0,0 +1,22 @@
+package criticality
+
+import (
+	"context"
+	"testing"
+
+	"kratos/pkg/net/metadata"
+)
+
+func TestFromContext(t *testing.T) {
+	if c := FromContext(context.TODO()); c != Critical {
+		t.Fatalf("default criticality should be CRITICAL, got %s", c)
+	}
+	ctx := metadata.NewContext(context.TODO(), metadata.MD{metadata.Criticality: string(Sheddable)})
+	if c := FromContext(ctx); c != Sheddable {
+		t.Fatalf("criticality should be SHEDDABLE, got %s", c)
+	}
+	ctx = metadata.NewContext(context.TODO(), metadata.MD{metadata.Criticality: "unknown"})
+	if c := FromContext(ctx); c != Critical {
+		t.Fatalf("invalid criticality should be CRITICAL, got %s", c)
+	}
+}
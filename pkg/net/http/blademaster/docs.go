@@ -0,0 +1,131 @@
+package blademaster
+
+import (
+	_ "embed" // for the bundled api explorer
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const _docsPath = "/docs"
+
+// _docsHTML is the bundled api explorer, it loads nothing but the spec and
+// routes of current service so that it works offline.
+//
+//go:embed docs.html
+var _docsHTML []byte
+
+// _internalPaths are registered by blademaster itself and never in the spec.
+var _internalPaths = []string{"/metrics", "/metadata", "/debug/pprof", _docsPath}
+
+// _specMethods are the operations of a path item, the others like parameters
+// are ignored.
+var _specMethods = map[string]struct{}{
+	"get": {}, "put": {}, "post": {}, "delete": {}, "options": {}, "head": {}, "patch": {}, "trace": {},
+}
+
+// RouteInfo is a live route of the engine.
+type RouteInfo struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	InSpec bool   `json:"in_spec"`
+}
+
+// Docs registers the api explorer under /docs, spec is the openapi.json or
+// swagger.json generated by kratos tool protoc and usually embedded by
+// go:embed. The handlers are called before the explorer to protect it,
+// e.g. an auth or ip allowlist middleware, nothing is registered unless Docs
+// is called.
+//
+// GET /docs               the explorer ui
+// GET /docs/spec.json     the spec
+// GET /docs/routes        the live routes, which are not in the spec are marked
+func (engine *Engine) Docs(spec []byte, handlers ...HandlerFunc) {
+	operations, err := specOperations(spec)
+	if err != nil {
+		panic(fmt.Sprintf("blademaster: invalid api docs spec: %v", err))
+	}
+	group := engine.Group(_docsPath, handlers...)
+	group.GET("", func(c *Context) {
+		c.Bytes(200, "text/html; charset=utf-8", _docsHTML)
+	})
+	group.GET("/spec.json", func(c *Context) {
+		c.Bytes(200, "application/json; charset=utf-8", spec)
+	})
+	group.GET("/routes", func(c *Context) {
+		c.JSON(engine.routes(operations), nil)
+	})
+}
+
+// routes walks the trees and returns the live routes sorted by path.
+func (engine *Engine) routes(operations map[string]struct{}) []*RouteInfo {
+	var routes []*RouteInfo
+	for _, tree := range engine.trees {
+		tree.root.walk("", func(path string) {
+			for _, p := range _internalPaths {
+				if path == p || strings.HasPrefix(path, p+"/") {
+					return
+				}
+			}
+			_, ok := operations[operationKey(tree.method, path)]
+			routes = append(routes, &RouteInfo{Method: tree.method, Path: path, InSpec: ok})
+		})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// walk calls fn with the full path of every node which has handlers.
+func (n *node) walk(prefix string, fn func(path string)) {
+	path := prefix + n.path
+	if len(n.handlers) > 0 {
+		fn(path)
+	}
+	for _, child := range n.children {
+		child.walk(path, fn)
+	}
+}
+
+// specOperations returns the operations of an openapi or swagger spec keyed
+// by operationKey.
+func specOperations(spec []byte) (map[string]struct{}, error) {
+	var doc struct {
+		BasePath string                                `json:"basePath"`
+		Paths    map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return nil, err
+	}
+	basePath := strings.TrimRight(doc.BasePath, "/")
+	operations := make(map[string]struct{})
+	for path, item := range doc.Paths {
+		for method := range item {
+			if _, ok := _specMethods[method]; !ok {
+				continue
+			}
+			operations[operationKey(method, basePath+path)] = struct{}{}
+		}
+	}
+	return operations, nil
+}
+
+// operationKey normalizes the params of "/user/:mid" and "/user/{mid}" so
+// that the routes and the spec paths can be matched.
+func operationKey(method, path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		switch {
+		case strings.HasPrefix(s, ":"), strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}"):
+			segments[i] = ":"
+		case strings.HasPrefix(s, "*"):
+			segments[i] = "*"
+		}
+	}
+	return strings.ToUpper(method) + " " + strings.Join(segments, "/")
+}
@@ -0,0 +1,91 @@
+package blademaster
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	xtime "kratos/pkg/time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const _testSpec = `{
+	"openapi": "3.1.0",
+	"info": {"title": "user", "version": "v1"},
+	"paths": {
+		"/user/{mid}": {"parameters": [], "get": {"operationId": "User_Info"}, "put": {"operationId": "User_Update"}},
+		"/article": {"post": {"operationId": "Article_Add"}}
+	}
+}`
+
+func TestDocs(t *testing.T) {
+	e := NewServer(&ServerConfig{Timeout: xtime.Duration(time.Second)})
+	e.GET("/user/:mid", func(c *Context) {})
+	e.POST("/article", func(c *Context) {})
+	e.DELETE("/article/:id", func(c *Context) {})
+	e.GET("/static/*filepath", func(c *Context) {})
+	e.Docs([]byte(_testSpec), func(c *Context) {
+		if c.Request.Header.Get("X-Token") != "secret" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+		}
+	})
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	get := func(path string) (*http.Response, []byte) {
+		req, _ := http.NewRequest("GET", srv.URL+path, nil)
+		req.Header.Set("X-Token", "secret")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp, body
+	}
+
+	resp, err := http.Get(srv.URL + "/docs/spec.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, body := get("/docs")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/html")
+	assert.Equal(t, _docsHTML, body)
+
+	resp, body = get("/docs/spec.json")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, _testSpec, string(body))
+
+	_, body = get("/docs/routes")
+	var res struct {
+		Code int          `json:"code"`
+		Data []*RouteInfo `json:"data"`
+	}
+	if err = json.Unmarshal(body, &res); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []*RouteInfo{
+		{Method: "POST", Path: "/article", InSpec: true},
+		{Method: "DELETE", Path: "/article/:id", InSpec: false},
+		{Method: "GET", Path: "/static/*filepath", InSpec: false},
+		{Method: "GET", Path: "/user/:mid", InSpec: true},
+	}, res.Data)
+}
+
+func TestOperationKey(t *testing.T) {
+	assert.Equal(t, operationKey("get", "/user/{id}/info"), operationKey("GET", "/user/:mid/info"))
+	assert.NotEqual(t, operationKey("get", "/user/{id}"), operationKey("GET", "/user/info"))
+	assert.NotEqual(t, operationKey("get", "/user/{id}"), operationKey("POST", "/user/:id"))
+
+	ops, err := specOperations([]byte(`{"basePath": "/x/", "paths": {"/a/{id}": {"get": {}, "parameters": []}}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]struct{}{"GET /x/a/:": {}}, ops)
+}
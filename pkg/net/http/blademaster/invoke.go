@@ -0,0 +1,309 @@
+package blademaster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	xhttp "net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"kratos/pkg/conf/env"
+	"kratos/pkg/ecode"
+
+	pkgerr "github.com/pkg/errors"
+)
+
+// ClientMethod describes how a typed client generated by protoc-gen-bm maps
+// a request message onto an HTTP call, following the google.api.http rules.
+type ClientMethod struct {
+	// Method is the http method, GET/POST/PUT/PATCH/DELETE.
+	Method string
+	// Path is the route path, uri params are in the form of :name or {name}.
+	Path string
+	// Body is the request field sent as the json body: "*" means the whole
+	// message, a go field path like "Info" means that field only and
+	// empty means no body. Fields not in path or body are sent as query.
+	Body string
+	// ResponseBody is the go field path of the response message which the
+	// data of the envelope is decoded into, empty means the whole message.
+	ResponseBody string
+}
+
+// envelope is the response format written by Context.JSON.
+type envelope struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Invoke sends the request message in to host according to method m, decodes
+// the data of the {code,message,data} response envelope into out and returns
+// the non-zero code as ecode.Codes.
+func (client *Client) Invoke(c context.Context, host string, m *ClientMethod, in, out interface{}) (err error) {
+	req, err := newInvokeRequest(host, m, in)
+	if err != nil {
+		return
+	}
+	// NOTE use the path pattern as prom & config uri key.
+	bs, err := client.Raw(c, req, host+m.Path)
+	if err != nil {
+		return
+	}
+	var res envelope
+	if err = json.Unmarshal(bs, &res); err != nil {
+		return pkgerr.Wrapf(err, "host:%s, url:%s", req.URL.Host, req.URL.Path)
+	}
+	if res.Code != ecode.OK.Code() {
+		code := ecode.Int(res.Code)
+		if res.Message == "" || res.Message == code.Message() {
+			return code
+		}
+		return ecode.Error(code, res.Message)
+	}
+	if out == nil || len(res.Data) == 0 || string(res.Data) == "null" {
+		return
+	}
+	target, err := fieldPath(reflect.ValueOf(out), m.ResponseBody, true)
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(res.Data, target.Addr().Interface()); err != nil {
+		err = pkgerr.Wrapf(err, "host:%s, url:%s", req.URL.Host, req.URL.Path)
+	}
+	return
+}
+
+func newInvokeRequest(host string, m *ClientMethod, in interface{}) (req *xhttp.Request, err error) {
+	rv := reflect.Indirect(reflect.ValueOf(in))
+	if rv.Kind() != reflect.Struct {
+		return nil, pkgerr.Errorf("blademaster: request must be a struct pointer, got %T", in)
+	}
+	// skip holds the top level fields which must not be sent as query.
+	skip := make(map[int]bool)
+	segs := strings.Split(m.Path, "/")
+	for i, seg := range segs {
+		var name string
+		if len(seg) > 1 && seg[0] == ':' {
+			name = seg[1:]
+		} else if len(seg) > 2 && seg[0] == '{' && seg[len(seg)-1] == '}' {
+			name = seg[1 : len(seg)-1]
+		} else {
+			continue
+		}
+		v, idx, ok := fieldByParam(rv, name)
+		if !ok {
+			return nil, pkgerr.Errorf("blademaster: uri param %s not found in %T", name, in)
+		}
+		s, ok := formatScalar(reflect.Indirect(v))
+		if !ok {
+			return nil, pkgerr.Errorf("blademaster: uri param %s of %T is not a scalar", name, in)
+		}
+		segs[i] = url.PathEscape(s)
+		skip[idx] = true
+	}
+	var body io.Reader
+	switch m.Body {
+	case "":
+	case "*":
+		var bs []byte
+		if bs, err = json.Marshal(in); err != nil {
+			return
+		}
+		body = bytes.NewReader(bs)
+	default:
+		var v reflect.Value
+		if v, err = fieldPath(rv, m.Body, false); err != nil {
+			return
+		}
+		var bs []byte
+		if bs, err = json.Marshal(v.Interface()); err != nil {
+			return
+		}
+		body = bytes.NewReader(bs)
+		f, _ := rv.Type().FieldByName(strings.SplitN(m.Body, ".", 2)[0])
+		skip[f.Index[0]] = true
+	}
+	uri := host + strings.Join(segs, "/")
+	if m.Body != "*" {
+		if query := queryValues(rv, skip).Encode(); query != "" {
+			uri += "?" + query
+		}
+	}
+	if req, err = xhttp.NewRequest(m.Method, uri, body); err != nil {
+		err = pkgerr.Wrapf(err, "method:%s,uri:%s", m.Method, uri)
+		return
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("User-Agent", _noKickUserAgent+" "+env.AppID)
+	return
+}
+
+// fieldPath returns the field of v addressed by the go field path like
+// "Info.Name", nil pointers on the path are allocated if alloc is true
+// otherwise the nil pointer is returned.
+func fieldPath(v reflect.Value, path string, alloc bool) (reflect.Value, error) {
+	if path != "" && path != "*" {
+		for _, name := range strings.Split(path, ".") {
+			if v = indirect(v, alloc); v.Kind() == reflect.Ptr {
+				return v, nil
+			}
+			if v.Kind() != reflect.Struct {
+				return v, pkgerr.Errorf("blademaster: field path %s is not a struct", path)
+			}
+			if v = v.FieldByName(name); !v.IsValid() {
+				return v, pkgerr.Errorf("blademaster: field %s of path %s not found", name, path)
+			}
+		}
+	}
+	return indirect(v, alloc), nil
+}
+
+func indirect(v reflect.Value, alloc bool) reflect.Value {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			if !alloc {
+				break
+			}
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// fieldByParam resolves the uri param like "id" or "info.mid" and returns the
+// value with the index of the top level field.
+func fieldByParam(v reflect.Value, param string) (reflect.Value, int, bool) {
+	top := -1
+	for _, name := range strings.Split(param, ".") {
+		v = reflect.Indirect(v)
+		if v.Kind() != reflect.Struct {
+			return v, top, false
+		}
+		idx := -1
+		for i := 0; i < v.NumField(); i++ {
+			if fieldMatch(v.Type().Field(i), name) {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return v, top, false
+		}
+		if top < 0 {
+			top = idx
+		}
+		v = v.Field(idx)
+	}
+	return v, top, true
+}
+
+func fieldMatch(f reflect.StructField, name string) bool {
+	for _, tag := range []string{"uri", "form", "json"} {
+		if tagName(f.Tag.Get(tag)) == name {
+			return true
+		}
+	}
+	return f.Name == name || protoName(f) == name
+}
+
+func tagName(tag string) string {
+	return strings.SplitN(tag, ",", 2)[0]
+}
+
+func protoName(f reflect.StructField) string {
+	for _, opt := range strings.Split(f.Tag.Get("protobuf"), ",") {
+		if strings.HasPrefix(opt, "name=") {
+			return opt[len("name="):]
+		}
+	}
+	return ""
+}
+
+// queryName returns the query key of field, the form tag is preferred since
+// the server binds query by it.
+func queryName(f reflect.StructField) string {
+	if f.PkgPath != "" || strings.HasPrefix(f.Name, "XXX_") {
+		return ""
+	}
+	if name := tagName(f.Tag.Get("form")); name != "" {
+		return name
+	}
+	if name := tagName(f.Tag.Get("json")); name != "" {
+		if name == "-" {
+			return ""
+		}
+		return name
+	}
+	return protoName(f)
+}
+
+func queryValues(v reflect.Value, skip map[int]bool) url.Values {
+	params := url.Values{}
+	tp := v.Type()
+	for i := 0; i < tp.NumField(); i++ {
+		if skip[i] {
+			continue
+		}
+		name := queryName(tp.Field(i))
+		if name == "" {
+			continue
+		}
+		for _, s := range formatValue(v.Field(i)) {
+			params.Add(name, s)
+		}
+	}
+	return params
+}
+
+// formatValue formats the non-zero scalar or scalar slice to strings,
+// messages and bytes can't be sent in uri and are ignored.
+func formatValue(v reflect.Value) []string {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		if e := v.Elem(); e.Kind() != reflect.Struct {
+			return formatValue(e)
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return nil
+		}
+		var vs []string
+		for i := 0; i < v.Len(); i++ {
+			if s, ok := formatScalar(v.Index(i)); ok {
+				vs = append(vs, s)
+			}
+		}
+		return vs
+	default:
+		if s, ok := formatScalar(v); ok && !v.IsZero() {
+			return []string{s}
+		}
+	}
+	return nil
+}
+
+func formatScalar(v reflect.Value) (string, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), true
+	}
+	return "", false
+}
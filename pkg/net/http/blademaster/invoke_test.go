@@ -0,0 +1,131 @@
+package blademaster
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"kratos/pkg/ecode"
+	xtime "kratos/pkg/time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type invokeInfo struct {
+	Mid  int64  `protobuf:"varint,1,opt,name=mid,proto3" json:"mid,omitempty" form:"mid"`
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty" form:"name"`
+}
+
+type invokeReq struct {
+	Mid                  int64       `protobuf:"varint,1,opt,name=mid,proto3" json:"mid,omitempty" form:"mid" uri:"mid"`
+	Tags                 []string    `protobuf:"bytes,2,rep,name=tags,proto3" json:"tags,omitempty" form:"tags"`
+	Info                 *invokeInfo `protobuf:"bytes,3,opt,name=info,proto3" json:"info,omitempty"`
+	Plat                 int32       `protobuf:"varint,4,opt,name=plat,proto3" json:"plat,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+}
+
+type invokeReply struct {
+	Info *invokeInfo `protobuf:"bytes,1,opt,name=info,proto3" json:"info,omitempty"`
+}
+
+func newInvokeServer(t *testing.T) (*httptest.Server, *Client) {
+	e := NewServer(&ServerConfig{Timeout: xtime.Duration(time.Second)})
+	e.GET("/info/:mid", func(c *Context) {
+		var p invokeReq
+		if err := c.BindUri(&p); err != nil {
+			return
+		}
+		if err := c.Bind(&p); err != nil {
+			return
+		}
+		assert.Equal(t, []string{"a", "b"}, p.Tags)
+		c.JSON(&invokeReply{Info: &invokeInfo{Mid: p.Mid, Name: "get"}}, nil)
+	})
+	e.POST("/info", func(c *Context) {
+		var p invokeReq
+		if err := c.Bind(&p); err != nil {
+			return
+		}
+		c.JSON(&invokeReply{Info: p.Info}, nil)
+	})
+	e.PUT("/info/:mid", func(c *Context) {
+		var p invokeReq
+		if err := c.BindUri(&p); err != nil {
+			return
+		}
+		if err := c.Bind(&p.Info); err != nil {
+			return
+		}
+		p.Info.Mid = p.Mid
+		c.JSON(p.Info, nil)
+	})
+	e.GET("/err/:mid", func(c *Context) {
+		var p invokeReq
+		if err := c.BindUri(&p); err != nil {
+			return
+		}
+		if p.Mid == 1 {
+			c.JSON(nil, ecode.Error(ecode.Int(-4000), "custom message"))
+			return
+		}
+		c.JSON(nil, ecode.NothingFound)
+	})
+	client := NewClient(&ClientConfig{
+		Dial:    xtime.Duration(time.Second),
+		Timeout: xtime.Duration(time.Second),
+	})
+	return httptest.NewServer(e), client
+}
+
+func TestInvoke(t *testing.T) {
+	srv, client := newInvokeServer(t)
+	defer srv.Close()
+	c := context.Background()
+
+	t.Run("path and query", func(t *testing.T) {
+		reply := new(invokeReply)
+		m := &ClientMethod{Method: "GET", Path: "/info/:mid"}
+		err := client.Invoke(c, srv.URL, m, &invokeReq{Mid: 12, Tags: []string{"a", "b"}}, reply)
+		assert.NoError(t, err)
+		assert.Equal(t, &invokeInfo{Mid: 12, Name: "get"}, reply.Info)
+	})
+
+	t.Run("whole body", func(t *testing.T) {
+		reply := new(invokeReply)
+		m := &ClientMethod{Method: "POST", Path: "/info", Body: "*"}
+		err := client.Invoke(c, srv.URL, m, &invokeReq{Info: &invokeInfo{Mid: 3, Name: "post"}}, reply)
+		assert.NoError(t, err)
+		assert.Equal(t, &invokeInfo{Mid: 3, Name: "post"}, reply.Info)
+	})
+
+	t.Run("field body and response body", func(t *testing.T) {
+		reply := new(invokeReply)
+		m := &ClientMethod{Method: "PUT", Path: "/info/{mid}", Body: "Info", ResponseBody: "Info"}
+		err := client.Invoke(c, srv.URL, m, &invokeReq{Mid: 7, Info: &invokeInfo{Name: "put"}}, reply)
+		assert.NoError(t, err)
+		assert.Equal(t, &invokeInfo{Mid: 7, Name: "put"}, reply.Info)
+	})
+
+	t.Run("ecode", func(t *testing.T) {
+		m := &ClientMethod{Method: "GET", Path: "/err/:mid"}
+		err := client.Invoke(c, srv.URL, m, &invokeReq{Mid: 1}, new(invokeReply))
+		assert.Equal(t, -4000, ecode.Cause(err).Code())
+		assert.Equal(t, "custom message", ecode.Cause(err).Message())
+
+		err = client.Invoke(c, srv.URL, m, &invokeReq{Mid: 2}, new(invokeReply))
+		assert.Equal(t, ecode.NothingFound, err)
+	})
+}
+
+func TestNewInvokeRequest(t *testing.T) {
+	req, err := newInvokeRequest("http://127.0.0.1", &ClientMethod{Method: "GET", Path: "/info/:mid"},
+		&invokeReq{Mid: 1, Tags: []string{"x"}, Plat: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, "/info/1", req.URL.Path)
+	assert.Equal(t, "plat=2&tags=x", req.URL.RawQuery)
+	assert.Nil(t, req.Body)
+
+	_, err = newInvokeRequest("http://127.0.0.1", &ClientMethod{Method: "GET", Path: "/info/:id"}, &invokeReq{})
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package blademaster
 
 import (
 	"fmt"
+	"strconv"
 	"sync/atomic"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 // RateLimiter bbr middleware.
 type RateLimiter struct {
 	group   *bbr.Group
+	limiter limit.Limiter
 	logTime int64
 }
 
@@ -24,22 +26,48 @@ func NewRateLimiter(conf *bbr.Config) (s *RateLimiter) {
 	}
 }
 
+// NewRateLimiterWith return a ratelimit middleware with the limiter shared by all routes,
+// e.g. the distributed limiter of ratelimit/redislimit.
+func NewRateLimiterWith(limiter limit.Limiter) (s *RateLimiter) {
+	return &RateLimiter{
+		limiter: limiter,
+		logTime: time.Now().UnixNano(),
+	}
+}
+
+func (b *RateLimiter) get(uri string) limit.Limiter {
+	if b.limiter != nil {
+		return b.limiter
+	}
+	return b.group.Get(uri)
+}
+
 func (b *RateLimiter) printStats(routePath string, limiter limit.Limiter) {
+	bl, ok := limiter.(*bbr.BBR)
+	if !ok {
+		return
+	}
 	now := time.Now().UnixNano()
 	if now-atomic.LoadInt64(&b.logTime) > int64(time.Second*3) {
 		atomic.StoreInt64(&b.logTime, now)
-		log.Info("http.bbr path:%s stat:%+v", routePath, limiter.(*bbr.BBR).Stat())
+		log.Info("http.bbr path:%s stat:%+v", routePath, bl.Stat())
 	}
 }
 
 // Limit return a bm handler func.
+// The less critical requests are dropped first by bbr, the criticality is from the
+// X-Bm-Metadata-Criticality header or the Criticality handler used before Limit.
 func (b *RateLimiter) Limit() HandlerFunc {
 	return func(c *Context) {
 		uri := fmt.Sprintf("%s://%s%s", c.Request.URL.Scheme, c.Request.Host, c.Request.URL.Path)
-		limiter := b.group.Get(uri)
-		done, err := limiter.Allow(c)
+		limiter := b.get(uri)
+		done, err := limiter.Allow(c, limit.WithRoute(c.RoutePath))
 		if err != nil {
 			_metricServerBBR.Inc(uri, c.Request.Method)
+			if d, ok := limit.RetryAfter(err); ok {
+				// NOTE: Retry-After is in seconds, round up the delay.
+				c.Writer.Header().Set("Retry-After", strconv.FormatInt(int64((d+time.Second-1)/time.Second), 10))
+			}
 			c.JSON(nil, err)
 			c.Abort()
 			return
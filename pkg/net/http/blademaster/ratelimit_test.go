@@ -0,0 +1,71 @@
+package blademaster
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"kratos/pkg/ecode"
+	limit "kratos/pkg/ratelimit"
+	xtime "kratos/pkg/time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeLimiter limits the route of limited and counts the done requests.
+type fakeLimiter struct {
+	limited    string
+	retryAfter time.Duration
+	done       int
+}
+
+func (l *fakeLimiter) Allow(ctx context.Context, opts ...limit.AllowOption) (func(limit.DoneInfo), error) {
+	opt := limit.DefaultAllowOpts()
+	for _, o := range opts {
+		o.Apply(&opt)
+	}
+	if opt.Route == l.limited {
+		return nil, limit.LimitExceeded(l.retryAfter)
+	}
+	return func(limit.DoneInfo) { l.done++ }, nil
+}
+
+func TestRateLimiterWith(t *testing.T) {
+	l := &fakeLimiter{limited: "/limited", retryAfter: 1500 * time.Millisecond}
+	e := NewServer(&ServerConfig{Timeout: xtime.Duration(time.Second)})
+	e.Use(NewRateLimiterWith(l).Limit())
+	e.GET("/limited", func(c *Context) { c.JSON("ok", nil) })
+	e.GET("/ok", func(c *Context) { c.JSON("ok", nil) })
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	get := func(path string) (*http.Response, int) {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		var res struct {
+			Code int `json:"code"`
+		}
+		if err = json.Unmarshal(body, &res); err != nil {
+			t.Fatalf("unmarshal %s error, %v", body, err)
+		}
+		return resp, res.Code
+	}
+
+	resp, code := get("/limited")
+	assert.Equal(t, ecode.LimitExceed.Code(), code)
+	// 1.5s is rounded up to 2s.
+	assert.Equal(t, "2", resp.Header.Get("Retry-After"))
+
+	resp, code = get("/ok")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "", resp.Header.Get("Retry-After"))
+	assert.Equal(t, 1, l.done)
+}
@@ -38,7 +38,8 @@ const Name = "p2c"
 
 // newBuilder creates a new weighted-roundrobin balancer builder.
 func newBuilder() balancer.Builder {
-	return base.NewBalancerBuilder(Name, &p2cPickerBuilder{}, base.Config{})
+	// NOTE: subconns failing the health checking are not ready, so they are not picked.
+	return base.NewBalancerBuilder(Name, &p2cPickerBuilder{}, base.Config{HealthCheck: true})
 }
 
 func init() {
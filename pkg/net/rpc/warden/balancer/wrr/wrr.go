@@ -33,7 +33,8 @@ const Name = "wrr"
 
 // newBuilder creates a new weighted-roundrobin balancer builder.
 func newBuilder() balancer.Builder {
-	return base.NewBalancerBuilder(Name, &wrrPickerBuilder{}, base.Config{})
+	// NOTE: subconns failing the health checking are not ready, so they are not picked.
+	return base.NewBalancerBuilder(Name, &wrrPickerBuilder{}, base.Config{HealthCheck: true})
 }
 
 func init() {
@@ -3,6 +3,7 @@ package warden
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"strconv"
@@ -27,6 +28,7 @@ import (
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	_ "google.golang.org/grpc/health" // NOTE: register the client health checking of grpc
 	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
@@ -35,6 +37,10 @@ import (
 
 var _grpcTarget flagvar.StringVars
 
+// _healthCheckServiceConfig enables the client health checking of the whole server,
+// servers without the health service are treated as healthy.
+const _healthCheckServiceConfig = `{"healthCheckConfig":{"serviceName":""}}`
+
 var (
 	_once           sync.Once
 	_defaultCliConf = &ClientConfig{
@@ -73,6 +79,11 @@ type ClientConfig struct {
 	KeepAliveInterval      xtime.Duration
 	KeepAliveTimeout       xtime.Duration
 	KeepAliveWithoutStream bool
+	// DisableHealthCheck disables watching the grpc.health.v1.Health of servers,
+	// the balancers skip the servers not SERVING if it is enabled.
+	DisableHealthCheck bool
+	// Retry is the retry policy, it is configured per method in Method usually.
+	Retry *RetryConfig
 }
 
 // Client is the framework's client side instance, it contains the ctx, opt and interceptors.
@@ -80,10 +91,59 @@ type ClientConfig struct {
 type Client struct {
 	conf    *ClientConfig
 	breaker *breaker.Group
+	budgets retryBudgets
 	mutex   sync.RWMutex
 
-	opts     []grpc.DialOption
-	handlers []grpc.UnaryClientInterceptor
+	opts           []grpc.DialOption
+	handlers       []grpc.UnaryClientInterceptor
+	streamHandlers []grpc.StreamClientInterceptor
+}
+
+// clientStream wraps grpc.ClientStream to be notified when the stream finishes.
+type clientStream struct {
+	grpc.ClientStream
+	desc     *grpc.StreamDesc
+	once     sync.Once
+	finish   func(err error) error
+	finished chan struct{}
+}
+
+// newClientStream wraps cs, finish is called once the stream is read to the end
+// or ctx of the stream is done, e.g. the caller cancels or abandons the stream.
+func newClientStream(ctx context.Context, cs grpc.ClientStream, desc *grpc.StreamDesc, finish func(err error) error) grpc.ClientStream {
+	s := &clientStream{ClientStream: cs, desc: desc, finish: finish, finished: make(chan struct{})}
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.done(gstatus.FromContextError(ctx.Err()).Err())
+		case <-s.finished:
+		}
+	}()
+	return s
+}
+
+// RecvMsg calls finish once the stream terminated, io.EOF is treated as success.
+func (cs *clientStream) RecvMsg(m interface{}) (err error) {
+	if err = cs.ClientStream.RecvMsg(m); err == nil {
+		// NOTE: non server-streaming rpc ends with the first response.
+		if !cs.desc.ServerStreams {
+			cs.done(nil)
+		}
+		return
+	}
+	if err == io.EOF {
+		cs.done(nil)
+		return
+	}
+	return cs.done(err)
+}
+
+func (cs *clientStream) done(err error) error {
+	cs.once.Do(func() {
+		err = cs.finish(err)
+		close(cs.finished)
+	})
+	return err
 }
 
 // TimeoutCallOption timeout option.
@@ -107,9 +167,7 @@ func (c *Client) handle() grpc.UnaryClientInterceptor {
 			conf   *ClientConfig
 			cancel context.CancelFunc
 			addr   string
-			p      peer.Peer
 		)
-		var ec ecode.Codes = ecode.OK
 		// apm tracing
 		if t, ok = trace.FromContext(ctx); ok {
 			t = t.Fork("", method)
@@ -158,14 +216,14 @@ func (c *Client) handle() grpc.UnaryClientInterceptor {
 		}
 		ctx = metadata.NewOutgoingContext(ctx, gmd)
 
-		opts = append(opts, grpc.Peer(&p))
-		if err = invoker(ctx, method, req, reply, cc, opts...); err != nil {
-			gst, _ := gstatus.FromError(err)
-			ec = status.ToEcode(gst)
-			err = errors.WithMessage(ec, gst.Message())
-		}
-		if p.Addr != nil {
-			addr = p.Addr.String()
+		attempt := newAttempt(method, req, cc, invoker, opts)
+		switch rc := conf.Retry; {
+		case rc == nil || rc.MaxAttempts < 2:
+			addr, err = attempt(ctx, reply)
+		case rc.HedgingDelay > 0:
+			addr, err = c.hedge(ctx, t, method, rc, reply, attempt)
+		default:
+			addr, err = c.retry(ctx, t, method, rc, reply, attempt)
 		}
 		if t != nil {
 			t.SetTag(trace.String(trace.TagAddress, addr), trace.String(trace.TagComment, ""))
@@ -174,6 +232,68 @@ func (c *Client) handle() grpc.UnaryClientInterceptor {
 	}
 }
 
+// handleStream returns a new stream client interceptor for OpenTracing\Metadata\Breaker.
+// NOTE: streams are long-lived, so the configured Timeout is not applied.
+func (c *Client) handleStream() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (cs grpc.ClientStream, err error) {
+		var (
+			ok bool
+			t  trace.Trace
+			p  peer.Peer
+		)
+		// apm tracing
+		if t, ok = trace.FromContext(ctx); ok {
+			t = t.Fork("", method)
+		}
+
+		// setup metadata
+		gmd := baseMetadata()
+		trace.Inject(t, trace.GRPCFormat, gmd)
+		brk := c.breaker.Get(method)
+		if err = brk.Allow(); err != nil {
+			_metricClientReqCodeTotal.Inc(method, "breaker")
+			if t != nil {
+				t.Finish(&err)
+			}
+			return
+		}
+		nmd.Range(ctx,
+			func(key string, value interface{}) {
+				if valstr, ok := value.(string); ok {
+					gmd[key] = []string{valstr}
+				}
+			},
+			nmd.IsOutgoingKey)
+		// merge with old matadata if exists
+		if oldmd, ok := metadata.FromOutgoingContext(ctx); ok {
+			gmd = metadata.Join(gmd, oldmd)
+		}
+		ctx = metadata.NewOutgoingContext(ctx, gmd)
+
+		finish := func(err error) error {
+			if err != nil {
+				gst, _ := gstatus.FromError(err)
+				err = errors.WithMessage(status.ToEcode(gst), gst.Message())
+			}
+			onBreaker(brk, &err)
+			if t != nil {
+				var addr string
+				if p.Addr != nil {
+					addr = p.Addr.String()
+				}
+				t.SetTag(trace.String(trace.TagAddress, addr), trace.String(trace.TagComment, ""))
+				t.Finish(&err)
+			}
+			return err
+		}
+		opts = append(opts, grpc.Peer(&p))
+		if cs, err = streamer(ctx, desc, cc, method, opts...); err != nil {
+			return nil, finish(err)
+		}
+		return newClientStream(ctx, cs, desc, finish), nil
+	}
+}
+
 func onBreaker(breaker breaker.Breaker, err *error) {
 	if err != nil && *err != nil {
 		if ecode.EqualError(ecode.ServerErr, *err) || ecode.EqualError(ecode.ServiceUnavailable, *err) || ecode.EqualError(ecode.Deadline, *err) || ecode.EqualError(ecode.LimitExceed, *err) {
@@ -257,6 +377,20 @@ func (c *Client) Use(handlers ...grpc.UnaryClientInterceptor) *Client {
 	return c
 }
 
+// UseStream attachs a global stream inteceptor to the Client.
+// Stream inteceptors are only executed for streaming rpc calls.
+func (c *Client) UseStream(handlers ...grpc.StreamClientInterceptor) *Client {
+	finalSize := len(c.streamHandlers) + len(handlers)
+	if finalSize >= int(_abortIndex) {
+		panic("warden: client use too many stream handlers")
+	}
+	mergedHandlers := make([]grpc.StreamClientInterceptor, finalSize)
+	copy(mergedHandlers, c.streamHandlers)
+	copy(mergedHandlers[len(c.streamHandlers):], handlers)
+	c.streamHandlers = mergedHandlers
+	return c
+}
+
 // UseOpt attachs a global grpc DialOption to the Client.
 func (c *Client) UseOpt(opts ...grpc.DialOption) *Client {
 	c.opts = append(c.opts, opts...)
@@ -279,6 +413,11 @@ func (c *Client) dial(ctx context.Context, target string, opts ...grpc.DialOptio
 		Timeout:             time.Duration(c.conf.KeepAliveTimeout),
 		PermitWithoutStream: !c.conf.KeepAliveWithoutStream,
 	}))
+	if c.conf.DisableHealthCheck {
+		dialOptions = append(dialOptions, grpc.WithDisableHealthCheck())
+	} else {
+		dialOptions = append(dialOptions, grpc.WithDefaultServiceConfig(_healthCheckServiceConfig))
+	}
 	dialOptions = append(dialOptions, opts...)
 
 	// init default handler
@@ -289,7 +428,15 @@ func (c *Client) dial(ctx context.Context, target string, opts ...grpc.DialOptio
 	// NOTE: c.handle must be a last interceptor.
 	handlers = append(handlers, c.handle())
 
+	var streamHandlers []grpc.StreamClientInterceptor
+	streamHandlers = append(streamHandlers, c.streamRecovery())
+	streamHandlers = append(streamHandlers, clientStreamLogging(dialOptions...))
+	streamHandlers = append(streamHandlers, c.streamHandlers...)
+	// NOTE: c.handleStream must be a last stream interceptor.
+	streamHandlers = append(streamHandlers, c.handleStream())
+
 	dialOptions = append(dialOptions, grpc.WithUnaryInterceptor(chainUnaryClient(handlers)))
+	dialOptions = append(dialOptions, grpc.WithStreamInterceptor(chainStreamClient(streamHandlers)))
 	c.mutex.RLock()
 	conf := c.conf
 	c.mutex.RUnlock()
@@ -379,3 +526,33 @@ func chainUnaryClient(handlers []grpc.UnaryClientInterceptor) grpc.UnaryClientIn
 		return handlers[0](ctx, method, req, reply, cc, chainHandler, opts...)
 	}
 }
+
+// chainStreamClient creates a single stream interceptor out of a chain of many stream interceptors.
+//
+// Execution is done in left-to-right order, the same as chainUnaryClient.
+func chainStreamClient(handlers []grpc.StreamClientInterceptor) grpc.StreamClientInterceptor {
+	n := len(handlers)
+	if n == 0 {
+		return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+			streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+	}
+
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		var (
+			i             int
+			chainStreamer grpc.Streamer
+		)
+		chainStreamer = func(ictx context.Context, idesc *grpc.StreamDesc, ic *grpc.ClientConn, imethod string, iopts ...grpc.CallOption) (grpc.ClientStream, error) {
+			if i == n-1 {
+				return streamer(ictx, idesc, ic, imethod, iopts...)
+			}
+			i++
+			return handlers[i](ictx, idesc, ic, imethod, chainStreamer, iopts...)
+		}
+
+		return handlers[0](ctx, desc, cc, method, chainStreamer, opts...)
+	}
+}
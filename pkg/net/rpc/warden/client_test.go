@@ -3,9 +3,12 @@ package warden
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	gstatus "google.golang.org/grpc/status"
 )
 
 func TestChainUnaryClient(t *testing.T) {
@@ -32,3 +35,50 @@ func TestChainUnaryClient(t *testing.T) {
 		"h1-out",
 	}, orders)
 }
+
+func TestChainStreamClient(t *testing.T) {
+	var orders []string
+	factory := func(name string) grpc.StreamClientInterceptor {
+		return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			orders = append(orders, name+"-in")
+			cs, err := streamer(ctx, desc, cc, method, opts...)
+			orders = append(orders, name+"-out")
+			return cs, err
+		}
+	}
+	handlers := []grpc.StreamClientInterceptor{factory("h1"), factory("h2"), factory("h3")}
+	interceptor := chainStreamClient(handlers)
+	interceptor(context.Background(), &grpc.StreamDesc{}, nil, "test", func(context.Context, *grpc.StreamDesc, *grpc.ClientConn, string, ...grpc.CallOption) (grpc.ClientStream, error) {
+		return nil, nil
+	})
+	assert.Equal(t, []string{
+		"h1-in",
+		"h2-in",
+		"h3-in",
+		"h3-out",
+		"h2-out",
+		"h1-out",
+	}, orders)
+}
+
+func TestClientStreamFinish(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	finished := make(chan error, 2)
+	newClientStream(ctx, nil, &grpc.StreamDesc{ServerStreams: true}, func(err error) error {
+		finished <- err
+		return err
+	})
+	// the abandoned stream finishes when its context is canceled.
+	cancel()
+	select {
+	case err := <-finished:
+		assert.Equal(t, codes.Canceled, gstatus.Code(err))
+	case <-time.After(time.Second):
+		t.Fatalf("canceled stream should be finished")
+	}
+	select {
+	case err := <-finished:
+		t.Fatalf("stream should be finished once, got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,142 @@
+package warden
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	gstatus "google.golang.org/grpc/status"
+)
+
+// isHealthMethod reports whether the method is of the health service, the
+// interceptors of server are skipped for health checking.
+func isHealthMethod(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, "/grpc.health.v1.Health/")
+}
+
+// healthServer implements grpc.health.v1.Health, the status of every service
+// can be changed at runtime, the empty service is the status of the server.
+// NOTE: unlike the health server of grpc, Watch streams are ended on shutdown,
+// so they do not block the graceful stop.
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+
+	mu       sync.Mutex
+	shutdown bool
+	statuses map[string]healthpb.HealthCheckResponse_ServingStatus
+	watchers map[string]map[chan healthpb.HealthCheckResponse_ServingStatus]struct{}
+	done     chan struct{}
+}
+
+func newHealthServer() *healthServer {
+	return &healthServer{
+		statuses: map[string]healthpb.HealthCheckResponse_ServingStatus{"": healthpb.HealthCheckResponse_SERVING},
+		watchers: make(map[string]map[chan healthpb.HealthCheckResponse_ServingStatus]struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Check returns the status of service, NotFound is returned for unknown services.
+func (h *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if st, ok := h.statuses[req.Service]; ok {
+		return &healthpb.HealthCheckResponse{Status: st}, nil
+	}
+	return nil, gstatus.Error(codes.NotFound, "unknown service")
+}
+
+// Watch sends the status of service once it changes, the status of unknown
+// services is SERVICE_UNKNOWN. The stream is ended after the server shuts down.
+func (h *healthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	// NOTE: only the latest status is kept in the buffer.
+	ch := make(chan healthpb.HealthCheckResponse_ServingStatus, 1)
+	h.mu.Lock()
+	if st, ok := h.statuses[req.Service]; ok {
+		ch <- st
+	} else {
+		ch <- healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+	}
+	if _, ok := h.watchers[req.Service]; !ok {
+		h.watchers[req.Service] = make(map[chan healthpb.HealthCheckResponse_ServingStatus]struct{})
+	}
+	h.watchers[req.Service][ch] = struct{}{}
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.watchers[req.Service], ch)
+		h.mu.Unlock()
+	}()
+	var last healthpb.HealthCheckResponse_ServingStatus = -1
+	send := func(st healthpb.HealthCheckResponse_ServingStatus) error {
+		if st == last {
+			return nil
+		}
+		last = st
+		return stream.Send(&healthpb.HealthCheckResponse{Status: st})
+	}
+	for {
+		select {
+		case st := <-ch:
+			if err := send(st); err != nil {
+				return gstatus.Error(codes.Canceled, "stream has ended")
+			}
+		case <-h.done:
+			// the NOT_SERVING of shutdown is sent before the stream ends.
+			select {
+			case st := <-ch:
+				send(st)
+			default:
+			}
+			return nil
+		case <-stream.Context().Done():
+			return gstatus.Error(codes.Canceled, "stream has ended")
+		}
+	}
+}
+
+// setStatus sets the status of service and notifies the watchers, it is ignored after shutdown.
+func (h *healthServer) setStatus(service string, st healthpb.HealthCheckResponse_ServingStatus) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.shutdown {
+		return
+	}
+	h.setStatusLocked(service, st)
+}
+
+// initStatus sets the status of service if it is not set yet.
+func (h *healthServer) initStatus(service string, st healthpb.HealthCheckResponse_ServingStatus) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.statuses[service]; !ok && !h.shutdown {
+		h.setStatusLocked(service, st)
+	}
+}
+
+func (h *healthServer) setStatusLocked(service string, st healthpb.HealthCheckResponse_ServingStatus) {
+	h.statuses[service] = st
+	for ch := range h.watchers[service] {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- st
+	}
+}
+
+// close sets all services NOT_SERVING and ends the Watch streams.
+func (h *healthServer) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.shutdown {
+		return
+	}
+	for service := range h.statuses {
+		h.setStatusLocked(service, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+	h.shutdown = true
+	close(h.done)
+}
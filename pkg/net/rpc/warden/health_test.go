@@ -0,0 +1,117 @@
+package warden
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	gstatus "google.golang.org/grpc/status"
+
+	pb "kratos/pkg/net/rpc/warden/internal/proto/testproto"
+	xtime "kratos/pkg/time"
+)
+
+func startHealthServer(t *testing.T, name string) (*Server, string) {
+	srv := NewServer(&ServerConfig{Addr: "127.0.0.1:0", Timeout: xtime.Duration(time.Second)})
+	pb.RegisterGreeterServer(srv.Server(), &testServer{helloFn: func(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+		return &pb.HelloReply{Message: name}, nil
+	}})
+	_, addr, err := srv.StartWithAddr()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv, addr.String()
+}
+
+func TestHealth(t *testing.T) {
+	srv, addr := startHealthServer(t, "s1")
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	cli := healthpb.NewHealthClient(conn)
+	ctx := context.Background()
+
+	resp, err := cli.Check(ctx, &healthpb.HealthCheckRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+	resp, err = cli.Check(ctx, &healthpb.HealthCheckRequest{Service: "testproto.Greeter"})
+	assert.Nil(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+	_, err = cli.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown"})
+	assert.Equal(t, codes.NotFound, gstatus.Code(err))
+
+	srv.SetServingStatus("testproto.Greeter", healthpb.HealthCheckResponse_NOT_SERVING)
+	resp, err = cli.Check(ctx, &healthpb.HealthCheckRequest{Service: "testproto.Greeter"})
+	assert.Nil(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+
+	stream, err := cli.Watch(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = stream.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+	srv.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	resp, err = stream.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+	srv.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	resp, err = stream.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	// the watch stream gets NOT_SERVING and ends on shutdown, it does not block the graceful stop.
+	done := make(chan error, 1)
+	go func() {
+		sctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		done <- srv.Shutdown(sctx)
+	}()
+	resp, err = stream.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+	_, err = stream.Recv()
+	assert.Equal(t, io.EOF, err)
+	assert.Nil(t, <-done)
+}
+
+func TestHealthBalancing(t *testing.T) {
+	srv1, addr1 := startHealthServer(t, "s1")
+	defer srv1.Shutdown(context.Background())
+	srv2, addr2 := startHealthServer(t, "s2")
+	defer srv2.Shutdown(context.Background())
+
+	conn, err := NewClient(&ClientConfig{Timeout: xtime.Duration(time.Second)}).Dial(context.Background(), "direct://default/"+addr1+","+addr2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	cli := pb.NewGreeterClient(conn)
+
+	srv1.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		served := make(map[string]int)
+		for i := 0; i < 20; i++ {
+			reply, err := cli.SayHello(context.Background(), &pb.HelloRequest{Name: "health"})
+			if err == nil {
+				served[reply.Message]++
+			}
+		}
+		if served["s1"] == 0 && served["s2"] == 20 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("requests should not be balanced to the server not serving, got %v", served)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -180,3 +180,98 @@ func serverLogging(logFlag int8) grpc.UnaryServerInterceptor {
 		return resp, err
 	}
 }
+
+// clientStreamLogging warden grpc stream logging, the access log is written when the stream finishes.
+func clientStreamLogging(dialOptions ...grpc.DialOption) grpc.StreamClientInterceptor {
+	defaultFlag := extractLogDialOption(dialOptions)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		logFlag := extractLogCallOption(opts) | defaultFlag
+
+		startTime := time.Now()
+		var peerInfo peer.Peer
+		opts = append(opts, grpc.Peer(&peerInfo))
+
+		finish := func(err error) error {
+			code := ecode.Cause(err).Code()
+			duration := time.Since(startTime)
+			// monitor
+			_metricClientReqDur.Observe(int64(duration/time.Millisecond), method)
+			_metricClientReqCodeTotal.Inc(method, strconv.Itoa(code))
+
+			if logFlag&LogFlagDisable != 0 {
+				return err
+			}
+			if logFlag&LogFlagDisableInfo != 0 && err == nil && duration < 500*time.Millisecond {
+				return err
+			}
+			logFields := make([]log.D, 0, 7)
+			logFields = append(logFields, log.KVString("path", method))
+			logFields = append(logFields, log.KVInt("ret", code))
+			logFields = append(logFields, log.KVFloat64("ts", duration.Seconds()))
+			logFields = append(logFields, log.KVString("source", "grpc-access-log"))
+			logFields = append(logFields, log.KVString("stream", "true"))
+			if peerInfo.Addr != nil {
+				logFields = append(logFields, log.KVString("ip", peerInfo.Addr.String()))
+			}
+			if err != nil {
+				logFields = append(logFields, log.KVString("error", err.Error()), log.KVString("stack", fmt.Sprintf("%+v", err)))
+			}
+			logFn(code, duration)(ctx, logFields...)
+			return err
+		}
+
+		// invoker requests
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, finish(err)
+		}
+		return newClientStream(ctx, cs, desc, finish), nil
+	}
+}
+
+// serverStreamLogging warden grpc stream logging
+func serverStreamLogging(logFlag int8) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+		startTime := time.Now()
+		caller := metadata.String(ctx, metadata.Caller)
+		if caller == "" {
+			caller = "no_user"
+		}
+		var remoteIP string
+		if peerInfo, ok := peer.FromContext(ctx); ok {
+			remoteIP = peerInfo.Addr.String()
+		}
+
+		// call server handler
+		err := handler(srv, ss)
+
+		// after server response
+		code := ecode.Cause(err).Code()
+		duration := time.Since(startTime)
+		// monitor
+		_metricServerReqDur.Observe(int64(duration/time.Millisecond), info.FullMethod, caller)
+		_metricServerReqCodeTotal.Inc(info.FullMethod, caller, strconv.Itoa(code))
+
+		if logFlag&LogFlagDisable != 0 {
+			return err
+		}
+		if logFlag&LogFlagDisableInfo != 0 && err == nil && duration < 500*time.Millisecond {
+			return err
+		}
+		logFields := []log.D{
+			log.KVString("user", caller),
+			log.KVString("ip", remoteIP),
+			log.KVString("path", info.FullMethod),
+			log.KVInt("ret", code),
+			log.KVFloat64("ts", duration.Seconds()),
+			log.KVString("source", "grpc-access-log"),
+			log.KVString("stream", "true"),
+		}
+		if err != nil {
+			logFields = append(logFields, log.KVString("error", err.Error()), log.KVString("stack", fmt.Sprintf("%+v", err)))
+		}
+		logFn(code, duration)(ctx, logFields...)
+		return err
+	}
+}
@@ -38,4 +38,11 @@ var (
 		Help:      "grpc client requests code count.",
 		Labels:    []string{"method", "code"},
 	})
+	_metricClientRetryTotal = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: clientNamespace,
+		Subsystem: "requests",
+		Name:      "retry_total",
+		Help:      "grpc client retries and hedged requests count.",
+		Labels:    []string{"method", "type"},
+	})
 )
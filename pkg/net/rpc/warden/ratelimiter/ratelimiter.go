@@ -2,10 +2,12 @@ package ratelimiter
 
 import (
 	"context"
+	"strconv"
 	"sync/atomic"
 	"time"
 
 	"google.golang.org/grpc"
+	gmd "google.golang.org/grpc/metadata"
 
 	"kratos/pkg/log"
 	limit "kratos/pkg/ratelimit"
@@ -26,6 +28,7 @@ var (
 // RateLimiter bbr middleware.
 type RateLimiter struct {
 	group   *bbr.Group
+	limiter limit.Limiter
 	logTime int64
 }
 
@@ -37,22 +40,51 @@ func New(conf *bbr.Config) (s *RateLimiter) {
 	}
 }
 
+// NewWith return a ratelimit middleware with the limiter shared by all methods,
+// e.g. the distributed limiter of ratelimit/redislimit.
+func NewWith(limiter limit.Limiter) (s *RateLimiter) {
+	return &RateLimiter{
+		limiter: limiter,
+		logTime: time.Now().UnixNano(),
+	}
+}
+
+func (b *RateLimiter) get(fullMethod string) limit.Limiter {
+	if b.limiter != nil {
+		return b.limiter
+	}
+	return b.group.Get(fullMethod)
+}
+
+// setRetryAfter sends the retry delay of limited request by the retry-after-ms header.
+func setRetryAfter(ctx context.Context, err error) {
+	if d, ok := limit.RetryAfter(err); ok {
+		grpc.SetHeader(ctx, gmd.Pairs("retry-after-ms", strconv.FormatInt(int64(d/time.Millisecond), 10)))
+	}
+}
+
 func (b *RateLimiter) printStats(fullMethod string, limiter limit.Limiter) {
+	bl, ok := limiter.(*bbr.BBR)
+	if !ok {
+		return
+	}
 	now := time.Now().UnixNano()
 	if now-atomic.LoadInt64(&b.logTime) > int64(time.Second*3) {
 		atomic.StoreInt64(&b.logTime, now)
-		log.Info("grpc.bbr path:%s stat:%+v", fullMethod, limiter.(*bbr.BBR).Stat())
+		log.Info("grpc.bbr path:%s stat:%+v", fullMethod, bl.Stat())
 	}
 }
 
 // Limit is a server interceptor that detects and rejects overloaded traffic.
+// The less critical requests are dropped first by bbr, the criticality is from the metadata of caller.
 func (b *RateLimiter) Limit() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, args *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
 		uri := args.FullMethod
-		limiter := b.group.Get(uri)
-		done, err := limiter.Allow(ctx)
+		limiter := b.get(uri)
+		done, err := limiter.Allow(ctx, limit.WithRoute(uri))
 		if err != nil {
 			_metricServerBBR.Inc(uri)
+			setRetryAfter(ctx, err)
 			return
 		}
 		defer func() {
@@ -63,3 +95,23 @@ func (b *RateLimiter) Limit() grpc.UnaryServerInterceptor {
 		return
 	}
 }
+
+// StreamLimit is a stream server interceptor that detects and rejects overloaded traffic.
+func (b *RateLimiter) StreamLimit() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, args *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		uri := args.FullMethod
+		limiter := b.get(uri)
+		done, err := limiter.Allow(ss.Context(), limit.WithRoute(uri))
+		if err != nil {
+			_metricServerBBR.Inc(uri)
+			setRetryAfter(ss.Context(), err)
+			return
+		}
+		defer func() {
+			done(limit.DoneInfo{Op: limit.Success})
+			b.printStats(uri, limiter)
+		}()
+		err = handler(srv, ss)
+		return
+	}
+}
@@ -0,0 +1,104 @@
+package ratelimiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	gmd "google.golang.org/grpc/metadata"
+
+	"kratos/pkg/ecode"
+	limit "kratos/pkg/ratelimit"
+)
+
+// fakeLimiter limits the route of limited and counts the done requests.
+type fakeLimiter struct {
+	limited    string
+	retryAfter time.Duration
+	done       int
+}
+
+func (l *fakeLimiter) Allow(ctx context.Context, opts ...limit.AllowOption) (func(limit.DoneInfo), error) {
+	opt := limit.DefaultAllowOpts()
+	for _, o := range opts {
+		o.Apply(&opt)
+	}
+	if opt.Route == l.limited {
+		return nil, limit.LimitExceeded(l.retryAfter)
+	}
+	return func(limit.DoneInfo) { l.done++ }, nil
+}
+
+// fakeStream records the header set by grpc.SetHeader.
+type fakeStream struct {
+	ctx    context.Context
+	method string
+	header gmd.MD
+}
+
+func (s *fakeStream) Method() string { return s.method }
+
+func (s *fakeStream) SetHeader(md gmd.MD) error {
+	s.header = gmd.Join(s.header, md)
+	return nil
+}
+
+func (s *fakeStream) SendHeader(md gmd.MD) error { return s.SetHeader(md) }
+
+func (s *fakeStream) SetTrailer(md gmd.MD) error { return nil }
+
+func newStream(method string) *fakeStream {
+	s := &fakeStream{method: method}
+	s.ctx = grpc.NewContextWithServerTransportStream(context.Background(), s)
+	return s
+}
+
+// serverStream is the grpc.ServerStream of fakeStream for stream interceptors.
+type serverStream struct {
+	grpc.ServerStream
+	s *fakeStream
+}
+
+func (ss *serverStream) Context() context.Context { return ss.s.ctx }
+
+func TestLimit(t *testing.T) {
+	l := &fakeLimiter{limited: "/test.Limited/Call", retryAfter: 1500 * time.Millisecond}
+	interceptor := NewWith(l).Limit()
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	s := newStream("/test.Limited/Call")
+	_, err := interceptor(s.ctx, nil, &grpc.UnaryServerInfo{FullMethod: s.method}, handler)
+	if !ecode.EqualError(ecode.LimitExceed, err) {
+		t.Fatalf("limited request got %v, expect %v", err, ecode.LimitExceed)
+	}
+	if v := s.header.Get("retry-after-ms"); len(v) != 1 || v[0] != "1500" {
+		t.Fatalf("got retry-after-ms header %v, expect 1500", v)
+	}
+
+	s = newStream("/test.Ok/Call")
+	resp, err := interceptor(s.ctx, nil, &grpc.UnaryServerInfo{FullMethod: s.method}, handler)
+	if err != nil || resp != "ok" || len(s.header.Get("retry-after-ms")) != 0 || l.done != 1 {
+		t.Fatalf("allowed request got %v, %v, header %v, done %d", resp, err, s.header, l.done)
+	}
+}
+
+func TestStreamLimit(t *testing.T) {
+	l := &fakeLimiter{limited: "/test.Limited/Stream", retryAfter: 20 * time.Millisecond}
+	interceptor := NewWith(l).StreamLimit()
+	handler := func(srv interface{}, ss grpc.ServerStream) error { return nil }
+
+	s := newStream("/test.Limited/Stream")
+	err := interceptor(nil, &serverStream{s: s}, &grpc.StreamServerInfo{FullMethod: s.method}, handler)
+	if !ecode.EqualError(ecode.LimitExceed, err) {
+		t.Fatalf("limited stream got %v, expect %v", err, ecode.LimitExceed)
+	}
+	if v := s.header.Get("retry-after-ms"); len(v) != 1 || v[0] != "20" {
+		t.Fatalf("got retry-after-ms header %v, expect 20", v)
+	}
+
+	s = newStream("/test.Ok/Stream")
+	if err = interceptor(nil, &serverStream{s: s}, &grpc.StreamServerInfo{FullMethod: s.method}, handler); err != nil || l.done != 1 {
+		t.Fatalf("allowed stream got %v, done %d", err, l.done)
+	}
+}
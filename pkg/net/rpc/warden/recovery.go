@@ -37,6 +37,29 @@ func (s *Server) recovery() grpc.UnaryServerInterceptor {
 	}
 }
 
+// streamRecovery is a stream server interceptor that recovers from any panics.
+func (s *Server) streamRecovery() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, args *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if rerr := recover(); rerr != nil {
+				const size = 64 << 10
+				buf := make([]byte, size)
+				rs := runtime.Stack(buf, false)
+				if rs > size {
+					rs = size
+				}
+				buf = buf[:rs]
+				pl := fmt.Sprintf("grpc server stream panic: %s\n%v\n%s\n", args.FullMethod, rerr, buf)
+				fmt.Fprint(os.Stderr, pl)
+				log.Error(pl)
+				err = status.Errorf(codes.Unknown, ecode.ServerErr.Error())
+			}
+		}()
+		err = handler(srv, ss)
+		return
+	}
+}
+
 // recovery return a client interceptor  that recovers from any panics.
 func (c *Client) recovery() grpc.UnaryClientInterceptor {
 	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) (err error) {
@@ -59,3 +82,26 @@ func (c *Client) recovery() grpc.UnaryClientInterceptor {
 		return
 	}
 }
+
+// streamRecovery return a stream client interceptor that recovers from any panics.
+func (c *Client) streamRecovery() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (cs grpc.ClientStream, err error) {
+		defer func() {
+			if rerr := recover(); rerr != nil {
+				const size = 64 << 10
+				buf := make([]byte, size)
+				rs := runtime.Stack(buf, false)
+				if rs > size {
+					rs = size
+				}
+				buf = buf[:rs]
+				pl := fmt.Sprintf("grpc client stream panic: %s\n%v\n%s\n", method, rerr, buf)
+				fmt.Fprint(os.Stderr, pl)
+				log.Error(pl)
+				cs, err = nil, ecode.ServerErr
+			}
+		}()
+		cs, err = streamer(ctx, desc, cc, method, opts...)
+		return
+	}
+}
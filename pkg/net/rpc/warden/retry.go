@@ -0,0 +1,261 @@
+package warden
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	gstatus "google.golang.org/grpc/status"
+
+	"kratos/pkg/ecode"
+	"kratos/pkg/net/netutil"
+	"kratos/pkg/net/rpc/warden/internal/status"
+	"kratos/pkg/net/trace"
+	"kratos/pkg/stat/metric"
+	xtime "kratos/pkg/time"
+)
+
+// _minRetryBudget is the retries always allowed in the budget window, so methods of low qps can retry too.
+const _minRetryBudget = 10
+
+// RetryConfig is the client retry policy of method.
+type RetryConfig struct {
+	// MaxAttempts is the max attempts including the original request, retry is disabled if it is less than 2.
+	MaxAttempts int
+	// Codes are the retryable ecodes, default ServiceUnavailable.
+	Codes []int
+	// BaseDelay and MaxDelay are the exponential backoff between attempts, default 10ms and 100ms.
+	BaseDelay xtime.Duration
+	MaxDelay  xtime.Duration
+	// BudgetRatio is the max ratio of retries to requests in 10s, default 0.1.
+	BudgetRatio float64
+	// HedgingDelay enables hedged requests if it is positive, a new attempt is sent every HedgingDelay
+	// or once an attempt fails retryably, without canceling the pending ones. The first success wins.
+	// NOTE: hedged requests may be handled more than once, use it for idempotent methods only.
+	HedgingDelay xtime.Duration
+}
+
+func (rc *RetryConfig) retryable(err error) bool {
+	code := ecode.Cause(err).Code()
+	if len(rc.Codes) == 0 {
+		return code == ecode.ServiceUnavailable.Code()
+	}
+	for _, c := range rc.Codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (rc *RetryConfig) backoff() *netutil.BackoffConfig {
+	bc := &netutil.BackoffConfig{
+		BaseDelay: time.Duration(rc.BaseDelay),
+		MaxDelay:  time.Duration(rc.MaxDelay),
+		Factor:    1.6,
+		Jitter:    0.2,
+	}
+	if bc.BaseDelay <= 0 {
+		bc.BaseDelay = 10 * time.Millisecond
+	}
+	if bc.MaxDelay < bc.BaseDelay {
+		bc.MaxDelay = 10 * bc.BaseDelay
+	}
+	return bc
+}
+
+func (rc *RetryConfig) budgetRatio() float64 {
+	if rc.BudgetRatio <= 0 {
+		return 0.1
+	}
+	return rc.BudgetRatio
+}
+
+// retryBudget limits retries to a ratio of requests, so retries do not amplify the load of an overloaded service.
+type retryBudget struct {
+	requests metric.RollingCounter
+	retries  metric.RollingCounter
+}
+
+func newRetryBudget() *retryBudget {
+	opts := metric.RollingCounterOpts{Size: 10, BucketDuration: time.Second}
+	return &retryBudget{
+		requests: metric.NewRollingCounter(opts),
+		retries:  metric.NewRollingCounter(opts),
+	}
+}
+
+// allow reports whether a retry is allowed, the retry is counted if it is.
+func (b *retryBudget) allow(ratio float64) bool {
+	if b.retries.Sum()+1 > b.requests.Sum()*ratio+_minRetryBudget {
+		return false
+	}
+	b.retries.Add(1)
+	return true
+}
+
+type retryBudgets struct {
+	mu      sync.RWMutex
+	budgets map[string]*retryBudget
+}
+
+func (bs *retryBudgets) get(method string) *retryBudget {
+	bs.mu.RLock()
+	b, ok := bs.budgets[method]
+	bs.mu.RUnlock()
+	if ok {
+		return b
+	}
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	if b, ok = bs.budgets[method]; !ok {
+		if bs.budgets == nil {
+			bs.budgets = make(map[string]*retryBudget)
+		}
+		b = newRetryBudget()
+		bs.budgets[method] = b
+	}
+	return b
+}
+
+// attemptFunc makes an attempt of the call with the reply, and returns the peer address.
+type attemptFunc func(ctx context.Context, reply interface{}) (addr string, err error)
+
+func newAttempt(method string, req interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts []grpc.CallOption) attemptFunc {
+	return func(ctx context.Context, reply interface{}) (addr string, err error) {
+		var p peer.Peer
+		aopts := make([]grpc.CallOption, 0, len(opts)+1)
+		aopts = append(append(aopts, opts...), grpc.Peer(&p))
+		if err = invoker(ctx, method, req, reply, cc, aopts...); err != nil {
+			gst, _ := gstatus.FromError(err)
+			err = errors.WithMessage(status.ToEcode(gst), gst.Message())
+		}
+		if p.Addr != nil {
+			addr = p.Addr.String()
+		}
+		return
+	}
+}
+
+// hasTime reports whether the remaining deadline of ctx is longer than d.
+func hasTime(ctx context.Context, d time.Duration) bool {
+	dl, ok := ctx.Deadline()
+	return !ok || time.Until(dl) > d
+}
+
+func traceAttempt(t trace.Trace, event string, attempt int, err error) {
+	if t == nil {
+		return
+	}
+	msg := fmt.Sprintf("attempt %d", attempt)
+	if err != nil {
+		msg = fmt.Sprintf("attempt %d after error: %v", attempt, err)
+	}
+	t.SetLog(trace.Log(trace.LogEvent, event), trace.Log(trace.LogMessage, msg))
+}
+
+// retry makes attempts one by one with backoff until success, a non-retryable error,
+// the max attempts, the exhausted budget or the deadline.
+func (c *Client) retry(ctx context.Context, t trace.Trace, method string, rc *RetryConfig, reply interface{}, attempt attemptFunc) (addr string, err error) {
+	budget := c.budgets.get(method)
+	budget.requests.Add(1)
+	bc := rc.backoff()
+	for i := 1; ; i++ {
+		if addr, err = attempt(ctx, reply); err == nil || i >= rc.MaxAttempts || !rc.retryable(err) {
+			return
+		}
+		delay := bc.Backoff(i - 1)
+		if !hasTime(ctx, delay) {
+			return
+		}
+		if !budget.allow(rc.budgetRatio()) {
+			_metricClientRetryTotal.Inc(method, "budget")
+			return
+		}
+		_metricClientRetryTotal.Inc(method, "retry")
+		traceAttempt(t, "retry", i+1, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// hedge sends hedged attempts in parallel, every attempt has its own reply and
+// the reply of the first success is copied to reply.
+func (c *Client) hedge(ctx context.Context, t trace.Trace, method string, rc *RetryConfig, reply interface{}, attempt attemptFunc) (addr string, err error) {
+	type result struct {
+		reply interface{}
+		addr  string
+		err   error
+	}
+	// NOTE: the pending attempts are canceled once the call returns.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	budget := c.budgets.get(method)
+	budget.requests.Add(1)
+	var (
+		delay   = time.Duration(rc.HedgingDelay)
+		results = make(chan result, rc.MaxAttempts)
+		sent    int
+		pending int
+	)
+	send := func() {
+		var r interface{}
+		if reply != nil {
+			r = reflect.New(reflect.TypeOf(reply).Elem()).Interface()
+		}
+		sent++
+		pending++
+		go func() {
+			a, e := attempt(ctx, r)
+			results <- result{reply: r, addr: a, err: e}
+		}()
+	}
+	// more sends a hedged attempt if the policy, the budget and the deadline allow.
+	more := func(cause error) bool {
+		if sent >= rc.MaxAttempts || !hasTime(ctx, 0) {
+			return false
+		}
+		if !budget.allow(rc.budgetRatio()) {
+			_metricClientRetryTotal.Inc(method, "budget")
+			return false
+		}
+		_metricClientRetryTotal.Inc(method, "hedge")
+		traceAttempt(t, "hedge", sent+1, cause)
+		send()
+		return true
+	}
+	send()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			if more(nil) {
+				timer.Reset(delay)
+			}
+		case r := <-results:
+			pending--
+			addr, err = r.addr, r.err
+			if err == nil {
+				if reply != nil {
+					reflect.ValueOf(reply).Elem().Set(reflect.ValueOf(r.reply).Elem())
+				}
+				return
+			}
+			if !rc.retryable(err) {
+				return
+			}
+			if !more(err) && pending == 0 {
+				return
+			}
+		}
+	}
+}
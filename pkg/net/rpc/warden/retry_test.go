@@ -0,0 +1,124 @@
+package warden
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	gstatus "google.golang.org/grpc/status"
+
+	"kratos/pkg/ecode"
+	pb "kratos/pkg/net/rpc/warden/internal/proto/testproto"
+	xtime "kratos/pkg/time"
+)
+
+func newRetryClient(rc *RetryConfig) *Client {
+	return NewClient(&ClientConfig{
+		Timeout: xtime.Duration(time.Second),
+		Method: map[string]*ClientConfig{
+			"/test/retry": {Timeout: xtime.Duration(time.Second), Retry: rc},
+		},
+	})
+}
+
+func TestRetry(t *testing.T) {
+	c := newRetryClient(&RetryConfig{MaxAttempts: 3, BaseDelay: xtime.Duration(time.Millisecond)})
+	var calls int32
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			return gstatus.Error(codes.Unavailable, "unavailable")
+		}
+		return nil
+	}
+	err := c.handle()(context.Background(), "/test/retry", nil, nil, nil, invoker)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), calls)
+
+	// non-retryable errors and methods without retry policy are not retried.
+	calls = 0
+	invoker = func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		atomic.AddInt32(&calls, 1)
+		return gstatus.Error(codes.InvalidArgument, "invalid")
+	}
+	err = c.handle()(context.Background(), "/test/retry", nil, nil, nil, invoker)
+	assert.True(t, ecode.EqualError(ecode.RequestErr, err))
+	assert.Equal(t, int32(1), calls)
+	calls = 0
+	c.handle()(context.Background(), "/test/other", nil, nil, nil, func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		atomic.AddInt32(&calls, 1)
+		return gstatus.Error(codes.Unavailable, "unavailable")
+	})
+	assert.Equal(t, int32(1), calls)
+}
+
+func TestRetryDeadline(t *testing.T) {
+	c := newRetryClient(&RetryConfig{MaxAttempts: 5, BaseDelay: xtime.Duration(100 * time.Millisecond)})
+	var calls int32
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		atomic.AddInt32(&calls, 1)
+		return gstatus.Error(codes.Unavailable, "unavailable")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := c.handle()(ctx, "/test/retry", nil, nil, nil, invoker)
+	assert.True(t, ecode.EqualError(ecode.ServiceUnavailable, err))
+	// the backoff is longer than the remaining deadline, so there is no retry.
+	assert.Equal(t, int32(1), calls)
+}
+
+func TestRetryBudget(t *testing.T) {
+	c := newRetryClient(&RetryConfig{MaxAttempts: 2, BaseDelay: xtime.Duration(time.Microsecond), BudgetRatio: 0.1})
+	var calls int32
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		atomic.AddInt32(&calls, 1)
+		return gstatus.Error(codes.Unavailable, "unavailable")
+	}
+	for i := 0; i < 50; i++ {
+		c.handle()(context.Background(), "/test/retry", nil, nil, nil, invoker)
+	}
+	// 50 requests with retries limited to 50*0.1 plus the min budget 10.
+	assert.Equal(t, int32(50+15), calls)
+}
+
+func TestHedge(t *testing.T) {
+	c := newRetryClient(&RetryConfig{MaxAttempts: 3, HedgingDelay: xtime.Duration(20 * time.Millisecond)})
+	var calls int32
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		n := atomic.AddInt32(&calls, 1)
+		if n == 1 {
+			// the original request is slow, the hedged one wins.
+			select {
+			case <-ctx.Done():
+				return gstatus.Error(codes.Canceled, "canceled")
+			case <-time.After(time.Second):
+			}
+		}
+		reply.(*pb.HelloReply).Message = "hedged"
+		return nil
+	}
+	reply := &pb.HelloReply{}
+	start := time.Now()
+	err := c.handle()(context.Background(), "/test/retry", &pb.HelloRequest{}, reply, nil, invoker)
+	assert.Nil(t, err)
+	assert.Equal(t, "hedged", reply.Message)
+	assert.True(t, time.Since(start) < 500*time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// a retryable failure sends the next hedged request at once.
+	calls = 0
+	invoker = func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			return gstatus.Error(codes.Unavailable, "unavailable")
+		}
+		return nil
+	}
+	start = time.Now()
+	err = c.handle()(context.Background(), "/test/retry", &pb.HelloRequest{}, &pb.HelloReply{}, nil, invoker)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	assert.True(t, time.Since(start) < 20*time.Millisecond)
+}
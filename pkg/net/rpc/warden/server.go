@@ -24,6 +24,7 @@ import (
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
 	_ "google.golang.org/grpc/encoding/gzip" // NOTE: use grpc gzip by header grpc-accept-encoding
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
@@ -77,17 +78,28 @@ type Server struct {
 	conf  *ServerConfig
 	mutex sync.RWMutex
 
-	server   *grpc.Server
-	handlers []grpc.UnaryServerInterceptor
+	server         *grpc.Server
+	health         *healthServer
+	handlers       []grpc.UnaryServerInterceptor
+	streamHandlers []grpc.StreamServerInterceptor
+}
+
+// serverStream wraps grpc.ServerStream and overrides its context,
+// so that stream handlers see the metadata and trace set by interceptors.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context of this stream.
+func (ss *serverStream) Context() context.Context {
+	return ss.ctx
 }
 
 // handle return a new unary server interceptor for OpenTracing\Logging\LinkTimeout.
 func (s *Server) handle() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, args *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
-		var (
-			cancel func()
-			addr   string
-		)
+		var cancel func()
 		s.mutex.RLock()
 		conf := s.conf
 		s.mutex.RUnlock()
@@ -107,38 +119,57 @@ func (s *Server) handle() grpc.UnaryServerInterceptor {
 		ctx, cancel = context.WithTimeout(ctx, timeout)
 		defer cancel()
 
-		// get grpc metadata(trace & remote_ip & color)
 		var t trace.Trace
-		cmd := nmd.MD{}
-		if gmd, ok := metadata.FromIncomingContext(ctx); ok {
-			t, _ = trace.Extract(trace.GRPCFormat, gmd)
-			for key, vals := range gmd {
-				if nmd.IsIncomingKey(key) {
-					cmd[key] = vals[0]
-				}
-			}
-		}
-		if t == nil {
-			t = trace.New(args.FullMethod)
-		} else {
-			t.SetTitle(args.FullMethod)
-		}
-
-		if pr, ok := peer.FromContext(ctx); ok {
-			addr = pr.Addr.String()
-			t.SetTag(trace.String(trace.TagAddress, addr))
-		}
+		ctx, t = incomingContext(ctx, args.FullMethod)
 		defer t.Finish(&err)
 
-		// use common meta data context instead of grpc context
-		ctx = nmd.NewContext(ctx, cmd)
-		ctx = trace.NewContext(ctx, t)
-
 		resp, err = handler(ctx, req)
 		return resp, status.FromError(err).Err()
 	}
 }
 
+// handleStream return a new stream server interceptor for OpenTracing\Logging.
+// NOTE: streams are long-lived, so the configured Timeout is not applied,
+// only the deadline propagated by the client is honored.
+func (s *Server) handleStream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, args *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		ctx, t := incomingContext(ss.Context(), args.FullMethod)
+		defer t.Finish(&err)
+
+		err = handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		return status.FromError(err).Err()
+	}
+}
+
+// incomingContext extracts grpc metadata(trace & remote_ip & color) from ctx,
+// returns the common meta data context and the server side trace.
+func incomingContext(ctx context.Context, fullMethod string) (context.Context, trace.Trace) {
+	var t trace.Trace
+	cmd := nmd.MD{}
+	if gmd, ok := metadata.FromIncomingContext(ctx); ok {
+		t, _ = trace.Extract(trace.GRPCFormat, gmd)
+		for key, vals := range gmd {
+			if nmd.IsIncomingKey(key) {
+				cmd[key] = vals[0]
+			}
+		}
+	}
+	if t == nil {
+		t = trace.New(fullMethod)
+	} else {
+		t.SetTitle(fullMethod)
+	}
+
+	if pr, ok := peer.FromContext(ctx); ok {
+		t.SetTag(trace.String(trace.TagAddress, pr.Addr.String()))
+	}
+
+	// use common meta data context instead of grpc context
+	ctx = nmd.NewContext(ctx, cmd)
+	ctx = trace.NewContext(ctx, t)
+	return ctx, t
+}
+
 func init() {
 	addFlag(flag.CommandLine)
 }
@@ -185,10 +216,15 @@ func NewServer(conf *ServerConfig, opt ...grpc.ServerOption) (s *Server) {
 		Timeout:               time.Duration(s.conf.KeepAliveTimeout),
 		MaxConnectionAge:      time.Duration(s.conf.MaxLifeTime),
 	})
-	opt = append(opt, keepParam, grpc.UnaryInterceptor(s.interceptor))
+	opt = append(opt, keepParam, grpc.UnaryInterceptor(s.interceptor), grpc.StreamInterceptor(s.streamInterceptor))
 	s.server = grpc.NewServer(opt...)
+	s.health = newHealthServer()
+	healthpb.RegisterHealthServer(s.server, s.health)
+	limiter := ratelimiter.New(nil)
 	s.Use(s.recovery(), s.handle(), serverLogging(conf.LogFlag), s.stats(), s.validate())
-	s.Use(ratelimiter.New(nil).Limit())
+	s.Use(limiter.Limit())
+	s.UseStream(s.streamRecovery(), s.handleStream(), serverStreamLogging(conf.LogFlag), s.streamStats())
+	s.UseStream(limiter.StreamLimit())
 	return
 }
 
@@ -238,7 +274,7 @@ func (s *Server) interceptor(ctx context.Context, req interface{}, args *grpc.Un
 	)
 
 	n := len(s.handlers)
-	if n == 0 {
+	if n == 0 || isHealthMethod(args.FullMethod) {
 		return handler(ctx, req)
 	}
 
@@ -253,6 +289,30 @@ func (s *Server) interceptor(ctx context.Context, req interface{}, args *grpc.Un
 	return s.handlers[0](ctx, req, args, chain)
 }
 
+// streamInterceptor is a single stream interceptor out of a chain of many stream interceptors.
+// Execution is done in left-to-right order, the same as interceptor.
+func (s *Server) streamInterceptor(srv interface{}, ss grpc.ServerStream, args *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	var (
+		i     int
+		chain grpc.StreamHandler
+	)
+
+	n := len(s.streamHandlers)
+	if n == 0 || isHealthMethod(args.FullMethod) {
+		return handler(srv, ss)
+	}
+
+	chain = func(isrv interface{}, iss grpc.ServerStream) error {
+		if i == n-1 {
+			return handler(isrv, iss)
+		}
+		i++
+		return s.streamHandlers[i](isrv, iss, args, chain)
+	}
+
+	return s.streamHandlers[0](srv, ss, args, chain)
+}
+
 // Server return the grpc server for registering service.
 func (s *Server) Server() *grpc.Server {
 	return s.server
@@ -272,6 +332,20 @@ func (s *Server) Use(handlers ...grpc.UnaryServerInterceptor) *Server {
 	return s
 }
 
+// UseStream attachs a global stream inteceptor to the server.
+// Stream inteceptors are only executed for streaming rpc calls.
+func (s *Server) UseStream(handlers ...grpc.StreamServerInterceptor) *Server {
+	finalSize := len(s.streamHandlers) + len(handlers)
+	if finalSize >= int(_abortIndex) {
+		panic("warden: server use too many stream handlers")
+	}
+	mergedHandlers := make([]grpc.StreamServerInterceptor, finalSize)
+	copy(mergedHandlers, s.streamHandlers)
+	copy(mergedHandlers[len(s.streamHandlers):], handlers)
+	s.streamHandlers = mergedHandlers
+	return s
+}
+
 // Run create a tcp listener and start goroutine for serving each incoming request.
 // Run will return a non-nil error unless Stop or GracefulStop is called.
 func (s *Server) Run(addr string) error {
@@ -339,14 +413,28 @@ func (s *Server) startWithAddr() (net.Addr, error) {
 // Serve accepts incoming connections on the listener lis, creating a new
 // ServerTransport and service goroutine for each.
 // Serve will return a non-nil error unless Stop or GracefulStop is called.
+// The registered services are SERVING in the health service unless their status is set already.
 func (s *Server) Serve(lis net.Listener) error {
+	for service := range s.server.GetServiceInfo() {
+		s.health.initStatus(service, healthpb.HealthCheckResponse_SERVING)
+	}
 	return s.server.Serve(lis)
 }
 
+// SetServingStatus sets the status of service in the health service at runtime,
+// the empty service is the status of the whole server.
+// NOTE: the status is ignored after Shutdown, all services are NOT_SERVING then.
+func (s *Server) SetServingStatus(service string, status healthpb.HealthCheckResponse_ServingStatus) {
+	s.health.setStatus(service, status)
+}
+
 // Shutdown stops the server gracefully. It stops the server from
 // accepting new connections and RPCs and blocks until all the pending RPCs are
 // finished or the context deadline is reached.
+// All services are NOT_SERVING in the health service before stopping, so the
+// health-aware balancers of clients skip the server.
 func (s *Server) Shutdown(ctx context.Context) (err error) {
+	s.health.close()
 	ch := make(chan struct{})
 	go func() {
 		s.server.GracefulStop()
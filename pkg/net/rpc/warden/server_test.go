@@ -543,14 +543,20 @@ func TestParseDSN(t *testing.T) {
 }
 
 type testServer struct {
-	helloFn func(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error)
+	helloFn  func(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error)
+	streamFn func(pb.Greeter_StreamHelloServer) error
 }
 
 func (t *testServer) SayHello(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
 	return t.helloFn(ctx, req)
 }
 
-func (t *testServer) StreamHello(pb.Greeter_StreamHelloServer) error { panic("not implemented") }
+func (t *testServer) StreamHello(ss pb.Greeter_StreamHelloServer) error {
+	if t.streamFn == nil {
+		panic("not implemented")
+	}
+	return t.streamFn(ss)
+}
 
 // NewTestServerClient .
 func NewTestServerClient(invoker func(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error), svrcfg *ServerConfig, clicfg *ClientConfig) (pb.GreeterClient, func() error) {
@@ -603,3 +609,80 @@ func TestStartWithAddr(t *testing.T) {
 		assert.Nil(t, err)
 	}
 }
+
+func TestStreamInterceptor(t *testing.T) {
+	var orders []string
+	srv := NewServer(&ServerConfig{Addr: "127.0.0.1:0", Timeout: xtime.Duration(time.Second)})
+	srv.UseStream(func(srv interface{}, ss grpc.ServerStream, args *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		orders = append(orders, "server-in")
+		err := handler(srv, ss)
+		orders = append(orders, "server-out")
+		return err
+	})
+	pb.RegisterGreeterServer(srv.Server(), &testServer{streamFn: func(ss pb.Greeter_StreamHelloServer) error {
+		ctx := ss.Context()
+		if _, ok := xtrace.FromContext(ctx); !ok {
+			t.Errorf("no trace extracted from stream context")
+		}
+		assert.Equal(t, "red", nmd.String(ctx, nmd.Color))
+		for {
+			in, err := ss.Recv()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if in.Name == "stream_error" {
+				return ecode.Conflict
+			}
+			if err = ss.Send(&pb.HelloReply{Message: "Hello " + in.Name, Success: true}); err != nil {
+				return err
+			}
+		}
+	}})
+	_, addr, err := srv.StartWithAddr()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Shutdown(context.Background())
+
+	client := NewClient(&clientConfig)
+	client.UseStream(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		orders = append(orders, "client")
+		return streamer(ctx, desc, cc, method, opts...)
+	})
+	conn, err := client.Dial(context.Background(), addr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	ctx := nmd.NewContext(context.Background(), nmd.MD{nmd.Color: "red"})
+	ctx = xtrace.NewContext(ctx, xtrace.New("stream_test"))
+
+	t.Run("stream", func(t *testing.T) {
+		stream, err := pb.NewGreeterClient(conn).StreamHello(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"a", "b", "c"} {
+			assert.Nil(t, stream.Send(&pb.HelloRequest{Name: name, Age: 1}))
+			reply, err := stream.Recv()
+			assert.Nil(t, err)
+			assert.Equal(t, "Hello "+name, reply.Message)
+		}
+		assert.Nil(t, stream.CloseSend())
+		_, err = stream.Recv()
+		assert.Equal(t, io.EOF, err)
+	})
+	t.Run("stream_error", func(t *testing.T) {
+		stream, err := pb.NewGreeterClient(conn).StreamHello(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Nil(t, stream.Send(&pb.HelloRequest{Name: "stream_error", Age: 1}))
+		_, err = stream.Recv()
+		assert.True(t, ecode.EqualError(ecode.Conflict, err), "stream error should be converted to ecode, got %v", err)
+	})
+	assert.Equal(t, []string{"client", "server-in", "server-out", "client", "server-in", "server-out"}, orders)
+}
@@ -14,12 +14,29 @@ import (
 func (s *Server) stats() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, args *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
 		resp, err = handler(ctx, req)
-		var cpustat cpu.Stat
-		cpu.ReadStat(&cpustat)
-		if cpustat.Usage != 0 {
-			trailer := gmd.Pairs([]string{nmd.CPUUsage, strconv.FormatInt(int64(cpustat.Usage), 10)}...)
+		if trailer := cpuTrailer(); trailer != nil {
 			grpc.SetTrailer(ctx, trailer)
 		}
 		return
 	}
 }
+
+func (s *Server) streamStats() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, args *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		err = handler(srv, ss)
+		if trailer := cpuTrailer(); trailer != nil {
+			ss.SetTrailer(trailer)
+		}
+		return
+	}
+}
+
+// cpuTrailer returns the cpu usage trailer used by client balancers, nil if usage is unknown.
+func cpuTrailer() gmd.MD {
+	var cpustat cpu.Stat
+	cpu.ReadStat(&cpustat)
+	if cpustat.Usage == 0 {
+		return nil
+	}
+	return gmd.Pairs([]string{nmd.CPUUsage, strconv.FormatInt(int64(cpustat.Usage), 10)}...)
+}
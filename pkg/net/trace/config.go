@@ -37,6 +37,9 @@ type Config struct {
 	ProtocolVersion int32 `dsn:"query.protocol_version,1"`
 	// Probability probability sampling
 	Probability float32 `dsn:"-"`
+	// Report enables writing spans to the collector, spans are dropped by
+	// default so that Finish never waits for an absent collector.
+	Report bool `dsn:"query.report"`
 }
 
 func parseDSN(rawdsn string) (*Config, error) {
@@ -57,8 +60,7 @@ func TracerFromEnvFlag() (Tracer, error) {
 	if err != nil {
 		return nil, err
 	}
-	report := newReport(cfg.Network, cfg.Addr, time.Duration(cfg.Timeout), cfg.ProtocolVersion)
-	return NewTracer(env.AppID, report, cfg.DisableSample), nil
+	return NewTracer(env.AppID, reportFromConfig(cfg), cfg.DisableSample), nil
 }
 
 // Init init trace report.
@@ -70,6 +72,12 @@ func Init(cfg *Config) {
 			panic(fmt.Errorf("parse trace dsn error: %s", err))
 		}
 	}
-	report := newReport(cfg.Network, cfg.Addr, time.Duration(cfg.Timeout), cfg.ProtocolVersion)
-	SetGlobalTracer(NewTracer(env.AppID, report, cfg.DisableSample))
+	SetGlobalTracer(NewTracer(env.AppID, reportFromConfig(cfg), cfg.DisableSample))
+}
+
+func reportFromConfig(cfg *Config) reporter {
+	if !cfg.Report {
+		return noopReport{}
+	}
+	return newReport(cfg.Network, cfg.Addr, time.Duration(cfg.Timeout), cfg.ProtocolVersion)
 }
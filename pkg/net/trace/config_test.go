@@ -2,6 +2,7 @@ package trace
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -31,3 +32,21 @@ func TestInit(t *testing.T) {
 	_, ok = _tracer.(nooptracer)
 	assert.False(t, ok)
 }
+
+func TestDefaultReportNoBlock(t *testing.T) {
+	cfg, err := parseDSN("unixgram:///nonexistent/dapper-collect.sock?disable_sample=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer := NewTracer("service1", reportFromConfig(cfg), cfg.DisableSample)
+	start := time.Now()
+	// more than the queue size of connReport
+	for i := 0; i < _dataChSize*2; i++ {
+		tracer.New("opt").Finish(nil)
+	}
+	assert.True(t, time.Since(start) < time.Second, "finish blocked by absent collector")
+
+	cfg.Report = true
+	_, ok := reportFromConfig(cfg).(*connReport)
+	assert.True(t, ok)
+}
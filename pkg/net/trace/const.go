@@ -4,4 +4,9 @@ package trace
 const (
 	KratosTraceID    = "kratos-trace-id"
 	KratosTraceDebug = "kratos-trace-debug"
+
+	// W3CTraceParent and W3CTraceState are the headers of W3C trace context,
+	// see https://www.w3.org/TR/trace-context/
+	W3CTraceParent = "traceparent"
+	W3CTraceState  = "tracestate"
 )
@@ -1,6 +1,7 @@
 package trace
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -23,6 +24,10 @@ type spanContext struct {
 	// Usually generated as a random number.
 	TraceID uint64
 
+	// TraceIDHigh is the high 64 bits of the 128 bits trace id of W3C trace context,
+	// it is zero for the traces started by kratos.
+	TraceIDHigh uint64
+
 	// SpanID represents span ID that must be unique within its trace,
 	// but does not have to be globally unique.
 	SpanID uint64
@@ -39,6 +44,9 @@ type spanContext struct {
 
 	// Level current level
 	Level int
+
+	// TraceState is the W3C tracestate of the trace, it is propagated as is.
+	TraceState string
 }
 
 func (c spanContext) isSampled() bool {
@@ -108,3 +116,54 @@ func contextFromString(value string) (spanContext, error) {
 	}
 	return sctx, nil
 }
+
+// traceParent convert spanContext to W3C traceparent
+// {version}-{trace-id}-{parent-id}-{trace-flags}
+// version: 00
+// trace-id: 128 bits base16, TraceIDHigh and TraceID
+// parent-id: SpanID base16
+// trace-flags: only the sampled flag is kept
+func (c spanContext) traceParent() string {
+	return fmt.Sprintf("00-%016x%016x-%016x-%02x", c.TraceIDHigh, c.TraceID, c.SpanID, c.Flags&flagSampled)
+}
+
+// contextFromTraceParent parse spanContext from W3C traceparent
+func contextFromTraceParent(value string) (spanContext, error) {
+	if value == "" {
+		return emptyContext, errEmptyTracerString
+	}
+	items := strings.Split(value, "-")
+	// NOTE: the later versions may append fields, the version ff is invalid.
+	if len(items) < 4 || len(items[0]) != 2 || items[0] == "ff" || (items[0] == "00" && len(items) != 4) {
+		return emptyContext, errInvalidTracerString
+	}
+	if len(items[1]) != 32 || len(items[2]) != 16 || len(items[3]) != 2 {
+		return emptyContext, errInvalidTracerString
+	}
+	parseHex := func(hex string) (uint64, bool) {
+		v, err := strconv.ParseUint(hex, 16, 64)
+		return v, err == nil && strings.ToLower(hex) == hex
+	}
+	var (
+		rets [4]uint64
+		ok   bool
+	)
+	for i, hex := range []string{items[1][:16], items[1][16:], items[2], items[3]} {
+		if rets[i], ok = parseHex(hex); !ok {
+			return emptyContext, errInvalidTracerString
+		}
+	}
+	if _, ok = parseHex(items[0]); !ok || (rets[0] == 0 && rets[1] == 0) || rets[2] == 0 {
+		return emptyContext, errInvalidTracerString
+	}
+	// TODO: the trace id of which low 64 bits are zero is not supported.
+	if rets[1] == 0 {
+		return emptyContext, errInvalidTracerString
+	}
+	return spanContext{
+		TraceIDHigh: rets[0],
+		TraceID:     rets[1],
+		SpanID:      rets[2],
+		Flags:       byte(rets[3]) & flagSampled,
+	}, nil
+}
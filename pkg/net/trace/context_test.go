@@ -24,3 +24,41 @@ func TestSpanContext(t *testing.T) {
 		t.Errorf("wrong spancontext get %+v -> %+v", pctx, pctx2)
 	}
 }
+
+func TestTraceParent(t *testing.T) {
+	pctx := spanContext{
+		TraceIDHigh: genID(),
+		TraceID:     genID(),
+		SpanID:      genID(),
+		Flags:       flagSampled | flagDebug,
+	}
+	value := pctx.traceParent()
+	if len(value) != 55 {
+		t.Fatalf("wrong traceparent %s", value)
+	}
+	pctx2, err := contextFromTraceParent(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pctx2.TraceIDHigh != pctx.TraceIDHigh || pctx2.TraceID != pctx.TraceID || pctx2.SpanID != pctx.SpanID || pctx2.Flags != flagSampled {
+		t.Errorf("wrong spancontext get %+v -> %+v", pctx, pctx2)
+	}
+	for _, value := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01",
+	} {
+		if _, err = contextFromTraceParent(value); err == nil {
+			t.Errorf("traceparent %q should be invalid", value)
+		}
+	}
+	// the later versions may have more fields.
+	if _, err = contextFromTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"); err != nil {
+		t.Error(err)
+	}
+}
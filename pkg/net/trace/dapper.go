@@ -86,10 +86,12 @@ func (d *dapper) newSpanWithContext(operationName string, pctx spanContext) Trac
 	}
 	level := pctx.Level + 1
 	nctx := spanContext{
-		TraceID:  pctx.TraceID,
-		ParentID: pctx.SpanID,
-		Flags:    pctx.Flags,
-		Level:    level,
+		TraceID:     pctx.TraceID,
+		TraceIDHigh: pctx.TraceIDHigh,
+		ParentID:    pctx.SpanID,
+		Flags:       pctx.Flags,
+		Level:       level,
+		TraceState:  pctx.TraceState,
 	}
 	if pctx.SpanID == 0 {
 		nctx.SpanID = pctx.TraceID
@@ -149,6 +151,16 @@ func (d *dapper) extract(format interface{}, carrier interface{}) (Trace, error)
 		}
 	}
 	pctx, err := contextFromString(carr.Get(KratosTraceID))
+	// NOTE: traceparent is used if the request is from the services of OpenTelemetry,
+	// the high bits of trace id are kept if the trace is the same one.
+	if w3c, werr := contextFromTraceParent(carr.Get(W3CTraceParent)); werr == nil {
+		if err != nil {
+			pctx, err = w3c, nil
+		} else if pctx.TraceID == w3c.TraceID {
+			pctx.TraceIDHigh = w3c.TraceIDHigh
+		}
+		pctx.TraceState = carr.Get(W3CTraceState)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -162,9 +174,9 @@ func (d *dapper) Close() error {
 
 func (d *dapper) report(sp *Span) {
 	if sp.context.isSampled() {
-		//if err := d.reporter.WriteSpan(sp); err != nil {
-		//d.stdlog.Printf("marshal trace span error: %s", err)
-		//}
+		if err := d.reporter.WriteSpan(sp); err != nil {
+			d.stdlog.Printf("marshal trace span error: %s", err)
+		}
 	}
 	d.putSpan(sp)
 }
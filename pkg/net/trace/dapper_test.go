@@ -101,6 +101,42 @@ func TestDapperPropagation(t *testing.T) {
 		assert.Equal(t, report.sps[1].context.ParentID, report.sps[2].context.SpanID)
 		assert.Equal(t, report.sps[0].context.ParentID, report.sps[1].context.SpanID)
 	})
+	t.Run("test W3C progagation", func(t *testing.T) {
+		report := &mockReport{}
+		t1 := NewTracer("service1", report, true)
+		// the request from a service of OpenTelemetry has traceparent only.
+		header := make(http.Header)
+		header.Set(W3CTraceParent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		header.Set(W3CTraceState, "congo=t61rcWkgMzE")
+		sp1, err := t1.Extract(HTTPFormat, header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sp2 := sp1.Fork("", "opt_client")
+		md := make(metadata.MD)
+		t1.Inject(sp2, GRPCFormat, md)
+		assert.Equal(t, []string{"congo=t61rcWkgMzE"}, md[W3CTraceState])
+		assert.Regexp(t, "^00-4bf92f3577b34da6a3ce929d0e0e4736-[0-9a-f]{16}-01$", md[W3CTraceParent][0])
+		// the high bits of trace id are kept in the trace of kratos.
+		t2 := NewTracer("service2", report, true)
+		sp3, err := t2.Extract(GRPCFormat, md)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sp3.Finish(nil)
+		sp2.Finish(nil)
+		sp1.Finish(nil)
+
+		assert.Len(t, report.sps, 3)
+		assert.Equal(t, uint64(0x00f067aa0ba902b7), report.sps[2].context.ParentID)
+		for _, sp := range report.sps {
+			assert.Equal(t, uint64(0x4bf92f3577b34da6), sp.context.TraceIDHigh)
+			assert.Equal(t, uint64(0xa3ce929d0e0e4736), sp.context.TraceID)
+			assert.Equal(t, "congo=t61rcWkgMzE", sp.context.TraceState)
+		}
+		assert.Equal(t, report.sps[0].context.ParentID, report.sps[1].context.SpanID)
+		assert.Equal(t, report.sps[1].context.ParentID, report.sps[2].context.SpanID)
+	})
 }
 
 func BenchmarkSample(b *testing.B) {
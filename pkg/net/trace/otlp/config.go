@@ -0,0 +1,55 @@
+package otlp
+
+import (
+	"time"
+
+	"kratos/pkg/conf/env"
+	"kratos/pkg/net/trace"
+	xtime "kratos/pkg/time"
+)
+
+// protocols of OTLP.
+const (
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http"
+)
+
+// Config config.
+// endpoint should be the address of collector, e.g.
+// grpc: 127.0.0.1:4317
+// http: http://127.0.0.1:4318/v1/traces
+type Config struct {
+	Endpoint      string         `dsn:"endpoint"`
+	Protocol      string         `dsn:"query.protocol,grpc"`
+	BatchSize     int            `dsn:"query.batch_size,512"`
+	FlushInterval xtime.Duration `dsn:"query.flush_interval,1s"`
+	Timeout       xtime.Duration `dsn:"query.timeout,1s"`
+	DisableSample bool           `dsn:"query.disable_sample"`
+}
+
+func (c *Config) fix() {
+	if c.Protocol == "" {
+		c.Protocol = ProtocolGRPC
+	}
+	if c.Endpoint == "" {
+		if c.Protocol == ProtocolHTTP {
+			c.Endpoint = "http://127.0.0.1:4318/v1/traces"
+		} else {
+			c.Endpoint = "127.0.0.1:4317"
+		}
+	}
+	if c.BatchSize == 0 {
+		c.BatchSize = 512
+	}
+	if c.FlushInterval == 0 {
+		c.FlushInterval = xtime.Duration(time.Second)
+	}
+	if c.Timeout == 0 {
+		c.Timeout = xtime.Duration(time.Second)
+	}
+}
+
+// Init init trace report.
+func Init(c *Config) {
+	trace.SetGlobalTracer(trace.NewTracer(env.AppID, newReport(c), c.DisableSample))
+}
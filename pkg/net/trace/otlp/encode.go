@@ -0,0 +1,185 @@
+package otlp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"kratos/pkg/net/trace"
+)
+
+// NOTE: the messages of OTLP are encoded by hand, the generated code of
+// opentelemetry-proto requires a newer grpc than kratos.
+// see https://github.com/open-telemetry/opentelemetry-proto/tree/main/opentelemetry/proto/trace/v1
+
+const _scopeName = "kratos/pkg/net/trace"
+
+// span kinds of OTLP.
+const (
+	_kindInternal = 1
+	_kindServer   = 2
+	_kindClient   = 3
+	_kindProducer = 4
+	_kindConsumer = 5
+)
+
+// _statusError is the error code of span status.
+const _statusError = 2
+
+// span is a span encoded as opentelemetry.proto.trace.v1.Span.
+type span struct {
+	service string
+	data    []byte
+}
+
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendFixed64(b []byte, num protowire.Number, v uint64) []byte {
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, v)
+}
+
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+// appendKeyValue appends opentelemetry.proto.common.v1.KeyValue.
+func appendKeyValue(b []byte, num protowire.Number, key string, value interface{}) []byte {
+	var v []byte
+	switch val := value.(type) {
+	case string:
+		v = appendString(v, 1, val)
+	case []byte:
+		v = appendString(v, 1, string(val))
+	case bool:
+		v = appendVarint(v, 2, protowire.EncodeBool(val))
+	case int:
+		v = appendVarint(v, 3, uint64(val))
+	case int64:
+		v = appendVarint(v, 3, uint64(val))
+	case float32:
+		v = appendFixed64(v, 4, math.Float64bits(float64(val)))
+	case float64:
+		v = appendFixed64(v, 4, math.Float64bits(val))
+	default:
+		v = appendString(v, 1, fmt.Sprint(val))
+	}
+	var kv []byte
+	kv = appendString(kv, 1, key)
+	kv = appendMessage(kv, 2, v)
+	return appendMessage(b, num, kv)
+}
+
+// encodeSpan encodes the span, it must be called before the span is put back to pool.
+func encodeSpan(sp *trace.Span) *span {
+	ctx := sp.Context()
+	var traceID [16]byte
+	binary.BigEndian.PutUint64(traceID[:8], ctx.TraceIDHigh)
+	binary.BigEndian.PutUint64(traceID[8:], ctx.TraceID)
+	var b []byte
+	b = appendMessage(b, 1, traceID[:])
+	b = appendMessage(b, 2, idBytes(ctx.SpanID))
+	if ctx.TraceState != "" {
+		b = appendString(b, 3, ctx.TraceState)
+	}
+	if ctx.ParentID != 0 {
+		b = appendMessage(b, 4, idBytes(ctx.ParentID))
+	}
+	b = appendString(b, 5, sp.OperationName())
+	var (
+		kind   uint64 = _kindInternal
+		failed bool
+		attrs  []byte
+	)
+	for _, tag := range sp.Tags() {
+		switch tag.Key {
+		case trace.TagSpanKind:
+			switch tag.Value {
+			case "server":
+				kind = _kindServer
+			case "client":
+				kind = _kindClient
+			case "producer":
+				kind = _kindProducer
+			case "consumer":
+				kind = _kindConsumer
+			}
+			continue
+		case trace.TagError:
+			failed, _ = tag.Value.(bool)
+		}
+		attrs = appendKeyValue(attrs, 9, tag.Key, tag.Value)
+	}
+	b = appendVarint(b, 6, kind)
+	start := sp.StartTime()
+	b = appendFixed64(b, 7, uint64(start.UnixNano()))
+	b = appendFixed64(b, 8, uint64(start.Add(sp.Duration()).UnixNano()))
+	b = append(b, attrs...)
+	for _, lg := range sp.Logs() {
+		// the event of log is the name of event, other fields are the attributes.
+		name := "log"
+		var ev []byte
+		ev = appendFixed64(ev, 1, uint64(lg.Timestamp))
+		var evAttrs []byte
+		for _, f := range lg.Fields {
+			if f.Key == trace.LogEvent {
+				name = string(f.Value)
+				continue
+			}
+			evAttrs = appendKeyValue(evAttrs, 3, f.Key, f.Value)
+		}
+		ev = appendString(ev, 2, name)
+		ev = append(ev, evAttrs...)
+		b = appendMessage(b, 11, ev)
+	}
+	if failed {
+		b = appendMessage(b, 15, appendVarint(nil, 3, _statusError))
+	}
+	return &span{service: sp.ServiceName(), data: b}
+}
+
+func idBytes(id uint64) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], id)
+	return b[:]
+}
+
+// encodeRequest encodes the spans as opentelemetry.proto.collector.trace.v1.ExportTraceServiceRequest,
+// the spans are grouped by the service.
+func encodeRequest(spans []*span) []byte {
+	var (
+		services []string
+		groups   = make(map[string][]*span)
+	)
+	for _, sp := range spans {
+		if _, ok := groups[sp.service]; !ok {
+			services = append(services, sp.service)
+		}
+		groups[sp.service] = append(groups[sp.service], sp)
+	}
+	var req []byte
+	for _, service := range services {
+		var resource, scope, scopeSpans, resourceSpans []byte
+		resource = appendKeyValue(resource, 1, "service.name", service)
+		scope = appendString(scope, 1, _scopeName)
+		scopeSpans = appendMessage(scopeSpans, 1, scope)
+		for _, sp := range groups[service] {
+			scopeSpans = appendMessage(scopeSpans, 2, sp.data)
+		}
+		resourceSpans = appendMessage(resourceSpans, 1, resource)
+		resourceSpans = appendMessage(resourceSpans, 2, scopeSpans)
+		req = appendMessage(req, 1, resourceSpans)
+	}
+	return req
+}
@@ -0,0 +1,215 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+
+	"kratos/pkg/log"
+	"kratos/pkg/net/trace"
+)
+
+const (
+	_exportMethod = "/opentelemetry.proto.collector.trace.v1.TraceService/Export"
+	_httpPath     = "/v1/traces"
+	_queueSize    = 4096
+)
+
+var (
+	errQueueFull = errors.New("otlp: span queue is full")
+	errClosed    = errors.New("otlp: report already closed")
+)
+
+// exporter sends the encoded ExportTraceServiceRequest to collector.
+type exporter interface {
+	export(ctx context.Context, req []byte) error
+	close() error
+}
+
+// report batches the spans and exports them by OTLP.
+type report struct {
+	c        *Config
+	exporter exporter
+
+	rmx    sync.RWMutex
+	closed bool
+	spans  chan *span
+	done   chan struct{}
+}
+
+func newReport(c *Config) *report {
+	c.fix()
+	var exp exporter
+	if c.Protocol == ProtocolHTTP {
+		exp = newHTTPExporter(c)
+	} else {
+		exp = newGRPCExporter(c)
+	}
+	r := &report{
+		c:        c,
+		exporter: exp,
+		spans:    make(chan *span, _queueSize),
+		done:     make(chan struct{}),
+	}
+	go r.daemon()
+	return r
+}
+
+// WriteSpan write a trace span to queue.
+func (r *report) WriteSpan(raw *trace.Span) error {
+	sp := encodeSpan(raw)
+	r.rmx.RLock()
+	defer r.rmx.RUnlock()
+	if r.closed {
+		return errClosed
+	}
+	select {
+	case r.spans <- sp:
+		return nil
+	default:
+		return errQueueFull
+	}
+}
+
+// Close flushes the pending spans and closes the report.
+func (r *report) Close() error {
+	r.rmx.Lock()
+	if r.closed {
+		r.rmx.Unlock()
+		return nil
+	}
+	r.closed = true
+	close(r.spans)
+	r.rmx.Unlock()
+	<-r.done
+	return r.exporter.close()
+}
+
+func (r *report) daemon() {
+	defer close(r.done)
+	ticker := time.NewTicker(time.Duration(r.c.FlushInterval))
+	defer ticker.Stop()
+	batch := make([]*span, 0, r.c.BatchSize)
+	for {
+		select {
+		case sp, ok := <-r.spans:
+			if !ok {
+				r.flush(batch)
+				return
+			}
+			if batch = append(batch, sp); len(batch) >= r.c.BatchSize {
+				r.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			r.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (r *report) flush(batch []*span) {
+	if len(batch) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(r.c.Timeout))
+	defer cancel()
+	if err := r.exporter.export(ctx, encodeRequest(batch)); err != nil {
+		log.Error("otlp: export %d spans to %s error(%v)", len(batch), r.c.Endpoint, err)
+	}
+}
+
+// rawCodec sends the encoded message as is.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	b, ok := v.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("otlp: unexpected message type %T", v)
+	}
+	return b, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("otlp: unexpected message type %T", v)
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
+
+// grpcExporter exports by the TraceService of collector.
+// NOTE: grpc is used directly instead of warden, or the export calls would be traced.
+type grpcExporter struct {
+	conn *grpc.ClientConn
+}
+
+func newGRPCExporter(c *Config) *grpcExporter {
+	// NOTE: the dial is not blocking, so it does not fail with an unreachable collector.
+	conn, err := grpc.Dial(c.Endpoint, grpc.WithInsecure())
+	if err != nil {
+		panic(fmt.Errorf("otlp: dial %s error: %v", c.Endpoint, err))
+	}
+	return &grpcExporter{conn: conn}
+}
+
+func (e *grpcExporter) export(ctx context.Context, req []byte) error {
+	var reply []byte
+	return e.conn.Invoke(ctx, _exportMethod, req, &reply, grpc.ForceCodec(rawCodec{}))
+}
+
+func (e *grpcExporter) close() error {
+	return e.conn.Close()
+}
+
+// httpExporter exports by posting the protobuf encoded request.
+type httpExporter struct {
+	url    string
+	client *http.Client
+}
+
+func newHTTPExporter(c *Config) *httpExporter {
+	endpoint := c.Endpoint
+	if u, err := url.Parse(endpoint); err == nil && u.Scheme != "" && (u.Path == "" || u.Path == "/") {
+		u.Path = _httpPath
+		endpoint = u.String()
+	}
+	return &httpExporter{url: endpoint, client: &http.Client{}}
+}
+
+func (e *httpExporter) export(ctx context.Context, req []byte) error {
+	hreq, err := http.NewRequest(http.MethodPost, e.url, bytes.NewReader(req))
+	if err != nil {
+		return err
+	}
+	hreq.Header.Set("Content-Type", "application/x-protobuf")
+	resp, err := e.client.Do(hreq.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("otlp: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+func (e *httpExporter) close() error {
+	e.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,219 @@
+package otlp
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"kratos/pkg/net/trace"
+	xtime "kratos/pkg/time"
+)
+
+// fields decodes a protobuf message to the values of fields, varints and
+// fixed64s are decoded to uint64, others are []byte.
+func fields(t *testing.T, b []byte) map[protowire.Number][]interface{} {
+	m := make(map[protowire.Number][]interface{})
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatalf("invalid tag: %v", protowire.ParseError(n))
+		}
+		b = b[n:]
+		var v interface{}
+		switch typ {
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			v, n = protowire.ConsumeFixed64(b)
+		case protowire.BytesType:
+			v, n = protowire.ConsumeBytes(b)
+		default:
+			t.Fatalf("unexpected wire type %d", typ)
+		}
+		if n < 0 {
+			t.Fatalf("invalid field %d: %v", num, protowire.ParseError(n))
+		}
+		m[num] = append(m[num], v)
+		b = b[n:]
+	}
+	return m
+}
+
+type exportedSpan struct {
+	service  string
+	traceID  []byte
+	spanID   []byte
+	parentID []byte
+	name     string
+	kind     uint64
+	attrs    map[string][]byte
+	events   []string
+	status   uint64
+}
+
+func decodeRequest(t *testing.T, req []byte) (spans []*exportedSpan) {
+	for _, rs := range fields(t, req)[1] {
+		rsf := fields(t, rs.([]byte))
+		resource := fields(t, rsf[1][0].([]byte))
+		kv := fields(t, resource[1][0].([]byte))
+		service := string(fields(t, kv[2][0].([]byte))[1][0].([]byte))
+		for _, ss := range rsf[2] {
+			for _, raw := range fields(t, ss.([]byte))[2] {
+				f := fields(t, raw.([]byte))
+				sp := &exportedSpan{
+					service: service,
+					traceID: f[1][0].([]byte),
+					spanID:  f[2][0].([]byte),
+					name:    string(f[5][0].([]byte)),
+					kind:    f[6][0].(uint64),
+					attrs:   make(map[string][]byte),
+				}
+				if len(f[4]) > 0 {
+					sp.parentID = f[4][0].([]byte)
+				}
+				for _, a := range f[9] {
+					af := fields(t, a.([]byte))
+					sp.attrs[string(af[1][0].([]byte))] = af[2][0].([]byte)
+				}
+				for _, e := range f[11] {
+					sp.events = append(sp.events, string(fields(t, e.([]byte))[2][0].([]byte)))
+				}
+				if len(f[15]) > 0 {
+					sp.status = fields(t, f[15][0].([]byte))[3][0].(uint64)
+				}
+				spans = append(spans, sp)
+			}
+		}
+	}
+	return
+}
+
+// collector is a stub of OTLP collector.
+type collector struct {
+	mu    sync.Mutex
+	reqs  [][]byte
+	spans []*exportedSpan
+}
+
+func (c *collector) add(t *testing.T, req []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reqs = append(c.reqs, req)
+	c.spans = append(c.spans, decodeRequest(t, req)...)
+}
+
+func writeSpans(t *testing.T, r *report) {
+	t1 := trace.NewTracer("service1", r, true)
+	t2 := trace.NewTracer("service2", r, true)
+	sp1 := t1.New("opt_1")
+	sp2 := sp1.Fork("", "opt_client")
+	sp2.SetLog(trace.Log(trace.LogEvent, "retry"), trace.Log(trace.LogMessage, "attempt 2"))
+	header := make(http.Header)
+	t1.Inject(sp2, trace.HTTPFormat, header)
+	sp3, err := t2.Extract(trace.HTTPFormat, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp3.SetTitle("opt_server")
+	err = errors.New("failed")
+	sp3.Finish(&err)
+	sp2.Finish(nil)
+	sp1.Finish(nil)
+	if err = r.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkSpans(t *testing.T, spans []*exportedSpan) {
+	if len(spans) != 3 {
+		t.Fatalf("want 3 spans, got %d", len(spans))
+	}
+	server, client, root := spans[0], spans[1], spans[2]
+	if server.service != "service2" || client.service != "service1" || root.service != "service1" {
+		t.Fatalf("wrong services %s %s %s", server.service, client.service, root.service)
+	}
+	if server.name != "opt_server" || server.kind != _kindServer || server.status != _statusError {
+		t.Fatalf("wrong server span %+v", server)
+	}
+	if client.name != "opt_client" || client.kind != _kindClient || len(client.events) != 1 || client.events[0] != "retry" {
+		t.Fatalf("wrong client span %+v", client)
+	}
+	if string(server.parentID) != string(client.spanID) || string(client.parentID) != string(root.spanID) || root.parentID != nil {
+		t.Fatalf("wrong span parents")
+	}
+	for _, sp := range spans {
+		if len(sp.traceID) != 16 || binary.BigEndian.Uint64(sp.traceID[8:]) == 0 || string(sp.traceID) != string(root.traceID) {
+			t.Fatalf("wrong trace id %x", sp.traceID)
+		}
+		if _, ok := sp.attrs["hostname"]; !ok {
+			t.Fatalf("span should have the attributes of tags")
+		}
+	}
+}
+
+func TestHTTPExporter(t *testing.T) {
+	c := &collector{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/x-protobuf" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		req, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		c.add(t, req)
+	}))
+	defer ts.Close()
+
+	writeSpans(t, newReport(&Config{Endpoint: ts.URL, Protocol: ProtocolHTTP, BatchSize: 2}))
+	// the spans are exported in batches of 2.
+	if len(c.reqs) != 2 {
+		t.Fatalf("want 2 batches, got %d", len(c.reqs))
+	}
+	checkSpans(t, c.spans)
+}
+
+// serverCodec is the rawCodec of grpc server.
+type serverCodec struct {
+	rawCodec
+}
+
+func (serverCodec) String() string {
+	return "proto"
+}
+
+func TestGRPCExporter(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &collector{}
+	srv := grpc.NewServer(grpc.CustomCodec(serverCodec{}), grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+		if method, _ := grpc.MethodFromServerStream(stream); method != _exportMethod {
+			t.Errorf("unexpected method %s", method)
+		}
+		var req []byte
+		if err := stream.RecvMsg(&req); err != nil {
+			return err
+		}
+		c.add(t, req)
+		return stream.SendMsg([]byte{})
+	}))
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	writeSpans(t, newReport(&Config{
+		Endpoint:      lis.Addr().String(),
+		FlushInterval: xtime.Duration(10 * time.Millisecond),
+	}))
+	checkSpans(t, c.spans)
+}
@@ -24,6 +24,13 @@ type reporter interface {
 	Close() error
 }
 
+// noopReport drops the spans, it's used unless report is enabled by config.
+type noopReport struct{}
+
+func (noopReport) WriteSpan(sp *Span) error { return nil }
+
+func (noopReport) Close() error { return nil }
+
 // newReport with network address
 func newReport(network, address string, timeout time.Duration, protocolVersion int32) reporter {
 	if timeout == 0 {
@@ -129,6 +129,10 @@ func (s *Span) setLog(logs ...LogField) Trace {
 // Visit visits the k-v pair in trace, calling fn for each.
 func (s *Span) Visit(fn func(k, v string)) {
 	fn(KratosTraceID, s.context.String())
+	fn(W3CTraceParent, s.context.traceParent())
+	if s.context.TraceState != "" {
+		fn(W3CTraceState, s.context.TraceState)
+	}
 }
 
 // SetTitle reset trace title
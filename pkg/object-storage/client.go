@@ -0,0 +1,187 @@
+package object_storage
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"kratos/pkg/net/netutil/breaker"
+	"kratos/pkg/net/trace"
+)
+
+const (
+	_family             = "object_storage"
+	_traceComponentName = "library/object-storage"
+	_traceSpanKind      = "client"
+)
+
+var _ Client = &client{}
+
+// client wraps a Client with trace, metrics and breaker.
+type client struct {
+	Client
+	name    string
+	driver  string
+	addr    string
+	breaker breaker.Breaker
+}
+
+func newClient(c *Config, cli Client) *client {
+	name := c.Name
+	if name == "" {
+		name = c.Driver
+	}
+	addr := c.addr()
+	return &client{
+		Client:  cli,
+		name:    name,
+		driver:  c.Driver,
+		addr:    addr,
+		breaker: breaker.NewGroup(c.Breaker).Get(addr),
+	}
+}
+
+// errCode returns the error label of metrics.
+func errCode(err error) string {
+	switch err {
+	case ErrNotFound:
+		return "not_found"
+	case context.Canceled:
+		return "canceled"
+	case context.DeadlineExceeded:
+		return "timeout"
+	}
+	return "error"
+}
+
+// do calls fn with trace, metrics and breaker, ErrNotFound is not a failure of the breaker.
+func (c *client) do(ctx context.Context, command, path string, fn func() error) (err error) {
+	now := time.Now()
+	if t, ok := trace.FromContext(ctx); ok {
+		t = t.Fork(_family, command)
+		t.SetTag(
+			trace.String(trace.TagSpanKind, _traceSpanKind),
+			trace.String(trace.TagComponent, _traceComponentName),
+			trace.String(trace.TagPeerService, c.driver),
+			trace.String(trace.TagAddress, c.addr),
+			trace.String(trace.TagComment, path),
+		)
+		defer t.Finish(&err)
+	}
+	if err = c.breaker.Allow(); err != nil {
+		_metricReqErr.Inc(c.name, c.addr, command, "breaker")
+		return
+	}
+	err = fn()
+	_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), c.name, c.addr, command)
+	if err != nil {
+		_metricReqErr.Inc(c.name, c.addr, command, errCode(err))
+	}
+	if err != nil && err != ErrNotFound && err != context.Canceled {
+		c.breaker.MarkFailed()
+	} else {
+		c.breaker.MarkSuccess()
+	}
+	return
+}
+
+// countReader counts bytes read, the count is added to metrics on Close.
+type countReader struct {
+	io.Reader
+	n int64
+}
+
+func (r *countReader) Read(p []byte) (n int, err error) {
+	n, err = r.Reader.Read(p)
+	atomic.AddInt64(&r.n, int64(n))
+	return
+}
+
+func (c *client) Put(ctx context.Context, path string, file io.Reader) error {
+	r := &countReader{Reader: file}
+	defer func() { _metricBytes.Add(float64(r.n), c.name, c.addr, "put") }()
+	return c.do(ctx, "put", path, func() error {
+		return c.Client.Put(ctx, path, r)
+	})
+}
+
+func (c *client) PutMultipart(ctx context.Context, path string, file io.Reader, partSize int64) error {
+	r := &countReader{Reader: file}
+	defer func() { _metricBytes.Add(float64(r.n), c.name, c.addr, "put_multipart") }()
+	return c.do(ctx, "put_multipart", path, func() error {
+		return c.Client.PutMultipart(ctx, path, r, partSize)
+	})
+}
+
+// countReadCloser counts bytes of downloaded object.
+type countReadCloser struct {
+	countReader
+	closer io.Closer
+	c      *client
+	closed int32
+}
+
+func (r *countReadCloser) Close() error {
+	if atomic.CompareAndSwapInt32(&r.closed, 0, 1) {
+		_metricBytes.Add(float64(atomic.LoadInt64(&r.n)), r.c.name, r.c.addr, "get")
+	}
+	return r.closer.Close()
+}
+
+func (c *client) Get(ctx context.Context, path string) (body io.ReadCloser, err error) {
+	err = c.do(ctx, "get", path, func() (err error) {
+		body, err = c.Client.Get(ctx, path)
+		return
+	})
+	if err != nil {
+		return
+	}
+	return &countReadCloser{countReader: countReader{Reader: body}, closer: body, c: c}, nil
+}
+
+func (c *client) Delete(ctx context.Context, path string) error {
+	return c.do(ctx, "delete", path, func() error {
+		return c.Client.Delete(ctx, path)
+	})
+}
+
+func (c *client) Exists(ctx context.Context, path string) (ok bool, err error) {
+	err = c.do(ctx, "exists", path, func() (err error) {
+		ok, err = c.Client.Exists(ctx, path)
+		return
+	})
+	return
+}
+
+func (c *client) Stat(ctx context.Context, path string) (info *ObjectInfo, err error) {
+	err = c.do(ctx, "stat", path, func() (err error) {
+		info, err = c.Client.Stat(ctx, path)
+		return
+	})
+	return
+}
+
+func (c *client) List(ctx context.Context, prefix, marker string, limit int) (res *ListResult, err error) {
+	err = c.do(ctx, "list", prefix, func() (err error) {
+		res, err = c.Client.List(ctx, prefix, marker, limit)
+		return
+	})
+	return
+}
+
+func (c *client) PresignGet(ctx context.Context, path string, expire time.Duration) (u string, err error) {
+	err = c.do(ctx, "presign_get", path, func() (err error) {
+		u, err = c.Client.PresignGet(ctx, path, expire)
+		return
+	})
+	return
+}
+
+func (c *client) PresignPut(ctx context.Context, path string, expire time.Duration) (u string, err error) {
+	err = c.do(ctx, "presign_put", path, func() (err error) {
+		u, err = c.Client.PresignPut(ctx, path, expire)
+		return
+	})
+	return
+}
@@ -0,0 +1,75 @@
+package object_storage
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"kratos/pkg/ecode"
+	"kratos/pkg/net/netutil/breaker"
+	xtime "kratos/pkg/time"
+)
+
+type failClient struct {
+	Client
+	err error
+}
+
+func (c *failClient) Stat(ctx context.Context, path string) (*ObjectInfo, error) {
+	return nil, c.err
+}
+
+func TestClientBreaker(t *testing.T) {
+	conf := &Config{Driver: DriverLocal, Local: &LocalConfig{Root: "/tmp"}, Breaker: &breaker.Config{
+		Window:  xtime.Duration(time.Second),
+		Bucket:  10,
+		Request: 10,
+		K:       1.5,
+	}}
+	fc := &failClient{err: ErrNotFound}
+	c := newClient(conf, fc)
+	for i := 0; i < 100; i++ {
+		if _, err := c.Stat(context.TODO(), "a"); err != ErrNotFound {
+			t.Fatalf("not found should not trip the breaker, got %v", err)
+		}
+	}
+	fc.err = errors.New("internal error")
+	var rejected bool
+	for i := 0; i < 200 && !rejected; i++ {
+		_, err := c.Stat(context.TODO(), "a")
+		rejected = ecode.EqualError(ecode.ServiceUnavailable, err)
+	}
+	if !rejected {
+		t.Fatalf("breaker should reject requests after continuous failures")
+	}
+}
+
+func TestClientGet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "object_storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c, err := New(&Config{Name: "test", Driver: DriverLocal, Local: &LocalConfig{Root: dir}})
+	if err != nil {
+		t.Fatalf("new client error, %v", err)
+	}
+	if _, ok := c.(*client); !ok {
+		t.Fatalf("client of New should be wrapped, got %T", c)
+	}
+	if err = c.Put(context.TODO(), "a.txt", strings.NewReader("hello")); err != nil {
+		t.Fatalf("put error, %v", err)
+	}
+	body, err := c.Get(context.TODO(), "a.txt")
+	if err != nil {
+		t.Fatalf("get error, %v", err)
+	}
+	data, _ := ioutil.ReadAll(body)
+	if err = body.Close(); err != nil || string(data) != "hello" {
+		t.Fatalf("got unexpected content %s, close error %v", data, err)
+	}
+}
@@ -0,0 +1,210 @@
+package object_storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+
+	"kratos/pkg/log"
+)
+
+// 腾讯云COS
+type CosConfig struct {
+	BucketUrl string
+	SecretId  string
+	SecretKey string
+	UrlPrefix string
+}
+
+type Cos struct {
+	config *CosConfig
+	client *cos.Client
+}
+
+// NewCosClient new a bare client without trace, metrics and breaker, New with
+// Driver DriverCos wraps every call of it.
+func NewCosClient(config *CosConfig) (c *Cos, err error) {
+	u, err := url.Parse(config.BucketUrl)
+	if err != nil {
+		return
+	}
+	b := &cos.BaseURL{BucketURL: u}
+	client := cos.NewClient(b, &http.Client{
+		Transport: &cos.AuthorizationTransport{
+			SecretID:  config.SecretId,
+			SecretKey: config.SecretKey,
+		},
+	})
+
+	c = &Cos{
+		config: config,
+		client: client,
+	}
+
+	return
+}
+
+func (c *Cos) Put(ctx context.Context, path string, file io.Reader) (err error) {
+	resp, err := c.client.Object.Put(ctx, path, file, &cos.ObjectPutOptions{})
+	if err != nil {
+		log.Errorc(ctx, "[cos.Put] path: %s, err: (%v)", path, err)
+		return
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	log.Infoc(ctx, "[cos.Put] upload to cos success, body: %s, path: %s", string(body), path)
+	return
+}
+
+// cosUpload is a cos multipart upload session.
+type cosUpload struct {
+	ctx      context.Context
+	client   *cos.Client
+	path     string
+	uploadID string
+	parts    []cos.Object
+}
+
+func (u *cosUpload) upload(number int, part []byte) error {
+	resp, err := u.client.Object.UploadPart(u.ctx, u.path, u.uploadID, number, bytes.NewReader(part), &cos.ObjectUploadPartOptions{ContentLength: int64(len(part))})
+	if err != nil {
+		return err
+	}
+	u.parts = append(u.parts, cos.Object{PartNumber: number, ETag: resp.Header.Get("ETag")})
+	return nil
+}
+
+func (u *cosUpload) complete() error {
+	_, _, err := u.client.Object.CompleteMultipartUpload(u.ctx, u.path, u.uploadID, &cos.CompleteMultipartUploadOptions{Parts: u.parts})
+	return err
+}
+
+func (u *cosUpload) abort() error {
+	// NOTE: abort with a new context, the upload context may be canceled.
+	_, err := u.client.Object.AbortMultipartUpload(context.Background(), u.path, u.uploadID)
+	return err
+}
+
+func (c *Cos) PutMultipart(ctx context.Context, path string, file io.Reader, partSize int64) (err error) {
+	err = putMultipart(ctx, file, partSize, func(r io.Reader) error {
+		_, err := c.client.Object.Put(ctx, path, r, &cos.ObjectPutOptions{})
+		return err
+	}, func() (multipartUpload, error) {
+		res, _, err := c.client.Object.InitiateMultipartUpload(ctx, path, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &cosUpload{ctx: ctx, client: c.client, path: path, uploadID: res.UploadID}, nil
+	})
+	if err != nil {
+		log.Errorc(ctx, "[cos.PutMultipart] path: %s, err: (%v)", path, err)
+		return
+	}
+	log.Infoc(ctx, "[cos.PutMultipart] upload to cos success, path: %s", path)
+	return
+}
+
+// cosErr converts cos not found error to ErrNotFound.
+func cosErr(err error) error {
+	if cos.IsNotFoundError(err) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (c *Cos) Get(ctx context.Context, path string) (body io.ReadCloser, err error) {
+	resp, err := c.client.Object.Get(ctx, path, nil)
+	if err != nil {
+		if err = cosErr(err); err != ErrNotFound {
+			log.Errorc(ctx, "[cos.Get] path: %s, err: (%v)", path, err)
+		}
+		return
+	}
+	return resp.Body, nil
+}
+
+func (c *Cos) Delete(ctx context.Context, path string) (err error) {
+	if _, err = c.client.Object.Delete(ctx, path); err != nil {
+		log.Errorc(ctx, "[cos.Delete] path: %s, err: (%v)", path, err)
+	}
+	return
+}
+
+func (c *Cos) Exists(ctx context.Context, path string) (ok bool, err error) {
+	if _, err = c.Stat(ctx, path); err == nil {
+		return true, nil
+	}
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return
+}
+
+func (c *Cos) Stat(ctx context.Context, path string) (info *ObjectInfo, err error) {
+	resp, err := c.client.Object.Head(ctx, path, nil)
+	if err != nil {
+		if err = cosErr(err); err != ErrNotFound {
+			log.Errorc(ctx, "[cos.Stat] path: %s, err: (%v)", path, err)
+		}
+		return
+	}
+	return objectInfoFromHeader(path, resp.Header), nil
+}
+
+func (c *Cos) List(ctx context.Context, prefix, marker string, limit int) (res *ListResult, err error) {
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	bgr, _, err := c.client.Bucket.Get(ctx, &cos.BucketGetOptions{Prefix: prefix, Marker: marker, MaxKeys: limit})
+	if err != nil {
+		log.Errorc(ctx, "[cos.List] prefix: %s, err: (%v)", prefix, err)
+		return
+	}
+	res = &ListResult{
+		Objects:     make([]*ObjectInfo, 0, len(bgr.Contents)),
+		IsTruncated: bgr.IsTruncated,
+	}
+	for _, o := range bgr.Contents {
+		lastModified, _ := time.Parse(time.RFC3339, o.LastModified)
+		res.Objects = append(res.Objects, &ObjectInfo{
+			Key:          o.Key,
+			Size:         o.Size,
+			ETag:         trimETag(o.ETag),
+			LastModified: lastModified,
+		})
+	}
+	if res.IsTruncated {
+		// NOTE: cos only returns NextMarker when delimiter is set, the last key is the next marker.
+		res.NextMarker = bgr.NextMarker
+		if res.NextMarker == "" && len(bgr.Contents) > 0 {
+			res.NextMarker = bgr.Contents[len(bgr.Contents)-1].Key
+		}
+	}
+	return
+}
+
+func (c *Cos) presign(ctx context.Context, method, path string, expire time.Duration) (string, error) {
+	u, err := c.client.Object.GetPresignedURL(ctx, method, path, c.config.SecretId, c.config.SecretKey, expire, nil)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func (c *Cos) PresignGet(ctx context.Context, path string, expire time.Duration) (string, error) {
+	return c.presign(ctx, http.MethodGet, path, expire)
+}
+
+func (c *Cos) PresignPut(ctx context.Context, path string, expire time.Duration) (string, error) {
+	return c.presign(ctx, http.MethodPut, path, expire)
+}
+
+func (c *Cos) GetUrlPrefix() string {
+	return c.config.UrlPrefix
+}
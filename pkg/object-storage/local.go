@@ -0,0 +1,220 @@
+package object_storage
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"mime"
+	"os"
+	slashpath "path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"kratos/pkg/log"
+)
+
+// _localTempPrefix is the name prefix of files being written.
+const _localTempPrefix = ".kratos-tmp-"
+
+// 本地文件存储，用于开发环境和单元测试
+type LocalConfig struct {
+	// Root 文件存储根目录
+	Root      string
+	UrlPrefix string
+}
+
+// Local stores objects as files under Root, the object key is the relative path.
+type Local struct {
+	config *LocalConfig
+}
+
+// NewLocalClient new a bare client without trace, metrics and breaker, New with
+// Driver DriverLocal wraps every call of it.
+func NewLocalClient(config *LocalConfig) (c *Local, err error) {
+	if err = os.MkdirAll(config.Root, 0755); err != nil {
+		return
+	}
+
+	c = &Local{
+		config: config,
+	}
+
+	return
+}
+
+// file returns the file name of object key, the key can not escape the root.
+func (c *Local) file(key string) string {
+	return filepath.Join(c.config.Root, filepath.FromSlash(slashpath.Clean("/"+key)))
+}
+
+// Put writes file to a temp file then renames it, so readers never see a partial object.
+func (c *Local) Put(ctx context.Context, path string, file io.Reader) (err error) {
+	name := c.file(path)
+	if err = os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		log.Errorc(ctx, "[local.Put] path: %s, err: (%v)", path, err)
+		return
+	}
+	f, err := ioutil.TempFile(filepath.Dir(name), _localTempPrefix)
+	if err != nil {
+		log.Errorc(ctx, "[local.Put] path: %s, err: (%v)", path, err)
+		return
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+	if _, err = io.Copy(f, file); err != nil {
+		f.Close()
+		log.Errorc(ctx, "[local.Put] path: %s, err: (%v)", path, err)
+		return
+	}
+	if err = f.Close(); err != nil {
+		log.Errorc(ctx, "[local.Put] path: %s, err: (%v)", path, err)
+		return
+	}
+	if err = os.Rename(f.Name(), name); err != nil {
+		log.Errorc(ctx, "[local.Put] path: %s, err: (%v)", path, err)
+		return
+	}
+	log.Infoc(ctx, "[local.Put] write to local success, path: %s", path)
+	return
+}
+
+// PutMultipart is the same as Put, local file has no part size limit.
+func (c *Local) PutMultipart(ctx context.Context, path string, file io.Reader, partSize int64) error {
+	return c.Put(ctx, path, file)
+}
+
+func (c *Local) Get(ctx context.Context, path string) (body io.ReadCloser, err error) {
+	f, err := os.Open(c.file(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		log.Errorc(ctx, "[local.Get] path: %s, err: (%v)", path, err)
+		return
+	}
+	fi, err := f.Stat()
+	if err != nil || fi.IsDir() {
+		f.Close()
+		if err == nil {
+			return nil, ErrNotFound
+		}
+		log.Errorc(ctx, "[local.Get] path: %s, err: (%v)", path, err)
+		return
+	}
+	return f, nil
+}
+
+func (c *Local) Delete(ctx context.Context, path string) (err error) {
+	if err = os.Remove(c.file(path)); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		log.Errorc(ctx, "[local.Delete] path: %s, err: (%v)", path, err)
+	}
+	return
+}
+
+func (c *Local) Exists(ctx context.Context, path string) (ok bool, err error) {
+	fi, err := os.Stat(c.file(path))
+	if err == nil {
+		return !fi.IsDir(), nil
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	log.Errorc(ctx, "[local.Exists] path: %s, err: (%v)", path, err)
+	return
+}
+
+func (c *Local) Stat(ctx context.Context, path string) (info *ObjectInfo, err error) {
+	fi, err := os.Stat(c.file(path))
+	if err != nil || fi.IsDir() {
+		if err == nil || os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		log.Errorc(ctx, "[local.Stat] path: %s, err: (%v)", path, err)
+		return
+	}
+	return objectInfo(path, fi), nil
+}
+
+// objectInfo returns the object info of file, the etag is derived from size and
+// modify time like http file servers, so the content is never read.
+func objectInfo(key string, fi os.FileInfo) *ObjectInfo {
+	contentType := mime.TypeByExtension(filepath.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return &ObjectInfo{
+		Key:          key,
+		Size:         fi.Size(),
+		ETag:         strconv.FormatInt(fi.ModTime().UnixNano(), 16) + "-" + strconv.FormatInt(fi.Size(), 16),
+		ContentType:  contentType,
+		LastModified: fi.ModTime(),
+	}
+}
+
+func (c *Local) List(ctx context.Context, prefix, marker string, limit int) (res *ListResult, err error) {
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	files := make(map[string]os.FileInfo)
+	var keys []string
+	err = filepath.Walk(c.config.Root, func(name string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() || strings.HasPrefix(fi.Name(), _localTempPrefix) {
+			return nil
+		}
+		rel, err := filepath.Rel(c.config.Root, name)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if strings.HasPrefix(key, prefix) && key > marker {
+			keys = append(keys, key)
+			files[key] = fi
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorc(ctx, "[local.List] prefix: %s, err: (%v)", prefix, err)
+		return
+	}
+	sort.Strings(keys)
+	res = &ListResult{}
+	if len(keys) > limit {
+		keys = keys[:limit]
+		res.IsTruncated = true
+		res.NextMarker = keys[len(keys)-1]
+	}
+	res.Objects = make([]*ObjectInfo, 0, len(keys))
+	for _, key := range keys {
+		res.Objects = append(res.Objects, objectInfo(key, files[key]))
+	}
+	return
+}
+
+// presign returns UrlPrefix+path with the expire time, local files are not signed.
+func (c *Local) presign(path string, expire time.Duration) string {
+	return c.config.UrlPrefix + strings.TrimPrefix(slashpath.Clean("/"+path), "/") + "?expires=" + strconv.FormatInt(time.Now().Add(expire).Unix(), 10)
+}
+
+func (c *Local) PresignGet(ctx context.Context, path string, expire time.Duration) (string, error) {
+	return c.presign(path, expire), nil
+}
+
+func (c *Local) PresignPut(ctx context.Context, path string, expire time.Duration) (string, error) {
+	return c.presign(path, expire), nil
+}
+
+func (c *Local) GetUrlPrefix() string {
+	return c.config.UrlPrefix
+}
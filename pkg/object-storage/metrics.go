@@ -0,0 +1,30 @@
+package object_storage
+
+import "kratos/pkg/stat/metric"
+
+const namespace = "object_storage_client"
+
+var (
+	_metricReqDur = metric.NewHistogramVec(&metric.HistogramVecOpts{
+		Namespace: namespace,
+		Subsystem: "requests",
+		Name:      "duration_ms",
+		Help:      "object storage client requests duration(ms).",
+		Labels:    []string{"name", "addr", "command"},
+		Buckets:   []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000},
+	})
+	_metricReqErr = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: namespace,
+		Subsystem: "requests",
+		Name:      "error_total",
+		Help:      "object storage client requests error count.",
+		Labels:    []string{"name", "addr", "command", "error"},
+	})
+	_metricBytes = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: namespace,
+		Subsystem: "traffic",
+		Name:      "bytes_total",
+		Help:      "object storage client uploaded and downloaded bytes.",
+		Labels:    []string{"name", "addr", "command"},
+	})
+)
@@ -1,114 +1,192 @@
 package object_storage
 
 import (
+	"bytes"
 	"context"
-	"github.com/aliyun/aliyun-oss-go-sdk/oss"
-	"github.com/tencentyun/cos-go-sdk-v5"
+	"errors"
+	"fmt"
 	"io"
-	"io/ioutil"
-	"kratos/pkg/log"
-	"net/http"
-	"net/url"
+	"strings"
+	"time"
+
+	"kratos/pkg/net/netutil/breaker"
+)
+
+// Driver names of Config.
+const (
+	DriverOss   = "oss"
+	DriverCos   = "cos"
+	DriverS3    = "s3"
+	DriverLocal = "local"
+)
+
+const (
+	// DefaultPartSize is the default part size of multipart upload.
+	DefaultPartSize int64 = 8 << 20
+
+	// _probeSize is read before the part buffer is allocated, so small files are put without it.
+	_probeSize int64 = 64 << 10
+	// DefaultListLimit is the default max objects of List.
+	DefaultListLimit = 100
+)
+
+var (
+	_ Client = &Oss{}
+	_ Client = &Cos{}
+	_ Client = &S3{}
+	_ Client = &Local{}
+
+	// ErrNotFound object not found.
+	ErrNotFound = errors.New("object_storage: object not found")
 )
 
 type Client interface {
 	GetUrlPrefix() string                         // 获取图片路径前缀
 	Put(context.Context, string, io.Reader) error // 上传
+	// PutMultipart 分片上传大文件，partSize<=0时使用DefaultPartSize，小于一个分片时直接上传
+	PutMultipart(ctx context.Context, path string, file io.Reader, partSize int64) error
+	// Get 获取文件内容，调用方需要Close，文件不存在时返回ErrNotFound
+	Get(ctx context.Context, path string) (io.ReadCloser, error)
+	// Delete 删除文件，文件不存在时不返回错误
+	Delete(ctx context.Context, path string) error
+	// Exists 判断文件是否存在
+	Exists(ctx context.Context, path string) (bool, error)
+	// Stat 获取文件信息，文件不存在时返回ErrNotFound
+	Stat(ctx context.Context, path string) (*ObjectInfo, error)
+	// List 按前缀分页列出文件，marker为上一页的NextMarker，limit<=0时使用DefaultListLimit
+	List(ctx context.Context, prefix, marker string, limit int) (*ListResult, error)
+	// PresignGet 生成带过期时间的下载URL
+	PresignGet(ctx context.Context, path string, expire time.Duration) (string, error)
+	// PresignPut 生成带过期时间的上传URL
+	PresignPut(ctx context.Context, path string, expire time.Duration) (string, error)
 	// TODO 一些加水印的图片URL等
 }
 
-// 阿里云OSS
-type OssConfig struct {
-	Endpoint        string
-	AccessKeyId     string
-	AccessKeySecret string
-	Bucket          string
-	UrlPrefix       string
+// Config selects the backend by Driver, only the config of the driver is required.
+type Config struct {
+	// Name is the name of metrics, default is Driver.
+	Name    string
+	Driver  string
+	Breaker *breaker.Config
+	Oss     *OssConfig
+	Cos     *CosConfig
+	S3      *S3Config
+	Local   *LocalConfig
 }
 
-type Oss struct {
-	config *OssConfig
-	bucket *oss.Bucket
+// addr returns the bucket address of the driver.
+func (c *Config) addr() string {
+	switch c.Driver {
+	case DriverOss:
+		return c.Oss.Endpoint + "/" + c.Oss.Bucket
+	case DriverCos:
+		return c.Cos.BucketUrl
+	case DriverS3:
+		return c.S3.Endpoint + "/" + c.S3.Bucket
+	case DriverLocal:
+		return c.Local.Root
+	}
+	return ""
 }
 
-func NewOssClient(config *OssConfig) (c *Oss, err error) {
-	client, err := oss.New(config.Endpoint, config.AccessKeyId, config.AccessKeySecret)
-	if err != nil {
-		return
+// New new a Client of the config driver, the client is wrapped with trace, metrics and breaker.
+func New(c *Config) (cli Client, err error) {
+	switch {
+	case c.Driver == DriverOss && c.Oss != nil:
+		cli, err = NewOssClient(c.Oss)
+	case c.Driver == DriverCos && c.Cos != nil:
+		cli, err = NewCosClient(c.Cos)
+	case c.Driver == DriverS3 && c.S3 != nil:
+		cli, err = NewS3Client(c.S3)
+	case c.Driver == DriverLocal && c.Local != nil:
+		cli, err = NewLocalClient(c.Local)
+	default:
+		return nil, fmt.Errorf("object_storage: invalid driver(%s) or missing its config", c.Driver)
 	}
-	bucket, err := client.Bucket(config.Bucket)
 	if err != nil {
 		return
 	}
-
-	c = &Oss{
-		config: config,
-		bucket: bucket,
-	}
-
-	return
+	return newClient(c, cli), nil
 }
 
-func (c *Oss) Put(ctx context.Context, path string, file io.Reader) (err error) {
-	err = c.bucket.PutObject(path, file)
-	if err != nil {
-		log.Errorc(ctx, "[dao.UploadWxMaCode] err: (%v)", err)
-		return
-	}
-	log.Infoc(ctx, "[oss.Put] upload to oss success, path: %s", path)
-	return
+// ObjectInfo is the object meta.
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ETag         string // md5 of content for single part objects of cloud drivers, local derives it from size and modify time
+	ContentType  string
+	LastModified time.Time
 }
 
-func (c *Oss) GetUrlPrefix() string {
-	return c.config.UrlPrefix
+// ListResult is a page of List.
+type ListResult struct {
+	Objects []*ObjectInfo
+	// NextMarker is the marker of next page, it is empty if IsTruncated is false.
+	NextMarker  string
+	IsTruncated bool
 }
 
-// 腾讯云COS
-type CosConfig struct {
-	BucketUrl string
-	SecretId  string
-	SecretKey string
-	UrlPrefix string
+// trimETag removes quotes of http etag.
+func trimETag(etag string) string {
+	return strings.Trim(etag, "\"")
 }
 
-type Cos struct {
-	config *CosConfig
-	client *cos.Client
+// multipartUpload is a multipart upload session.
+type multipartUpload interface {
+	upload(number int, part []byte) error
+	complete() error
+	abort() error
 }
 
-func NewCosClient(config *CosConfig) (c *Cos, err error) {
-	u, err := url.Parse(config.BucketUrl)
-	if err != nil {
-		return
+// putMultipart uploads file by put if it is smaller than a part, otherwise by a multipart upload session.
+func putMultipart(ctx context.Context, file io.Reader, partSize int64, put func(io.Reader) error, newUpload func() (multipartUpload, error)) (err error) {
+	if partSize <= 0 {
+		partSize = DefaultPartSize
 	}
-	b := &cos.BaseURL{BucketURL: u}
-	client := cos.NewClient(b, &http.Client{
-		Transport: &cos.AuthorizationTransport{
-			SecretID:  config.SecretId,
-			SecretKey: config.SecretKey,
-		},
-	})
-
-	c = &Cos{
-		config: config,
-		client: client,
+	if partSize > _probeSize {
+		probe := make([]byte, _probeSize)
+		size, rerr := io.ReadFull(file, probe)
+		if rerr == io.EOF || rerr == io.ErrUnexpectedEOF {
+			return put(bytes.NewReader(probe[:size]))
+		}
+		if rerr != nil {
+			return rerr
+		}
+		file = io.MultiReader(bytes.NewReader(probe), file)
 	}
-
-	return
-}
-
-func (c *Cos) Put(ctx context.Context, path string, file io.Reader) (err error) {
-	resp, err := c.client.Object.Put(ctx, path, file, &cos.ObjectPutOptions{})
-	if err != nil {
-		log.Errorc(ctx, "[dao.UploadImage] err: (%v)", err)
-		return
+	var (
+		mu  multipartUpload
+		buf = make([]byte, partSize)
+	)
+	defer func() {
+		if err != nil && mu != nil {
+			mu.abort()
+		}
+	}()
+	for number := 1; ; number++ {
+		size, rerr := io.ReadFull(file, buf)
+		last := rerr == io.EOF || rerr == io.ErrUnexpectedEOF
+		if rerr != nil && !last {
+			return rerr
+		}
+		if number == 1 && last {
+			return put(bytes.NewReader(buf[:size]))
+		}
+		if mu == nil {
+			if mu, err = newUpload(); err != nil {
+				return
+			}
+		}
+		if size > 0 {
+			if err = ctx.Err(); err != nil {
+				return
+			}
+			if err = mu.upload(number, buf[:size]); err != nil {
+				return
+			}
+		}
+		if last {
+			return mu.complete()
+		}
 	}
-	defer resp.Body.Close()
-	body, _ := ioutil.ReadAll(resp.Body)
-	log.Infoc(ctx, "[cos.Put] upload to cos success, body: %s, path: %s", string(body), path)
-	return
-}
-
-func (c *Cos) GetUrlPrefix() string {
-	return c.config.UrlPrefix
 }
@@ -0,0 +1,387 @@
+package object_storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+	modified    time.Time
+}
+
+// fakeServer is an in-process s3 like object storage server, it serves path style requests under prefix.
+type fakeServer struct {
+	prefix string
+
+	mu      sync.Mutex
+	objects map[string]*fakeObject
+	uploads map[string]map[int][]byte
+	seq     int
+}
+
+func newFakeServer(prefix string) (*fakeServer, *httptest.Server) {
+	fs := &fakeServer{prefix: prefix, objects: map[string]*fakeObject{}, uploads: map[string]map[int][]byte{}}
+	return fs, httptest.NewServer(fs)
+}
+
+func crc(data []byte) string {
+	return strconv.FormatUint(crc64.Checksum(data, crc64.MakeTable(crc64.ECMA)), 10)
+}
+
+func etag(data []byte) string {
+	sum := md5.Sum(data)
+	return "\"" + hex.EncodeToString(sum[:]) + "\""
+}
+
+type fakeContents struct {
+	Key          string
+	Size         int64
+	ETag         string
+	LastModified string
+}
+
+type fakeListResult struct {
+	XMLName     xml.Name `xml:"ListBucketResult"`
+	IsTruncated bool
+	NextMarker  string `xml:",omitempty"`
+	Contents    []fakeContents
+}
+
+func (fs *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, fs.prefix), "/")
+	q := r.URL.Query()
+	body, _ := ioutil.ReadAll(r.Body)
+	if strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		body = decodeChunked(body)
+	}
+	switch {
+	case r.Method == http.MethodGet && key == "":
+		fs.list(w, q.Get("prefix"), q.Get("marker"), q.Get("max-keys"))
+	case r.Method == http.MethodPost && q.Get("uploads") == "" && q["uploads"] != nil:
+		fs.seq++
+		id := strconv.Itoa(fs.seq)
+		fs.uploads[id] = map[int][]byte{}
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><Key>%s</Key><UploadId>%s</UploadId></InitiateMultipartUploadResult>", key, id)
+	case r.Method == http.MethodPut && q.Get("uploadId") != "":
+		number, _ := strconv.Atoi(q.Get("partNumber"))
+		fs.uploads[q.Get("uploadId")][number] = body
+		w.Header().Set("ETag", etag(body))
+		w.Header().Set("x-cos-hash-crc64ecma", crc(body))
+	case r.Method == http.MethodPost && q.Get("uploadId") != "":
+		var req struct {
+			Parts []struct{ PartNumber int } `xml:"Part"`
+		}
+		xml.Unmarshal(body, &req)
+		var data []byte
+		for _, p := range req.Parts {
+			data = append(data, fs.uploads[q.Get("uploadId")][p.PartNumber]...)
+		}
+		delete(fs.uploads, q.Get("uploadId"))
+		fs.objects[key] = &fakeObject{data: data, contentType: "application/octet-stream", modified: time.Now()}
+		fmt.Fprintf(w, "<CompleteMultipartUploadResult><Bucket>bucket</Bucket><Key>%s</Key><ETag>%s</ETag></CompleteMultipartUploadResult>", key, etag(data))
+	case r.Method == http.MethodDelete && q.Get("uploadId") != "":
+		delete(fs.uploads, q.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		ct := r.Header.Get("Content-Type")
+		if ct == "" {
+			ct = "application/octet-stream"
+		}
+		fs.objects[key] = &fakeObject{data: body, contentType: ct, modified: time.Now()}
+		w.Header().Set("ETag", etag(body))
+		w.Header().Set("x-cos-hash-crc64ecma", crc(body))
+	case r.Method == http.MethodDelete:
+		delete(fs.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		o, ok := fs.objects[key]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				fmt.Fprint(w, "<Error><Code>NoSuchKey</Code><Message>not found</Message></Error>")
+			}
+			return
+		}
+		w.Header().Set("ETag", etag(o.data))
+		w.Header().Set("Content-Type", o.contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(o.data)))
+		w.Header().Set("Last-Modified", o.modified.UTC().Format(http.TimeFormat))
+		if r.Method == http.MethodGet {
+			w.Write(o.data)
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// decodeChunked decodes aws-chunked body of s3 streaming signature.
+func decodeChunked(body []byte) (data []byte) {
+	for {
+		i := bytes.Index(body, []byte("\r\n"))
+		if i < 0 {
+			return
+		}
+		size, _ := strconv.ParseInt(strings.SplitN(string(body[:i]), ";", 2)[0], 16, 64)
+		if size == 0 {
+			return
+		}
+		body = body[i+2:]
+		data = append(data, body[:size]...)
+		body = body[size+2:]
+	}
+}
+
+func (fs *fakeServer) list(w http.ResponseWriter, prefix, marker, maxKeys string) {
+	limit, _ := strconv.Atoi(maxKeys)
+	var keys []string
+	for k := range fs.objects {
+		if strings.HasPrefix(k, prefix) && k > marker {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	res := fakeListResult{}
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+		res.IsTruncated = true
+		res.NextMarker = keys[len(keys)-1]
+	}
+	for _, k := range keys {
+		o := fs.objects[k]
+		res.Contents = append(res.Contents, fakeContents{Key: k, Size: int64(len(o.data)), ETag: etag(o.data), LastModified: o.modified.UTC().Format(time.RFC3339)})
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(res)
+}
+
+// testClient tests the common behaviors of c, md5ETag is true if the etag of
+// driver is the md5 of content.
+func testClient(t *testing.T, c Client, md5ETag bool) {
+	ctx := context.TODO()
+	if err := c.Put(ctx, "dir/a.txt", strings.NewReader("hello")); err != nil {
+		t.Fatalf("put error, %v", err)
+	}
+	info, err := c.Stat(ctx, "dir/a.txt")
+	if err != nil {
+		t.Fatalf("stat error, %v", err)
+	}
+	if info.Size != 5 || info.ETag == "" || info.ContentType == "" || info.LastModified.IsZero() {
+		t.Fatalf("got unexpected object info %+v", info)
+	}
+	if md5ETag && info.ETag != strings.Trim(etag([]byte("hello")), "\"") {
+		t.Fatalf("got unexpected object info %+v", info)
+	}
+	body, err := c.Get(ctx, "dir/a.txt")
+	if err != nil {
+		t.Fatalf("get error, %v", err)
+	}
+	data, _ := ioutil.ReadAll(body)
+	body.Close()
+	if string(data) != "hello" {
+		t.Fatalf("got unexpected content %s", data)
+	}
+
+	large := bytes.Repeat([]byte("0123456789"), 3)
+	if err = c.PutMultipart(ctx, "dir/b.bin", bytes.NewReader(large), 8); err != nil {
+		t.Fatalf("put multipart error, %v", err)
+	}
+	if err = c.PutMultipart(ctx, "dir/c.bin", strings.NewReader("small"), 8); err != nil {
+		t.Fatalf("put multipart small file error, %v", err)
+	}
+	for key, expect := range map[string][]byte{"dir/b.bin": large, "dir/c.bin": []byte("small")} {
+		body, err = c.Get(ctx, key)
+		if err != nil {
+			t.Fatalf("get %s error, %v", key, err)
+		}
+		data, _ = ioutil.ReadAll(body)
+		body.Close()
+		if !bytes.Equal(data, expect) {
+			t.Fatalf("got %s unexpected content %s", key, data)
+		}
+	}
+	c.Put(ctx, "other/d.txt", strings.NewReader("other"))
+
+	var keys []string
+	var pages int
+	for marker := ""; ; pages++ {
+		res, err := c.List(ctx, "dir/", marker, 2)
+		if err != nil {
+			t.Fatalf("list error, %v", err)
+		}
+		for _, o := range res.Objects {
+			keys = append(keys, o.Key)
+		}
+		if !res.IsTruncated {
+			break
+		}
+		marker = res.NextMarker
+	}
+	if strings.Join(keys, ",") != "dir/a.txt,dir/b.bin,dir/c.bin" || pages != 1 {
+		t.Fatalf("got unexpected list %v in %d pages", keys, pages+1)
+	}
+
+	if err = c.Delete(ctx, "dir/a.txt"); err != nil {
+		t.Fatalf("delete error, %v", err)
+	}
+	if ok, err := c.Exists(ctx, "dir/a.txt"); ok || err != nil {
+		t.Fatalf("deleted object should not exist, got %v %v", ok, err)
+	}
+	if ok, err := c.Exists(ctx, "dir/b.bin"); !ok || err != nil {
+		t.Fatalf("object should exist, got %v %v", ok, err)
+	}
+	if _, err = c.Get(ctx, "dir/a.txt"); err != ErrNotFound {
+		t.Fatalf("get deleted object should return ErrNotFound, got %v", err)
+	}
+	if _, err = c.Stat(ctx, "dir/a.txt"); err != ErrNotFound {
+		t.Fatalf("stat deleted object should return ErrNotFound, got %v", err)
+	}
+
+	for _, presign := range []func(context.Context, string, time.Duration) (string, error){c.PresignGet, c.PresignPut} {
+		u, err := presign(ctx, "dir/b.bin", time.Hour)
+		if err != nil {
+			t.Fatalf("presign error, %v", err)
+		}
+		if u, err = url.PathUnescape(u); err != nil || !strings.Contains(u, "dir/b.bin") || !strings.Contains(u, "?") {
+			t.Fatalf("got unexpected presigned url %s", u)
+		}
+	}
+}
+
+func TestOss(t *testing.T) {
+	_, srv := newFakeServer("/bucket")
+	defer srv.Close()
+	c, err := New(&Config{Driver: DriverOss, Oss: &OssConfig{Endpoint: srv.URL, AccessKeyId: "ak", AccessKeySecret: "sk", Bucket: "bucket"}})
+	if err != nil {
+		t.Fatalf("new oss client error, %v", err)
+	}
+	testClient(t, c, true)
+}
+
+func TestCos(t *testing.T) {
+	_, srv := newFakeServer("")
+	defer srv.Close()
+	c, err := New(&Config{Driver: DriverCos, Cos: &CosConfig{BucketUrl: srv.URL, SecretId: "ak", SecretKey: "sk"}})
+	if err != nil {
+		t.Fatalf("new cos client error, %v", err)
+	}
+	testClient(t, c, true)
+}
+
+func TestS3(t *testing.T) {
+	_, srv := newFakeServer("/bucket")
+	defer srv.Close()
+	c, err := New(&Config{Driver: DriverS3, S3: &S3Config{
+		Endpoint:        strings.TrimPrefix(srv.URL, "http://"),
+		AccessKeyId:     "ak",
+		AccessKeySecret: "sk",
+		Bucket:          "bucket",
+		Region:          "us-east-1",
+		PathStyle:       true,
+	}})
+	if err != nil {
+		t.Fatalf("new s3 client error, %v", err)
+	}
+	testClient(t, c, true)
+}
+
+func TestLocal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "object_storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c, err := New(&Config{Driver: DriverLocal, Local: &LocalConfig{Root: dir, UrlPrefix: "http://127.0.0.1/"}})
+	if err != nil {
+		t.Fatalf("new local client error, %v", err)
+	}
+	testClient(t, c, false)
+	ctx := context.TODO()
+	info, err := c.Stat(ctx, "../dir/b.bin")
+	if err != nil {
+		t.Fatalf("path should not escape the root, got %v", err)
+	}
+	res, err := c.List(ctx, "dir/b.bin", "", 1)
+	if err != nil || len(res.Objects) != 1 || res.Objects[0].ETag != info.ETag {
+		t.Fatalf("etag of list should be the same as stat, got %+v, %v", res, err)
+	}
+	// the etag changes with the content.
+	c.Put(ctx, "dir/b.bin", strings.NewReader("changed"))
+	if changed, err := c.Stat(ctx, "dir/b.bin"); err != nil || changed.ETag == info.ETag {
+		t.Fatalf("etag of changed object should change, got %+v, %v", changed, err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(&Config{Driver: DriverS3}); err == nil {
+		t.Fatalf("new without driver config should fail")
+	}
+}
+
+type fakeUpload struct {
+	parts     [][]byte
+	completed bool
+}
+
+func (u *fakeUpload) upload(number int, part []byte) error {
+	u.parts = append(u.parts, append([]byte(nil), part...))
+	return nil
+}
+
+func (u *fakeUpload) complete() error {
+	u.completed = true
+	return nil
+}
+
+func (u *fakeUpload) abort() error { return nil }
+
+func TestPutMultipart(t *testing.T) {
+	partSize := 2 * _probeSize
+	for _, size := range []int64{0, _probeSize - 1, _probeSize, partSize, partSize*2 + 1} {
+		var (
+			put    []byte
+			u      *fakeUpload
+			data   = bytes.Repeat([]byte("x"), int(size))
+			putErr = putMultipart(context.TODO(), bytes.NewReader(data), partSize, func(r io.Reader) (err error) {
+				put, err = ioutil.ReadAll(r)
+				return
+			}, func() (multipartUpload, error) {
+				u = &fakeUpload{}
+				return u, nil
+			})
+		)
+		if putErr != nil {
+			t.Fatalf("put %d bytes error, %v", size, putErr)
+		}
+		if size < partSize {
+			if u != nil || !bytes.Equal(put, data) {
+				t.Fatalf("%d bytes should be put in one request", size)
+			}
+			continue
+		}
+		if put != nil || !u.completed || !bytes.Equal(bytes.Join(u.parts, nil), data) {
+			t.Fatalf("%d bytes should be uploaded by parts, got %d parts", size, len(u.parts))
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package object_storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+
+	"kratos/pkg/log"
+)
+
+// 阿里云OSS
+type OssConfig struct {
+	Endpoint        string
+	AccessKeyId     string
+	AccessKeySecret string
+	Bucket          string
+	UrlPrefix       string
+}
+
+type Oss struct {
+	config *OssConfig
+	bucket *oss.Bucket
+}
+
+// NewOssClient new a bare client without trace, metrics and breaker, New with
+// Driver DriverOss wraps every call of it.
+func NewOssClient(config *OssConfig) (c *Oss, err error) {
+	client, err := oss.New(config.Endpoint, config.AccessKeyId, config.AccessKeySecret)
+	if err != nil {
+		return
+	}
+	bucket, err := client.Bucket(config.Bucket)
+	if err != nil {
+		return
+	}
+
+	c = &Oss{
+		config: config,
+		bucket: bucket,
+	}
+
+	return
+}
+
+func (c *Oss) Put(ctx context.Context, path string, file io.Reader) (err error) {
+	err = c.bucket.PutObject(path, file)
+	if err != nil {
+		log.Errorc(ctx, "[oss.Put] path: %s, err: (%v)", path, err)
+		return
+	}
+	log.Infoc(ctx, "[oss.Put] upload to oss success, path: %s", path)
+	return
+}
+
+// ossUpload is an oss multipart upload session.
+type ossUpload struct {
+	bucket *oss.Bucket
+	imur   oss.InitiateMultipartUploadResult
+	parts  []oss.UploadPart
+}
+
+func (u *ossUpload) upload(number int, part []byte) error {
+	p, err := u.bucket.UploadPart(u.imur, bytes.NewReader(part), int64(len(part)), number)
+	if err != nil {
+		return err
+	}
+	u.parts = append(u.parts, p)
+	return nil
+}
+
+func (u *ossUpload) complete() error {
+	_, err := u.bucket.CompleteMultipartUpload(u.imur, u.parts)
+	return err
+}
+
+func (u *ossUpload) abort() error {
+	return u.bucket.AbortMultipartUpload(u.imur)
+}
+
+func (c *Oss) PutMultipart(ctx context.Context, path string, file io.Reader, partSize int64) (err error) {
+	err = putMultipart(ctx, file, partSize, func(r io.Reader) error {
+		return c.bucket.PutObject(path, r)
+	}, func() (multipartUpload, error) {
+		imur, err := c.bucket.InitiateMultipartUpload(path)
+		if err != nil {
+			return nil, err
+		}
+		return &ossUpload{bucket: c.bucket, imur: imur}, nil
+	})
+	if err != nil {
+		log.Errorc(ctx, "[oss.PutMultipart] path: %s, err: (%v)", path, err)
+		return
+	}
+	log.Infoc(ctx, "[oss.PutMultipart] upload to oss success, path: %s", path)
+	return
+}
+
+// ossErr converts oss not found error to ErrNotFound.
+func ossErr(err error) error {
+	if se, ok := err.(oss.ServiceError); ok && se.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (c *Oss) Get(ctx context.Context, path string) (body io.ReadCloser, err error) {
+	if body, err = c.bucket.GetObject(path); err != nil {
+		err = ossErr(err)
+		if err != ErrNotFound {
+			log.Errorc(ctx, "[oss.Get] path: %s, err: (%v)", path, err)
+		}
+	}
+	return
+}
+
+func (c *Oss) Delete(ctx context.Context, path string) (err error) {
+	if err = c.bucket.DeleteObject(path); err != nil {
+		log.Errorc(ctx, "[oss.Delete] path: %s, err: (%v)", path, err)
+	}
+	return
+}
+
+func (c *Oss) Exists(ctx context.Context, path string) (ok bool, err error) {
+	if ok, err = c.bucket.IsObjectExist(path); err != nil {
+		log.Errorc(ctx, "[oss.Exists] path: %s, err: (%v)", path, err)
+	}
+	return
+}
+
+func (c *Oss) Stat(ctx context.Context, path string) (info *ObjectInfo, err error) {
+	header, err := c.bucket.GetObjectDetailedMeta(path)
+	if err != nil {
+		if err = ossErr(err); err != ErrNotFound {
+			log.Errorc(ctx, "[oss.Stat] path: %s, err: (%v)", path, err)
+		}
+		return
+	}
+	return objectInfoFromHeader(path, header), nil
+}
+
+func (c *Oss) List(ctx context.Context, prefix, marker string, limit int) (res *ListResult, err error) {
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	lor, err := c.bucket.ListObjects(oss.Prefix(prefix), oss.Marker(marker), oss.MaxKeys(limit))
+	if err != nil {
+		log.Errorc(ctx, "[oss.List] prefix: %s, err: (%v)", prefix, err)
+		return
+	}
+	res = &ListResult{
+		Objects:     make([]*ObjectInfo, 0, len(lor.Objects)),
+		IsTruncated: lor.IsTruncated,
+	}
+	if lor.IsTruncated {
+		res.NextMarker = lor.NextMarker
+	}
+	for _, o := range lor.Objects {
+		res.Objects = append(res.Objects, &ObjectInfo{
+			Key:          o.Key,
+			Size:         o.Size,
+			ETag:         trimETag(o.ETag),
+			LastModified: o.LastModified,
+		})
+	}
+	return
+}
+
+func (c *Oss) PresignGet(ctx context.Context, path string, expire time.Duration) (string, error) {
+	return c.bucket.SignURL(path, oss.HTTPGet, int64(expire/time.Second))
+}
+
+func (c *Oss) PresignPut(ctx context.Context, path string, expire time.Duration) (string, error) {
+	return c.bucket.SignURL(path, oss.HTTPPut, int64(expire/time.Second))
+}
+
+func (c *Oss) GetUrlPrefix() string {
+	return c.config.UrlPrefix
+}
+
+// objectInfoFromHeader parses object info from http response header.
+func objectInfoFromHeader(path string, header http.Header) *ObjectInfo {
+	info := &ObjectInfo{
+		Key:         path,
+		ETag:        trimETag(header.Get("ETag")),
+		ContentType: header.Get("Content-Type"),
+	}
+	info.Size, _ = strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	info.LastModified, _ = http.ParseTime(header.Get("Last-Modified"))
+	return info
+}
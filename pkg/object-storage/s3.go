@@ -0,0 +1,224 @@
+package object_storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/minio/minio-go/v6"
+	"github.com/minio/minio-go/v6/pkg/credentials"
+
+	"kratos/pkg/log"
+)
+
+// S3兼容存储，如AWS S3、MinIO
+type S3Config struct {
+	// Endpoint 不带协议头，如s3.amazonaws.com、127.0.0.1:9000
+	Endpoint        string
+	AccessKeyId     string
+	AccessKeySecret string
+	Bucket          string
+	// Region 为空时首次请求会查询bucket所在region
+	Region string
+	UseSSL bool
+	// PathStyle 使用path style访问bucket，MinIO等自建服务一般需要开启
+	PathStyle bool
+	UrlPrefix string
+}
+
+type S3 struct {
+	config *S3Config
+	client minio.Core
+}
+
+// NewS3Client new a bare client without trace, metrics and breaker, New with
+// Driver DriverS3 wraps every call of it.
+func NewS3Client(config *S3Config) (c *S3, err error) {
+	lookup := minio.BucketLookupAuto
+	if config.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+	client, err := minio.NewWithOptions(config.Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(config.AccessKeyId, config.AccessKeySecret, ""),
+		Secure:       config.UseSSL,
+		Region:       config.Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return
+	}
+
+	c = &S3{
+		config: config,
+		client: minio.Core{Client: client},
+	}
+
+	return
+}
+
+// put uploads the buffered part in a single request.
+func (c *S3) put(ctx context.Context, path string, part *bytes.Reader) error {
+	_, err := c.client.PutObjectWithContext(ctx, c.config.Bucket, path, part, part.Size(), "", "", nil, nil)
+	return err
+}
+
+// Put buffers file by DefaultPartSize, file larger than a part is uploaded by multipart.
+func (c *S3) Put(ctx context.Context, path string, file io.Reader) (err error) {
+	if err = c.putMultipart(ctx, path, file, DefaultPartSize); err != nil {
+		log.Errorc(ctx, "[s3.Put] path: %s, err: (%v)", path, err)
+		return
+	}
+	log.Infoc(ctx, "[s3.Put] upload to s3 success, path: %s", path)
+	return
+}
+
+// s3Upload is a s3 multipart upload session.
+type s3Upload struct {
+	ctx      context.Context
+	c        *S3
+	path     string
+	uploadID string
+	parts    []minio.CompletePart
+}
+
+func (u *s3Upload) upload(number int, part []byte) error {
+	p, err := u.c.client.PutObjectPartWithContext(u.ctx, u.c.config.Bucket, u.path, u.uploadID, number, bytes.NewReader(part), int64(len(part)), "", "", nil)
+	if err != nil {
+		return err
+	}
+	u.parts = append(u.parts, minio.CompletePart{PartNumber: number, ETag: p.ETag})
+	return nil
+}
+
+func (u *s3Upload) complete() error {
+	_, err := u.c.client.CompleteMultipartUploadWithContext(u.ctx, u.c.config.Bucket, u.path, u.uploadID, u.parts)
+	return err
+}
+
+func (u *s3Upload) abort() error {
+	return u.c.client.AbortMultipartUploadWithContext(context.Background(), u.c.config.Bucket, u.path, u.uploadID)
+}
+
+func (c *S3) putMultipart(ctx context.Context, path string, file io.Reader, partSize int64) error {
+	return putMultipart(ctx, file, partSize, func(r io.Reader) error {
+		return c.put(ctx, path, r.(*bytes.Reader))
+	}, func() (multipartUpload, error) {
+		uploadID, err := c.client.NewMultipartUpload(c.config.Bucket, path, minio.PutObjectOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &s3Upload{ctx: ctx, c: c, path: path, uploadID: uploadID}, nil
+	})
+}
+
+func (c *S3) PutMultipart(ctx context.Context, path string, file io.Reader, partSize int64) (err error) {
+	if err = c.putMultipart(ctx, path, file, partSize); err != nil {
+		log.Errorc(ctx, "[s3.PutMultipart] path: %s, err: (%v)", path, err)
+		return
+	}
+	log.Infoc(ctx, "[s3.PutMultipart] upload to s3 success, path: %s", path)
+	return
+}
+
+// s3Err converts s3 not found error to ErrNotFound.
+func s3Err(err error) error {
+	if resp := minio.ToErrorResponse(err); resp.StatusCode == http.StatusNotFound || resp.Code == "NoSuchKey" {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (c *S3) Get(ctx context.Context, path string) (body io.ReadCloser, err error) {
+	if body, _, _, err = c.client.GetObjectWithContext(ctx, c.config.Bucket, path, minio.GetObjectOptions{}); err != nil {
+		if err = s3Err(err); err != ErrNotFound {
+			log.Errorc(ctx, "[s3.Get] path: %s, err: (%v)", path, err)
+		}
+	}
+	return
+}
+
+func (c *S3) Delete(ctx context.Context, path string) (err error) {
+	if err = c.client.RemoveObject(c.config.Bucket, path); err != nil {
+		log.Errorc(ctx, "[s3.Delete] path: %s, err: (%v)", path, err)
+	}
+	return
+}
+
+func (c *S3) Exists(ctx context.Context, path string) (ok bool, err error) {
+	if _, err = c.Stat(ctx, path); err == nil {
+		return true, nil
+	}
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return
+}
+
+func (c *S3) Stat(ctx context.Context, path string) (info *ObjectInfo, err error) {
+	oi, err := c.client.StatObjectWithContext(ctx, c.config.Bucket, path, minio.StatObjectOptions{})
+	if err != nil {
+		if err = s3Err(err); err != ErrNotFound {
+			log.Errorc(ctx, "[s3.Stat] path: %s, err: (%v)", path, err)
+		}
+		return
+	}
+	return &ObjectInfo{
+		Key:          path,
+		Size:         oi.Size,
+		ETag:         trimETag(oi.ETag),
+		ContentType:  oi.ContentType,
+		LastModified: oi.LastModified,
+	}, nil
+}
+
+func (c *S3) List(ctx context.Context, prefix, marker string, limit int) (res *ListResult, err error) {
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	lbr, err := c.client.ListObjects(c.config.Bucket, prefix, marker, "", limit)
+	if err != nil {
+		log.Errorc(ctx, "[s3.List] prefix: %s, err: (%v)", prefix, err)
+		return
+	}
+	res = &ListResult{
+		Objects:     make([]*ObjectInfo, 0, len(lbr.Contents)),
+		IsTruncated: lbr.IsTruncated,
+	}
+	for _, o := range lbr.Contents {
+		res.Objects = append(res.Objects, &ObjectInfo{
+			Key:          o.Key,
+			Size:         o.Size,
+			ETag:         trimETag(o.ETag),
+			LastModified: o.LastModified,
+		})
+	}
+	if lbr.IsTruncated {
+		// NextMarker is only returned with delimiter, the last key is the marker of next page.
+		if res.NextMarker = lbr.NextMarker; res.NextMarker == "" && len(lbr.Contents) > 0 {
+			res.NextMarker = lbr.Contents[len(lbr.Contents)-1].Key
+		}
+	}
+	return
+}
+
+func (c *S3) PresignGet(ctx context.Context, path string, expire time.Duration) (string, error) {
+	u, err := c.client.PresignedGetObject(c.config.Bucket, path, expire, nil)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func (c *S3) PresignPut(ctx context.Context, path string, expire time.Duration) (string, error) {
+	u, err := c.client.PresignedPutObject(c.config.Bucket, path, expire)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func (c *S3) GetUrlPrefix() string {
+	return c.config.UrlPrefix
+}
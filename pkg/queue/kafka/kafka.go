@@ -0,0 +1,450 @@
+// Package kafka is a kafka implementation of queue.Publisher and queue.Subscriber.
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"kratos/pkg/log"
+	"kratos/pkg/net/trace"
+	"kratos/pkg/queue"
+	xtime "kratos/pkg/time"
+)
+
+// message headers of queue.Message fields.
+const (
+	_headerTag         = "kratos-tag"
+	_headerShardingKey = "kratos-sharding-key"
+	_headerKey         = "kratos-key"
+)
+
+var (
+	_ queue.Publisher  = &Publisher{}
+	_ queue.Subscriber = &Subscriber{}
+
+	// ErrDelayUnsupported is returned when publish a delayed message.
+	ErrDelayUnsupported = errors.New("kafka: delayed message is unsupported")
+)
+
+// Config is kafka client common config.
+type Config struct {
+	// Name is the client name used in metrics.
+	Name        string
+	Brokers     []string
+	DialTimeout xtime.Duration
+}
+
+// PublisherConfig is kafka publisher config.
+type PublisherConfig struct {
+	*Config
+	Topic string
+	// BatchSize is max messages of a batch, default 100.
+	BatchSize int
+	// BatchTimeout is max wait time to fill a batch, default 10ms.
+	BatchTimeout xtime.Duration
+	// WriteTimeout is timeout of writing a batch, default 10s.
+	WriteTimeout xtime.Duration
+	// Async makes Publish return once the message is queued, errors are logged only.
+	Async bool
+	// QueueSize is the buffer size of pending messages, default 1024.
+	QueueSize int
+}
+
+// SubscriberConfig is kafka consumer group subscriber config.
+type SubscriberConfig struct {
+	*Config
+	Topic   string
+	GroupID string
+	// MinBytes and MaxBytes are the fetch size limits, default 1 and 1MB.
+	MinBytes int
+	MaxBytes int
+	// MaxWait is max wait time of a fetch, default 1s.
+	MaxWait xtime.Duration
+	// RetryDelay is the delay before redelivering a nacked message without delay, default 1s.
+	RetryDelay xtime.Duration
+}
+
+// writer is the kafka writer used by Publisher, *kafka.Writer implements it.
+type writer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// reader is the kafka consumer group reader used by Subscriber, *kafka.Reader implements it.
+type reader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+func dialer(c *Config) *kafka.Dialer {
+	d := &kafka.Dialer{Timeout: 10 * time.Second, DualStack: true}
+	if c.DialTimeout > 0 {
+		d.Timeout = time.Duration(c.DialTimeout)
+	}
+	return d
+}
+
+type pending struct {
+	msg   kafka.Message
+	t     trace.Trace
+	start time.Time
+	done  chan error
+}
+
+// Publisher is a batched kafka publisher, messages are written in batches
+// of BatchSize or every BatchTimeout.
+type Publisher struct {
+	c *PublisherConfig
+	w writer
+
+	mu     sync.RWMutex
+	closed bool
+	ch     chan *pending
+	done   chan struct{}
+}
+
+// NewPublisher new a kafka publisher.
+func NewPublisher(c *PublisherConfig) *Publisher {
+	w := kafka.NewWriter(kafka.WriterConfig{
+		Brokers:   c.Brokers,
+		Topic:     c.Topic,
+		Dialer:    dialer(c.Config),
+		Balancer:  &kafka.Hash{},
+		BatchSize: c.BatchSize,
+		// NOTE: batches are made by Publisher, so the writer flushes immediately.
+		BatchTimeout: time.Millisecond,
+	})
+	return newPublisher(c, w)
+}
+
+func newPublisher(c *PublisherConfig, w writer) *Publisher {
+	if c.BatchSize <= 0 {
+		c.BatchSize = 100
+	}
+	if c.BatchTimeout <= 0 {
+		c.BatchTimeout = xtime.Duration(10 * time.Millisecond)
+	}
+	if c.WriteTimeout <= 0 {
+		c.WriteTimeout = xtime.Duration(10 * time.Second)
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = 1024
+	}
+	p := &Publisher{
+		c:    c,
+		w:    w,
+		ch:   make(chan *pending, c.QueueSize),
+		done: make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// Publish publishes a message, message topic is ignored and the topic of config is used.
+// it returns after the batch of message written, or once the message queued if Async.
+func (p *Publisher) Publish(ctx context.Context, m *queue.Message) (err error) {
+	if m.Delay > 0 {
+		return ErrDelayUnsupported
+	}
+	m.Topic = p.c.Topic
+	pd := &pending{t: queue.StartPublish(ctx, m), start: time.Now()}
+	pd.msg = toKafka(m)
+	if !p.c.Async {
+		pd.done = make(chan error, 1)
+	}
+	p.mu.RLock()
+	if p.closed {
+		p.mu.RUnlock()
+		err = queue.ErrClosed
+		if pd.t != nil {
+			pd.t.Finish(&err)
+		}
+		return
+	}
+	select {
+	case p.ch <- pd:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	p.mu.RUnlock()
+	if err != nil {
+		if pd.t != nil {
+			pd.t.Finish(&err)
+		}
+		return
+	}
+	if p.c.Async {
+		return
+	}
+	select {
+	case err = <-pd.done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	return
+}
+
+func (p *Publisher) run() {
+	defer close(p.done)
+	var (
+		batch = make([]*pending, 0, p.c.BatchSize)
+		timer = time.NewTimer(time.Duration(p.c.BatchTimeout))
+	)
+	timer.Stop()
+	for {
+		select {
+		case pd, ok := <-p.ch:
+			if !ok {
+				p.flush(batch)
+				return
+			}
+			if len(batch) == 0 {
+				timer.Reset(time.Duration(p.c.BatchTimeout))
+			}
+			if batch = append(batch, pd); len(batch) < p.c.BatchSize {
+				continue
+			}
+			if !timer.Stop() {
+				<-timer.C
+			}
+		case <-timer.C:
+		}
+		p.flush(batch)
+		batch = batch[:0]
+	}
+}
+
+func (p *Publisher) flush(batch []*pending) {
+	if len(batch) == 0 {
+		return
+	}
+	msgs := make([]kafka.Message, 0, len(batch))
+	for _, pd := range batch {
+		msgs = append(msgs, pd.msg)
+	}
+	now := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(p.c.WriteTimeout))
+	err := p.w.WriteMessages(ctx, msgs...)
+	cancel()
+	_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), p.c.Name, p.c.Topic, "publish")
+	state := "published"
+	if err != nil {
+		state = "failed"
+		_metricReqErr.Inc(p.c.Name, p.c.Topic, "publish", formatErr(err))
+		log.Error("kafka: publish topic(%s) %d messages error(%v)", p.c.Topic, len(batch), err)
+	}
+	_metricMessages.Add(float64(len(batch)), p.c.Name, p.c.Topic, state)
+	for _, pd := range batch {
+		if pd.t != nil {
+			pd.t.SetTag(trace.Int("batch_size", len(batch)))
+			pd.t.Finish(&err)
+		}
+		if pd.done != nil {
+			pd.done <- err
+		}
+	}
+}
+
+// Close stops accepting messages, flushes queued messages and closes the writer.
+func (p *Publisher) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.ch)
+	p.mu.Unlock()
+	<-p.done
+	return p.w.Close()
+}
+
+// Subscriber is a kafka consumer group subscriber, messages are handled one by one
+// and the offset is committed only after the message acked. a nacked message is
+// redelivered to the handler until it is acked, so the partition is blocked meanwhile.
+type Subscriber struct {
+	c *SubscriberConfig
+	r reader
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewSubscriber new a kafka consumer group subscriber.
+func NewSubscriber(c *SubscriberConfig) *Subscriber {
+	r := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:  c.Brokers,
+		GroupID:  c.GroupID,
+		Topic:    c.Topic,
+		Dialer:   dialer(c.Config),
+		MinBytes: c.MinBytes,
+		MaxBytes: c.MaxBytes,
+		MaxWait:  time.Duration(c.MaxWait),
+	})
+	return newSubscriber(c, r)
+}
+
+func newSubscriber(c *SubscriberConfig, r reader) *Subscriber {
+	if c.MinBytes <= 0 {
+		c.MinBytes = 1
+	}
+	if c.MaxBytes <= 0 {
+		c.MaxBytes = 1 << 20
+	}
+	if c.MaxWait <= 0 {
+		c.MaxWait = xtime.Duration(time.Second)
+	}
+	if c.RetryDelay <= 0 {
+		c.RetryDelay = xtime.Duration(time.Second)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Subscriber{c: c, r: r, ctx: ctx, cancel: cancel}
+}
+
+// Subscribe starts consuming messages with h.
+func (s *Subscriber) Subscribe(h queue.Handler) error {
+	if s.ctx.Err() != nil {
+		return queue.ErrClosed
+	}
+	s.wg.Add(1)
+	go s.consume(h)
+	return nil
+}
+
+func (s *Subscriber) consume(h queue.Handler) {
+	defer s.wg.Done()
+	for {
+		now := time.Now()
+		msg, err := s.r.FetchMessage(s.ctx)
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return
+			}
+			_metricReqErr.Inc(s.c.Name, s.c.Topic, "fetch", formatErr(err))
+			log.Error("kafka: fetch topic(%s) group(%s) error(%v)", s.c.Topic, s.c.GroupID, err)
+			select {
+			case <-time.After(time.Duration(s.c.RetryDelay)):
+			case <-s.ctx.Done():
+				return
+			}
+			continue
+		}
+		_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), s.c.Name, s.c.Topic, "fetch")
+		if !s.handle(msg, h) {
+			return
+		}
+	}
+}
+
+// handle delivers msg to h until it is acked then commits it,
+// it returns false if the subscriber is closed before the message acked.
+func (s *Subscriber) handle(msg kafka.Message, h queue.Handler) bool {
+	for attempts := 1; ; attempts++ {
+		a := &acker{}
+		m := fromKafka(msg)
+		m.Attempts = attempts
+		now := time.Now()
+		queue.Dispatch(context.Background(), m.WithAcker(a), h)
+		_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), s.c.Name, s.c.Topic, "handle")
+		if !a.nacked {
+			_metricMessages.Inc(s.c.Name, s.c.Topic, "acked")
+			break
+		}
+		_metricMessages.Inc(s.c.Name, s.c.Topic, "nacked")
+		delay := a.delay
+		if delay <= 0 {
+			delay = time.Duration(s.c.RetryDelay)
+		}
+		select {
+		case <-time.After(delay):
+		case <-s.ctx.Done():
+			return false
+		}
+	}
+	// NOTE: the in-flight message is committed even if the subscriber is closing.
+	now := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.c.MaxWait)+5*time.Second)
+	err := s.r.CommitMessages(ctx, msg)
+	cancel()
+	_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), s.c.Name, s.c.Topic, "commit")
+	if err != nil {
+		_metricReqErr.Inc(s.c.Name, s.c.Topic, "commit", formatErr(err))
+		log.Error("kafka: commit topic(%s) group(%s) partition(%d) offset(%d) error(%v)", s.c.Topic, s.c.GroupID, msg.Partition, msg.Offset, err)
+	}
+	return true
+}
+
+// Close stops fetching, waits the in-flight message handled and committed, then closes the reader.
+func (s *Subscriber) Close() error {
+	s.cancel()
+	s.wg.Wait()
+	return s.r.Close()
+}
+
+// acker records the result of a message.
+type acker struct {
+	nacked bool
+	delay  time.Duration
+}
+
+func (a *acker) Ack(m *queue.Message) error {
+	return nil
+}
+
+func (a *acker) Nack(m *queue.Message, delay time.Duration) error {
+	a.nacked = true
+	a.delay = delay
+	return nil
+}
+
+func toKafka(m *queue.Message) kafka.Message {
+	msg := kafka.Message{
+		Key:   []byte(m.Key),
+		Value: m.Body,
+		Time:  time.Now(),
+	}
+	for k, v := range m.Properties {
+		msg.Headers = append(msg.Headers, kafka.Header{Key: k, Value: []byte(v)})
+	}
+	if m.Tag != "" {
+		msg.Headers = append(msg.Headers, kafka.Header{Key: _headerTag, Value: []byte(m.Tag)})
+	}
+	if m.ShardingKey != "" {
+		msg.Headers = append(msg.Headers, kafka.Header{Key: _headerShardingKey, Value: []byte(m.ShardingKey)})
+		// NOTE: kafka partitions messages by key, so sharding key takes precedence,
+		// the key is carried by header and restored by fromKafka.
+		msg.Headers = append(msg.Headers, kafka.Header{Key: _headerKey, Value: []byte(m.Key)})
+		msg.Key = []byte(m.ShardingKey)
+	}
+	return msg
+}
+
+func fromKafka(msg kafka.Message) *queue.Message {
+	m := &queue.Message{
+		ID:          fmt.Sprintf("%s/%d/%d", msg.Topic, msg.Partition, msg.Offset),
+		Topic:       msg.Topic,
+		Key:         string(msg.Key),
+		Body:        msg.Value,
+		PublishTime: msg.Time,
+	}
+	for _, h := range msg.Headers {
+		switch h.Key {
+		case _headerTag:
+			m.Tag = string(h.Value)
+		case _headerShardingKey:
+			m.ShardingKey = string(h.Value)
+		case _headerKey:
+			m.Key = string(h.Value)
+		default:
+			m.SetProperty(h.Key, string(h.Value))
+		}
+	}
+	return m
+}
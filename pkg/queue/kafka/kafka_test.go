@@ -0,0 +1,267 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"kratos/pkg/net/metadata"
+	"kratos/pkg/queue"
+	xtime "kratos/pkg/time"
+)
+
+// fakeBroker is an in-process single partition kafka broker.
+type fakeBroker struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	topic     string
+	log       []kafka.Message
+	batches   []int
+	committed map[string]int64
+	writeErr  error
+}
+
+func newFakeBroker(topic string) *fakeBroker {
+	b := &fakeBroker{topic: topic, committed: make(map[string]int64)}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+func (b *fakeBroker) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.writeErr != nil {
+		return b.writeErr
+	}
+	b.batches = append(b.batches, len(msgs))
+	for _, msg := range msgs {
+		msg.Topic = b.topic
+		msg.Offset = int64(len(b.log))
+		b.log = append(b.log, msg)
+	}
+	b.cond.Broadcast()
+	return nil
+}
+
+func (b *fakeBroker) Close() error { return nil }
+
+func (b *fakeBroker) messages() []kafka.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]kafka.Message(nil), b.log...)
+}
+
+// reader returns a consumer group reader starts from the committed offset of group.
+func (b *fakeBroker) reader(group string) *fakeReader {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return &fakeReader{b: b, group: group, offset: b.committed[group]}
+}
+
+type fakeReader struct {
+	b      *fakeBroker
+	group  string
+	offset int64
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			r.b.mu.Lock()
+			r.b.cond.Broadcast()
+			r.b.mu.Unlock()
+		case <-stop:
+		}
+	}()
+	r.b.mu.Lock()
+	defer r.b.mu.Unlock()
+	for int64(len(r.b.log)) <= r.offset {
+		if ctx.Err() != nil {
+			return kafka.Message{}, ctx.Err()
+		}
+		r.b.cond.Wait()
+	}
+	msg := r.b.log[r.offset]
+	r.offset++
+	return msg, nil
+}
+
+func (r *fakeReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	r.b.mu.Lock()
+	defer r.b.mu.Unlock()
+	for _, msg := range msgs {
+		if msg.Offset+1 > r.b.committed[r.group] {
+			r.b.committed[r.group] = msg.Offset + 1
+		}
+	}
+	return nil
+}
+
+func (r *fakeReader) Close() error { return nil }
+
+func (b *fakeBroker) committedOffset(group string) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.committed[group]
+}
+
+func TestPublisher(t *testing.T) {
+	b := newFakeBroker("topic")
+	p := newPublisher(&PublisherConfig{Config: &Config{Name: "test"}, Topic: "topic", BatchSize: 3, BatchTimeout: xtime.Duration(time.Second)}, b)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := p.Publish(context.TODO(), &queue.Message{Body: []byte("sync")}); err != nil {
+				t.Errorf("publish error, %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(b.batches) != 1 || b.batches[0] != 3 {
+		t.Fatalf("messages should be written in one batch, got %v", b.batches)
+	}
+	if err := p.Publish(context.TODO(), &queue.Message{Delay: time.Second}); err != ErrDelayUnsupported {
+		t.Fatalf("publish delayed message should return ErrDelayUnsupported, got %v", err)
+	}
+
+	b.writeErr = errors.New("broker down")
+	if err := p.Publish(context.TODO(), &queue.Message{Body: []byte("fail")}); err != b.writeErr {
+		t.Fatalf("publish should return write error, got %v", err)
+	}
+	b.writeErr = nil
+	p.Close()
+	if err := p.Publish(context.TODO(), &queue.Message{}); err != queue.ErrClosed {
+		t.Fatalf("publish after close should return ErrClosed, got %v", err)
+	}
+}
+
+func TestAsyncPublisherClose(t *testing.T) {
+	b := newFakeBroker("topic")
+	p := newPublisher(&PublisherConfig{Config: &Config{Name: "test"}, Topic: "topic", Async: true, BatchSize: 100, BatchTimeout: xtime.Duration(time.Hour)}, b)
+	for i := 0; i < 5; i++ {
+		if err := p.Publish(context.TODO(), &queue.Message{Body: []byte("async")}); err != nil {
+			t.Fatalf("publish error, %v", err)
+		}
+	}
+	if len(b.messages()) != 0 {
+		t.Fatalf("messages should be batched before close")
+	}
+	p.Close()
+	if n := len(b.messages()); n != 5 {
+		t.Fatalf("queued messages should be flushed on close, got %d", n)
+	}
+}
+
+func TestSubscriber(t *testing.T) {
+	b := newFakeBroker("topic")
+	p := newPublisher(&PublisherConfig{Config: &Config{Name: "test"}, Topic: "topic", BatchSize: 1}, b)
+	defer p.Close()
+	ctx := metadata.NewContext(context.TODO(), metadata.MD{metadata.Color: "red"})
+	p.Publish(ctx, &queue.Message{Key: "k1", Tag: "tag", Body: []byte("m1"), Properties: map[string]string{"a": "b"}})
+	p.Publish(ctx, &queue.Message{Key: "k2", Body: []byte("m2")})
+	p.Publish(ctx, &queue.Message{Key: "k3", ShardingKey: "s3", Body: []byte("m3")})
+	p.Publish(ctx, &queue.Message{ShardingKey: "s4", Body: []byte("m4")})
+
+	type delivery struct {
+		m     *queue.Message
+		color string
+	}
+	ch := make(chan delivery, 10)
+	s := newSubscriber(&SubscriberConfig{Config: &Config{Name: "test"}, Topic: "topic", GroupID: "group", RetryDelay: xtime.Duration(time.Millisecond)}, b.reader("group"))
+	s.Subscribe(func(ctx context.Context, m *queue.Message) error {
+		ch <- delivery{m: m, color: metadata.String(ctx, metadata.Color)}
+		if string(m.Body) == "m1" && m.Attempts == 1 {
+			return errors.New("retry")
+		}
+		return nil
+	})
+	expect := []struct {
+		body     string
+		attempts int
+	}{{"m1", 1}, {"m1", 2}, {"m2", 1}, {"m3", 1}, {"m4", 1}}
+	for _, e := range expect {
+		select {
+		case d := <-ch:
+			if string(d.m.Body) != e.body || d.m.Attempts != e.attempts {
+				t.Fatalf("got message %s attempts %d, expect %s attempts %d", d.m.Body, d.m.Attempts, e.body, e.attempts)
+			}
+			if d.color != "red" {
+				t.Fatalf("metadata should be propagated, got color %q", d.color)
+			}
+			if e.body == "m1" && (d.m.Key != "k1" || d.m.Tag != "tag" || d.m.Property("a") != "b") {
+				t.Fatalf("got unexpected message %+v", d.m)
+			}
+			if e.body == "m3" && (d.m.Key != "k3" || d.m.ShardingKey != "s3") {
+				t.Fatalf("key should be kept with sharding key, got %+v", d.m)
+			}
+			if e.body == "m4" && (d.m.Key != "" || d.m.ShardingKey != "s4") {
+				t.Fatalf("empty key should be kept with sharding key, got %+v", d.m)
+			}
+		case <-time.After(time.Second * 2):
+			t.Fatalf("wait message timeout")
+		}
+	}
+	s.Close()
+	if offset := b.committedOffset("group"); offset != 4 {
+		t.Fatalf("got committed offset %d, expect 4", offset)
+	}
+}
+
+func TestSubscriberCloseDrain(t *testing.T) {
+	b := newFakeBroker("topic")
+	p := newPublisher(&PublisherConfig{Config: &Config{Name: "test"}, Topic: "topic", BatchSize: 1}, b)
+	defer p.Close()
+	p.Publish(context.TODO(), &queue.Message{Body: []byte("m1")})
+	p.Publish(context.TODO(), &queue.Message{Body: []byte("m2")})
+
+	handling, release := make(chan struct{}), make(chan struct{})
+	s := newSubscriber(&SubscriberConfig{Config: &Config{Name: "test"}, Topic: "topic", GroupID: "group"}, b.reader("group"))
+	s.Subscribe(func(ctx context.Context, m *queue.Message) error {
+		close(handling)
+		<-release
+		return nil
+	})
+	<-handling
+	closed := make(chan struct{})
+	go func() {
+		s.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		t.Fatalf("close should wait the in-flight message")
+	case <-time.After(time.Millisecond * 50):
+	}
+	close(release)
+	<-closed
+	if offset := b.committedOffset("group"); offset != 1 {
+		t.Fatalf("in-flight message should be committed on close, got offset %d", offset)
+	}
+
+	// a new subscriber of the group resumes from the committed offset.
+	ch := make(chan *queue.Message, 1)
+	s = newSubscriber(&SubscriberConfig{Config: &Config{Name: "test"}, Topic: "topic", GroupID: "group"}, b.reader("group"))
+	defer s.Close()
+	s.Subscribe(func(ctx context.Context, m *queue.Message) error {
+		ch <- m
+		return nil
+	})
+	select {
+	case m := <-ch:
+		if string(m.Body) != "m2" {
+			t.Fatalf("got message %s, expect m2", m.Body)
+		}
+	case <-time.After(time.Second * 2):
+		t.Fatalf("wait message timeout")
+	}
+}
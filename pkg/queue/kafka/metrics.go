@@ -0,0 +1,51 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/kafka-go"
+
+	"kratos/pkg/stat/metric"
+)
+
+const namespace = "kafka_client"
+
+var (
+	_metricReqDur = metric.NewHistogramVec(&metric.HistogramVecOpts{
+		Namespace: namespace,
+		Subsystem: "requests",
+		Name:      "duration_ms",
+		Help:      "kafka client requests duration(ms).",
+		Labels:    []string{"name", "topic", "command"},
+		Buckets:   []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500},
+	})
+	_metricReqErr = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: namespace,
+		Subsystem: "requests",
+		Name:      "error_total",
+		Help:      "kafka client requests error count.",
+		Labels:    []string{"name", "topic", "command", "error"},
+	})
+	_metricMessages = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: namespace,
+		Subsystem: "messages",
+		Name:      "total",
+		Help:      "kafka client messages total count.",
+		Labels:    []string{"name", "topic", "state"},
+	})
+)
+
+func formatErr(err error) string {
+	switch e := errors.Cause(err); e {
+	case context.DeadlineExceeded:
+		return "timeout"
+	case context.Canceled:
+		return "canceled"
+	default:
+		if ke, ok := e.(kafka.Error); ok {
+			return ke.Title()
+		}
+		return "unknown"
+	}
+}
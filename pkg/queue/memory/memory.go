@@ -0,0 +1,258 @@
+// Package memory is an in-memory queue for unit tests.
+package memory
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"kratos/pkg/queue"
+)
+
+var (
+	_ queue.Publisher  = &Publisher{}
+	_ queue.Subscriber = &Subscriber{}
+)
+
+// Broker is an in-memory message broker, each consumer group of a topic
+// receives a copy of messages published after the group is created.
+type Broker struct {
+	mu     sync.Mutex
+	topics map[string]map[string]*group
+	seq    int64
+	closed bool
+}
+
+// New new an in-memory broker.
+func New() *Broker {
+	return &Broker{topics: make(map[string]map[string]*group)}
+}
+
+// Publisher returns a publisher of the broker.
+func (b *Broker) Publisher() *Publisher {
+	return &Publisher{b: b}
+}
+
+// Subscriber returns a subscriber of topic in consumer group, subscribers in
+// the same group compete for messages.
+func (b *Broker) Subscriber(topic, groupName string) *Subscriber {
+	return &Subscriber{g: b.group(topic, groupName)}
+}
+
+// Pending returns the number of messages not yet delivered to the group, including delayed ones.
+func (b *Broker) Pending(topic, groupName string) int {
+	g := b.group(topic, groupName)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.msgs) + g.delayed
+}
+
+// Close closes the broker, delayed messages are dropped.
+func (b *Broker) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	var gs []*group
+	for _, groups := range b.topics {
+		for _, g := range groups {
+			gs = append(gs, g)
+		}
+	}
+	b.mu.Unlock()
+	for _, g := range gs {
+		g.close()
+	}
+	return nil
+}
+
+func (b *Broker) group(topic, name string) *group {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	groups, ok := b.topics[topic]
+	if !ok {
+		groups = make(map[string]*group)
+		b.topics[topic] = groups
+	}
+	g, ok := groups[name]
+	if !ok {
+		g = newGroup()
+		if b.closed {
+			g.closed = true
+		}
+		groups[name] = g
+	}
+	return g
+}
+
+func (b *Broker) publish(m *queue.Message) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return queue.ErrClosed
+	}
+	m.ID = strconv.FormatInt(atomic.AddInt64(&b.seq, 1), 10)
+	m.PublishTime = time.Now()
+	groups := make([]*group, 0, len(b.topics[m.Topic]))
+	for _, g := range b.topics[m.Topic] {
+		groups = append(groups, g)
+	}
+	b.mu.Unlock()
+	for _, g := range groups {
+		g.push(copyMessage(m), m.Delay)
+	}
+	return nil
+}
+
+func copyMessage(m *queue.Message) *queue.Message {
+	c := &queue.Message{
+		ID:          m.ID,
+		Topic:       m.Topic,
+		Tag:         m.Tag,
+		Key:         m.Key,
+		ShardingKey: m.ShardingKey,
+		Body:        append([]byte(nil), m.Body...),
+		Delay:       m.Delay,
+		Attempts:    m.Attempts,
+		PublishTime: m.PublishTime,
+	}
+	if m.Properties != nil {
+		c.Properties = make(map[string]string, len(m.Properties))
+		for k, v := range m.Properties {
+			c.Properties[k] = v
+		}
+	}
+	return c
+}
+
+// group is a consumer group of a topic.
+type group struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	msgs    []*queue.Message
+	delayed int
+	closed  bool
+}
+
+func newGroup() *group {
+	g := &group{}
+	g.cond = sync.NewCond(&g.mu)
+	return g
+}
+
+func (g *group) push(m *queue.Message, delay time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return
+	}
+	if delay <= 0 {
+		g.msgs = append(g.msgs, m)
+		g.cond.Broadcast()
+		return
+	}
+	g.delayed++
+	time.AfterFunc(delay, func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.delayed--
+		if g.closed {
+			return
+		}
+		g.msgs = append(g.msgs, m)
+		g.cond.Broadcast()
+	})
+}
+
+// pop blocks until a message is available, it returns nil after the group closed or stopped.
+func (g *group) pop(stopped func() bool) *queue.Message {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for len(g.msgs) == 0 && !g.closed && !stopped() {
+		g.cond.Wait()
+	}
+	if g.closed || stopped() {
+		return nil
+	}
+	m := g.msgs[0]
+	g.msgs[0] = nil
+	g.msgs = g.msgs[1:]
+	return m
+}
+
+func (g *group) close() {
+	g.mu.Lock()
+	g.closed = true
+	g.msgs = nil
+	g.cond.Broadcast()
+	g.mu.Unlock()
+}
+
+// Publisher is an in-memory publisher.
+type Publisher struct {
+	b *Broker
+}
+
+// Publish publishes a message.
+func (p *Publisher) Publish(ctx context.Context, m *queue.Message) (err error) {
+	if t := queue.StartPublish(ctx, m); t != nil {
+		defer t.Finish(&err)
+	}
+	return p.b.publish(m)
+}
+
+// Close closes the publisher.
+func (p *Publisher) Close() error {
+	return nil
+}
+
+// Subscriber is an in-memory subscriber.
+type Subscriber struct {
+	g       *group
+	started int32
+	stopped int32
+	wg      sync.WaitGroup
+}
+
+// Subscribe starts consuming messages with h.
+func (s *Subscriber) Subscribe(h queue.Handler) error {
+	if !atomic.CompareAndSwapInt32(&s.started, 0, 1) {
+		return errors.New("memory: subscriber already subscribed")
+	}
+	stopped := func() bool { return atomic.LoadInt32(&s.stopped) == 1 }
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			m := s.g.pop(stopped)
+			if m == nil {
+				return
+			}
+			m.Attempts++
+			queue.Dispatch(context.Background(), m.WithAcker(s), h)
+		}
+	}()
+	return nil
+}
+
+// Ack acks the message.
+func (s *Subscriber) Ack(m *queue.Message) error {
+	return nil
+}
+
+// Nack redelivers the message after delay.
+func (s *Subscriber) Nack(m *queue.Message, delay time.Duration) error {
+	s.g.push(copyMessage(m), delay)
+	return nil
+}
+
+// Close stops consuming, the message being handled is finished before it returns.
+func (s *Subscriber) Close() error {
+	atomic.StoreInt32(&s.stopped, 1)
+	s.g.mu.Lock()
+	s.g.cond.Broadcast()
+	s.g.mu.Unlock()
+	s.wg.Wait()
+	return nil
+}
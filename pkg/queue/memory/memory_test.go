@@ -0,0 +1,126 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"kratos/pkg/net/trace"
+	"kratos/pkg/queue"
+)
+
+type mockReport struct{}
+
+func (mockReport) WriteSpan(sp *trace.Span) error { return nil }
+
+func (mockReport) Close() error { return nil }
+
+func waitMessage(t *testing.T, ch <-chan *queue.Message) *queue.Message {
+	t.Helper()
+	select {
+	case m := <-ch:
+		return m
+	case <-time.After(time.Second * 2):
+		t.Fatalf("wait message timeout")
+	}
+	return nil
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	b := New()
+	defer b.Close()
+	g1 := b.Subscriber("topic", "g1")
+	g2 := b.Subscriber("topic", "g2")
+	ch1, ch2 := make(chan *queue.Message, 1), make(chan *queue.Message, 1)
+	g1.Subscribe(func(ctx context.Context, m *queue.Message) error {
+		ch1 <- m
+		return nil
+	})
+	g2.Subscribe(func(ctx context.Context, m *queue.Message) error {
+		ch2 <- m
+		return m.Ack()
+	})
+	defer g1.Close()
+	defer g2.Close()
+
+	p := b.Publisher()
+	msg := &queue.Message{Topic: "topic", Key: "k", Body: []byte("hello"), Properties: map[string]string{"a": "b"}}
+	if err := p.Publish(context.TODO(), msg); err != nil {
+		t.Fatalf("publish error, %v", err)
+	}
+	if msg.ID == "" {
+		t.Fatalf("message id should be set after publish")
+	}
+	for _, ch := range []chan *queue.Message{ch1, ch2} {
+		m := waitMessage(t, ch)
+		if m.ID != msg.ID || m.Key != "k" || string(m.Body) != "hello" || m.Property("a") != "b" || m.Attempts != 1 {
+			t.Fatalf("got unexpected message %+v", m)
+		}
+		if !m.Settled() {
+			t.Fatalf("message should be settled after dispatch")
+		}
+		if err := m.Ack(); err != queue.ErrSettled {
+			t.Fatalf("ack settled message should return ErrSettled, got %v", err)
+		}
+	}
+}
+
+func TestNackAndDelay(t *testing.T) {
+	b := New()
+	defer b.Close()
+	s := b.Subscriber("topic", "group")
+	ch := make(chan *queue.Message, 3)
+	s.Subscribe(func(ctx context.Context, m *queue.Message) error {
+		ch <- m
+		switch m.Attempts {
+		case 1:
+			return errors.New("retry")
+		case 2:
+			panic("retry")
+		}
+		return nil
+	})
+	defer s.Close()
+
+	start := time.Now()
+	b.Publisher().Publish(context.TODO(), &queue.Message{Topic: "topic", Delay: time.Millisecond * 100})
+	if b.Pending("topic", "group") != 1 {
+		t.Fatalf("delayed message should be pending")
+	}
+	for i := 1; i <= 3; i++ {
+		if m := waitMessage(t, ch); m.Attempts != i {
+			t.Fatalf("got unexpected attempts %d, expect %d", m.Attempts, i)
+		}
+	}
+	if time.Since(start) < time.Millisecond*100 {
+		t.Fatalf("delayed message delivered too early")
+	}
+}
+
+func TestTracePropagation(t *testing.T) {
+	trace.SetGlobalTracer(trace.NewTracer("queue", mockReport{}, true))
+
+	b := New()
+	defer b.Close()
+	s := b.Subscriber("topic", "group")
+	ch := make(chan string, 1)
+	s.Subscribe(func(ctx context.Context, m *queue.Message) error {
+		tr, _ := trace.FromContext(ctx)
+		ch <- tr.TraceID()
+		return nil
+	})
+	defer s.Close()
+
+	root := trace.New("root")
+	b.Publisher().Publish(trace.NewContext(context.TODO(), root), &queue.Message{Topic: "topic"})
+	select {
+	case id := <-ch:
+		if strings.Split(id, ":")[0] != strings.Split(root.TraceID(), ":")[0] {
+			t.Fatalf("got trace id %s, expect %s", id, root.TraceID())
+		}
+	case <-time.After(time.Second * 2):
+		t.Fatalf("wait message timeout")
+	}
+}
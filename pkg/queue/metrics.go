@@ -0,0 +1,15 @@
+package queue
+
+import "kratos/pkg/stat/metric"
+
+const namespace = "queue_consumer"
+
+var (
+	_metricMsgErr = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: namespace,
+		Subsystem: "messages",
+		Name:      "error_total",
+		Help:      "queue consumer failed messages count.",
+		Labels:    []string{"topic", "result"},
+	})
+)
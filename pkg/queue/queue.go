@@ -0,0 +1,195 @@
+// Package queue is a vendor neutral message queue abstraction,
+// rocketmq/alimq, rocketmq/txmq, kafka, redisstream and memory implement it.
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"kratos/pkg/log"
+	"kratos/pkg/net/metadata"
+	"kratos/pkg/net/trace"
+)
+
+const (
+	_family = "queue"
+	// _metadataPrefix is the property key prefix of propagated metadata.
+	_metadataPrefix = "x-md-"
+)
+
+var (
+	// ErrSettled is returned when a message is acked or nacked more than once.
+	ErrSettled = errors.New("queue: message already acked or nacked")
+	// ErrClosed is returned when publish or subscribe after close.
+	ErrClosed = errors.New("queue: closed")
+)
+
+// Acker acks or nacks a consumed message, it is implemented by subscribers.
+type Acker interface {
+	Ack(m *Message) error
+	// Nack requeues the message, it is redelivered after delay if the backend supports,
+	// zero delay means the default redelivery policy of the backend.
+	Nack(m *Message, delay time.Duration) error
+}
+
+// Message is a queue message.
+type Message struct {
+	// ID is the message id set by the backend, it is available after publish or on consume.
+	ID          string
+	Topic       string
+	Tag         string
+	Key         string
+	ShardingKey string
+	Body        []byte
+	Properties  map[string]string
+	// Delay delivers the message after the duration, zero means immediately.
+	Delay time.Duration
+	// Attempts is the delivery count of a consumed message, starts from 1.
+	Attempts    int
+	PublishTime time.Time
+
+	acker   Acker
+	settled int32
+}
+
+// WithAcker binds the acker of a consumed message, it is used by subscribers.
+func (m *Message) WithAcker(a Acker) *Message {
+	m.acker = a
+	return m
+}
+
+// Ack acks the message, the message will not be redelivered.
+func (m *Message) Ack() error {
+	if !atomic.CompareAndSwapInt32(&m.settled, 0, 1) {
+		return ErrSettled
+	}
+	if m.acker == nil {
+		return nil
+	}
+	return m.acker.Ack(m)
+}
+
+// Nack requeues the message, it is redelivered after delay.
+func (m *Message) Nack(delay time.Duration) error {
+	if !atomic.CompareAndSwapInt32(&m.settled, 0, 1) {
+		return ErrSettled
+	}
+	if m.acker == nil {
+		return nil
+	}
+	return m.acker.Nack(m, delay)
+}
+
+// Settled reports whether the message is acked or nacked.
+func (m *Message) Settled() bool {
+	return atomic.LoadInt32(&m.settled) == 1
+}
+
+// Property returns the property value of key.
+func (m *Message) Property(key string) string {
+	return m.Properties[key]
+}
+
+// SetProperty sets the property of key.
+func (m *Message) SetProperty(key, value string) {
+	if m.Properties == nil {
+		m.Properties = make(map[string]string)
+	}
+	m.Properties[key] = value
+}
+
+// Handler handles a consumed message. if the handler neither acks nor nacks the message,
+// it is acked when the handler returns nil, otherwise nacked.
+type Handler func(ctx context.Context, m *Message) error
+
+// Publisher publishes messages.
+type Publisher interface {
+	Publish(ctx context.Context, m *Message) error
+	Close() error
+}
+
+// Subscriber consumes messages of the subscribed topic.
+type Subscriber interface {
+	Subscribe(h Handler) error
+	Close() error
+}
+
+// propertiesCarrier carries trace in message properties.
+type propertiesCarrier map[string]string
+
+func (p propertiesCarrier) Set(key, val string) { p[key] = val }
+
+func (p propertiesCarrier) Get(key string) string { return p[key] }
+
+// StartPublish injects outgoing metadata of ctx into message properties, then forks the trace
+// of ctx for publishing m and injects it too. the returned trace should be finished by the publisher,
+// it is nil if ctx has no trace.
+func StartPublish(ctx context.Context, m *Message) trace.Trace {
+	if md, ok := metadata.FromContext(ctx); ok {
+		for k, v := range md {
+			if metadata.IsOutgoingKey(k) {
+				m.SetProperty(_metadataPrefix+k, fmt.Sprint(v))
+			}
+		}
+	}
+	t, ok := trace.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	t = t.Fork(_family, "publish:"+m.Topic)
+	t.SetTag(trace.String(trace.TagSpanKind, "producer"), trace.String(trace.TagMessageBusDestination, m.Topic))
+	if m.Properties == nil {
+		m.Properties = make(map[string]string)
+	}
+	trace.Inject(t, nil, propertiesCarrier(m.Properties))
+	return t
+}
+
+// incomingContext returns ctx with metadata and trace extracted from message properties.
+func incomingContext(ctx context.Context, m *Message) (context.Context, trace.Trace) {
+	md := metadata.MD{}
+	for k, v := range m.Properties {
+		if strings.HasPrefix(k, _metadataPrefix) {
+			md[strings.TrimPrefix(k, _metadataPrefix)] = v
+		}
+	}
+	if len(md) > 0 {
+		ctx = metadata.NewContext(ctx, md)
+	}
+	t, err := trace.Extract(nil, propertiesCarrier(m.Properties))
+	if err != nil {
+		t = trace.New("consume:" + m.Topic)
+	}
+	t.SetTitle("consume:" + m.Topic)
+	t.SetTag(trace.String(trace.TagSpanKind, "consumer"), trace.String(trace.TagMessageBusDestination, m.Topic))
+	return trace.NewContext(ctx, t), t
+}
+
+// Dispatch calls h with the metadata and trace extracted from message properties,
+// it recovers panics and settles the message by the result if h leaves it unsettled.
+func Dispatch(ctx context.Context, m *Message, h Handler) (err error) {
+	ctx, t := incomingContext(ctx, m)
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("queue: handler panic: %v", r)
+			log.Error("queue: topic(%s) message(%s) handler panic: %v", m.Topic, m.ID, r)
+		}
+		if !m.Settled() {
+			var serr error
+			if err == nil {
+				serr = m.Ack()
+			} else {
+				serr = m.Nack(0)
+			}
+			if serr != nil {
+				log.Error("queue: topic(%s) message(%s) settle error(%v)", m.Topic, m.ID, serr)
+			}
+		}
+		t.Finish(&err)
+	}()
+	return h(ctx, m)
+}
@@ -0,0 +1,51 @@
+package redisstream
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"kratos/pkg/cache/redis"
+	"kratos/pkg/stat/metric"
+)
+
+const namespace = "redis_stream_client"
+
+var (
+	_metricReqDur = metric.NewHistogramVec(&metric.HistogramVecOpts{
+		Namespace: namespace,
+		Subsystem: "requests",
+		Name:      "duration_ms",
+		Help:      "redis stream client requests duration(ms).",
+		Labels:    []string{"name", "stream", "command"},
+		Buckets:   []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500},
+	})
+	_metricReqErr = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: namespace,
+		Subsystem: "requests",
+		Name:      "error_total",
+		Help:      "redis stream client requests error count.",
+		Labels:    []string{"name", "stream", "command", "error"},
+	})
+	_metricMessages = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: namespace,
+		Subsystem: "messages",
+		Name:      "total",
+		Help:      "redis stream client messages total count.",
+		Labels:    []string{"name", "stream", "state"},
+	})
+)
+
+func formatErr(err error) string {
+	switch e := errors.Cause(err); e {
+	case context.DeadlineExceeded:
+		return "timeout"
+	case context.Canceled:
+		return "canceled"
+	default:
+		if _, ok := e.(redis.Error); ok {
+			return "redis"
+		}
+		return "unknown"
+	}
+}
@@ -0,0 +1,441 @@
+// Package redisstream is a redis streams implementation of queue.Publisher and queue.Subscriber,
+// subscribers consume a stream in a consumer group, stale pending messages of dead consumers
+// are claimed by live ones and poison messages are moved to a dead letter stream.
+package redisstream
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"kratos/pkg/cache/xredis"
+	"kratos/pkg/conf/env"
+	"kratos/pkg/log"
+	"kratos/pkg/queue"
+	xtime "kratos/pkg/time"
+)
+
+// stream entry fields of queue.Message.
+const (
+	_fieldBody        = "body"
+	_fieldTag         = "tag"
+	_fieldKey         = "key"
+	_fieldShardingKey = "sharding_key"
+	_fieldPublishTime = "publish_time"
+	// _fieldPropertyPrefix is the field prefix of message properties.
+	_fieldPropertyPrefix = "p:"
+
+	_claimBatch = 100
+)
+
+var (
+	_ queue.Publisher  = &Publisher{}
+	_ queue.Subscriber = &Subscriber{}
+
+	// ErrDelayUnsupported is returned when publish a delayed message.
+	ErrDelayUnsupported = errors.New("redisstream: delayed message is unsupported")
+
+	errMaxDeliveries = errors.New("redisstream: message exceeded max deliveries")
+	errSubscribed    = errors.New("redisstream: subscriber already subscribed")
+)
+
+// PublisherConfig is redis stream publisher config.
+type PublisherConfig struct {
+	// Name is the client name used in metrics.
+	Name string
+	// MaxLen trims the stream to about MaxLen entries on publishing, zero means no trimming.
+	MaxLen int64
+}
+
+// SubscriberConfig is redis stream consumer group subscriber config.
+type SubscriberConfig struct {
+	// Name is the client name used in metrics.
+	Name   string
+	Stream string
+	Group  string
+	// Consumer is the consumer name in the group, default hostname.
+	Consumer string
+	// Start is the id the group starts from if it is created by the subscriber, default "$".
+	Start string
+	// Count is max messages of a read, default 10.
+	Count int64
+	// Block is max wait time of a read, default 1s. it must be shorter than the read timeout of redis.
+	Block xtime.Duration
+	// ClaimInterval is the interval of checking stale pending messages, default 30s.
+	ClaimInterval xtime.Duration
+	// MinIdle is the idle time after which a pending message is claimed by another consumer, default 1m.
+	// NOTE: nacked messages stay pending, so they are redelivered after MinIdle and the nack delay is ignored.
+	MinIdle xtime.Duration
+	// MaxDeliveries is max deliveries of a message, the message is moved to DeadLetterStream
+	// when it is delivered MaxDeliveries times without ack. zero means no limit.
+	MaxDeliveries int64
+	// DeadLetterStream is the stream of poison messages, they are dropped if it is empty.
+	DeadLetterStream string
+	// RetryDelay is the delay after a failed read, default 1s.
+	RetryDelay xtime.Duration
+}
+
+// Publisher appends messages to the stream named by message topic.
+type Publisher struct {
+	c *PublisherConfig
+	r xredis.Cmdable
+}
+
+// NewPublisher new a redis stream publisher.
+func NewPublisher(c *PublisherConfig, r xredis.Cmdable) *Publisher {
+	return &Publisher{c: c, r: r}
+}
+
+// Publish appends a message to the stream of m.Topic, m.ID is set to the entry id.
+func (p *Publisher) Publish(ctx context.Context, m *queue.Message) (err error) {
+	if m.Delay > 0 {
+		return ErrDelayUnsupported
+	}
+	if t := queue.StartPublish(ctx, m); t != nil {
+		defer t.Finish(&err)
+	}
+	m.PublishTime = time.Now()
+	now := time.Now()
+	m.ID, err = p.r.XAdd(ctx, &xredis.XAddArgs{
+		Stream: m.Topic,
+		MaxLen: p.c.MaxLen,
+		Approx: true,
+		Values: toValues(m),
+	}).Result()
+	_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), p.c.Name, m.Topic, "xadd")
+	if err != nil {
+		_metricReqErr.Inc(p.c.Name, m.Topic, "xadd", formatErr(err))
+		_metricMessages.Inc(p.c.Name, m.Topic, "failed")
+		log.Errorc(ctx, "redisstream: publish stream(%s) error(%v)", m.Topic, err)
+		return
+	}
+	_metricMessages.Inc(p.c.Name, m.Topic, "published")
+	return
+}
+
+// Close closes the publisher, the redis client is not closed.
+func (p *Publisher) Close() error {
+	return nil
+}
+
+// Subscriber is a redis stream consumer group subscriber. messages are acked on handler success,
+// failed messages stay pending and are redelivered after MinIdle, by this or other consumers.
+// A Subscriber subscribes only one handler, which also handles the claimed messages.
+type Subscriber struct {
+	c *SubscriberConfig
+	r xredis.Cmdable
+
+	started int32
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewSubscriber new a redis stream subscriber.
+func NewSubscriber(c *SubscriberConfig, r xredis.Cmdable) *Subscriber {
+	if c.Consumer == "" {
+		c.Consumer = env.Hostname
+	}
+	if c.Start == "" {
+		c.Start = "$"
+	}
+	if c.Count <= 0 {
+		c.Count = 10
+	}
+	if c.Block <= 0 {
+		c.Block = xtime.Duration(time.Second)
+	}
+	if c.ClaimInterval <= 0 {
+		c.ClaimInterval = xtime.Duration(30 * time.Second)
+	}
+	if c.MinIdle <= 0 {
+		c.MinIdle = xtime.Duration(time.Minute)
+	}
+	if c.RetryDelay <= 0 {
+		c.RetryDelay = xtime.Duration(time.Second)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Subscriber{c: c, r: r, ctx: ctx, cancel: cancel}
+}
+
+// Subscribe creates the consumer group if not exists and starts consuming messages with h.
+func (s *Subscriber) Subscribe(h queue.Handler) error {
+	if s.ctx.Err() != nil {
+		return queue.ErrClosed
+	}
+	if !atomic.CompareAndSwapInt32(&s.started, 0, 1) {
+		return errSubscribed
+	}
+	err := s.r.XGroupCreateMkStream(s.ctx, s.c.Stream, s.c.Group, s.c.Start).Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		log.Error("redisstream: create stream(%s) group(%s) error(%v)", s.c.Stream, s.c.Group, err)
+		atomic.StoreInt32(&s.started, 0)
+		return err
+	}
+	s.wg.Add(2)
+	go s.claim(h)
+	go s.consume(h)
+	return nil
+}
+
+func (s *Subscriber) consume(h queue.Handler) {
+	defer s.wg.Done()
+	for s.ctx.Err() == nil {
+		now := time.Now()
+		streams, err := s.r.XReadGroup(s.ctx, &xredis.XReadGroupArgs{
+			Group:    s.c.Group,
+			Consumer: s.c.Consumer,
+			Streams:  []string{s.c.Stream, ">"},
+			Count:    s.c.Count,
+			Block:    time.Duration(s.c.Block),
+		}).Result()
+		if err == xredis.ErrNil {
+			continue
+		}
+		if err != nil {
+			_metricReqErr.Inc(s.c.Name, s.c.Stream, "xreadgroup", formatErr(err))
+			log.Error("redisstream: read stream(%s) group(%s) error(%v)", s.c.Stream, s.c.Group, err)
+			s.sleep(time.Duration(s.c.RetryDelay))
+			continue
+		}
+		_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), s.c.Name, s.c.Stream, "xreadgroup")
+		for _, stream := range streams {
+			for _, msg := range stream.Messages {
+				// NOTE: the in-flight messages of a read are handled even if the subscriber is closing.
+				s.handle(msg, 1, h)
+			}
+		}
+	}
+}
+
+func (s *Subscriber) handle(msg xredis.XMessage, attempts int, h queue.Handler) {
+	m := fromStream(s.c.Stream, msg)
+	m.Attempts = attempts
+	now := time.Now()
+	queue.Dispatch(context.Background(), m.WithAcker(s), h)
+	_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), s.c.Name, s.c.Stream, "handle")
+}
+
+// claim claims stale pending messages every ClaimInterval.
+func (s *Subscriber) claim(h queue.Handler) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(time.Duration(s.c.ClaimInterval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.claimPending(h)
+		}
+	}
+}
+
+// claimPending scans pending messages of the group, messages idle longer than MinIdle are
+// claimed and handled, or moved to the dead letter stream if they reached MaxDeliveries.
+func (s *Subscriber) claimPending(h queue.Handler) {
+	start := "-"
+	for s.ctx.Err() == nil {
+		now := time.Now()
+		exts, err := s.r.XPendingExt(s.ctx, &xredis.XPendingExtArgs{
+			Stream: s.c.Stream,
+			Group:  s.c.Group,
+			Start:  start,
+			End:    "+",
+			Count:  _claimBatch,
+		}).Result()
+		if err != nil {
+			_metricReqErr.Inc(s.c.Name, s.c.Stream, "xpending", formatErr(err))
+			log.Error("redisstream: pending stream(%s) group(%s) error(%v)", s.c.Stream, s.c.Group, err)
+			return
+		}
+		_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), s.c.Name, s.c.Stream, "xpending")
+		var (
+			ids      []string
+			poisons  []string
+			attempts = make(map[string]int)
+		)
+		for _, ext := range exts {
+			if ext.Idle < time.Duration(s.c.MinIdle) {
+				continue
+			}
+			if s.c.MaxDeliveries > 0 && ext.RetryCount >= s.c.MaxDeliveries {
+				poisons = append(poisons, ext.ID)
+				continue
+			}
+			ids = append(ids, ext.ID)
+			attempts[ext.ID] = int(ext.RetryCount) + 1
+		}
+		if len(poisons) > 0 {
+			s.deadLetter(poisons)
+		}
+		if len(ids) > 0 {
+			for _, msg := range s.xclaim(ids) {
+				_metricMessages.Inc(s.c.Name, s.c.Stream, "claimed")
+				s.handle(msg, attempts[msg.ID], h)
+			}
+		}
+		if len(exts) < _claimBatch {
+			return
+		}
+		start = nextID(exts[len(exts)-1].ID)
+	}
+}
+
+// xclaim claims messages to the consumer, messages claimed by others meanwhile are skipped.
+func (s *Subscriber) xclaim(ids []string) []xredis.XMessage {
+	now := time.Now()
+	msgs, err := s.r.XClaim(s.ctx, &xredis.XClaimArgs{
+		Stream:   s.c.Stream,
+		Group:    s.c.Group,
+		Consumer: s.c.Consumer,
+		MinIdle:  time.Duration(s.c.MinIdle),
+		Messages: ids,
+	}).Result()
+	_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), s.c.Name, s.c.Stream, "xclaim")
+	if err != nil {
+		_metricReqErr.Inc(s.c.Name, s.c.Stream, "xclaim", formatErr(err))
+		log.Error("redisstream: claim stream(%s) group(%s) messages(%v) error(%v)", s.c.Stream, s.c.Group, ids, err)
+		return nil
+	}
+	return msgs
+}
+
+// deadLetter moves poison messages to the dead letter stream and acks them.
+func (s *Subscriber) deadLetter(ids []string) {
+	for _, msg := range s.xclaim(ids) {
+		m := fromStream(s.c.Stream, msg)
+		if s.c.DeadLetterStream != "" {
+			dm := &queue.Message{
+				Topic:       s.c.DeadLetterStream,
+				Tag:         m.Tag,
+				Key:         m.Key,
+				ShardingKey: m.ShardingKey,
+				Body:        m.Body,
+				Properties:  m.Properties,
+			}
+			dm.SetProperty(queue.PropertyDeadLetterTopic, s.c.Stream)
+			dm.SetProperty(queue.PropertyDeadLetterID, msg.ID)
+			dm.SetProperty(queue.PropertyDeadLetterAttempts, strconv.FormatInt(s.c.MaxDeliveries, 10))
+			dm.SetProperty(queue.PropertyDeadLetterError, errMaxDeliveries.Error())
+			err := s.r.XAdd(s.ctx, &xredis.XAddArgs{
+				Stream: s.c.DeadLetterStream,
+				Values: toValues(dm),
+			}).Err()
+			if err != nil {
+				// NOTE: keep the message pending to retry dead letter later.
+				_metricReqErr.Inc(s.c.Name, s.c.Stream, "xadd", formatErr(err))
+				log.Error("redisstream: stream(%s) message(%s) publish to dead letter stream(%s) error(%v)", s.c.Stream, msg.ID, s.c.DeadLetterStream, err)
+				continue
+			}
+			_metricMessages.Inc(s.c.Name, s.c.Stream, "dead_letter")
+			log.Warn("redisstream: stream(%s) message(%s) moved to dead letter stream(%s) after %d deliveries", s.c.Stream, msg.ID, s.c.DeadLetterStream, s.c.MaxDeliveries)
+		} else {
+			_metricMessages.Inc(s.c.Name, s.c.Stream, "drop")
+			log.Error("redisstream: stream(%s) message(%s) dropped after %d deliveries", s.c.Stream, msg.ID, s.c.MaxDeliveries)
+		}
+		s.Ack(m)
+	}
+}
+
+func (s *Subscriber) sleep(d time.Duration) {
+	select {
+	case <-time.After(d):
+	case <-s.ctx.Done():
+	}
+}
+
+// Ack acks the message in the consumer group.
+func (s *Subscriber) Ack(m *queue.Message) error {
+	now := time.Now()
+	// NOTE: ack is not canceled by closing the subscriber.
+	err := s.r.XAck(context.Background(), s.c.Stream, s.c.Group, m.ID).Err()
+	_metricReqDur.Observe(int64(time.Since(now)/time.Millisecond), s.c.Name, s.c.Stream, "xack")
+	if err != nil {
+		_metricReqErr.Inc(s.c.Name, s.c.Stream, "xack", formatErr(err))
+		log.Error("redisstream: ack stream(%s) group(%s) message(%s) error(%v)", s.c.Stream, s.c.Group, m.ID, err)
+		return err
+	}
+	_metricMessages.Inc(s.c.Name, s.c.Stream, "acked")
+	return nil
+}
+
+// Nack leaves the message pending, it is claimed and redelivered after MinIdle.
+func (s *Subscriber) Nack(m *queue.Message, delay time.Duration) error {
+	_metricMessages.Inc(s.c.Name, s.c.Stream, "nacked")
+	return nil
+}
+
+// Close stops reading, waits the in-flight messages handled. it waits at most Block for the pending read.
+func (s *Subscriber) Close() error {
+	s.cancel()
+	s.wg.Wait()
+	return nil
+}
+
+// nextID returns the smallest stream id greater than id.
+func nextID(id string) string {
+	i := strings.IndexByte(id, '-')
+	if i < 0 {
+		return id
+	}
+	seq, err := strconv.ParseUint(id[i+1:], 10, 64)
+	if err != nil {
+		return id
+	}
+	return id[:i+1] + strconv.FormatUint(seq+1, 10)
+}
+
+func toValues(m *queue.Message) []interface{} {
+	values := make([]interface{}, 0, 10+2*len(m.Properties))
+	values = append(values, _fieldBody, m.Body)
+	if m.Tag != "" {
+		values = append(values, _fieldTag, m.Tag)
+	}
+	if m.Key != "" {
+		values = append(values, _fieldKey, m.Key)
+	}
+	if m.ShardingKey != "" {
+		values = append(values, _fieldShardingKey, m.ShardingKey)
+	}
+	if !m.PublishTime.IsZero() {
+		values = append(values, _fieldPublishTime, m.PublishTime.UnixNano()/int64(time.Millisecond))
+	}
+	for k, v := range m.Properties {
+		values = append(values, _fieldPropertyPrefix+k, v)
+	}
+	return values
+}
+
+func fromStream(stream string, msg xredis.XMessage) *queue.Message {
+	m := &queue.Message{
+		ID:    msg.ID,
+		Topic: stream,
+	}
+	for k, v := range msg.Values {
+		s, _ := v.(string)
+		switch k {
+		case _fieldBody:
+			m.Body = []byte(s)
+		case _fieldTag:
+			m.Tag = s
+		case _fieldKey:
+			m.Key = s
+		case _fieldShardingKey:
+			m.ShardingKey = s
+		case _fieldPublishTime:
+			if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+				m.PublishTime = time.Unix(0, ms*int64(time.Millisecond))
+			}
+		default:
+			if strings.HasPrefix(k, _fieldPropertyPrefix) {
+				m.SetProperty(strings.TrimPrefix(k, _fieldPropertyPrefix), s)
+			}
+		}
+	}
+	return m
+}
@@ -0,0 +1,169 @@
+package redisstream
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"kratos/pkg/cache/redis/redistest"
+	"kratos/pkg/cache/xredis"
+	"kratos/pkg/queue"
+	xtime "kratos/pkg/time"
+)
+
+func newClient(t *testing.T) (*miniredis.Miniredis, *xredis.Client) {
+	s, conf := redistest.New(t)
+	return s, xredis.New(conf)
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	s, r := newClient(t)
+	defer s.Close()
+	defer r.Close()
+	ctx := context.TODO()
+
+	sub := NewSubscriber(&SubscriberConfig{
+		Stream:           "orders",
+		Group:            "group",
+		Consumer:         "c1",
+		Start:            "0",
+		Block:            xtime.Duration(50 * time.Millisecond),
+		ClaimInterval:    xtime.Duration(50 * time.Millisecond),
+		MinIdle:          xtime.Duration(50 * time.Millisecond),
+		MaxDeliveries:    3,
+		DeadLetterStream: "orders.dlq",
+	}, r)
+
+	var (
+		mu       sync.Mutex
+		handled  = make(map[string][]int)
+		received = make(chan string, 10)
+	)
+	err := sub.Subscribe(func(ctx context.Context, m *queue.Message) error {
+		mu.Lock()
+		handled[string(m.Body)] = append(handled[string(m.Body)], m.Attempts)
+		attempts := len(handled[string(m.Body)])
+		mu.Unlock()
+		switch string(m.Body) {
+		case "poison":
+			return errors.New("poison")
+		case "retry":
+			if attempts == 1 {
+				return errors.New("retry")
+			}
+		}
+		if m.Tag != "tag" || m.Property("p") != "v" || m.PublishTime.IsZero() {
+			t.Errorf("message fields lost, got %+v", m)
+		}
+		received <- string(m.Body)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("subscribe error, %v", err)
+	}
+	if err = sub.Subscribe(func(ctx context.Context, m *queue.Message) error { return nil }); err != errSubscribed {
+		t.Fatalf("second subscribe should fail, got %v", err)
+	}
+
+	pub := NewPublisher(&PublisherConfig{MaxLen: 100}, r)
+	for _, body := range []string{"ok", "retry", "poison"} {
+		m := &queue.Message{Topic: "orders", Tag: "tag", Body: []byte(body), Properties: map[string]string{"p": "v"}}
+		if err = pub.Publish(ctx, m); err != nil || m.ID == "" {
+			t.Fatalf("publish got %s, %v", m.ID, err)
+		}
+	}
+	if err = pub.Publish(ctx, &queue.Message{Topic: "orders", Delay: time.Second}); err != ErrDelayUnsupported {
+		t.Fatalf("delayed message should be rejected, got %v", err)
+	}
+
+	for _, want := range []string{"ok", "retry"} {
+		select {
+		case body := <-received:
+			if body != want {
+				t.Fatalf("received %s, want %s", body, want)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("message %s should be received", want)
+		}
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if n, _ := r.XLen(ctx, "orders.dlq").Result(); n > 0 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err = sub.Close(); err != nil {
+		t.Fatalf("close error, %v", err)
+	}
+
+	mu.Lock()
+	if a := handled["retry"]; len(a) != 2 || a[0] != 1 || a[1] != 2 {
+		t.Fatalf("retry message attempts got %v", a)
+	}
+	if a := handled["poison"]; len(a) != 3 || a[2] != 3 {
+		t.Fatalf("poison message should be delivered MaxDeliveries times, got %v", a)
+	}
+	mu.Unlock()
+	msgs, err := r.XRange(ctx, "orders.dlq", "-", "+").Result()
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("dead letter stream got %v, %v", msgs, err)
+	}
+	dm := fromStream("orders.dlq", msgs[0])
+	if string(dm.Body) != "poison" || dm.Property(queue.PropertyDeadLetterTopic) != "orders" || dm.Property("p") != "v" {
+		t.Fatalf("dead letter message got %+v", dm)
+	}
+	if p, err := r.XPending(ctx, "orders", "group").Result(); err != nil || p.Count != 0 {
+		t.Fatalf("all messages should be acked, got %+v, %v", p, err)
+	}
+}
+
+func TestClaimFromDeadConsumer(t *testing.T) {
+	s, r := newClient(t)
+	defer s.Close()
+	defer r.Close()
+	ctx := context.TODO()
+
+	pub := NewPublisher(&PublisherConfig{}, r)
+	r.XGroupCreateMkStream(ctx, "jobs", "group", "$")
+	pub.Publish(ctx, &queue.Message{Topic: "jobs", Body: []byte("job")})
+	// a consumer reads the message then dies.
+	if err := r.XReadGroup(ctx, &xredis.XReadGroupArgs{Group: "group", Consumer: "dead", Streams: []string{"jobs", ">"}}).Err(); err != nil {
+		t.Fatalf("xreadgroup error, %v", err)
+	}
+
+	received := make(chan *queue.Message, 1)
+	sub := NewSubscriber(&SubscriberConfig{
+		Stream:        "jobs",
+		Group:         "group",
+		Consumer:      "live",
+		Block:         xtime.Duration(50 * time.Millisecond),
+		ClaimInterval: xtime.Duration(50 * time.Millisecond),
+		MinIdle:       xtime.Duration(100 * time.Millisecond),
+	}, r)
+	defer sub.Close()
+	if err := sub.Subscribe(func(ctx context.Context, m *queue.Message) error {
+		received <- m
+		return nil
+	}); err != nil {
+		t.Fatalf("subscribe error, %v", err)
+	}
+	select {
+	case m := <-received:
+		if string(m.Body) != "job" || m.Attempts != 2 {
+			t.Fatalf("claimed message got %+v", m)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("message of dead consumer should be claimed")
+	}
+}
+
+func TestNextID(t *testing.T) {
+	if id := nextID("1526985054069-9"); id != "1526985054069-10" {
+		t.Fatalf("next id got %s", id)
+	}
+}
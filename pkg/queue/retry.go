@@ -0,0 +1,97 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"kratos/pkg/log"
+	"kratos/pkg/net/netutil"
+)
+
+// dead letter message properties.
+const (
+	PropertyDeadLetterTopic    = "x-dlq-topic"
+	PropertyDeadLetterID       = "x-dlq-id"
+	PropertyDeadLetterAttempts = "x-dlq-attempts"
+	PropertyDeadLetterError    = "x-dlq-error"
+)
+
+// RetryConfig is the retry policy of failed messages.
+type RetryConfig struct {
+	// MaxAttempts is max delivery attempts of a message, the message is routed to
+	// the dead letter topic after the last attempt failed. zero means retry forever.
+	MaxAttempts int
+	// Backoff is the redelivery delay of failed messages, default netutil.DefaultBackoffConfig.
+	// NOTE: the delay is a hint, backends without delayed redelivery ignore it.
+	Backoff *netutil.BackoffConfig
+	// DeadLetterTopic is the topic of messages failed MaxAttempts times,
+	// they are dropped if it is empty.
+	DeadLetterTopic string
+}
+
+// Retry wraps h with retry policy c, failed messages are nacked with backoff delay
+// until MaxAttempts, then published to the dead letter topic by dlq and acked.
+func Retry(h Handler, c *RetryConfig, dlq Publisher) Handler {
+	backoff := c.Backoff
+	if backoff == nil {
+		backoff = &netutil.DefaultBackoffConfig
+	}
+	return func(ctx context.Context, m *Message) (err error) {
+		if err = safeHandle(ctx, m, h); err == nil || m.Settled() {
+			return
+		}
+		delay := backoff.Backoff(m.Attempts - 1)
+		if c.MaxAttempts <= 0 || m.Attempts < c.MaxAttempts {
+			_metricMsgErr.Inc(m.Topic, "retry")
+			m.Nack(delay)
+			return
+		}
+		if c.DeadLetterTopic == "" || dlq == nil {
+			_metricMsgErr.Inc(m.Topic, "drop")
+			log.Error("queue: topic(%s) message(%s) dropped after %d attempts, error(%v)", m.Topic, m.ID, m.Attempts, err)
+			m.Ack()
+			return
+		}
+		if perr := dlq.Publish(ctx, deadLetter(c.DeadLetterTopic, m, err)); perr != nil {
+			// NOTE: keep the message to retry dead letter later.
+			_metricMsgErr.Inc(m.Topic, "dead_letter_failed")
+			log.Error("queue: topic(%s) message(%s) publish to dead letter topic(%s) error(%v)", m.Topic, m.ID, c.DeadLetterTopic, perr)
+			m.Nack(delay)
+			return
+		}
+		_metricMsgErr.Inc(m.Topic, "dead_letter")
+		log.Warn("queue: topic(%s) message(%s) routed to dead letter topic(%s) after %d attempts, error(%v)", m.Topic, m.ID, c.DeadLetterTopic, m.Attempts, err)
+		m.Ack()
+		return
+	}
+}
+
+func safeHandle(ctx context.Context, m *Message, h Handler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("queue: handler panic: %v", r)
+			log.Error("queue: topic(%s) message(%s) handler panic: %v", m.Topic, m.ID, r)
+		}
+	}()
+	return h(ctx, m)
+}
+
+func deadLetter(topic string, m *Message, err error) *Message {
+	dm := &Message{
+		Topic:       topic,
+		Tag:         m.Tag,
+		Key:         m.Key,
+		ShardingKey: m.ShardingKey,
+		Body:        m.Body,
+		Properties:  make(map[string]string, len(m.Properties)+4),
+	}
+	for k, v := range m.Properties {
+		dm.Properties[k] = v
+	}
+	dm.Properties[PropertyDeadLetterTopic] = m.Topic
+	dm.Properties[PropertyDeadLetterID] = m.ID
+	dm.Properties[PropertyDeadLetterAttempts] = strconv.Itoa(m.Attempts)
+	dm.Properties[PropertyDeadLetterError] = err.Error()
+	return dm
+}
@@ -0,0 +1,99 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"kratos/pkg/net/netutil"
+	"kratos/pkg/queue"
+	"kratos/pkg/queue/memory"
+)
+
+func TestRetry(t *testing.T) {
+	b := memory.New()
+	defer b.Close()
+	dead := b.Subscriber("topic-dlq", "group")
+	dlqs := make(chan *queue.Message, 1)
+	dead.Subscribe(func(ctx context.Context, m *queue.Message) error {
+		dlqs <- m
+		return nil
+	})
+	defer dead.Close()
+
+	s := b.Subscriber("topic", "group")
+	attempts := make(chan time.Time, 10)
+	s.Subscribe(queue.Retry(func(ctx context.Context, m *queue.Message) error {
+		attempts <- time.Now()
+		if string(m.Body) == "panic" {
+			panic("boom")
+		}
+		return errors.New("boom")
+	}, &queue.RetryConfig{
+		MaxAttempts:     3,
+		Backoff:         &netutil.BackoffConfig{BaseDelay: 20 * time.Millisecond, MaxDelay: time.Second, Factor: 2},
+		DeadLetterTopic: "topic-dlq",
+	}, b.Publisher()))
+	defer s.Close()
+
+	for _, body := range []string{"error", "panic"} {
+		b.Publisher().Publish(context.TODO(), &queue.Message{Topic: "topic", Body: []byte(body), Properties: map[string]string{"a": "b"}})
+		var last time.Time
+		for i := 0; i < 3; i++ {
+			select {
+			case now := <-attempts:
+				// backoff of retries: 20ms, 40ms
+				if i > 0 && now.Sub(last) < 10*time.Millisecond<<uint(i) {
+					t.Fatalf("retry %d is too early: %v", i, now.Sub(last))
+				}
+				last = now
+			case <-time.After(time.Second * 2):
+				t.Fatalf("wait attempt %d timeout", i+1)
+			}
+		}
+		select {
+		case m := <-dlqs:
+			if string(m.Body) != body || m.Property("a") != "b" || m.Property(queue.PropertyDeadLetterTopic) != "topic" ||
+				m.Property(queue.PropertyDeadLetterAttempts) != "3" || m.Property(queue.PropertyDeadLetterError) == "" {
+				t.Fatalf("got unexpected dead letter %+v", m)
+			}
+		case <-time.After(time.Second * 2):
+			t.Fatalf("wait dead letter timeout")
+		}
+		select {
+		case <-attempts:
+			t.Fatalf("dead letter should not be retried")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+func TestRetryDrop(t *testing.T) {
+	b := memory.New()
+	defer b.Close()
+	s := b.Subscriber("topic", "group")
+	attempts := make(chan int, 10)
+	s.Subscribe(queue.Retry(func(ctx context.Context, m *queue.Message) error {
+		attempts <- m.Attempts
+		return errors.New("boom")
+	}, &queue.RetryConfig{MaxAttempts: 2, Backoff: &netutil.BackoffConfig{BaseDelay: time.Millisecond}}, nil))
+	defer s.Close()
+
+	b.Publisher().Publish(context.TODO(), &queue.Message{Topic: "topic"})
+	for i := 1; i <= 2; i++ {
+		select {
+		case n := <-attempts:
+			if n != i {
+				t.Fatalf("got attempts %d, expect %d", n, i)
+			}
+		case <-time.After(time.Second * 2):
+			t.Fatalf("wait attempt %d timeout", i)
+		}
+	}
+	select {
+	case <-attempts:
+		t.Fatalf("message should be dropped after max attempts")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
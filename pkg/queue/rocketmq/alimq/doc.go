@@ -0,0 +1,8 @@
+// Package alimq is the queue of aliyun rocketmq by the http sdk.
+//
+// NewQueuePublisher and NewQueueSubscriber implement the vendor neutral queue
+// interfaces with trace, metadata propagation, per-message metrics, and the
+// retry and dead-letter policy of SubscriberConfig.Retry. Failed messages are
+// redelivered after their invisible time, so RetryConfig.Backoff is rejected.
+// Publisher and Subscriber are the raw clients of the sdk.
+package alimq
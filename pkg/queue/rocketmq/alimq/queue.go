@@ -0,0 +1,127 @@
+package alimq
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	mqHttpSdk "github.com/aliyunmq/mq-http-go-sdk"
+
+	"kratos/pkg/queue"
+)
+
+var (
+	_ queue.Publisher  = &queuePublisher{}
+	_ queue.Subscriber = &queueSubscriber{}
+
+	errRetryBackoff = errors.New("alimq: retry backoff is not supported, failed messages are redelivered after the invisible time")
+)
+
+// NewQueuePublisher new a queue.Publisher of alimq, message topic is ignored,
+// the topic of config is used.
+func NewQueuePublisher(c *PublisherConfig) queue.Publisher {
+	return &queuePublisher{p: NewPublisher(c)}
+}
+
+type queuePublisher struct {
+	p *Publisher
+}
+
+func (qp *queuePublisher) Publish(ctx context.Context, m *queue.Message) (err error) {
+	m.Topic = qp.p.config.Topic
+	if t := queue.StartPublish(ctx, m); t != nil {
+		defer t.Finish(&err)
+	}
+	req := mqHttpSdk.PublishMessageRequest{
+		MessageBody: string(m.Body),
+		MessageTag:  m.Tag,
+		Properties:  m.Properties,
+		MessageKey:  m.Key,
+		ShardingKey: m.ShardingKey,
+	}
+	if m.Delay > 0 {
+		req.StartDeliverTime = time.Now().Add(m.Delay).UnixNano() / int64(time.Millisecond)
+	}
+	resp, err := qp.p.Publish(req)
+	if err != nil {
+		return
+	}
+	m.ID = resp.MessageId
+	return
+}
+
+func (qp *queuePublisher) Close() error {
+	qp.p.Close()
+	return nil
+}
+
+// NewQueueSubscriber new a queue.Subscriber of alimq, handler is wrapped with queue.Retry if c.Retry is set.
+// NOTE: alimq redelivers failed messages after their invisible time, the http sdk can't change it,
+// so Subscribe returns error if c.Retry.Backoff is set.
+func NewQueueSubscriber(c *SubscriberConfig) queue.Subscriber {
+	qs := &queueSubscriber{s: NewSubscriber(c)}
+	if c.Retry != nil && c.Retry.DeadLetterTopic != "" {
+		qs.dlq = NewQueuePublisher(&PublisherConfig{Config: c.Config, Topic: c.Retry.DeadLetterTopic, InstanceId: c.InstanceId})
+	}
+	return qs
+}
+
+type queueSubscriber struct {
+	s   *Subscriber
+	dlq queue.Publisher
+}
+
+func (qs *queueSubscriber) Subscribe(h queue.Handler) error {
+	if c := qs.s.config.Retry; c != nil {
+		if c.Backoff != nil {
+			return errRetryBackoff
+		}
+		h = queue.Retry(h, c, qs.dlq)
+	}
+	qs.s.Subscribe(qs.consume(h))
+	return nil
+}
+
+// consume returns the alimq handler of h, every message is acked by its own receipt handle.
+func (qs *queueSubscriber) consume(h queue.Handler) func([]mqHttpSdk.ConsumeMessageEntry) {
+	return func(entries []mqHttpSdk.ConsumeMessageEntry) {
+		for _, e := range entries {
+			m := &queue.Message{
+				ID:          e.MessageId,
+				Topic:       qs.s.config.Topic,
+				Tag:         e.MessageTag,
+				Key:         e.MessageKey,
+				ShardingKey: e.ShardingKey,
+				Body:        []byte(e.MessageBody),
+				Properties:  e.Properties,
+				Attempts:    int(e.ConsumedTimes),
+				PublishTime: time.Unix(0, e.PublishTime*int64(time.Millisecond)),
+			}
+			queue.Dispatch(context.Background(), m.WithAcker(&acker{s: qs.s, handle: e.ReceiptHandle}), h)
+		}
+	}
+}
+
+func (qs *queueSubscriber) Close() error {
+	qs.s.Close()
+	if qs.dlq != nil {
+		qs.dlq.Close()
+	}
+	return nil
+}
+
+// acker acks message by receipt handle.
+type acker struct {
+	s      *Subscriber
+	handle string
+}
+
+func (a *acker) Ack(m *queue.Message) error {
+	return a.s.Ack([]string{a.handle})
+}
+
+// Nack does nothing, alimq redelivers unacked message after its invisible time,
+// so the delay is ignored.
+func (a *acker) Nack(m *queue.Message, delay time.Duration) error {
+	return nil
+}
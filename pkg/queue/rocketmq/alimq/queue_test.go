@@ -0,0 +1,79 @@
+package alimq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mqHttpSdk "github.com/aliyunmq/mq-http-go-sdk"
+
+	"kratos/pkg/net/netutil"
+	"kratos/pkg/queue"
+)
+
+type fakeConsumer struct {
+	mqHttpSdk.MQConsumer
+	acked []string
+}
+
+func (c *fakeConsumer) AckMessage(receiptHandles []string) error {
+	c.acked = append(c.acked, receiptHandles...)
+	return nil
+}
+
+type fakePublisher struct {
+	msgs []*queue.Message
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, m *queue.Message) error {
+	p.msgs = append(p.msgs, m)
+	return nil
+}
+
+func (p *fakePublisher) Close() error { return nil }
+
+func entry(id, body string, consumed int64) mqHttpSdk.ConsumeMessageEntry {
+	e := mqHttpSdk.ConsumeMessageEntry{MessageId: id, ReceiptHandle: "h-" + id, MessageBody: body, ConsumedTimes: consumed}
+	e.MessageKey = "k-" + id
+	e.Properties = map[string]string{"a": "b"}
+	return e
+}
+
+func TestQueueSubscriberConsume(t *testing.T) {
+	c, dlq := new(fakeConsumer), new(fakePublisher)
+	retry := &queue.RetryConfig{MaxAttempts: 3, DeadLetterTopic: "dlq"}
+	qs := &queueSubscriber{s: &Subscriber{config: &SubscriberConfig{Topic: "topic", Retry: retry}, consumer: c}, dlq: dlq}
+	var handled []*queue.Message
+	consume := qs.consume(queue.Retry(func(ctx context.Context, m *queue.Message) error {
+		handled = append(handled, m)
+		if string(m.Body) == "fail" {
+			return errors.New("fail")
+		}
+		return nil
+	}, retry, qs.dlq))
+
+	consume([]mqHttpSdk.ConsumeMessageEntry{entry("ok", "ok", 1), entry("retry", "fail", 1), entry("dead", "fail", 3)})
+	if len(handled) != 3 {
+		t.Fatalf("got handled %d messages, expect 3", len(handled))
+	}
+	if m := handled[0]; m.Topic != "topic" || m.Key != "k-ok" || m.Attempts != 1 || m.Property("a") != "b" {
+		t.Fatalf("got unexpected message %+v", m)
+	}
+	// the failed message is left to be redelivered after its invisible time.
+	if len(c.acked) != 2 || c.acked[0] != "h-ok" || c.acked[1] != "h-dead" {
+		t.Fatalf("got acked %v, expect [h-ok h-dead]", c.acked)
+	}
+	if len(dlq.msgs) != 1 || dlq.msgs[0].Property(queue.PropertyDeadLetterID) != "dead" {
+		t.Fatalf("got dead letters %+v", dlq.msgs)
+	}
+}
+
+func TestQueueSubscriberBackoff(t *testing.T) {
+	qs := &queueSubscriber{s: &Subscriber{config: &SubscriberConfig{Retry: &queue.RetryConfig{
+		Backoff: &netutil.BackoffConfig{BaseDelay: time.Second, MaxDelay: time.Minute, Factor: 1.6},
+	}}}}
+	if err := qs.Subscribe(func(ctx context.Context, m *queue.Message) error { return nil }); err != errRetryBackoff {
+		t.Fatalf("subscribe with retry backoff got %v, expect %v", err, errRetryBackoff)
+	}
+}
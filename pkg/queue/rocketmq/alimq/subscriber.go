@@ -5,6 +5,7 @@ import (
 	mqHttpSdk "github.com/aliyunmq/mq-http-go-sdk"
 	"github.com/gogap/errors"
 	"kratos/pkg/log"
+	"kratos/pkg/queue"
 	"kratos/pkg/sync/errgroup"
 	"strings"
 	"sync/atomic"
@@ -29,6 +30,8 @@ type SubscriberConfig struct {
 	MessageTag    string
 	NumOfMessages int32 // 一次最多消费x条（最多可设置为16条）
 	WaitSeconds   int64 // 长轮询时间xs（最多可设置为30s）
+	// 失败重试及死信策略，仅用于NewQueueSubscriber，死信topic需与Topic在同一实例
+	Retry *queue.RetryConfig
 }
 
 func NewSubscriber(c *SubscriberConfig) (s *Subscriber) {
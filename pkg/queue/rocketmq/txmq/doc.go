@@ -0,0 +1,8 @@
+// Package txmq is the queue of rocketmq by the native client, e.g. tencent tdmq.
+//
+// NewQueuePublisher and NewQueueSubscriber implement the vendor neutral queue
+// interfaces with trace, metadata propagation, per-message metrics, and the
+// retry and dead-letter policy of SubscriberConfig.Retry, failed messages are
+// redelivered at the delay level not less than the backoff. Publisher and
+// Subscriber are the raw clients of rocketmq.
+package txmq
@@ -0,0 +1,169 @@
+package txmq
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/apache/rocketmq-client-go/v2/consumer"
+	"github.com/apache/rocketmq-client-go/v2/primitive"
+
+	"kratos/pkg/queue"
+)
+
+var (
+	_ queue.Publisher  = &queuePublisher{}
+	_ queue.Subscriber = &queueSubscriber{}
+
+	// _delayLevels is the default delay levels of rocketmq broker.
+	_delayLevels = []time.Duration{
+		time.Second, 5 * time.Second, 10 * time.Second, 30 * time.Second,
+		time.Minute, 2 * time.Minute, 3 * time.Minute, 4 * time.Minute, 5 * time.Minute,
+		6 * time.Minute, 7 * time.Minute, 8 * time.Minute, 9 * time.Minute, 10 * time.Minute,
+		20 * time.Minute, 30 * time.Minute, time.Hour, 2 * time.Hour,
+	}
+)
+
+// delayLevel returns the minimum delay level not less than d, zero means no delay.
+func delayLevel(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	for i, l := range _delayLevels {
+		if l >= d {
+			return i + 1
+		}
+	}
+	return len(_delayLevels)
+}
+
+// NewQueuePublisher new a queue.Publisher of txmq.
+// NOTE: rocketmq only supports delay levels, message delay is rounded up to the nearest level.
+func NewQueuePublisher(c *PublisherConfig) (queue.Publisher, error) {
+	p, err := NewPublisher(c)
+	if err != nil {
+		return nil, err
+	}
+	return &queuePublisher{p: p}, nil
+}
+
+type queuePublisher struct {
+	p *Publisher
+}
+
+func (qp *queuePublisher) Publish(ctx context.Context, m *queue.Message) (err error) {
+	if t := queue.StartPublish(ctx, m); t != nil {
+		defer t.Finish(&err)
+	}
+	msg := primitive.NewMessage(m.Topic, m.Body)
+	for k, v := range m.Properties {
+		msg.WithProperty(k, v)
+	}
+	msg.WithTag(m.Tag)
+	msg.WithShardingKey(m.ShardingKey)
+	if m.Key != "" {
+		msg.WithKeys([]string{m.Key})
+	}
+	if level := delayLevel(m.Delay); level > 0 {
+		msg.WithDelayTimeLevel(level)
+	}
+	res, err := qp.p.producer.SendSync(ctx, msg)
+	if err != nil {
+		return
+	}
+	m.ID = res.MsgID
+	return
+}
+
+func (qp *queuePublisher) Close() error {
+	qp.p.Close()
+	return nil
+}
+
+// NewQueueSubscriber new a queue.Subscriber of txmq, it subscribes topic and tag of config,
+// handler is wrapped with queue.Retry if c.Retry is set.
+// NOTE: rocketmq acks or retries a batch as a whole, so messages are consumed one by one.
+func NewQueueSubscriber(c *SubscriberConfig) (queue.Subscriber, error) {
+	s, err := newSubscriber(c, consumer.WithConsumeMessageBatchMaxSize(1))
+	if err != nil {
+		return nil, err
+	}
+	qs := &queueSubscriber{s: s}
+	if c.Retry != nil && c.Retry.DeadLetterTopic != "" {
+		if qs.dlq, err = NewQueuePublisher(&PublisherConfig{Config: c.Config}); err != nil {
+			return nil, err
+		}
+	}
+	return qs, nil
+}
+
+type queueSubscriber struct {
+	s   *Subscriber
+	dlq queue.Publisher
+}
+
+func (qs *queueSubscriber) Subscribe(h queue.Handler) error {
+	if qs.s.config.Retry != nil {
+		h = queue.Retry(h, qs.s.config.Retry, qs.dlq)
+	}
+	selector := consumer.MessageSelector{}
+	if qs.s.config.MessageTag != "" {
+		selector = consumer.MessageSelector{Type: consumer.TAG, Expression: qs.s.config.MessageTag}
+	}
+	return qs.s.Subscribe(qs.s.config.Topic, selector, qs.consume(h))
+}
+
+// consume returns the rocketmq handler of h, the result of a batch is retry
+// if any message of it is nacked.
+func (qs *queueSubscriber) consume(h queue.Handler) func(context.Context, ...*primitive.MessageExt) (consumer.ConsumeResult, error) {
+	return func(ctx context.Context, msgs ...*primitive.MessageExt) (consumer.ConsumeResult, error) {
+		a := new(acker)
+		for _, msg := range msgs {
+			m := &queue.Message{
+				ID:          msg.MsgId,
+				Topic:       qs.s.config.Topic,
+				Tag:         msg.GetTags(),
+				Key:         strings.TrimSpace(msg.GetKeys()),
+				ShardingKey: msg.GetShardingKey(),
+				Body:        msg.Body,
+				Properties:  msg.GetProperties(),
+				Attempts:    int(msg.ReconsumeTimes) + 1,
+				PublishTime: time.Unix(0, msg.BornTimestamp*int64(time.Millisecond)),
+			}
+			queue.Dispatch(ctx, m.WithAcker(a), h)
+		}
+		if !a.nacked {
+			return consumer.ConsumeSuccess, nil
+		}
+		if cc, ok := primitive.GetConcurrentlyCtx(ctx); ok && a.delay > 0 {
+			cc.DelayLevelWhenNextConsume = delayLevel(a.delay)
+		}
+		return consumer.ConsumeRetryLater, nil
+	}
+}
+
+func (qs *queueSubscriber) Close() error {
+	qs.s.Close()
+	if qs.dlq != nil {
+		qs.dlq.Close()
+	}
+	return nil
+}
+
+// acker records the consume result of a batch.
+type acker struct {
+	nacked bool
+	delay  time.Duration
+}
+
+func (a *acker) Ack(m *queue.Message) error {
+	return nil
+}
+
+func (a *acker) Nack(m *queue.Message, delay time.Duration) error {
+	a.nacked = true
+	if delay > a.delay {
+		a.delay = delay
+	}
+	return nil
+}
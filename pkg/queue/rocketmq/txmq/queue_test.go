@@ -0,0 +1,88 @@
+package txmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/apache/rocketmq-client-go/v2/consumer"
+	"github.com/apache/rocketmq-client-go/v2/primitive"
+
+	"kratos/pkg/net/netutil"
+	"kratos/pkg/queue"
+)
+
+func TestDelayLevel(t *testing.T) {
+	for d, level := range map[time.Duration]int{
+		0:                0,
+		time.Millisecond: 1,
+		time.Second:      1,
+		3 * time.Second:  2,
+		time.Minute + 1:  6,
+		2 * time.Hour:    18,
+		24 * time.Hour:   18,
+	} {
+		if got := delayLevel(d); got != level {
+			t.Errorf("delayLevel(%v) = %d, expect %d", d, got, level)
+		}
+	}
+}
+
+type fakePublisher struct {
+	msgs []*queue.Message
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, m *queue.Message) error {
+	p.msgs = append(p.msgs, m)
+	return nil
+}
+
+func (p *fakePublisher) Close() error { return nil }
+
+func TestQueueSubscriberConsume(t *testing.T) {
+	dlq := new(fakePublisher)
+	qs := &queueSubscriber{
+		s: &Subscriber{config: &SubscriberConfig{Topic: "topic", Retry: &queue.RetryConfig{
+			MaxAttempts:     3,
+			Backoff:         &netutil.BackoffConfig{BaseDelay: 3 * time.Second, MaxDelay: 3 * time.Second, Factor: 1},
+			DeadLetterTopic: "dlq",
+		}}},
+		dlq: dlq,
+	}
+	var handled []string
+	consume := qs.consume(queue.Retry(func(ctx context.Context, m *queue.Message) error {
+		handled = append(handled, m.ID)
+		if string(m.Body) == "fail" {
+			return errors.New("fail")
+		}
+		return nil
+	}, qs.s.config.Retry, qs.dlq))
+
+	// a mixed batch is consumed one by one, so the acked and dead lettered
+	// messages are not redelivered with the failed one.
+	for _, c := range []struct {
+		msg    *primitive.MessageExt
+		result consumer.ConsumeResult
+		level  int
+	}{
+		{&primitive.MessageExt{MsgId: "ok", Message: primitive.Message{Body: []byte("ok")}}, consumer.ConsumeSuccess, 0},
+		{&primitive.MessageExt{MsgId: "retry", Message: primitive.Message{Body: []byte("fail")}}, consumer.ConsumeRetryLater, 2},
+		{&primitive.MessageExt{MsgId: "dead", ReconsumeTimes: 2, Message: primitive.Message{Body: []byte("fail")}}, consumer.ConsumeSuccess, 0},
+	} {
+		cc := primitive.NewConsumeConcurrentlyContext()
+		res, err := consume(primitive.WithConcurrentlyCtx(context.TODO(), cc), c.msg)
+		if err != nil || res != c.result {
+			t.Fatalf("consume %s got %v, %v, expect %v", c.msg.MsgId, res, err, c.result)
+		}
+		if cc.DelayLevelWhenNextConsume != c.level {
+			t.Fatalf("consume %s got delay level %d, expect %d", c.msg.MsgId, cc.DelayLevelWhenNextConsume, c.level)
+		}
+	}
+	if len(handled) != 3 {
+		t.Fatalf("got handled %v", handled)
+	}
+	if len(dlq.msgs) != 1 || dlq.msgs[0].Property(queue.PropertyDeadLetterID) != "dead" {
+		t.Fatalf("got dead letters %+v", dlq.msgs)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/apache/rocketmq-client-go/v2/consumer"
 	"github.com/apache/rocketmq-client-go/v2/primitive"
 	"kratos/pkg/log"
+	"kratos/pkg/queue"
 	"sync/atomic"
 )
 
@@ -17,11 +18,19 @@ type Subscriber struct {
 
 type SubscriberConfig struct {
 	*Config
-	GroupName string
+	GroupName  string
+	Topic      string // 订阅的topic，仅用于NewQueueSubscriber
+	MessageTag string // 订阅的tag表达式，仅用于NewQueueSubscriber，为空时订阅全部
+	// 失败重试及死信策略，仅用于NewQueueSubscriber
+	Retry *queue.RetryConfig
 }
 
 func NewSubscriber(c *SubscriberConfig) (s *Subscriber, err error) {
-	cs, err := rocketmq.NewPushConsumer(
+	return newSubscriber(c)
+}
+
+func newSubscriber(c *SubscriberConfig, opts ...consumer.Option) (s *Subscriber, err error) {
+	opts = append([]consumer.Option{
 		// 设置消费者组
 		consumer.WithGroupName(c.GroupName),
 		// 设置服务地址
@@ -37,7 +46,8 @@ func NewSubscriber(c *SubscriberConfig) (s *Subscriber, err error) {
 		//consumer.WithConsumeFromWhere(consumer.ConsumeFromFirstOffset),
 		// 设置消费模式（默认集群模式）
 		//consumer.WithConsumerModel(consumer.Clustering),
-	)
+	}, opts...)
+	cs, err := rocketmq.NewPushConsumer(opts...)
 
 	if err != nil {
 		return
@@ -9,6 +9,7 @@ import (
 	"kratos/pkg/container/group"
 	"kratos/pkg/ecode"
 	"kratos/pkg/log"
+	"kratos/pkg/net/criticality"
 	limit "kratos/pkg/ratelimit"
 	"kratos/pkg/stat/metric"
 
@@ -24,6 +25,14 @@ var (
 		WinBucket:    100,
 		CPUThreshold: 800,
 	}
+	// the default thresholds of criticality, cpu is the ratio of CPUThreshold
+	// and inFlight is the ratio of max in flight.
+	defaultThresholds = map[criticality.Criticality]struct{ cpu, inFlight float64 }{
+		criticality.CriticalPlus:  {cpu: 1, inFlight: 1.2},
+		criticality.Critical:      {cpu: 1, inFlight: 1},
+		criticality.SheddablePlus: {cpu: 0.9, inFlight: 0.8},
+		criticality.Sheddable:     {cpu: 0.8, inFlight: 0.6},
+	}
 )
 
 type cpuGetter func() int64
@@ -74,7 +83,7 @@ type BBR struct {
 	bucketDuration  time.Duration
 	winSize         int
 	conf            *Config
-	prevDrop        atomic.Value
+	classes         map[criticality.Criticality]*class
 	maxPASSCache    atomic.Value
 	minRtCache      atomic.Value
 }
@@ -87,6 +96,13 @@ type CounterCache struct {
 	time time.Time
 }
 
+// class is the overload state of a criticality, every criticality is dropped by its own threshold.
+type class struct {
+	cpu      int64
+	inFlight float64
+	prevDrop atomic.Value
+}
+
 // Config contains configs of bbr limiter.
 type Config struct {
 	Enabled      bool
@@ -95,6 +111,34 @@ type Config struct {
 	Rule         string
 	Debug        bool
 	CPUThreshold int64
+	// Thresholds are the thresholds of criticality, the less critical requests are dropped first.
+	// The default thresholds of CRITICAL are CPUThreshold and the max in flight.
+	Thresholds map[criticality.Criticality]*Threshold
+}
+
+// Threshold is the overload threshold of a criticality.
+type Threshold struct {
+	// CPU is the cpu usage above which the requests may be dropped.
+	CPU int64
+	// InFlight is the ratio of max in flight above which the requests are dropped.
+	InFlight float64
+}
+
+func (c *Config) class(crtl criticality.Criticality) *class {
+	def := defaultThresholds[crtl]
+	cl := &class{
+		cpu:      int64(float64(c.CPUThreshold) * def.cpu),
+		inFlight: def.inFlight,
+	}
+	if th, ok := c.Thresholds[crtl]; ok && th != nil {
+		if th.CPU > 0 {
+			cl.cpu = th.CPU
+		}
+		if th.InFlight > 0 {
+			cl.inFlight = th.InFlight
+		}
+	}
+	return cl
 }
 
 func (l *BBR) maxPASS() int64 {
@@ -173,27 +217,30 @@ func (l *BBR) maxFlight() int64 {
 	return int64(math.Floor(float64(l.maxPASS()*l.minRT()*l.winBucketPerSec)/1000.0 + 0.5))
 }
 
-func (l *BBR) shouldDrop() bool {
-	if l.cpu() < l.conf.CPUThreshold {
-		prevDrop, _ := l.prevDrop.Load().(time.Duration)
+func (l *BBR) shouldDrop(crtl criticality.Criticality) bool {
+	cl := l.classes[crtl]
+	overload := func() bool {
+		inFlight := atomic.LoadInt64(&l.inFlight)
+		return inFlight > 1 && float64(inFlight) > float64(l.maxFlight())*cl.inFlight
+	}
+	if l.cpu() < cl.cpu {
+		prevDrop, _ := cl.prevDrop.Load().(time.Duration)
 		if prevDrop == 0 {
 			return false
 		}
 		if time.Since(initTime)-prevDrop <= time.Second {
-			inFlight := atomic.LoadInt64(&l.inFlight)
-			return inFlight > 1 && inFlight > l.maxFlight()
+			return overload()
 		}
-		l.prevDrop.Store(time.Duration(0))
+		cl.prevDrop.Store(time.Duration(0))
 		return false
 	}
-	inFlight := atomic.LoadInt64(&l.inFlight)
-	drop := inFlight > 1 && inFlight > l.maxFlight()
+	drop := overload()
 	if drop {
-		prevDrop, _ := l.prevDrop.Load().(time.Duration)
+		prevDrop, _ := cl.prevDrop.Load().(time.Duration)
 		if prevDrop != 0 {
 			return drop
 		}
-		l.prevDrop.Store(time.Since(initTime))
+		cl.prevDrop.Store(time.Since(initTime))
 	}
 	return drop
 }
@@ -210,13 +257,16 @@ func (l *BBR) Stat() Stat {
 }
 
 // Allow checks all inbound traffic.
-// Once overload is detected, it raises ecode.LimitExceed error.
+// Once overload is detected, it raises ecode.LimitExceed error,
+// the less critical requests of the criticality in metadata are dropped first.
 func (l *BBR) Allow(ctx context.Context, opts ...limit.AllowOption) (func(info limit.DoneInfo), error) {
 	allowOpts := limit.DefaultAllowOpts()
 	for _, opt := range opts {
 		opt.Apply(&allowOpts)
 	}
-	if l.shouldDrop() {
+	crtl := criticality.FromContext(ctx)
+	if l.shouldDrop(crtl) {
+		_metricDropped.Inc(allowOpts.Route, string(crtl))
 		return nil, ecode.LimitExceed
 	}
 	atomic.AddInt64(&l.inFlight, 1)
@@ -246,9 +296,14 @@ func newLimiter(conf *Config) limit.Limiter {
 	cpu := func() int64 {
 		return atomic.LoadInt64(&cpu)
 	}
+	classes := make(map[criticality.Criticality]*class, len(defaultThresholds))
+	for crtl := range defaultThresholds {
+		classes[crtl] = conf.class(crtl)
+	}
 	limiter := &BBR{
 		cpu:             cpu,
 		conf:            conf,
+		classes:         classes,
 		passStat:        passStat,
 		rtStat:          rtStat,
 		winBucketPerSec: int64(time.Second) / (int64(conf.Window) / int64(conf.WinBucket)),
@@ -11,6 +11,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"kratos/pkg/ecode"
+	"kratos/pkg/net/criticality"
+	"kratos/pkg/net/metadata"
 	"kratos/pkg/ratelimit"
 	"kratos/pkg/stat/metric"
 )
@@ -185,23 +188,74 @@ func TestBBRShouldDrop(t *testing.T) {
 	// cpu >=  800, inflight < maxQps
 	cpu = 800
 	bbr.inFlight = 50
-	assert.Equal(t, false, bbr.shouldDrop())
+	assert.Equal(t, false, bbr.shouldDrop(criticality.Critical))
 
 	// cpu >=  800, inflight > maxQps
 	cpu = 800
 	bbr.inFlight = 80
-	assert.Equal(t, true, bbr.shouldDrop())
+	assert.Equal(t, true, bbr.shouldDrop(criticality.Critical))
 
 	// cpu < 800, inflight > maxQps, cold duration
 	cpu = 700
 	bbr.inFlight = 80
-	assert.Equal(t, true, bbr.shouldDrop())
+	assert.Equal(t, true, bbr.shouldDrop(criticality.Critical))
 
 	// cpu < 800, inflight > maxQps
 	time.Sleep(2 * time.Second)
 	cpu = 700
 	bbr.inFlight = 80
-	assert.Equal(t, false, bbr.shouldDrop())
+	assert.Equal(t, false, bbr.shouldDrop(criticality.Critical))
+}
+
+func TestBBRShouldDropCriticality(t *testing.T) {
+	var cpu int64
+	conf := confForTest()
+	conf.Thresholds = map[criticality.Criticality]*Threshold{
+		criticality.CriticalPlus: {InFlight: 1.5},
+	}
+	bbr := newLimiter(conf).(*BBR)
+	bbr.cpu = func() int64 {
+		return cpu
+	}
+	bucketDuration := time.Millisecond * 100
+	passStat := metric.NewRollingCounter(metric.RollingCounterOpts{Size: 10, BucketDuration: bucketDuration})
+	rtStat := metric.NewRollingCounter(metric.RollingCounterOpts{Size: 10, BucketDuration: bucketDuration})
+	for i := 0; i < 10; i++ {
+		passStat.Add(int64((i + 1) * 100))
+		for j := i*10 + 1; j <= i*10+10; j++ {
+			rtStat.Add(int64(j))
+		}
+		if i != 9 {
+			time.Sleep(bucketDuration)
+		}
+	}
+	bbr.passStat = passStat
+	bbr.rtStat = rtStat
+	maxFlight := float64(bbr.maxFlight())
+
+	// cpu is above the threshold of sheddable only.
+	cpu = 700
+	bbr.inFlight = int64(maxFlight * 0.9)
+	assert.Equal(t, true, bbr.shouldDrop(criticality.Sheddable))
+	assert.Equal(t, false, bbr.shouldDrop(criticality.SheddablePlus))
+	assert.Equal(t, false, bbr.shouldDrop(criticality.Critical))
+
+	// the less critical requests are dropped with less in flight.
+	cpu = 800
+	bbr.inFlight = int64(maxFlight * 0.7)
+	assert.Equal(t, true, bbr.shouldDrop(criticality.Sheddable))
+	assert.Equal(t, false, bbr.shouldDrop(criticality.SheddablePlus))
+	bbr.inFlight = int64(maxFlight * 1.3)
+	assert.Equal(t, true, bbr.shouldDrop(criticality.SheddablePlus))
+	assert.Equal(t, true, bbr.shouldDrop(criticality.Critical))
+	assert.Equal(t, false, bbr.shouldDrop(criticality.CriticalPlus))
+
+	// the criticality in metadata is used by Allow.
+	ctx := metadata.NewContext(context.TODO(), metadata.MD{metadata.Criticality: string(criticality.CriticalPlus)})
+	_, err := bbr.Allow(ctx)
+	assert.Nil(t, err)
+	_, err = bbr.Allow(context.TODO())
+	assert.Equal(t, ecode.LimitExceed, err)
 }
 
 func TestGroup(t *testing.T) {
@@ -260,7 +314,7 @@ func BenchmarkBBRShouldDropUnderLowLoad(b *testing.B) {
 	warmup(bbr, 10000)
 	b.ResetTimer()
 	for i := 0; i <= b.N; i++ {
-		bbr.shouldDrop()
+		bbr.shouldDrop(criticality.Critical)
 	}
 }
 
@@ -273,7 +327,7 @@ func BenchmarkBBRShouldDropUnderHighLoad(b *testing.B) {
 	bbr.inFlight = 1000
 	b.ResetTimer()
 	for i := 0; i <= b.N; i++ {
-		bbr.shouldDrop()
+		bbr.shouldDrop(criticality.Critical)
 		if i%10000 == 0 {
 			forceAllow(bbr)
 		}
@@ -286,11 +340,11 @@ func BenchmarkBBRShouldDropUnderUnstableLoad(b *testing.B) {
 		return 500
 	}
 	warmup(bbr, 10000)
-	bbr.prevDrop.Store(time.Since(initTime))
+	bbr.classes[criticality.Critical].prevDrop.Store(time.Since(initTime))
 	bbr.inFlight = 1000
 	b.ResetTimer()
 	for i := 0; i <= b.N; i++ {
-		bbr.shouldDrop()
+		bbr.shouldDrop(criticality.Critical)
 		if i%100000 == 0 {
 			forceAllow(bbr)
 		}
@@ -0,0 +1,11 @@
+package bbr
+
+import "kratos/pkg/stat/metric"
+
+var _metricDropped = metric.NewCounterVec(&metric.CounterVecOpts{
+	Namespace: "ratelimit",
+	Subsystem: "bbr",
+	Name:      "dropped_total",
+	Help:      "bbr dropped requests total.",
+	Labels:    []string{"route", "criticality"},
+})
@@ -2,6 +2,12 @@ package ratelimit
 
 import (
 	"context"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+
+	"kratos/pkg/ecode"
 )
 
 // Op operations type.
@@ -16,13 +22,27 @@ const (
 	Drop
 )
 
-type allowOptions struct{}
+type allowOptions struct {
+	// Route is the route of request, e.g. the path of http or the full method of grpc.
+	Route string
+}
 
 // AllowOptions allow options.
 type AllowOption interface {
 	Apply(*allowOptions)
 }
 
+type routeOption string
+
+func (o routeOption) Apply(opts *allowOptions) {
+	opts.Route = string(o)
+}
+
+// WithRoute sets the route of request, limiters keyed by route use it.
+func WithRoute(route string) AllowOption {
+	return routeOption(route)
+}
+
 // DoneInfo done info.
 type DoneInfo struct {
 	Err error
@@ -38,3 +58,27 @@ func DefaultAllowOpts() allowOptions {
 type Limiter interface {
 	Allow(ctx context.Context, opts ...AllowOption) (func(info DoneInfo), error)
 }
+
+// LimitExceeded returns the ecode.LimitExceed error, retryAfter is carried
+// as the RetryInfo detail if it is positive.
+func LimitExceeded(retryAfter time.Duration) error {
+	st := ecode.FromCode(ecode.LimitExceed)
+	if retryAfter > 0 {
+		st.WithDetails(&errdetails.RetryInfo{RetryDelay: ptypes.DurationProto(retryAfter)})
+	}
+	return st
+}
+
+// RetryAfter returns the retry delay carried by the error of LimitExceeded.
+func RetryAfter(err error) (time.Duration, bool) {
+	if err == nil {
+		return 0, false
+	}
+	for _, detail := range ecode.Cause(err).Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			d, err := ptypes.Duration(info.RetryDelay)
+			return d, err == nil
+		}
+	}
+	return 0, false
+}
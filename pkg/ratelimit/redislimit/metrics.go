@@ -0,0 +1,11 @@
+package redislimit
+
+import "kratos/pkg/stat/metric"
+
+var _metricRequests = metric.NewCounterVec(&metric.CounterVecOpts{
+	Namespace: "ratelimit",
+	Subsystem: "redis",
+	Name:      "requests_total",
+	Help:      "redis rate limiter requests total by state.",
+	Labels:    []string{"name", "state"},
+})
@@ -0,0 +1,161 @@
+// Package redislimit is a distributed rate limiter shared by instances through redis.
+//
+// Requests are limited by GCRA (generic cell rate algorithm), the theoretical
+// arrival time of every key is kept in redis and updated by a lua script with
+// the redis server time, so the quota is enforced across the fleet.
+package redislimit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"kratos/pkg/cache/redis"
+	"kratos/pkg/log"
+	"kratos/pkg/net/metadata"
+	limit "kratos/pkg/ratelimit"
+	xtime "kratos/pkg/time"
+)
+
+// _gcraScript returns {allowed, retry_after_ms}, the tat is stored in seconds from 2017-01-01.
+var _gcraScript = redis.NewScript(1, `
+redis.replicate_commands()
+local emission = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = redis.call("TIME")
+now = (now[1] - 1483228800) + now[2] / 1000000
+local tat = tonumber(redis.call("GET", KEYS[1]))
+if not tat or tat < now then
+	tat = now
+end
+local new_tat = tat + emission
+local allow_at = new_tat - emission * burst
+if now < allow_at then
+	return {0, math.ceil((allow_at - now) * 1000)}
+end
+redis.call("SET", KEYS[1], tostring(new_tat), "PX", math.ceil((new_tat - now) * 1000))
+return {1, 0}`)
+
+// Config is the redis limiter config.
+type Config struct {
+	// Name is the key prefix of limiter in redis.
+	Name string
+	// Rate is the requests allowed per Period of every key.
+	Rate int64
+	// Period is the period of Rate, default 1s.
+	Period xtime.Duration
+	// Burst is the requests allowed at once, default Rate.
+	Burst int64
+}
+
+// KeyFunc extracts the limit key of request, requests with empty key are not limited.
+type KeyFunc func(ctx context.Context, route string) string
+
+// ByRoute limits requests by route.
+func ByRoute(ctx context.Context, route string) string {
+	return route
+}
+
+// ByCaller limits requests by the caller in metadata.
+func ByCaller(ctx context.Context, route string) string {
+	return metadata.String(ctx, metadata.Caller)
+}
+
+// ByRemoteIP limits requests by the remote ip in metadata.
+func ByRemoteIP(ctx context.Context, route string) string {
+	return metadata.String(ctx, metadata.RemoteIP)
+}
+
+// Compose limits requests by the joined keys of fns, e.g. Compose(ByCaller, ByRoute)
+// limits every caller on every route. Requests are not limited if any key is empty.
+func Compose(fns ...KeyFunc) KeyFunc {
+	return func(ctx context.Context, route string) string {
+		keys := make([]string, 0, len(fns))
+		for _, fn := range fns {
+			key := fn(ctx, route)
+			if key == "" {
+				return ""
+			}
+			keys = append(keys, key)
+		}
+		return strings.Join(keys, ":")
+	}
+}
+
+var _ limit.Limiter = &Limiter{}
+
+// Limiter is the redis rate limiter.
+// NOTE: requests are allowed if redis fails, the limiter should not break the service.
+type Limiter struct {
+	r        *redis.Redis
+	name     string
+	key      KeyFunc
+	emission float64
+	burst    int64
+}
+
+// New new a redis rate limiter with the key extractor, nil key limits by route.
+func New(r *redis.Redis, c *Config, key KeyFunc) *Limiter {
+	if c == nil || c.Rate <= 0 {
+		panic("redislimit: rate must be positive")
+	}
+	period := time.Duration(c.Period)
+	if period <= 0 {
+		period = time.Second
+	}
+	burst := c.Burst
+	if burst <= 0 {
+		burst = c.Rate
+	}
+	if key == nil {
+		key = ByRoute
+	}
+	return &Limiter{
+		r:        r,
+		name:     c.Name,
+		key:      key,
+		emission: period.Seconds() / float64(c.Rate),
+		burst:    burst,
+	}
+}
+
+// Allow checks the quota of request key, the error of ratelimit.LimitExceeded is returned if the quota is exceeded.
+func (l *Limiter) Allow(ctx context.Context, opts ...limit.AllowOption) (func(info limit.DoneInfo), error) {
+	o := limit.DefaultAllowOpts()
+	for _, opt := range opts {
+		opt.Apply(&o)
+	}
+	key := l.key(ctx, o.Route)
+	if key == "" {
+		return noop, nil
+	}
+	allowed, retryAfter, err := l.take(ctx, key)
+	if err != nil {
+		_metricRequests.Inc(l.name, "error")
+		log.Errorc(ctx, "redislimit: limiter(%s) take key(%s) error(%v)", l.name, key, err)
+		return noop, nil
+	}
+	if !allowed {
+		_metricRequests.Inc(l.name, "limit")
+		return nil, limit.LimitExceeded(retryAfter)
+	}
+	_metricRequests.Inc(l.name, "pass")
+	return noop, nil
+}
+
+func (l *Limiter) take(ctx context.Context, key string) (allowed bool, retryAfter time.Duration, err error) {
+	conn := l.r.Conn(ctx)
+	defer conn.Close()
+	res, err := redis.Int64s(_gcraScript.Do(conn, "ratelimit:"+l.name+":"+key, l.emission, l.burst))
+	if err != nil {
+		return
+	}
+	if len(res) != 2 {
+		err = fmt.Errorf("redislimit: unexpected reply %v", res)
+		return
+	}
+	return res[0] == 1, time.Duration(res[1]) * time.Millisecond, nil
+}
+
+func noop(limit.DoneInfo) {}
@@ -0,0 +1,93 @@
+package redislimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"kratos/pkg/cache/redis"
+	"kratos/pkg/cache/redis/redistest"
+	"kratos/pkg/ecode"
+	"kratos/pkg/net/metadata"
+	limit "kratos/pkg/ratelimit"
+	xtime "kratos/pkg/time"
+)
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Redis) {
+	s, conf := redistest.New(t)
+	r := redis.NewRedis(conf)
+	t.Cleanup(func() { r.Close() })
+	return s, r
+}
+
+func TestLimiter(t *testing.T) {
+	s, r := newTestRedis(t)
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.SetTime(now)
+	l := New(r, &Config{Name: "test", Rate: 10, Burst: 2}, Compose(ByCaller, ByRoute))
+	ctx := metadata.NewContext(context.TODO(), metadata.MD{metadata.Caller: "caller1"})
+	route := limit.WithRoute("/demo")
+
+	for i := 0; i < 2; i++ {
+		if _, err := l.Allow(ctx, route); err != nil {
+			t.Fatalf("burst requests should be allowed, %v", err)
+		}
+	}
+	_, err := l.Allow(ctx, route)
+	if !ecode.EqualError(ecode.LimitExceed, err) {
+		t.Fatalf("request over quota should be LimitExceed, %v", err)
+	}
+	if d, ok := limit.RetryAfter(err); !ok || d != 100*time.Millisecond {
+		t.Fatalf("retry after should be 100ms, got %v %v", d, ok)
+	}
+	// other callers and routes have their own quota.
+	if _, err = l.Allow(metadata.NewContext(context.TODO(), metadata.MD{metadata.Caller: "caller2"}), route); err != nil {
+		t.Fatalf("request of other caller should be allowed, %v", err)
+	}
+	if _, err = l.Allow(ctx, limit.WithRoute("/other")); err != nil {
+		t.Fatalf("request of other route should be allowed, %v", err)
+	}
+	// requests without caller are not limited.
+	for i := 0; i < 5; i++ {
+		if _, err = l.Allow(context.TODO(), route); err != nil {
+			t.Fatalf("request without key should not be limited, %v", err)
+		}
+	}
+
+	s.SetTime(now.Add(100 * time.Millisecond))
+	if _, err = l.Allow(ctx, route); err != nil {
+		t.Fatalf("request should be allowed after retry delay, %v", err)
+	}
+	if _, err = l.Allow(ctx, route); err == nil {
+		t.Fatalf("request over quota should be limited")
+	}
+
+	// requests are allowed if redis fails.
+	s.Close()
+	if _, err = l.Allow(ctx, route); err != nil {
+		t.Fatalf("request should be allowed if redis fails, %v", err)
+	}
+}
+
+func TestSharedQuota(t *testing.T) {
+	s, r := newTestRedis(t)
+	s.SetTime(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := &Config{Name: "shared", Rate: 5, Period: xtime.Duration(time.Minute)}
+	// limiters of different instances share the quota of the same key.
+	l1, l2 := New(r, c, nil), New(r, c, nil)
+	var passed int
+	for i := 0; i < 10; i++ {
+		l := l1
+		if i%2 == 1 {
+			l = l2
+		}
+		if _, err := l.Allow(context.TODO(), limit.WithRoute("/demo")); err == nil {
+			passed++
+		}
+	}
+	if passed != 5 {
+		t.Fatalf("shared quota should pass 5 requests, got %d", passed)
+	}
+}
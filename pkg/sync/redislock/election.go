@@ -0,0 +1,39 @@
+package redislock
+
+import (
+	"context"
+
+	"kratos/pkg/log"
+)
+
+// Elect campaigns for the leadership of key until ctx is done.
+//
+// fn is called every time the leadership is obtained, with the fencing token
+// and a context canceled when the leadership is lost or ctx is done. After fn
+// returns, the leadership is released and the campaign goes on unless ctx is
+// done, so fn should block while it works as the leader.
+func (c *Client) Elect(ctx context.Context, key string, fn func(ctx context.Context, token int64)) error {
+	for {
+		l, err := c.Lock(ctx, key)
+		if err != nil {
+			return err
+		}
+		lctx, cancel := context.WithCancel(ctx)
+		go func() {
+			select {
+			case <-l.Done():
+				cancel()
+			case <-lctx.Done():
+			}
+		}()
+		fn(lctx, l.Token())
+		cancel()
+		// NOTE: ctx may be done already, release with a fresh context.
+		if err = l.Unlock(context.Background()); err != nil && err != ErrNotHeld {
+			log.Error("redislock: release leadership(%s) error(%v)", key, err)
+		}
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
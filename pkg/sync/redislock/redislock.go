@@ -0,0 +1,252 @@
+// Package redislock provides distributed locks with fencing tokens and leader election on redis.
+//
+// A lock of key uses the redis keys "{key}:lock" and "{key}:fence", they share
+// the hash tag so the lock works on redis cluster too. The fence counter is
+// increased on every acquire, resources guarded by the lock should reject
+// writes with a token less than the latest one they have seen.
+package redislock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"kratos/pkg/cache/redis"
+	"kratos/pkg/log"
+	"kratos/pkg/net/netutil"
+	xtime "kratos/pkg/time"
+)
+
+var (
+	// ErrNotObtained is returned when the lock is held by others.
+	ErrNotObtained = errors.New("redislock: lock not obtained")
+	// ErrNotHeld is returned when the lock is released or the lease is lost.
+	ErrNotHeld = errors.New("redislock: lock not held")
+)
+
+var (
+	_obtainScript = redis.NewScript(2, `
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return redis.call("INCR", KEYS[2])
+end
+return 0`)
+	_renewScript = redis.NewScript(1, `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+	_releaseScript = redis.NewScript(1, `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// _defaultBackoff is the backoff of blocking acquire.
+var _defaultBackoff = netutil.BackoffConfig{
+	MaxDelay:  time.Second,
+	BaseDelay: 50 * time.Millisecond,
+	Factor:    1.6,
+	Jitter:    0.2,
+}
+
+// Config is the lock config.
+type Config struct {
+	// TTL is the lease of lock, the lease is renewed every TTL/3 while the lock is held, default 10s.
+	TTL xtime.Duration
+	// Backoff is the retry backoff of blocking Lock.
+	Backoff *netutil.BackoffConfig
+}
+
+// Client obtains locks from redis.
+type Client struct {
+	r       *redis.Redis
+	ttl     time.Duration
+	backoff netutil.Backoff
+}
+
+// New new a lock client.
+func New(r *redis.Redis, c *Config) *Client {
+	if c == nil {
+		c = &Config{}
+	}
+	cli := &Client{r: r, ttl: time.Duration(c.TTL), backoff: c.Backoff}
+	if cli.ttl <= 0 {
+		cli.ttl = 10 * time.Second
+	}
+	if c.Backoff == nil {
+		cli.backoff = &_defaultBackoff
+	}
+	return cli
+}
+
+// leaseLeft returns the time until the lease started at start expires, minus
+// a margin for the clock drift of redis.
+func (c *Client) leaseLeft(start time.Time) time.Duration {
+	return time.Until(start.Add(c.ttl - c.ttl/100 - 2*time.Millisecond))
+}
+
+func (c *Client) eval(ctx context.Context, s *redis.Script, keysAndArgs ...interface{}) (int64, error) {
+	conn := c.r.Conn(ctx)
+	defer conn.Close()
+	return redis.Int64(s.Do(conn, keysAndArgs...))
+}
+
+// TryLock obtains the lock of key without blocking, ErrNotObtained is returned if it is held by others.
+func (c *Client) TryLock(ctx context.Context, key string) (*Lock, error) {
+	owner, err := newOwner()
+	if err != nil {
+		return nil, err
+	}
+	l := &Lock{
+		c:     c,
+		key:   "{" + key + "}:lock",
+		owner: owner,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	// the lease starts before SET, so the local lease never outlives the key.
+	start := time.Now()
+	if l.token, err = c.eval(ctx, _obtainScript, l.key, "{"+key+"}:fence", owner, ttlMillis(c.ttl)); err != nil {
+		return nil, err
+	}
+	if l.token == 0 {
+		return nil, ErrNotObtained
+	}
+	go l.renewproc(start)
+	return l, nil
+}
+
+// Lock obtains the lock of key, it blocks with backoff until the lock is obtained or ctx is done.
+func (c *Client) Lock(ctx context.Context, key string) (*Lock, error) {
+	for retries := 0; ; retries++ {
+		l, err := c.TryLock(ctx, key)
+		if err == nil {
+			return l, nil
+		}
+		if err != ErrNotObtained {
+			log.Errorc(ctx, "redislock: obtain lock(%s) error(%v)", key, err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(c.backoff.Backoff(retries)):
+		}
+	}
+}
+
+// Lock is an obtained lock, the lease is renewed automatically until Unlock or the lease is lost.
+type Lock struct {
+	c     *Client
+	key   string
+	owner string
+	token int64
+
+	once sync.Once
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Token returns the fencing token of lock, tokens of a key increase monotonically.
+func (l *Lock) Token() int64 {
+	return l.token
+}
+
+// Done returns a channel that's closed when the lock is released or the lease is lost.
+func (l *Lock) Done() <-chan struct{} {
+	return l.done
+}
+
+type renewResult struct {
+	start time.Time
+	ok    int64
+	err   error
+}
+
+// renewproc renews the lease every ttl/3, the lock is lost if the key is not
+// owned any more or the lease expires. The lease timer runs independently of
+// renewing, so the lock is lost in time even if redis hangs.
+func (l *Lock) renewproc(start time.Time) {
+	defer close(l.done)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lease := time.NewTimer(l.c.leaseLeft(start))
+	defer lease.Stop()
+	ticker := time.NewTicker(l.c.ttl / 3)
+	defer ticker.Stop()
+	var (
+		renewing bool
+		results  = make(chan renewResult, 1)
+	)
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-lease.C:
+			log.Warn("redislock: lock(%s) lease expired", l.key)
+			return
+		case <-ticker.C:
+			if renewing {
+				continue
+			}
+			renewing = true
+			go func() {
+				res := renewResult{start: time.Now()}
+				rctx, rcancel := context.WithTimeout(ctx, l.c.ttl/3)
+				res.ok, res.err = l.c.eval(rctx, _renewScript, l.key, l.owner, ttlMillis(l.c.ttl))
+				rcancel()
+				results <- res
+			}()
+		case res := <-results:
+			renewing = false
+			if res.err != nil {
+				log.Error("redislock: renew lock(%s) error(%v)", l.key, res.err)
+				continue
+			}
+			if res.ok == 0 {
+				log.Warn("redislock: lock(%s) lease lost", l.key)
+				return
+			}
+			if !lease.Stop() {
+				<-lease.C
+			}
+			lease.Reset(l.c.leaseLeft(res.start))
+		}
+	}
+}
+
+// Unlock releases the lock, ErrNotHeld is returned if the lease is lost already.
+func (l *Lock) Unlock(ctx context.Context) error {
+	released := false
+	l.once.Do(func() {
+		close(l.stop)
+		released = true
+	})
+	if !released {
+		return ErrNotHeld
+	}
+	<-l.done
+	ok, err := l.c.eval(ctx, _releaseScript, l.key, l.owner)
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+func newOwner() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func ttlMillis(d time.Duration) int64 {
+	return int64(d / time.Millisecond)
+}
@@ -0,0 +1,182 @@
+package redislock
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"kratos/pkg/cache/redis"
+	"kratos/pkg/cache/redis/redistest"
+	"kratos/pkg/net/netutil"
+	xtime "kratos/pkg/time"
+)
+
+func newTestClient(t *testing.T, ttl time.Duration) (*miniredis.Miniredis, *Client) {
+	s, conf := redistest.New(t)
+	r := redis.NewRedis(conf)
+	t.Cleanup(func() { r.Close() })
+	return s, New(r, &Config{
+		TTL:     xtime.Duration(ttl),
+		Backoff: &netutil.BackoffConfig{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond, Factor: 1.6},
+	})
+}
+
+func TestLock(t *testing.T) {
+	s, c := newTestClient(t, time.Second)
+	ctx := context.TODO()
+
+	l1, err := c.TryLock(ctx, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l1.Token() != 1 {
+		t.Fatalf("first token should be 1, got %d", l1.Token())
+	}
+	if _, err = c.TryLock(ctx, "job"); err != ErrNotObtained {
+		t.Fatalf("lock held by others should not be obtained, %v", err)
+	}
+	if ttl := s.TTL("{job}:lock"); ttl != time.Second {
+		t.Fatalf("lease should be 1s, got %v", ttl)
+	}
+	if err = l1.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err = l1.Unlock(ctx); err != ErrNotHeld {
+		t.Fatalf("unlock twice should be ErrNotHeld, %v", err)
+	}
+	l2, err := c.TryLock(ctx, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l2.Token() <= l1.Token() {
+		t.Fatalf("fencing token should increase, %d <= %d", l2.Token(), l1.Token())
+	}
+
+	// blocking lock waits for the holder.
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		l2.Unlock(ctx)
+	}()
+	l3, err := c.Lock(ctx, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l3.Unlock(ctx)
+	tctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err = c.Lock(tctx, "job"); err != context.DeadlineExceeded {
+		t.Fatalf("lock should be canceled by context, %v", err)
+	}
+}
+
+func TestRenew(t *testing.T) {
+	s, c := newTestClient(t, 300*time.Millisecond)
+	ctx := context.TODO()
+
+	l, err := c.TryLock(ctx, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// NOTE: miniredis does not expire keys by wall clock, the lease is checked by pttl.
+	s.FastForward(250 * time.Millisecond)
+	time.Sleep(150 * time.Millisecond)
+	if ttl := s.TTL("{job}:lock"); ttl <= 50*time.Millisecond {
+		t.Fatalf("lease should be renewed, ttl %v", ttl)
+	}
+	select {
+	case <-l.Done():
+		t.Fatalf("lock should be held")
+	default:
+	}
+
+	// the lease is lost once the key is taken by others.
+	s.Set("{job}:lock", "others")
+	select {
+	case <-l.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("lost lease should be detected")
+	}
+	if err = l.Unlock(ctx); err != ErrNotHeld {
+		t.Fatalf("unlock lost lock should be ErrNotHeld, %v", err)
+	}
+	if v, _ := s.Get("{job}:lock"); v != "others" {
+		t.Fatalf("lock of others should not be released, got %s", v)
+	}
+}
+
+func TestLeaseExpire(t *testing.T) {
+	s, c := newTestClient(t, 300*time.Millisecond)
+	start := time.Now()
+	l, err := c.TryLock(context.TODO(), "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// redis hangs: the address accepts connections but never replies.
+	addr := s.Addr()
+	s.Close()
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	select {
+	case <-l.Done():
+		if elapsed := time.Since(start); elapsed >= 300*time.Millisecond {
+			t.Fatalf("lock should be lost before the lease expires, elapsed %v", elapsed)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expired lease should be detected")
+	}
+}
+
+func TestElect(t *testing.T) {
+	s, c := newTestClient(t, 300*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var (
+		tokens = make(chan int64, 10)
+		wg     sync.WaitGroup
+	)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Elect(ctx, "leader", func(ctx context.Context, token int64) {
+				tokens <- token
+				<-ctx.Done()
+			})
+		}()
+	}
+	first := <-tokens
+	// the lease is renewed, so the others keep campaigning.
+	select {
+	case token := <-tokens:
+		t.Fatalf("only one leader is allowed, got token %d", token)
+	case <-time.After(500 * time.Millisecond):
+	}
+	// the leader steps down when the lease is lost, and another campaigner takes over.
+	s.Del("{leader}:lock")
+	select {
+	case second := <-tokens:
+		if second <= first {
+			t.Fatalf("token of new leader should increase, %d <= %d", second, first)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("new leader should be elected")
+	}
+	cancel()
+	wg.Wait()
+}
@@ -0,0 +1,187 @@
+package main
+
+// list/zset存储 值为切片 每个元素单独编码 写入时整体替换
+var _collectionTemplates = map[string]string{
+	"single_get": _collectionSingleGetTemplate,
+	"single_set": _collectionSingleSetTemplate,
+	"single_del": _singleDelTemplate,
+	"multi_get":  _collectionMultiGetTemplate,
+	"multi_set":  _collectionMultiSetTemplate,
+	"multi_del":  _multiDelTemplate,
+	"none_get":   noneTemplate(_collectionSingleGetTemplate),
+	"none_set":   noneTemplate(_collectionSingleSetTemplate),
+	"none_del":   noneTemplate(_singleDelTemplate),
+}
+
+var _collectionSingleGetTemplate = `
+// NAME {{or .Comment "get data from redis"}}
+func (d *{{.StructName}}) NAME(c context.Context, id KEY {{.ExtraArgsType}}) (res VALUE, err error) {
+	key := {{.KeyMethod}}(id{{.ExtraArgs}})
+	var vs []string
+	if vs, err = CLIENT.{{.RangeMethod}}(c, key, 0, -1).Result(); err != nil {
+		log.Errorv(c, log.KV("NAME", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	if len(vs) == 0 {
+		cache.MetricMisses.Inc("redis:NAME")
+		return
+	}
+	res = make(VALUE, 0, len(vs))
+	for _, v := range vs {
+		DECODE
+		if err != nil {
+			log.Errorv(c, log.KV("NAME", fmt.Sprintf("%+v", err)), log.KV("key", key))
+			return nil, err
+		}
+		res = append(res, val)
+	}
+	cache.MetricHits.Inc("redis:NAME")
+	return
+}
+`
+
+var _collectionSingleSetTemplate = `
+// NAME {{or .Comment "set data to redis"}}
+func (d *{{.StructName}}) NAME(c context.Context, id KEY, val VALUE {{.ExtraArgsType}}) (err error) {
+	if len(val) == 0 {
+		return
+	}
+	key := {{.KeyMethod}}(id{{.ExtraArgs}})
+	{{if eq .RangeMethod "LRange"}}
+		members := make([]interface{}, 0, len(val))
+	{{else}}
+		members := make([]*xredis.Z, 0, len(val))
+	{{end}}
+	for _, v := range val {
+		ENCODE
+		{{if eq .RangeMethod "LRange"}}
+			members = append(members, bs)
+		{{else}}
+			members = append(members, &xredis.Z{Score: float64({{.ScoreCode}}), Member: bs})
+		{{end}}
+	}
+	expire := {{.ExpireCode}}
+	{{if .EnableNullCode}}
+		if {{.CheckNullCode}} {
+			expire = {{.ExpireNullCode}}
+		}
+	{{end}}
+	if _, err = CLIENT.TxPipelined(c, func(p xredis.Pipeliner) error {
+		p.Del(c, key)
+		{{if eq .RangeMethod "LRange"}}
+			p.RPush(c, key, members...)
+		{{else}}
+			p.ZAdd(c, key, members...)
+		{{end}}
+		p.Expire(c, key, expire)
+		return nil
+	}); err != nil {
+		log.Errorv(c, log.KV("NAME", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	return
+}
+`
+
+var _collectionMultiGetTemplate = `
+// NAME {{or .Comment "get data from redis"}}
+func (d *{{.StructName}}) NAME(c context.Context, ids []KEY {{.ExtraArgsType}}) (res map[KEY]VALUE, err error) {
+	l := len(ids)
+	if l == 0 {
+		return
+	}
+	keys := make([]string, 0, l)
+	pipe := CLIENT.Pipeline()
+	cmds := make([]*xredis.StringSliceCmd, 0, l)
+	for _, id := range ids {
+		key := {{.KeyMethod}}(id{{.ExtraArgs}})
+		keys = append(keys, key)
+		cmds = append(cmds, pipe.{{.RangeMethod}}(c, key, 0, -1))
+	}
+	if _, err = pipe.Exec(c); err != nil {
+		log.Errorv(c, log.KV("NAME", fmt.Sprintf("%+v", err)), log.KV("keys", keys))
+		return
+	}
+	for i, cmd := range cmds {
+		var vs []string
+		if vs, err = cmd.Result(); err != nil {
+			log.Errorv(c, log.KV("NAME", fmt.Sprintf("%+v", err)), log.KV("key", keys[i]))
+			return
+		}
+		if len(vs) == 0 {
+			continue
+		}
+		vals := make(VALUE, 0, len(vs))
+		for _, v := range vs {
+			DECODE
+			if err != nil {
+				log.Errorv(c, log.KV("NAME", fmt.Sprintf("%+v", err)), log.KV("key", keys[i]))
+				return
+			}
+			vals = append(vals, val)
+		}
+		if res == nil {
+			res = make(map[KEY]VALUE, l)
+		}
+		res[ids[i]] = vals
+	}
+	cache.MetricHits.Add(float64(len(res)), "redis:NAME")
+	cache.MetricMisses.Add(float64(l-len(res)), "redis:NAME")
+	return
+}
+`
+
+var _collectionMultiSetTemplate = `
+// NAME {{or .Comment "set data to redis"}}
+func (d *{{.StructName}}) NAME(c context.Context, values map[KEY]VALUE {{.ExtraArgsType}}) (err error) {
+	if len(values) == 0 {
+		return
+	}
+	pipe := CLIENT.Pipeline()
+	for id, val := range values {
+		if len(val) == 0 {
+			continue
+		}
+		key := {{.KeyMethod}}(id{{.ExtraArgs}})
+		{{if eq .RangeMethod "LRange"}}
+			members := make([]interface{}, 0, len(val))
+		{{else}}
+			members := make([]*xredis.Z, 0, len(val))
+		{{end}}
+		for _, v := range val {
+			ENCODE
+			{{if eq .RangeMethod "LRange"}}
+				members = append(members, bs)
+			{{else}}
+				members = append(members, &xredis.Z{Score: float64({{.ScoreCode}}), Member: bs})
+			{{end}}
+		}
+		expire := {{.ExpireCode}}
+		{{if .EnableNullCode}}
+			if {{.CheckNullCode}} {
+				expire = {{.ExpireNullCode}}
+			}
+		{{end}}
+		pipe.Del(c, key)
+		{{if eq .RangeMethod "LRange"}}
+			pipe.RPush(c, key, members...)
+		{{else}}
+			pipe.ZAdd(c, key, members...)
+		{{end}}
+		pipe.Expire(c, key, expire)
+	}
+	var cmds []xredis.Cmder
+	if cmds, err = pipe.Exec(c); err == nil {
+		for _, cmd := range cmds {
+			if err = cmd.Err(); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		log.Errorv(c, log.KV("NAME", fmt.Sprintf("%+v", err)))
+		return
+	}
+	return
+}
+`
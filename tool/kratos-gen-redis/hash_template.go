@@ -0,0 +1,173 @@
+package main
+
+// hash存储 key由额外参数生成 id作为field
+var _hashTemplates = map[string]string{
+	"single_get": _hashSingleGetTemplate,
+	"single_set": _hashSingleSetTemplate,
+	"single_del": _hashSingleDelTemplate,
+	"multi_get":  _hashMultiGetTemplate,
+	"multi_set":  _hashMultiSetTemplate,
+	"multi_del":  _hashMultiDelTemplate,
+}
+
+var _hashSingleGetTemplate = `
+// NAME {{or .Comment "get data from redis hash"}}
+func (d *{{.StructName}}) NAME(c context.Context, id KEY {{.ExtraArgsType}}) (res VALUE, err error) {
+	key := {{.KeyMethod}}({{.KeyArgs}})
+	field := {{.FieldMethod}}(id)
+	var v string
+	if v, err = CLIENT.HGet(c, key, field).Result(); err != nil {
+		if err == xredis.ErrNil {
+			err = nil
+			cache.MetricMisses.Inc("redis:NAME")
+			return
+		}
+		log.Errorv(c, log.KV("NAME", fmt.Sprintf("%+v", err)), log.KV("key", key), log.KV("field", field))
+		return
+	}
+	DECODE
+	if err != nil {
+		log.Errorv(c, log.KV("NAME", fmt.Sprintf("%+v", err)), log.KV("key", key), log.KV("field", field))
+		return
+	}
+	cache.MetricHits.Inc("redis:NAME")
+	res = val
+	return
+}
+`
+
+var _hashSingleSetTemplate = `
+// NAME {{or .Comment "set data to redis hash"}}
+func (d *{{.StructName}}) NAME(c context.Context, id KEY, val VALUE {{.ExtraArgsType}}) (err error) {
+	{{if .PointType}}
+		if val == nil {
+			return
+		}
+	{{end}}
+	{{if .LenType}}
+		if len(val) == 0 {
+			return
+		}
+	{{end}}
+	key := {{.KeyMethod}}({{.KeyArgs}})
+	field := {{.FieldMethod}}(id)
+	ENCODE
+	if _, err = CLIENT.TxPipelined(c, func(p xredis.Pipeliner) error {
+		p.{{.SetMethod}}(c, key, field, bs)
+		p.Expire(c, key, {{.ExpireCode}})
+		return nil
+	}); err != nil {
+		log.Errorv(c, log.KV("NAME", fmt.Sprintf("%+v", err)), log.KV("key", key), log.KV("field", field))
+		return
+	}
+	return
+}
+`
+
+var _hashSingleDelTemplate = `
+// NAME {{or .Comment "delete data from redis hash"}}
+func (d *{{.StructName}}) NAME(c context.Context, id KEY {{.ExtraArgsType}}) (err error) {
+	key := {{.KeyMethod}}({{.KeyArgs}})
+	field := {{.FieldMethod}}(id)
+	if err = CLIENT.HDel(c, key, field).Err(); err != nil {
+		log.Errorv(c, log.KV("NAME", fmt.Sprintf("%+v", err)), log.KV("key", key), log.KV("field", field))
+		return
+	}
+	return
+}
+`
+
+var _hashMultiGetTemplate = `
+// NAME {{or .Comment "get data from redis hash"}}
+func (d *{{.StructName}}) NAME(c context.Context, ids []KEY {{.ExtraArgsType}}) (res map[KEY]VALUE, err error) {
+	l := len(ids)
+	if l == 0 {
+		return
+	}
+	key := {{.KeyMethod}}({{.KeyArgs}})
+	fields := make([]string, 0, l)
+	for _, id := range ids {
+		fields = append(fields, {{.FieldMethod}}(id))
+	}
+	var replies []interface{}
+	if replies, err = CLIENT.HMGet(c, key, fields...).Result(); err != nil {
+		log.Errorv(c, log.KV("NAME", fmt.Sprintf("%+v", err)), log.KV("key", key), log.KV("fields", fields))
+		return
+	}
+	for i, reply := range replies {
+		bs, ok := reply.([]byte)
+		if !ok {
+			continue
+		}
+		v := string(bs)
+		DECODE
+		if err != nil {
+			log.Errorv(c, log.KV("NAME", fmt.Sprintf("%+v", err)), log.KV("key", key), log.KV("field", fields[i]))
+			return
+		}
+		if res == nil {
+			res = make(map[KEY]VALUE, l)
+		}
+		res[ids[i]] = val
+	}
+	cache.MetricHits.Add(float64(len(res)), "redis:NAME")
+	cache.MetricMisses.Add(float64(l-len(res)), "redis:NAME")
+	return
+}
+`
+
+var _hashMultiSetTemplate = `
+// NAME {{or .Comment "set data to redis hash"}}
+func (d *{{.StructName}}) NAME(c context.Context, values map[KEY]VALUE {{.ExtraArgsType}}) (err error) {
+	if len(values) == 0 {
+		return
+	}
+	key := {{.KeyMethod}}({{.KeyArgs}})
+	args := make([]interface{}, 0, len(values)*2)
+	for id, val := range values {
+		{{if .PointType}}
+			if val == nil {
+				continue
+			}
+		{{end}}
+		{{if .LenType}}
+			if len(val) == 0 {
+				continue
+			}
+		{{end}}
+		ENCODE
+		args = append(args, {{.FieldMethod}}(id), bs)
+	}
+	if len(args) == 0 {
+		return
+	}
+	if _, err = CLIENT.TxPipelined(c, func(p xredis.Pipeliner) error {
+		p.HMSet(c, key, args...)
+		p.Expire(c, key, {{.ExpireCode}})
+		return nil
+	}); err != nil {
+		log.Errorv(c, log.KV("NAME", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	return
+}
+`
+
+var _hashMultiDelTemplate = `
+// NAME {{or .Comment "delete data from redis hash"}}
+func (d *{{.StructName}}) NAME(c context.Context, ids []KEY {{.ExtraArgsType}}) (err error) {
+	if len(ids) == 0 {
+		return
+	}
+	key := {{.KeyMethod}}({{.KeyArgs}})
+	fields := make([]string, 0, len(ids))
+	for _, id := range ids {
+		fields = append(fields, {{.FieldMethod}}(id))
+	}
+	if err = CLIENT.HDel(c, key, fields...).Err(); err != nil {
+		log.Errorv(c, log.KV("NAME", fmt.Sprintf("%+v", err)), log.KV("key", key), log.KV("fields", fields))
+		return
+	}
+	return
+}
+`
@@ -0,0 +1,64 @@
+package main
+
+var _headerTemplate = `
+// Code generated by kratos tool genredis. DO NOT EDIT.
+
+NEWLINE
+/*
+  Package {{.PkgName}} is a generated redis cache package.
+  It is generated from:
+  ARGS
+*/
+NEWLINE
+
+package {{.PkgName}}
+
+import (
+	"context"
+	"fmt"
+	{{if .UseStrConv}}"strconv"{{end}}
+	{{if .EnableBatch }}"sync"{{end}}
+	{{if .UseTime}}"time"{{end}}
+NEWLINE
+	{{if .UseCache}}"kratos/pkg/cache"{{end}}
+	{{if .UseXRedis}}"kratos/pkg/cache/xredis"{{end}}
+	{{if .EnableBatch }}"kratos/pkg/sync/errgroup"{{end}}
+	"kratos/pkg/log"
+	{{.ImportPackage}}
+)
+
+var _ _redis
+`
+
+// _decodeTemplate 将redis中读取的字符串v解码为元素类型的val 失败时设置err
+var _decodeTemplate = `
+	{{if .GetDirectValue}}
+		val := ELEM(v)
+	{{else if .GetSimpleValue}}
+		var val ELEM
+		if r, e := {{.ConvertBytes2Value}}; e != nil {
+			err = e
+		} else {
+			val = ELEM(r)
+		}
+	{{else if .ElemPointType}}
+		val := &{{.OriginValueType}}{}
+		err = xredis.Unmarshal([]byte(v), val, {{.Encode}})
+	{{else}}
+		var val ELEM
+		err = xredis.Unmarshal([]byte(v), &val, {{.Encode}})
+	{{end}}
+`
+
+// _encodeTemplate 将元素类型的变量SRC编码为写入redis的bs 失败时记录日志并返回
+var _encodeTemplate = `
+	{{if .SimpleValue}}
+		bs := {{.ConvertValue2Bytes}}
+	{{else}}
+		var bs []byte
+		if bs, err = xredis.Marshal(SRC, {{.Encode}}); err != nil {
+			log.Errorv(c, log.KV("NAME", fmt.Sprintf("%+v", err)), log.KV("key", key))
+			return
+		}
+	{{end}}
+`
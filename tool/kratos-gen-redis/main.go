@@ -0,0 +1,667 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"go/ast"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/template"
+
+	common "kratos/tool/pkg"
+)
+
+var (
+	encode        = flag.String("encode", "", "encode type: json/pb/raw/gob/gzip")
+	redisType     = flag.String("type", "", "type: get/set/del/replace/only_add")
+	key           = flag.String("key", "", "key name method")
+	expire        = flag.String("expire", "", "expire time code")
+	structName    = flag.String("struct_name", "dao", "struct name")
+	client        = flag.String("client", "", "redis client code")
+	storage       = flag.String("storage", "string", "storage type: string/hash/zset/list")
+	field         = flag.String("field", "", "hash field name method")
+	score         = flag.String("score", "", "zset score code")
+	order         = flag.String("order", "asc", "zset order: asc/desc")
+	batchSize     = flag.Int("batch", 0, "batch size")
+	batchErr      = flag.String("batch_err", "break", "batch err to continue or break")
+	maxGroup      = flag.Int("max_group", 0, "max group size")
+	checkNullCode = flag.String("check_null_code", "", "check null code")
+	nullExpire    = flag.String("null_expire", "", "null cache expire time code")
+
+	redisValidTypes    = []string{"set", "replace", "del", "get", "only_add"}
+	redisValidPrefix   = []string{"set", "replace", "del", "get", "cache", "add"}
+	redisValidStorages = []string{_storageString, _storageHash, _storageZSet, _storageList}
+	optionNamesMap     = map[string]bool{"batch": true, "max_group": true, "encode": true, "type": true, "key": true, "expire": true, "batch_err": true, "struct_name": true, "check_null_code": true, "null_expire": true,
+		"client": true, "storage": true, "field": true, "score": true, "order": true}
+	simpleTypes = []string{"int", "int8", "int16", "int32", "int64", "float32", "float64", "uint", "uint8", "uint16", "uint32", "uint64", "bool", "string", "[]byte"}
+	lenTypes    = []string{"[]", "map"}
+)
+
+const (
+	_interfaceName = "_redis"
+	_multiTpl      = 1
+	_singleTpl     = 2
+	_noneTpl       = 3
+	_typeGet       = "get"
+	_typeSet       = "set"
+	_typeDel       = "del"
+	_typeReplace   = "replace"
+	_typeAdd       = "only_add"
+	_storageString = "string"
+	_storageHash   = "hash"
+	_storageZSet   = "zset"
+	_storageList   = "list"
+)
+
+func resetFlag() {
+	*encode = ""
+	*redisType = ""
+	*batchSize = 0
+	*maxGroup = 0
+	*batchErr = "break"
+	*checkNullCode = ""
+	*nullExpire = ""
+	*structName = "dao"
+	*client = ""
+	*storage = _storageString
+	*field = ""
+	*score = ""
+	*order = "asc"
+}
+
+// options options
+type options struct {
+	name      string
+	keyType   string
+	ValueType string
+	// 元素类型 list/zset存储时为切片的元素类型 其他存储时与ValueType相同
+	ElemType    string
+	template    int
+	SimpleValue bool
+	// int float 类型
+	GetSimpleValue bool
+	// string, []byte类型
+	GetDirectValue     bool
+	ConvertValue2Bytes string
+	ConvertBytes2Value string
+	ImportPackage      string
+	importPackages     []string
+	Args               string
+	PkgName            string
+	ExtraArgsType      string
+	ExtraArgs          string
+	KeyArgs            string
+	RedisType          string
+	Storage            string
+	KeyMethod          string
+	FieldMethod        string
+	ScoreCode          string
+	RangeMethod        string
+	SetMethod          string
+	Client             string
+	ExpireCode         string
+	Encode             string
+	OriginValueType    string
+	UseStrConv         bool
+	UseTime            bool
+	UseCache           bool
+	UseXRedis          bool
+	Comment            string
+	GroupSize          int
+	MaxGroup           int
+	EnableBatch        bool
+	BatchErrBreak      bool
+	LenType            bool
+	PointType          bool
+	ElemPointType      bool
+	StructName         string
+	CheckNullCode      string
+	ExpireNullCode     string
+	EnableNullCode     bool
+}
+
+func getOptions(opt *options, comment string) {
+	os.Args = []string{os.Args[0]}
+	if regexp.MustCompile(`\s+//\s*redis:.+`).Match([]byte(comment)) {
+		args := strings.Split(common.RegexpReplace(`//\s*redis:(?P<arg>.+)`, comment, "$arg"), " ")
+		for _, arg := range args {
+			arg = strings.TrimSpace(arg)
+			if arg != "" {
+				// validate option name
+				argName := common.RegexpReplace(`-(?P<name>[\w_-]+)=.+`, arg, "$name")
+				if !optionNamesMap[argName] {
+					log.Fatalf("选项:%s 不存在 请检查拼写\n", argName)
+				}
+				os.Args = append(os.Args, arg)
+			}
+		}
+	}
+	resetFlag()
+	flag.Parse()
+	if *redisType != "" {
+		opt.RedisType = *redisType
+	}
+	if *key != "" {
+		opt.KeyMethod = *key
+	}
+	if *expire != "" {
+		opt.ExpireCode = *expire
+	}
+	if *client != "" {
+		opt.Client = *client
+	}
+	if *field != "" {
+		opt.FieldMethod = *field
+	}
+	opt.Storage = *storage
+	opt.ScoreCode = *score
+	opt.RangeMethod = "LRange"
+	if opt.Storage == _storageZSet {
+		opt.RangeMethod = "ZRange"
+		if *order == "desc" {
+			opt.RangeMethod = "ZRevRange"
+		}
+	}
+	opt.EnableBatch = (*batchSize != 0) && (*maxGroup != 0)
+	opt.BatchErrBreak = *batchErr == "break"
+	opt.GroupSize = *batchSize
+	opt.MaxGroup = *maxGroup
+	opt.StructName = *structName
+	opt.CheckNullCode = *checkNullCode
+	if *nullExpire != "" {
+		opt.ExpireNullCode = *nullExpire
+	}
+	if opt.CheckNullCode != "" {
+		opt.EnableNullCode = true
+	}
+}
+
+func getTypeFromPrefix(opt *options, params []*ast.Field, s *common.Source) {
+	if opt.RedisType == "" {
+		for _, t := range redisValidPrefix {
+			if strings.HasPrefix(strings.ToLower(opt.name), t) {
+				if t == "add" {
+					t = _typeSet
+				}
+				opt.RedisType = t
+				break
+			}
+		}
+		if opt.RedisType == "" {
+			log.Fatalln(opt.name + "请指定方法类型(type=get/set/del...)")
+		}
+	}
+	if opt.RedisType == "cache" {
+		opt.RedisType = _typeGet
+	}
+	if len(params) == 0 {
+		log.Fatalln(opt.name + "参数不足")
+	}
+	for _, p := range params {
+		if len(p.Names) > 1 {
+			log.Fatalln(opt.name + "不支持省略类型 请写全声明中的字段类型名称")
+		}
+	}
+	if s.ExprString(params[0].Type) != "context.Context" {
+		log.Fatalln(opt.name + "第一个参数必须为context")
+	}
+}
+
+func isSetType(t string) bool {
+	return t == _typeSet || t == _typeAdd || t == _typeReplace
+}
+
+func processList(s *common.Source, list *ast.Field) (opt options) {
+	src := s.Src
+	fset := s.Fset
+	lines := strings.Split(src, "\n")
+	opt = options{Args: s.GetDef(_interfaceName), importPackages: s.Packages(list)}
+	opt.name = list.Names[0].Name
+	opt.KeyMethod = "key" + opt.name
+	opt.FieldMethod = "fmt.Sprint"
+	opt.Client = "d.redis"
+	opt.ExpireCode = "d.redis" + opt.name + "Expire"
+	opt.ExpireNullCode = "5 * time.Minute" // 默认5分钟
+	// get comment
+	line := fset.Position(list.Pos()).Line - 3
+	if len(lines)-1 >= line {
+		comment := lines[line]
+		opt.Comment = common.RegexpReplace(`\s+//(?P<name>.+)`, comment, "$name")
+		opt.Comment = strings.TrimSpace(opt.Comment)
+	}
+	// get options
+	line = fset.Position(list.Pos()).Line - 2
+	comment := lines[line]
+	getOptions(&opt, comment)
+	// get type from prefix
+	params := list.Type.(*ast.FuncType).Params.List
+	getTypeFromPrefix(&opt, params, s)
+	// get template
+	if len(params) == 1 {
+		opt.template = _noneTpl
+	} else if (len(params) == 2) && isSetType(opt.RedisType) {
+		if _, ok := params[1].Type.(*ast.MapType); ok {
+			opt.template = _multiTpl
+		} else {
+			opt.template = _noneTpl
+		}
+	} else {
+		if _, ok := params[1].Type.(*ast.ArrayType); ok {
+			opt.template = _multiTpl
+		} else if _, ok := params[1].Type.(*ast.MapType); ok {
+			opt.template = _multiTpl
+		} else {
+			opt.template = _singleTpl
+		}
+	}
+	// extra args
+	if len(params) > 2 {
+		args := []string{""}
+		allArgs := []string{""}
+		var pos = 2
+		if isSetType(opt.RedisType) {
+			pos = 3
+		}
+		if opt.template == _multiTpl && isSetType(opt.RedisType) {
+			pos = 2
+		}
+		for _, pa := range params[pos:] {
+			paType := s.ExprString(pa.Type)
+			if len(pa.Names) == 0 {
+				args = append(args, paType)
+				allArgs = append(allArgs, paType)
+				continue
+			}
+			var names []string
+			for _, name := range pa.Names {
+				names = append(names, name.Name)
+			}
+			allArgs = append(allArgs, strings.Join(names, ",")+" "+paType)
+			args = append(args, strings.Join(names, ","))
+		}
+		if len(args) > 1 {
+			opt.ExtraArgs = strings.Join(args, ",")
+			opt.ExtraArgsType = strings.Join(allArgs, ",")
+			opt.KeyArgs = strings.Join(args[1:], ",")
+		}
+	}
+	results := list.Type.(*ast.FuncType).Results.List
+	getKeyValueType(&opt, params, results, s)
+	return
+}
+
+func getKeyValueType(opt *options, params, results []*ast.Field, s *common.Source) {
+	// check
+	if s.ExprString(results[len(results)-1].Type) != "error" {
+		log.Fatalln("最后返回值参数需为error")
+	}
+	for _, res := range results {
+		if len(res.Names) > 1 {
+			log.Fatalln(opt.name + "返回值不支持省略类型")
+		}
+	}
+	if opt.RedisType == _typeGet {
+		if len(results) != 2 {
+			log.Fatalln("参数个数不对")
+		}
+	}
+	// get key type and value type
+	if isSetType(opt.RedisType) {
+		if opt.template == _multiTpl {
+			p, ok := params[1].Type.(*ast.MapType)
+			if !ok {
+				log.Fatalf("%s: 参数类型错误 批量设置数据时类型需为map类型\n", opt.name)
+			}
+			opt.keyType = s.ExprString(p.Key)
+			opt.ValueType = s.ExprString(p.Value)
+		} else if opt.template == _singleTpl {
+			opt.keyType = s.ExprString(params[1].Type)
+			opt.ValueType = s.ExprString(params[2].Type)
+		} else {
+			opt.ValueType = s.ExprString(params[1].Type)
+		}
+	}
+	if opt.RedisType == _typeGet {
+		if opt.template == _multiTpl {
+			if p, ok := results[0].Type.(*ast.MapType); ok {
+				opt.keyType = s.ExprString(p.Key)
+				opt.ValueType = s.ExprString(p.Value)
+			} else {
+				log.Fatalf("%s: 返回值类型错误 批量获取数据时返回值需为map类型\n", opt.name)
+			}
+		} else if opt.template == _singleTpl {
+			opt.keyType = s.ExprString(params[1].Type)
+			opt.ValueType = s.ExprString(results[0].Type)
+		} else {
+			opt.ValueType = s.ExprString(results[0].Type)
+		}
+	}
+	if opt.RedisType == _typeDel {
+		if opt.template == _multiTpl {
+			p, ok := params[1].Type.(*ast.ArrayType)
+			if !ok {
+				log.Fatalf("%s: 类型错误 参数需为[]类型\n", opt.name)
+			}
+			opt.keyType = s.ExprString(p.Elt)
+		} else if opt.template == _singleTpl {
+			opt.keyType = s.ExprString(params[1].Type)
+		}
+	}
+	if opt.ValueType == "string" {
+		opt.LenType = true
+	} else {
+		for _, t := range lenTypes {
+			if strings.HasPrefix(opt.ValueType, t) {
+				opt.LenType = true
+				break
+			}
+		}
+	}
+	opt.PointType = strings.HasPrefix(opt.ValueType, "*")
+	// list/zset 按元素编码 其他存储按值编码
+	opt.ElemType = opt.ValueType
+	if (opt.Storage == _storageList || opt.Storage == _storageZSet) && opt.RedisType != _typeDel {
+		if !strings.HasPrefix(opt.ValueType, "[]") || opt.ValueType == "[]byte" {
+			log.Fatalf("%s: %s存储的值类型需为切片类型\n", opt.name, opt.Storage)
+		}
+		opt.ElemType = strings.TrimPrefix(opt.ValueType, "[]")
+	}
+	for _, t := range simpleTypes {
+		if t == opt.ElemType {
+			opt.SimpleValue = true
+			opt.GetSimpleValue = true
+			opt.ConvertValue2Bytes = convertValue2Bytes(t)
+			opt.ConvertBytes2Value = convertBytes2Value(t)
+			break
+		}
+	}
+	if opt.SimpleValue && (opt.ElemType == "[]byte" || opt.ElemType == "string") {
+		opt.GetSimpleValue = false
+		opt.GetDirectValue = true
+	}
+	if strings.HasPrefix(opt.ElemType, "*") {
+		opt.ElemPointType = true
+		opt.OriginValueType = strings.Replace(opt.ElemType, "*", "", 1)
+	} else {
+		opt.OriginValueType = opt.ElemType
+	}
+	switch {
+	case opt.Storage == _storageHash && opt.RedisType == _typeAdd:
+		opt.SetMethod = "HSetNX"
+	case opt.Storage == _storageHash:
+		opt.SetMethod = "HSet"
+	case opt.RedisType == _typeAdd:
+		opt.SetMethod = "SetNX"
+	case opt.RedisType == _typeReplace:
+		opt.SetMethod = "SetXX"
+	default:
+		opt.SetMethod = "Set"
+	}
+	if *encode != "" {
+		var flags []string
+		for _, f := range strings.Split(*encode, "|") {
+			switch f {
+			case "gob":
+				flags = append(flags, "xredis.FlagGOB")
+			case "json":
+				flags = append(flags, "xredis.FlagJSON")
+			case "raw":
+				flags = append(flags, "xredis.FlagRAW")
+			case "pb":
+				flags = append(flags, "xredis.FlagProtobuf")
+			case "gzip":
+				flags = append(flags, "xredis.FlagGzip")
+			default:
+				log.Fatalf("%s: encode类型无效\n", opt.name)
+			}
+		}
+		opt.Encode = strings.Join(flags, " | ")
+	} else {
+		if opt.SimpleValue {
+			opt.Encode = "xredis.FlagRAW"
+		} else {
+			opt.Encode = "xredis.FlagJSON"
+		}
+	}
+}
+
+func parse(s *common.Source) (opts []*options) {
+	c := s.F.Scope.Lookup(_interfaceName)
+	if (c == nil) || (c.Kind != ast.Typ) {
+		log.Fatalln("无法找到缓存声明")
+	}
+	lists := c.Decl.(*ast.TypeSpec).Type.(*ast.InterfaceType).Methods.List
+	for _, list := range lists {
+		opt := processList(s, list)
+		opt.Check()
+		opts = append(opts, &opt)
+	}
+	return
+}
+
+func (option *options) Check() {
+	var valid bool
+	for _, x := range redisValidTypes {
+		if x == option.RedisType {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		log.Fatalf("%s: 类型错误 不支持%s类型\n", option.name, option.RedisType)
+	}
+	valid = false
+	for _, x := range redisValidStorages {
+		if x == option.Storage {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		log.Fatalf("%s: 存储类型错误 不支持%s存储\n", option.name, option.Storage)
+	}
+	if (option.RedisType != _typeDel) && !option.SimpleValue && !strings.Contains(option.ElemType, "*") && !strings.Contains(option.ElemType, "[]") && !strings.Contains(option.ElemType, "map") {
+		log.Fatalf("%s: 值类型只能为基本类型/slice/map/指针类型\n", option.name)
+	}
+	if option.EnableBatch && (option.Storage != _storageString || option.RedisType != _typeGet) {
+		log.Fatalf("%s: 仅string存储的批量获取支持batch选项\n", option.name)
+	}
+	switch option.Storage {
+	case _storageHash:
+		if option.template == _noneTpl {
+			log.Fatalf("%s: hash存储需要id参数作为field\n", option.name)
+		}
+		if option.EnableNullCode {
+			log.Fatalf("%s: hash存储不支持空缓存\n", option.name)
+		}
+		if option.RedisType == _typeReplace || (option.RedisType == _typeAdd && option.template == _multiTpl) {
+			log.Fatalf("%s: hash存储不支持%s类型\n", option.name, option.RedisType)
+		}
+	case _storageZSet, _storageList:
+		if option.RedisType == _typeReplace || option.RedisType == _typeAdd {
+			log.Fatalf("%s: %s存储不支持%s类型\n", option.name, option.Storage, option.RedisType)
+		}
+		if option.Storage == _storageZSet && isSetType(option.RedisType) && option.ScoreCode == "" {
+			log.Fatalf("%s: zset存储需要指定score选项\n", option.name)
+		}
+	}
+}
+
+func genHeader(opts []*options, body string) (src string) {
+	option := options{PkgName: os.Getenv("GOPACKAGE")}
+	var packages []string
+	packagesMap := map[string]bool{`"context"`: true, `"fmt"`: true, `"strconv"`: true, `"sync"`: true, `"time"`: true}
+	for _, opt := range opts {
+		if len(opt.importPackages) > 0 {
+			for _, pkg := range opt.importPackages {
+				if pkg == `"time"` {
+					option.UseTime = true
+				}
+				if !packagesMap[pkg] {
+					packages = append(packages, pkg)
+					packagesMap[pkg] = true
+				}
+			}
+		}
+		if opt.Args != "" {
+			option.Args = opt.Args
+		}
+		if opt.EnableBatch {
+			option.EnableBatch = true
+		}
+	}
+	// 依据生成的代码判断需要引入的包
+	option.UseStrConv = regexp.MustCompile(`\bstrconv\.`).MatchString(body)
+	option.UseTime = option.UseTime || regexp.MustCompile(`\btime\.`).MatchString(body)
+	option.UseCache = regexp.MustCompile(`\bcache\.`).MatchString(body)
+	option.UseXRedis = regexp.MustCompile(`\bxredis\.`).MatchString(body)
+	option.ImportPackage = strings.Join(packages, "\n")
+	src = _headerTemplate
+	t := template.Must(template.New("header").Parse(src))
+	var buffer bytes.Buffer
+	err := t.Execute(&buffer, option)
+	if err != nil {
+		log.Fatalf("execute template: %s", err)
+	}
+	// Format the output.
+	src = strings.Replace(buffer.String(), "\t", "", -1)
+	src = regexp.MustCompile("\n+").ReplaceAllString(src, "\n")
+	src = strings.Replace(src, "NEWLINE", "", -1)
+	src = strings.Replace(src, "ARGS", option.Args, -1)
+	return
+}
+
+func getNewTemplate(option *options) (src string) {
+	var tpls map[string]string
+	switch option.Storage {
+	case _storageHash:
+		tpls = _hashTemplates
+	case _storageZSet, _storageList:
+		tpls = _collectionTemplates
+	default:
+		tpls = _stringTemplates
+	}
+	var kind string
+	switch option.template {
+	case _multiTpl:
+		kind = "multi"
+	case _singleTpl:
+		kind = "single"
+	default:
+		kind = "none"
+	}
+	typ := option.RedisType
+	if isSetType(typ) {
+		typ = _typeSet
+	}
+	return tpls[kind+"_"+typ]
+}
+
+// noneTemplate 由单个数据的模板生成无id参数的模板
+func noneTemplate(src string) string {
+	src = strings.Replace(src, "id KEY, val VALUE {{.ExtraArgsType}}", "val VALUE", -1)
+	src = strings.Replace(src, "id KEY {{.ExtraArgsType}}", "", -1)
+	src = strings.Replace(src, "(id{{.ExtraArgs}})", "()", -1)
+	return src
+}
+
+func genBody(opts []*options) (res string) {
+	for _, option := range opts {
+		src := getNewTemplate(option)
+		// list/zset 逐个编码切片元素v 其他存储编码值val
+		elem := "val"
+		if option.Storage == _storageList || option.Storage == _storageZSet {
+			elem = "v"
+		}
+		src = strings.Replace(src, "DECODE", _decodeTemplate, -1)
+		src = strings.Replace(src, "ENCODE", strings.Replace(_encodeTemplate, "SRC", elem, -1), -1)
+		option.ConvertValue2Bytes = strings.Replace(option.ConvertValue2Bytes, "$", elem, -1)
+		src = strings.Replace(src, "CLIENT", option.Client, -1)
+		src = strings.Replace(src, "KEY", option.keyType, -1)
+		src = strings.Replace(src, "NAME", option.name, -1)
+		src = strings.Replace(src, "VALUE", option.ValueType, -1)
+		src = strings.Replace(src, "ELEM", option.ElemType, -1)
+		src = strings.Replace(src, "GROUPSIZE", strconv.Itoa(option.GroupSize), -1)
+		src = strings.Replace(src, "MAXGROUP", strconv.Itoa(option.MaxGroup), -1)
+		if option.EnableNullCode {
+			option.CheckNullCode = strings.Replace(option.CheckNullCode, "$", "val", -1)
+		}
+		option.ScoreCode = strings.Replace(option.ScoreCode, "$", "v", -1)
+		t := template.Must(template.New("cache").Parse(src))
+		var buffer bytes.Buffer
+		err := t.Execute(&buffer, option)
+		if err != nil {
+			log.Fatalf("execute template: %s", err)
+		}
+		// Format the output.
+		src = strings.Replace(buffer.String(), "\t", "", -1)
+		src = regexp.MustCompile("\n+").ReplaceAllString(src, "\n")
+		res = res + "\n" + src
+	}
+	return
+}
+
+func main() {
+	log.SetFlags(0)
+	defer func() {
+		if err := recover(); err != nil {
+			buf := make([]byte, 64*1024)
+			buf = buf[:runtime.Stack(buf, false)]
+			log.Fatalf("程序解析失败, err: %+v stack: %s", err, buf)
+		}
+	}()
+	options := parse(common.NewSource(common.SourceText()))
+	body := genBody(options)
+	header := genHeader(options, body)
+	code := common.FormatCode(header + "\n" + body)
+	// Write to file.
+	dir := filepath.Dir(".")
+	outputName := filepath.Join(dir, "redis.cache.go")
+	err := ioutil.WriteFile(outputName, []byte(code), 0644)
+	if err != nil {
+		log.Fatalf("写入文件失败: %s", err)
+	}
+	log.Println("redis.cache.go: 生成成功")
+}
+
+// convertValue2Bytes 基本类型转换为写入redis的值 $为待转换的变量
+func convertValue2Bytes(t string) string {
+	switch t {
+	case "int", "int8", "int16", "int32", "int64":
+		return "strconv.FormatInt(int64($), 10)"
+	case "uint", "uint8", "uint16", "uint32", "uint64":
+		return "strconv.FormatUint(uint64($), 10)"
+	case "bool":
+		return "strconv.FormatBool($)"
+	case "float32":
+		return "strconv.FormatFloat(float64($), 'E', -1, 32)"
+	case "float64":
+		return "strconv.FormatFloat($, 'E', -1, 64)"
+	case "string", "[]byte":
+		return "$"
+	}
+	return ""
+}
+
+// convertBytes2Value 从redis读取的字符串v转换为基本类型
+func convertBytes2Value(t string) string {
+	switch t {
+	case "int", "int8", "int16", "int32", "int64":
+		return "strconv.ParseInt(v, 10, 64)"
+	case "uint", "uint8", "uint16", "uint32", "uint64":
+		return "strconv.ParseUint(v, 10, 64)"
+	case "bool":
+		return "strconv.ParseBool(v)"
+	case "float32":
+		return "strconv.ParseFloat(v, 32)"
+	case "float64":
+		return "strconv.ParseFloat(v, 64)"
+	}
+	return ""
+}
@@ -0,0 +1,242 @@
+package main
+
+var _stringTemplates = map[string]string{
+	"single_get": _singleGetTemplate,
+	"single_set": _singleSetTemplate,
+	"single_del": _singleDelTemplate,
+	"multi_get":  _multiGetTemplate,
+	"multi_set":  _multiSetTemplate,
+	"multi_del":  _multiDelTemplate,
+	"none_get":   noneTemplate(_singleGetTemplate),
+	"none_set":   noneTemplate(_singleSetTemplate),
+	"none_del":   noneTemplate(_singleDelTemplate),
+}
+
+var _singleGetTemplate = `
+// NAME {{or .Comment "get data from redis"}}
+func (d *{{.StructName}}) NAME(c context.Context, id KEY {{.ExtraArgsType}}) (res VALUE, err error) {
+	key := {{.KeyMethod}}(id{{.ExtraArgs}})
+	var v string
+	if v, err = CLIENT.Get(c, key).Result(); err != nil {
+		if err == xredis.ErrNil {
+			err = nil
+			cache.MetricMisses.Inc("redis:NAME")
+			return
+		}
+		log.Errorv(c, log.KV("NAME", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	DECODE
+	if err != nil {
+		log.Errorv(c, log.KV("NAME", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	cache.MetricHits.Inc("redis:NAME")
+	res = val
+	return
+}
+`
+
+var _singleSetTemplate = `
+// NAME {{or .Comment "set data to redis"}}
+func (d *{{.StructName}}) NAME(c context.Context, id KEY, val VALUE {{.ExtraArgsType}}) (err error) {
+	{{if .PointType}}
+		if val == nil {
+			return
+		}
+	{{end}}
+	{{if .LenType}}
+		if len(val) == 0 {
+			return
+		}
+	{{end}}
+	key := {{.KeyMethod}}(id{{.ExtraArgs}})
+	ENCODE
+	expire := {{.ExpireCode}}
+	{{if .EnableNullCode}}
+		if {{.CheckNullCode}} {
+			expire = {{.ExpireNullCode}}
+		}
+	{{end}}
+	if err = CLIENT.{{.SetMethod}}(c, key, bs, expire).Err(); err != nil {
+		log.Errorv(c, log.KV("NAME", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	return
+}
+`
+
+var _singleDelTemplate = `
+// NAME {{or .Comment "delete data from redis"}}
+func (d *{{.StructName}}) NAME(c context.Context, id KEY {{.ExtraArgsType}}) (err error) {
+	key := {{.KeyMethod}}(id{{.ExtraArgs}})
+	if err = CLIENT.Del(c, key).Err(); err != nil {
+		log.Errorv(c, log.KV("NAME", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	return
+}
+`
+
+var _multiGetTemplate = `
+// NAME {{or .Comment "get data from redis"}}
+func (d *{{.StructName}}) NAME(c context.Context, ids []KEY {{.ExtraArgsType}}) (res map[KEY]VALUE, err error) {
+	l := len(ids)
+	if l == 0 {
+		return
+	}
+	{{if .EnableBatch}}
+		mutex := sync.Mutex{}
+		for i := 0; i < l; i += GROUPSIZE * MAXGROUP {
+			var subKeys []KEY
+			{{if .BatchErrBreak}}
+				group := errgroup.WithCancel(c)
+			{{else}}
+				group := errgroup.WithContext(c)
+			{{end}}
+			if (i + GROUPSIZE * MAXGROUP) > l {
+				subKeys = ids[i:]
+			} else {
+				subKeys = ids[i : i+GROUPSIZE*MAXGROUP]
+			}
+			subLen := len(subKeys)
+			for j := 0; j < subLen; j += GROUPSIZE {
+				var ks []KEY
+				if (j + GROUPSIZE) > subLen {
+					ks = subKeys[j:]
+				} else {
+					ks = subKeys[j : j+GROUPSIZE]
+				}
+				group.Go(func(ctx context.Context) (err error) {
+					keys := make([]string, 0, len(ks))
+					for _, id := range ks {
+						keys = append(keys, {{.KeyMethod}}(id{{.ExtraArgs}}))
+					}
+					replies, err := CLIENT.MGet(ctx, keys...).Result()
+					if err != nil {
+						log.Errorv(ctx, log.KV("NAME", fmt.Sprintf("%+v", err)), log.KV("keys", keys))
+						return
+					}
+					for i, reply := range replies {
+						bs, ok := reply.([]byte)
+						if !ok {
+							continue
+						}
+						v := string(bs)
+						DECODE
+						if err != nil {
+							log.Errorv(ctx, log.KV("NAME", fmt.Sprintf("%+v", err)), log.KV("key", keys[i]))
+							return
+						}
+						mutex.Lock()
+						if res == nil {
+							res = make(map[KEY]VALUE, l)
+						}
+						res[ks[i]] = val
+						mutex.Unlock()
+					}
+					return
+				})
+			}
+			err1 := group.Wait()
+			if err1 != nil {
+				err = err1
+			{{if .BatchErrBreak}}
+				break
+			{{end}}
+			}
+		}
+	{{else}}
+		keys := make([]string, 0, l)
+		for _, id := range ids {
+			keys = append(keys, {{.KeyMethod}}(id{{.ExtraArgs}}))
+		}
+		var replies []interface{}
+		if replies, err = CLIENT.MGet(c, keys...).Result(); err != nil {
+			log.Errorv(c, log.KV("NAME", fmt.Sprintf("%+v", err)), log.KV("keys", keys))
+			return
+		}
+		for i, reply := range replies {
+			bs, ok := reply.([]byte)
+			if !ok {
+				continue
+			}
+			v := string(bs)
+			DECODE
+			if err != nil {
+				log.Errorv(c, log.KV("NAME", fmt.Sprintf("%+v", err)), log.KV("key", keys[i]))
+				return
+			}
+			if res == nil {
+				res = make(map[KEY]VALUE, l)
+			}
+			res[ids[i]] = val
+		}
+	{{end}}
+	cache.MetricHits.Add(float64(len(res)), "redis:NAME")
+	cache.MetricMisses.Add(float64(l-len(res)), "redis:NAME")
+	return
+}
+`
+
+var _multiSetTemplate = `
+// NAME {{or .Comment "set data to redis"}}
+func (d *{{.StructName}}) NAME(c context.Context, values map[KEY]VALUE {{.ExtraArgsType}}) (err error) {
+	if len(values) == 0 {
+		return
+	}
+	pipe := CLIENT.Pipeline()
+	for id, val := range values {
+		{{if .PointType}}
+			if val == nil {
+				continue
+			}
+		{{end}}
+		{{if .LenType}}
+			if len(val) == 0 {
+				continue
+			}
+		{{end}}
+		key := {{.KeyMethod}}(id{{.ExtraArgs}})
+		ENCODE
+		expire := {{.ExpireCode}}
+		{{if .EnableNullCode}}
+			if {{.CheckNullCode}} {
+				expire = {{.ExpireNullCode}}
+			}
+		{{end}}
+		pipe.{{.SetMethod}}(c, key, bs, expire)
+	}
+	var cmds []xredis.Cmder
+	if cmds, err = pipe.Exec(c); err == nil {
+		for _, cmd := range cmds {
+			if err = cmd.Err(); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		log.Errorv(c, log.KV("NAME", fmt.Sprintf("%+v", err)))
+		return
+	}
+	return
+}
+`
+
+var _multiDelTemplate = `
+// NAME {{or .Comment "delete data from redis"}}
+func (d *{{.StructName}}) NAME(c context.Context, ids []KEY {{.ExtraArgsType}}) (err error) {
+	if len(ids) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, {{.KeyMethod}}(id{{.ExtraArgs}}))
+	}
+	if err = CLIENT.Del(c, keys...).Err(); err != nil {
+		log.Errorv(c, log.KV("NAME", fmt.Sprintf("%+v", err)), log.KV("keys", keys))
+		return
+	}
+	return
+}
+`
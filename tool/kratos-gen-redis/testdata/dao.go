@@ -0,0 +1,122 @@
+package testdata
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"kratos/pkg/cache/xredis"
+)
+
+// Demo .
+type Demo struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+type dao struct {
+	redis      *xredis.Client
+	demoExpire time.Duration
+}
+
+// New new dao
+func New(r *xredis.Client) (d *dao) {
+	d = &dao{
+		redis:      r,
+		demoExpire: time.Minute,
+	}
+	return
+}
+
+//go:generate kratos tool genredis
+type _redis interface {
+	// redis: -key=demoKey -batch=2 -max_group=2
+	CacheDemos(c context.Context, keys []int64) (map[int64]*Demo, error)
+	// redis: -key=demoKey
+	CacheDemo(c context.Context, key int64) (*Demo, error)
+	// redis: -key=keyMid -encode=gob
+	CacheDemo1(c context.Context, key int64, mid int64) (*Demo, error)
+	// redis: -key=noneKey
+	CacheNone(c context.Context) (*Demo, error)
+	// redis: -key=demoKey
+	CacheString(c context.Context, key int64) (string, error)
+	// redis: -key=countKey
+	CacheCounts(c context.Context, keys []int64) (map[int64]int64, error)
+	// redis: -key=hashKey -storage=hash -encode=json|gzip
+	CacheHashDemo(c context.Context, id int64, tp int64) (*Demo, error)
+	// redis: -key=hashKey -storage=hash -encode=json|gzip
+	CacheHashDemos(c context.Context, ids []int64, tp int64) (map[int64]*Demo, error)
+	// redis: -key=listKey -storage=list
+	CacheList(c context.Context, id int64) ([]int64, error)
+	// redis: -key=zsetKey -storage=zset -order=desc -encode=gob
+	CacheZSet(c context.Context, id int64) ([]*Demo, error)
+	// redis: -key=zsetKey -storage=zset -order=desc -encode=gob
+	CacheZSets(c context.Context, ids []int64) (map[int64][]*Demo, error)
+
+	// redis: -key=demoKey -expire=d.demoExpire -encode=json -check_null_code=$!=nil&&$.ID==-1 -null_expire=time.Second
+	AddCacheDemos(c context.Context, values map[int64]*Demo) error
+	// 这里也支持自定义注释 会替换默认的注释
+	// redis: -key=demoKey -expire=d.demoExpire -encode=json
+	AddCacheDemo(c context.Context, key int64, value *Demo) error
+	// redis: -key=keyMid -expire=d.demoExpire -encode=gob
+	AddCacheDemo1(c context.Context, key int64, value *Demo, mid int64) error
+	// redis: -key=noneKey -expire=d.demoExpire
+	AddCacheNone(c context.Context, value *Demo) error
+	// redis: -key=demoKey -expire=d.demoExpire -type=only_add
+	AddCacheString(c context.Context, key int64, value string) error
+	// redis: -key=countKey -expire=d.demoExpire
+	AddCacheCounts(c context.Context, values map[int64]int64) error
+	// redis: -key=hashKey -storage=hash -expire=d.demoExpire -encode=json|gzip
+	AddCacheHashDemo(c context.Context, id int64, value *Demo, tp int64) error
+	// redis: -key=hashKey -storage=hash -expire=d.demoExpire -encode=json|gzip
+	AddCacheHashDemos(c context.Context, values map[int64]*Demo, tp int64) error
+	// redis: -key=listKey -storage=list -expire=d.demoExpire
+	AddCacheList(c context.Context, id int64, value []int64) error
+	// redis: -key=zsetKey -storage=zset -score=$.ID -expire=d.demoExpire -encode=gob
+	AddCacheZSet(c context.Context, id int64, value []*Demo) error
+	// redis: -key=zsetKey -storage=zset -score=$.ID -expire=d.demoExpire -encode=gob
+	AddCacheZSets(c context.Context, values map[int64][]*Demo) error
+
+	// redis: -key=demoKey
+	DelCacheDemos(c context.Context, keys []int64) error
+	// redis: -key=demoKey
+	DelCacheDemo(c context.Context, key int64) error
+	// redis: -key=keyMid
+	DelCacheDemo1(c context.Context, key int64, mid int64) error
+	// redis: -key=noneKey
+	DelCacheNone(c context.Context) error
+	// redis: -key=hashKey -storage=hash
+	DelCacheHashDemo(c context.Context, id int64, tp int64) error
+	// redis: -key=hashKey -storage=hash
+	DelCacheHashDemos(c context.Context, ids []int64, tp int64) error
+	// redis: -key=zsetKey -storage=zset
+	DelCacheZSet(c context.Context, id int64) error
+}
+
+func demoKey(id int64) string {
+	return fmt.Sprintf("art_%d", id)
+}
+
+func keyMid(id, mid int64) string {
+	return fmt.Sprintf("art_%d_%d", id, mid)
+}
+
+func noneKey() string {
+	return "none"
+}
+
+func countKey(id int64) string {
+	return fmt.Sprintf("cnt_%d", id)
+}
+
+func hashKey(tp int64) string {
+	return fmt.Sprintf("hash_%d", tp)
+}
+
+func listKey(id int64) string {
+	return fmt.Sprintf("list_%d", id)
+}
+
+func zsetKey(id int64) string {
+	return fmt.Sprintf("zset_%d", id)
+}
@@ -0,0 +1,218 @@
+package testdata
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kratos/pkg/cache/redis/redistest"
+	"kratos/pkg/cache/xredis"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newDao(t *testing.T) (*miniredis.Miniredis, *dao) {
+	s, conf := redistest.New(t)
+	return s, New(xredis.New(conf))
+}
+
+func TestDemo(t *testing.T) {
+	s, d := newDao(t)
+	defer s.Close()
+	c := context.TODO()
+	art := &Demo{ID: 1, Title: "title"}
+	if err := d.AddCacheDemo(c, art.ID, art); err != nil {
+		t.Fatalf("err should be nil, get: %v", err)
+	}
+	if ttl := s.TTL(demoKey(art.ID)); ttl != d.demoExpire {
+		t.Fatalf("ttl should be %v, get: %v", d.demoExpire, ttl)
+	}
+	art1, err := d.CacheDemo(c, art.ID)
+	if err != nil {
+		t.Fatalf("err should be nil, get: %v", err)
+	}
+	if (art1.ID != art.ID) || (art.Title != art1.Title) {
+		t.Fatalf("art not equal, get: %+v", art1)
+	}
+	if err = d.DelCacheDemo(c, art.ID); err != nil {
+		t.Fatalf("err should be nil, get: %v", err)
+	}
+	art1, err = d.CacheDemo(c, art.ID)
+	if (art1 != nil) || (err != nil) {
+		t.Fatalf("art %v, err: %v", art1, err)
+	}
+}
+
+func TestDemo1(t *testing.T) {
+	s, d := newDao(t)
+	defer s.Close()
+	c := context.TODO()
+	art := &Demo{ID: 1, Title: "title"}
+	if err := d.AddCacheDemo1(c, art.ID, art, 2); err != nil {
+		t.Fatalf("err should be nil, get: %v", err)
+	}
+	art1, err := d.CacheDemo1(c, art.ID, 2)
+	if err != nil || art1 == nil || art1.Title != art.Title {
+		t.Fatalf("art %v, err: %v", art1, err)
+	}
+	if err = d.DelCacheDemo1(c, art.ID, 2); err != nil {
+		t.Fatalf("err should be nil, get: %v", err)
+	}
+	if art1, err = d.CacheDemo1(c, art.ID, 2); art1 != nil || err != nil {
+		t.Fatalf("art %v, err: %v", art1, err)
+	}
+}
+
+func TestNone(t *testing.T) {
+	s, d := newDao(t)
+	defer s.Close()
+	c := context.TODO()
+	art := &Demo{ID: 1, Title: "title"}
+	if err := d.AddCacheNone(c, art); err != nil {
+		t.Fatalf("err should be nil, get: %v", err)
+	}
+	art1, err := d.CacheNone(c)
+	if err != nil || art1 == nil || art1.ID != art.ID {
+		t.Fatalf("art %v, err: %v", art1, err)
+	}
+	if err = d.DelCacheNone(c); err != nil {
+		t.Fatalf("err should be nil, get: %v", err)
+	}
+	if art1, err = d.CacheNone(c); art1 != nil || err != nil {
+		t.Fatalf("art %v, err: %v", art1, err)
+	}
+}
+
+func TestString(t *testing.T) {
+	s, d := newDao(t)
+	defer s.Close()
+	c := context.TODO()
+	if err := d.AddCacheString(c, 1, "first"); err != nil {
+		t.Fatalf("err should be nil, get: %v", err)
+	}
+	// only_add does not overwrite the existing value
+	if err := d.AddCacheString(c, 1, "second"); err != nil {
+		t.Fatalf("err should be nil, get: %v", err)
+	}
+	res, err := d.CacheString(c, 1)
+	if err != nil || res != "first" {
+		t.Fatalf("res %q, err: %v", res, err)
+	}
+}
+
+func TestDemos(t *testing.T) {
+	s, d := newDao(t)
+	defer s.Close()
+	c := context.TODO()
+	arts := map[int64]*Demo{1: {ID: 1, Title: "1"}, 2: {ID: 2, Title: "2"}, 5: {ID: -1}}
+	if err := d.AddCacheDemos(c, arts); err != nil {
+		t.Fatalf("err should be nil, get: %v", err)
+	}
+	if ttl := s.TTL(demoKey(5)); ttl != time.Second {
+		t.Fatalf("null cache ttl should be 1s, get: %v", ttl)
+	}
+	res, err := d.CacheDemos(c, []int64{1, 2, 3, 4, 5, 6})
+	if err != nil {
+		t.Fatalf("err should be nil, get: %v", err)
+	}
+	if len(res) != 3 || res[1].Title != "1" || res[2].Title != "2" || res[5].ID != -1 {
+		t.Fatalf("res %+v", res)
+	}
+	if err = d.DelCacheDemos(c, []int64{1, 2, 5}); err != nil {
+		t.Fatalf("err should be nil, get: %v", err)
+	}
+	if res, err = d.CacheDemos(c, []int64{1, 2, 5}); err != nil || len(res) != 0 {
+		t.Fatalf("res %+v, err: %v", res, err)
+	}
+}
+
+func TestCounts(t *testing.T) {
+	s, d := newDao(t)
+	defer s.Close()
+	c := context.TODO()
+	if err := d.AddCacheCounts(c, map[int64]int64{1: 10, 2: 20}); err != nil {
+		t.Fatalf("err should be nil, get: %v", err)
+	}
+	res, err := d.CacheCounts(c, []int64{1, 2, 3})
+	if err != nil || len(res) != 2 || res[1] != 10 || res[2] != 20 {
+		t.Fatalf("res %+v, err: %v", res, err)
+	}
+}
+
+func TestHash(t *testing.T) {
+	s, d := newDao(t)
+	defer s.Close()
+	c := context.TODO()
+	if err := d.AddCacheHashDemo(c, 1, &Demo{ID: 1, Title: "1"}, 7); err != nil {
+		t.Fatalf("err should be nil, get: %v", err)
+	}
+	if err := d.AddCacheHashDemos(c, map[int64]*Demo{2: {ID: 2, Title: "2"}, 3: {ID: 3, Title: "3"}}, 7); err != nil {
+		t.Fatalf("err should be nil, get: %v", err)
+	}
+	if ttl := s.TTL(hashKey(7)); ttl != d.demoExpire {
+		t.Fatalf("ttl should be %v, get: %v", d.demoExpire, ttl)
+	}
+	art, err := d.CacheHashDemo(c, 2, 7)
+	if err != nil || art == nil || art.Title != "2" {
+		t.Fatalf("art %v, err: %v", art, err)
+	}
+	res, err := d.CacheHashDemos(c, []int64{1, 2, 3, 4}, 7)
+	if err != nil || len(res) != 3 || res[1].Title != "1" || res[3].Title != "3" {
+		t.Fatalf("res %+v, err: %v", res, err)
+	}
+	if err = d.DelCacheHashDemo(c, 1, 7); err != nil {
+		t.Fatalf("err should be nil, get: %v", err)
+	}
+	if err = d.DelCacheHashDemos(c, []int64{2}, 7); err != nil {
+		t.Fatalf("err should be nil, get: %v", err)
+	}
+	if res, err = d.CacheHashDemos(c, []int64{1, 2, 3}, 7); err != nil || len(res) != 1 || res[3] == nil {
+		t.Fatalf("res %+v, err: %v", res, err)
+	}
+}
+
+func TestList(t *testing.T) {
+	s, d := newDao(t)
+	defer s.Close()
+	c := context.TODO()
+	if err := d.AddCacheList(c, 1, []int64{3, 1, 2}); err != nil {
+		t.Fatalf("err should be nil, get: %v", err)
+	}
+	// set replaces the whole list
+	if err := d.AddCacheList(c, 1, []int64{5, 4}); err != nil {
+		t.Fatalf("err should be nil, get: %v", err)
+	}
+	res, err := d.CacheList(c, 1)
+	if err != nil || len(res) != 2 || res[0] != 5 || res[1] != 4 {
+		t.Fatalf("res %v, err: %v", res, err)
+	}
+	if res, err = d.CacheList(c, 2); err != nil || res != nil {
+		t.Fatalf("res %v, err: %v", res, err)
+	}
+}
+
+func TestZSet(t *testing.T) {
+	s, d := newDao(t)
+	defer s.Close()
+	c := context.TODO()
+	if err := d.AddCacheZSet(c, 1, []*Demo{{ID: 1, Title: "1"}, {ID: 3, Title: "3"}, {ID: 2, Title: "2"}}); err != nil {
+		t.Fatalf("err should be nil, get: %v", err)
+	}
+	if err := d.AddCacheZSets(c, map[int64][]*Demo{2: {{ID: 4, Title: "4"}}}); err != nil {
+		t.Fatalf("err should be nil, get: %v", err)
+	}
+	res, err := d.CacheZSet(c, 1)
+	if err != nil || len(res) != 3 || res[0].ID != 3 || res[2].ID != 1 {
+		t.Fatalf("res %v, err: %v", res, err)
+	}
+	all, err := d.CacheZSets(c, []int64{1, 2, 3})
+	if err != nil || len(all) != 2 || len(all[1]) != 3 || all[2][0].Title != "4" {
+		t.Fatalf("res %v, err: %v", all, err)
+	}
+	if err = d.DelCacheZSet(c, 1); err != nil {
+		t.Fatalf("err should be nil, get: %v", err)
+	}
+	if res, err = d.CacheZSet(c, 1); err != nil || res != nil {
+		t.Fatalf("res %v, err: %v", res, err)
+	}
+}
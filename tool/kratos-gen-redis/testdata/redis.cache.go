@@ -0,0 +1,821 @@
+// Code generated by kratos tool genredis. DO NOT EDIT.
+
+/*
+  Package testdata is a generated redis cache package.
+  It is generated from:
+  type _redis interface {
+		// redis: -key=demoKey -batch=2 -max_group=2
+		CacheDemos(c context.Context, keys []int64) (map[int64]*Demo, error)
+		// redis: -key=demoKey
+		CacheDemo(c context.Context, key int64) (*Demo, error)
+		// redis: -key=keyMid -encode=gob
+		CacheDemo1(c context.Context, key int64, mid int64) (*Demo, error)
+		// redis: -key=noneKey
+		CacheNone(c context.Context) (*Demo, error)
+		// redis: -key=demoKey
+		CacheString(c context.Context, key int64) (string, error)
+		// redis: -key=countKey
+		CacheCounts(c context.Context, keys []int64) (map[int64]int64, error)
+		// redis: -key=hashKey -storage=hash -encode=json|gzip
+		CacheHashDemo(c context.Context, id int64, tp int64) (*Demo, error)
+		// redis: -key=hashKey -storage=hash -encode=json|gzip
+		CacheHashDemos(c context.Context, ids []int64, tp int64) (map[int64]*Demo, error)
+		// redis: -key=listKey -storage=list
+		CacheList(c context.Context, id int64) ([]int64, error)
+		// redis: -key=zsetKey -storage=zset -order=desc -encode=gob
+		CacheZSet(c context.Context, id int64) ([]*Demo, error)
+		// redis: -key=zsetKey -storage=zset -order=desc -encode=gob
+		CacheZSets(c context.Context, ids []int64) (map[int64][]*Demo, error)
+
+		// redis: -key=demoKey -expire=d.demoExpire -encode=json -check_null_code=$!=nil&&$.ID==-1 -null_expire=time.Second
+		AddCacheDemos(c context.Context, values map[int64]*Demo) error
+		// 这里也支持自定义注释 会替换默认的注释
+		// redis: -key=demoKey -expire=d.demoExpire -encode=json
+		AddCacheDemo(c context.Context, key int64, value *Demo) error
+		// redis: -key=keyMid -expire=d.demoExpire -encode=gob
+		AddCacheDemo1(c context.Context, key int64, value *Demo, mid int64) error
+		// redis: -key=noneKey -expire=d.demoExpire
+		AddCacheNone(c context.Context, value *Demo) error
+		// redis: -key=demoKey -expire=d.demoExpire -type=only_add
+		AddCacheString(c context.Context, key int64, value string) error
+		// redis: -key=countKey -expire=d.demoExpire
+		AddCacheCounts(c context.Context, values map[int64]int64) error
+		// redis: -key=hashKey -storage=hash -expire=d.demoExpire -encode=json|gzip
+		AddCacheHashDemo(c context.Context, id int64, value *Demo, tp int64) error
+		// redis: -key=hashKey -storage=hash -expire=d.demoExpire -encode=json|gzip
+		AddCacheHashDemos(c context.Context, values map[int64]*Demo, tp int64) error
+		// redis: -key=listKey -storage=list -expire=d.demoExpire
+		AddCacheList(c context.Context, id int64, value []int64) error
+		// redis: -key=zsetKey -storage=zset -score=$.ID -expire=d.demoExpire -encode=gob
+		AddCacheZSet(c context.Context, id int64, value []*Demo) error
+		// redis: -key=zsetKey -storage=zset -score=$.ID -expire=d.demoExpire -encode=gob
+		AddCacheZSets(c context.Context, values map[int64][]*Demo) error
+
+		// redis: -key=demoKey
+		DelCacheDemos(c context.Context, keys []int64) error
+		// redis: -key=demoKey
+		DelCacheDemo(c context.Context, key int64) error
+		// redis: -key=keyMid
+		DelCacheDemo1(c context.Context, key int64, mid int64) error
+		// redis: -key=noneKey
+		DelCacheNone(c context.Context) error
+		// redis: -key=hashKey -storage=hash
+		DelCacheHashDemo(c context.Context, id int64, tp int64) error
+		// redis: -key=hashKey -storage=hash
+		DelCacheHashDemos(c context.Context, ids []int64, tp int64) error
+		// redis: -key=zsetKey -storage=zset
+		DelCacheZSet(c context.Context, id int64) error
+	}
+*/
+
+package testdata
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"kratos/pkg/cache"
+	"kratos/pkg/cache/xredis"
+	"kratos/pkg/log"
+	"kratos/pkg/sync/errgroup"
+)
+
+var _ _redis
+
+// CacheDemos get data from redis
+func (d *dao) CacheDemos(c context.Context, ids []int64) (res map[int64]*Demo, err error) {
+	l := len(ids)
+	if l == 0 {
+		return
+	}
+	mutex := sync.Mutex{}
+	for i := 0; i < l; i += 2 * 2 {
+		var subKeys []int64
+		group := errgroup.WithCancel(c)
+		if (i + 2*2) > l {
+			subKeys = ids[i:]
+		} else {
+			subKeys = ids[i : i+2*2]
+		}
+		subLen := len(subKeys)
+		for j := 0; j < subLen; j += 2 {
+			var ks []int64
+			if (j + 2) > subLen {
+				ks = subKeys[j:]
+			} else {
+				ks = subKeys[j : j+2]
+			}
+			group.Go(func(ctx context.Context) (err error) {
+				keys := make([]string, 0, len(ks))
+				for _, id := range ks {
+					keys = append(keys, demoKey(id))
+				}
+				replies, err := d.redis.MGet(ctx, keys...).Result()
+				if err != nil {
+					log.Errorv(ctx, log.KV("CacheDemos", fmt.Sprintf("%+v", err)), log.KV("keys", keys))
+					return
+				}
+				for i, reply := range replies {
+					bs, ok := reply.([]byte)
+					if !ok {
+						continue
+					}
+					v := string(bs)
+					val := &Demo{}
+					err = xredis.Unmarshal([]byte(v), val, xredis.FlagJSON)
+					if err != nil {
+						log.Errorv(ctx, log.KV("CacheDemos", fmt.Sprintf("%+v", err)), log.KV("key", keys[i]))
+						return
+					}
+					mutex.Lock()
+					if res == nil {
+						res = make(map[int64]*Demo, l)
+					}
+					res[ks[i]] = val
+					mutex.Unlock()
+				}
+				return
+			})
+		}
+		err1 := group.Wait()
+		if err1 != nil {
+			err = err1
+			break
+		}
+	}
+	cache.MetricHits.Add(float64(len(res)), "redis:CacheDemos")
+	cache.MetricMisses.Add(float64(l-len(res)), "redis:CacheDemos")
+	return
+}
+
+// CacheDemo get data from redis
+func (d *dao) CacheDemo(c context.Context, id int64) (res *Demo, err error) {
+	key := demoKey(id)
+	var v string
+	if v, err = d.redis.Get(c, key).Result(); err != nil {
+		if err == xredis.ErrNil {
+			err = nil
+			cache.MetricMisses.Inc("redis:CacheDemo")
+			return
+		}
+		log.Errorv(c, log.KV("CacheDemo", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	val := &Demo{}
+	err = xredis.Unmarshal([]byte(v), val, xredis.FlagJSON)
+	if err != nil {
+		log.Errorv(c, log.KV("CacheDemo", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	cache.MetricHits.Inc("redis:CacheDemo")
+	res = val
+	return
+}
+
+// CacheDemo1 get data from redis
+func (d *dao) CacheDemo1(c context.Context, id int64, mid int64) (res *Demo, err error) {
+	key := keyMid(id, mid)
+	var v string
+	if v, err = d.redis.Get(c, key).Result(); err != nil {
+		if err == xredis.ErrNil {
+			err = nil
+			cache.MetricMisses.Inc("redis:CacheDemo1")
+			return
+		}
+		log.Errorv(c, log.KV("CacheDemo1", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	val := &Demo{}
+	err = xredis.Unmarshal([]byte(v), val, xredis.FlagGOB)
+	if err != nil {
+		log.Errorv(c, log.KV("CacheDemo1", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	cache.MetricHits.Inc("redis:CacheDemo1")
+	res = val
+	return
+}
+
+// CacheNone get data from redis
+func (d *dao) CacheNone(c context.Context) (res *Demo, err error) {
+	key := noneKey()
+	var v string
+	if v, err = d.redis.Get(c, key).Result(); err != nil {
+		if err == xredis.ErrNil {
+			err = nil
+			cache.MetricMisses.Inc("redis:CacheNone")
+			return
+		}
+		log.Errorv(c, log.KV("CacheNone", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	val := &Demo{}
+	err = xredis.Unmarshal([]byte(v), val, xredis.FlagJSON)
+	if err != nil {
+		log.Errorv(c, log.KV("CacheNone", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	cache.MetricHits.Inc("redis:CacheNone")
+	res = val
+	return
+}
+
+// CacheString get data from redis
+func (d *dao) CacheString(c context.Context, id int64) (res string, err error) {
+	key := demoKey(id)
+	var v string
+	if v, err = d.redis.Get(c, key).Result(); err != nil {
+		if err == xredis.ErrNil {
+			err = nil
+			cache.MetricMisses.Inc("redis:CacheString")
+			return
+		}
+		log.Errorv(c, log.KV("CacheString", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	val := string(v)
+	if err != nil {
+		log.Errorv(c, log.KV("CacheString", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	cache.MetricHits.Inc("redis:CacheString")
+	res = val
+	return
+}
+
+// CacheCounts get data from redis
+func (d *dao) CacheCounts(c context.Context, ids []int64) (res map[int64]int64, err error) {
+	l := len(ids)
+	if l == 0 {
+		return
+	}
+	keys := make([]string, 0, l)
+	for _, id := range ids {
+		keys = append(keys, countKey(id))
+	}
+	var replies []interface{}
+	if replies, err = d.redis.MGet(c, keys...).Result(); err != nil {
+		log.Errorv(c, log.KV("CacheCounts", fmt.Sprintf("%+v", err)), log.KV("keys", keys))
+		return
+	}
+	for i, reply := range replies {
+		bs, ok := reply.([]byte)
+		if !ok {
+			continue
+		}
+		v := string(bs)
+		var val int64
+		if r, e := strconv.ParseInt(v, 10, 64); e != nil {
+			err = e
+		} else {
+			val = int64(r)
+		}
+		if err != nil {
+			log.Errorv(c, log.KV("CacheCounts", fmt.Sprintf("%+v", err)), log.KV("key", keys[i]))
+			return
+		}
+		if res == nil {
+			res = make(map[int64]int64, l)
+		}
+		res[ids[i]] = val
+	}
+	cache.MetricHits.Add(float64(len(res)), "redis:CacheCounts")
+	cache.MetricMisses.Add(float64(l-len(res)), "redis:CacheCounts")
+	return
+}
+
+// CacheHashDemo get data from redis hash
+func (d *dao) CacheHashDemo(c context.Context, id int64, tp int64) (res *Demo, err error) {
+	key := hashKey(tp)
+	field := fmt.Sprint(id)
+	var v string
+	if v, err = d.redis.HGet(c, key, field).Result(); err != nil {
+		if err == xredis.ErrNil {
+			err = nil
+			cache.MetricMisses.Inc("redis:CacheHashDemo")
+			return
+		}
+		log.Errorv(c, log.KV("CacheHashDemo", fmt.Sprintf("%+v", err)), log.KV("key", key), log.KV("field", field))
+		return
+	}
+	val := &Demo{}
+	err = xredis.Unmarshal([]byte(v), val, xredis.FlagJSON|xredis.FlagGzip)
+	if err != nil {
+		log.Errorv(c, log.KV("CacheHashDemo", fmt.Sprintf("%+v", err)), log.KV("key", key), log.KV("field", field))
+		return
+	}
+	cache.MetricHits.Inc("redis:CacheHashDemo")
+	res = val
+	return
+}
+
+// CacheHashDemos get data from redis hash
+func (d *dao) CacheHashDemos(c context.Context, ids []int64, tp int64) (res map[int64]*Demo, err error) {
+	l := len(ids)
+	if l == 0 {
+		return
+	}
+	key := hashKey(tp)
+	fields := make([]string, 0, l)
+	for _, id := range ids {
+		fields = append(fields, fmt.Sprint(id))
+	}
+	var replies []interface{}
+	if replies, err = d.redis.HMGet(c, key, fields...).Result(); err != nil {
+		log.Errorv(c, log.KV("CacheHashDemos", fmt.Sprintf("%+v", err)), log.KV("key", key), log.KV("fields", fields))
+		return
+	}
+	for i, reply := range replies {
+		bs, ok := reply.([]byte)
+		if !ok {
+			continue
+		}
+		v := string(bs)
+		val := &Demo{}
+		err = xredis.Unmarshal([]byte(v), val, xredis.FlagJSON|xredis.FlagGzip)
+		if err != nil {
+			log.Errorv(c, log.KV("CacheHashDemos", fmt.Sprintf("%+v", err)), log.KV("key", key), log.KV("field", fields[i]))
+			return
+		}
+		if res == nil {
+			res = make(map[int64]*Demo, l)
+		}
+		res[ids[i]] = val
+	}
+	cache.MetricHits.Add(float64(len(res)), "redis:CacheHashDemos")
+	cache.MetricMisses.Add(float64(l-len(res)), "redis:CacheHashDemos")
+	return
+}
+
+// CacheList get data from redis
+func (d *dao) CacheList(c context.Context, id int64) (res []int64, err error) {
+	key := listKey(id)
+	var vs []string
+	if vs, err = d.redis.LRange(c, key, 0, -1).Result(); err != nil {
+		log.Errorv(c, log.KV("CacheList", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	if len(vs) == 0 {
+		cache.MetricMisses.Inc("redis:CacheList")
+		return
+	}
+	res = make([]int64, 0, len(vs))
+	for _, v := range vs {
+		var val int64
+		if r, e := strconv.ParseInt(v, 10, 64); e != nil {
+			err = e
+		} else {
+			val = int64(r)
+		}
+		if err != nil {
+			log.Errorv(c, log.KV("CacheList", fmt.Sprintf("%+v", err)), log.KV("key", key))
+			return nil, err
+		}
+		res = append(res, val)
+	}
+	cache.MetricHits.Inc("redis:CacheList")
+	return
+}
+
+// CacheZSet get data from redis
+func (d *dao) CacheZSet(c context.Context, id int64) (res []*Demo, err error) {
+	key := zsetKey(id)
+	var vs []string
+	if vs, err = d.redis.ZRevRange(c, key, 0, -1).Result(); err != nil {
+		log.Errorv(c, log.KV("CacheZSet", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	if len(vs) == 0 {
+		cache.MetricMisses.Inc("redis:CacheZSet")
+		return
+	}
+	res = make([]*Demo, 0, len(vs))
+	for _, v := range vs {
+		val := &Demo{}
+		err = xredis.Unmarshal([]byte(v), val, xredis.FlagGOB)
+		if err != nil {
+			log.Errorv(c, log.KV("CacheZSet", fmt.Sprintf("%+v", err)), log.KV("key", key))
+			return nil, err
+		}
+		res = append(res, val)
+	}
+	cache.MetricHits.Inc("redis:CacheZSet")
+	return
+}
+
+// CacheZSets get data from redis
+func (d *dao) CacheZSets(c context.Context, ids []int64) (res map[int64][]*Demo, err error) {
+	l := len(ids)
+	if l == 0 {
+		return
+	}
+	keys := make([]string, 0, l)
+	pipe := d.redis.Pipeline()
+	cmds := make([]*xredis.StringSliceCmd, 0, l)
+	for _, id := range ids {
+		key := zsetKey(id)
+		keys = append(keys, key)
+		cmds = append(cmds, pipe.ZRevRange(c, key, 0, -1))
+	}
+	if _, err = pipe.Exec(c); err != nil {
+		log.Errorv(c, log.KV("CacheZSets", fmt.Sprintf("%+v", err)), log.KV("keys", keys))
+		return
+	}
+	for i, cmd := range cmds {
+		var vs []string
+		if vs, err = cmd.Result(); err != nil {
+			log.Errorv(c, log.KV("CacheZSets", fmt.Sprintf("%+v", err)), log.KV("key", keys[i]))
+			return
+		}
+		if len(vs) == 0 {
+			continue
+		}
+		vals := make([]*Demo, 0, len(vs))
+		for _, v := range vs {
+			val := &Demo{}
+			err = xredis.Unmarshal([]byte(v), val, xredis.FlagGOB)
+			if err != nil {
+				log.Errorv(c, log.KV("CacheZSets", fmt.Sprintf("%+v", err)), log.KV("key", keys[i]))
+				return
+			}
+			vals = append(vals, val)
+		}
+		if res == nil {
+			res = make(map[int64][]*Demo, l)
+		}
+		res[ids[i]] = vals
+	}
+	cache.MetricHits.Add(float64(len(res)), "redis:CacheZSets")
+	cache.MetricMisses.Add(float64(l-len(res)), "redis:CacheZSets")
+	return
+}
+
+// AddCacheDemos set data to redis
+func (d *dao) AddCacheDemos(c context.Context, values map[int64]*Demo) (err error) {
+	if len(values) == 0 {
+		return
+	}
+	pipe := d.redis.Pipeline()
+	for id, val := range values {
+		if val == nil {
+			continue
+		}
+		key := demoKey(id)
+		var bs []byte
+		if bs, err = xredis.Marshal(val, xredis.FlagJSON); err != nil {
+			log.Errorv(c, log.KV("AddCacheDemos", fmt.Sprintf("%+v", err)), log.KV("key", key))
+			return
+		}
+		expire := d.demoExpire
+		if val != nil && val.ID == -1 {
+			expire = time.Second
+		}
+		pipe.Set(c, key, bs, expire)
+	}
+	var cmds []xredis.Cmder
+	if cmds, err = pipe.Exec(c); err == nil {
+		for _, cmd := range cmds {
+			if err = cmd.Err(); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		log.Errorv(c, log.KV("AddCacheDemos", fmt.Sprintf("%+v", err)))
+		return
+	}
+	return
+}
+
+// AddCacheDemo 这里也支持自定义注释 会替换默认的注释
+func (d *dao) AddCacheDemo(c context.Context, id int64, val *Demo) (err error) {
+	if val == nil {
+		return
+	}
+	key := demoKey(id)
+	var bs []byte
+	if bs, err = xredis.Marshal(val, xredis.FlagJSON); err != nil {
+		log.Errorv(c, log.KV("AddCacheDemo", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	expire := d.demoExpire
+	if err = d.redis.Set(c, key, bs, expire).Err(); err != nil {
+		log.Errorv(c, log.KV("AddCacheDemo", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	return
+}
+
+// AddCacheDemo1 set data to redis
+func (d *dao) AddCacheDemo1(c context.Context, id int64, val *Demo, mid int64) (err error) {
+	if val == nil {
+		return
+	}
+	key := keyMid(id, mid)
+	var bs []byte
+	if bs, err = xredis.Marshal(val, xredis.FlagGOB); err != nil {
+		log.Errorv(c, log.KV("AddCacheDemo1", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	expire := d.demoExpire
+	if err = d.redis.Set(c, key, bs, expire).Err(); err != nil {
+		log.Errorv(c, log.KV("AddCacheDemo1", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	return
+}
+
+// AddCacheNone set data to redis
+func (d *dao) AddCacheNone(c context.Context, val *Demo) (err error) {
+	if val == nil {
+		return
+	}
+	key := noneKey()
+	var bs []byte
+	if bs, err = xredis.Marshal(val, xredis.FlagJSON); err != nil {
+		log.Errorv(c, log.KV("AddCacheNone", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	expire := d.demoExpire
+	if err = d.redis.Set(c, key, bs, expire).Err(); err != nil {
+		log.Errorv(c, log.KV("AddCacheNone", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	return
+}
+
+// AddCacheString set data to redis
+func (d *dao) AddCacheString(c context.Context, id int64, val string) (err error) {
+	if len(val) == 0 {
+		return
+	}
+	key := demoKey(id)
+	bs := val
+	expire := d.demoExpire
+	if err = d.redis.SetNX(c, key, bs, expire).Err(); err != nil {
+		log.Errorv(c, log.KV("AddCacheString", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	return
+}
+
+// AddCacheCounts set data to redis
+func (d *dao) AddCacheCounts(c context.Context, values map[int64]int64) (err error) {
+	if len(values) == 0 {
+		return
+	}
+	pipe := d.redis.Pipeline()
+	for id, val := range values {
+		key := countKey(id)
+		bs := strconv.FormatInt(int64(val), 10)
+		expire := d.demoExpire
+		pipe.Set(c, key, bs, expire)
+	}
+	var cmds []xredis.Cmder
+	if cmds, err = pipe.Exec(c); err == nil {
+		for _, cmd := range cmds {
+			if err = cmd.Err(); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		log.Errorv(c, log.KV("AddCacheCounts", fmt.Sprintf("%+v", err)))
+		return
+	}
+	return
+}
+
+// AddCacheHashDemo set data to redis hash
+func (d *dao) AddCacheHashDemo(c context.Context, id int64, val *Demo, tp int64) (err error) {
+	if val == nil {
+		return
+	}
+	key := hashKey(tp)
+	field := fmt.Sprint(id)
+	var bs []byte
+	if bs, err = xredis.Marshal(val, xredis.FlagJSON|xredis.FlagGzip); err != nil {
+		log.Errorv(c, log.KV("AddCacheHashDemo", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	if _, err = d.redis.TxPipelined(c, func(p xredis.Pipeliner) error {
+		p.HSet(c, key, field, bs)
+		p.Expire(c, key, d.demoExpire)
+		return nil
+	}); err != nil {
+		log.Errorv(c, log.KV("AddCacheHashDemo", fmt.Sprintf("%+v", err)), log.KV("key", key), log.KV("field", field))
+		return
+	}
+	return
+}
+
+// AddCacheHashDemos set data to redis hash
+func (d *dao) AddCacheHashDemos(c context.Context, values map[int64]*Demo, tp int64) (err error) {
+	if len(values) == 0 {
+		return
+	}
+	key := hashKey(tp)
+	args := make([]interface{}, 0, len(values)*2)
+	for id, val := range values {
+		if val == nil {
+			continue
+		}
+		var bs []byte
+		if bs, err = xredis.Marshal(val, xredis.FlagJSON|xredis.FlagGzip); err != nil {
+			log.Errorv(c, log.KV("AddCacheHashDemos", fmt.Sprintf("%+v", err)), log.KV("key", key))
+			return
+		}
+		args = append(args, fmt.Sprint(id), bs)
+	}
+	if len(args) == 0 {
+		return
+	}
+	if _, err = d.redis.TxPipelined(c, func(p xredis.Pipeliner) error {
+		p.HMSet(c, key, args...)
+		p.Expire(c, key, d.demoExpire)
+		return nil
+	}); err != nil {
+		log.Errorv(c, log.KV("AddCacheHashDemos", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	return
+}
+
+// AddCacheList set data to redis
+func (d *dao) AddCacheList(c context.Context, id int64, val []int64) (err error) {
+	if len(val) == 0 {
+		return
+	}
+	key := listKey(id)
+	members := make([]interface{}, 0, len(val))
+	for _, v := range val {
+		bs := strconv.FormatInt(int64(v), 10)
+		members = append(members, bs)
+	}
+	expire := d.demoExpire
+	if _, err = d.redis.TxPipelined(c, func(p xredis.Pipeliner) error {
+		p.Del(c, key)
+		p.RPush(c, key, members...)
+		p.Expire(c, key, expire)
+		return nil
+	}); err != nil {
+		log.Errorv(c, log.KV("AddCacheList", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	return
+}
+
+// AddCacheZSet set data to redis
+func (d *dao) AddCacheZSet(c context.Context, id int64, val []*Demo) (err error) {
+	if len(val) == 0 {
+		return
+	}
+	key := zsetKey(id)
+	members := make([]*xredis.Z, 0, len(val))
+	for _, v := range val {
+		var bs []byte
+		if bs, err = xredis.Marshal(v, xredis.FlagGOB); err != nil {
+			log.Errorv(c, log.KV("AddCacheZSet", fmt.Sprintf("%+v", err)), log.KV("key", key))
+			return
+		}
+		members = append(members, &xredis.Z{Score: float64(v.ID), Member: bs})
+	}
+	expire := d.demoExpire
+	if _, err = d.redis.TxPipelined(c, func(p xredis.Pipeliner) error {
+		p.Del(c, key)
+		p.ZAdd(c, key, members...)
+		p.Expire(c, key, expire)
+		return nil
+	}); err != nil {
+		log.Errorv(c, log.KV("AddCacheZSet", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	return
+}
+
+// AddCacheZSets set data to redis
+func (d *dao) AddCacheZSets(c context.Context, values map[int64][]*Demo) (err error) {
+	if len(values) == 0 {
+		return
+	}
+	pipe := d.redis.Pipeline()
+	for id, val := range values {
+		if len(val) == 0 {
+			continue
+		}
+		key := zsetKey(id)
+		members := make([]*xredis.Z, 0, len(val))
+		for _, v := range val {
+			var bs []byte
+			if bs, err = xredis.Marshal(v, xredis.FlagGOB); err != nil {
+				log.Errorv(c, log.KV("AddCacheZSets", fmt.Sprintf("%+v", err)), log.KV("key", key))
+				return
+			}
+			members = append(members, &xredis.Z{Score: float64(v.ID), Member: bs})
+		}
+		expire := d.demoExpire
+		pipe.Del(c, key)
+		pipe.ZAdd(c, key, members...)
+		pipe.Expire(c, key, expire)
+	}
+	var cmds []xredis.Cmder
+	if cmds, err = pipe.Exec(c); err == nil {
+		for _, cmd := range cmds {
+			if err = cmd.Err(); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		log.Errorv(c, log.KV("AddCacheZSets", fmt.Sprintf("%+v", err)))
+		return
+	}
+	return
+}
+
+// DelCacheDemos delete data from redis
+func (d *dao) DelCacheDemos(c context.Context, ids []int64) (err error) {
+	if len(ids) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, demoKey(id))
+	}
+	if err = d.redis.Del(c, keys...).Err(); err != nil {
+		log.Errorv(c, log.KV("DelCacheDemos", fmt.Sprintf("%+v", err)), log.KV("keys", keys))
+		return
+	}
+	return
+}
+
+// DelCacheDemo delete data from redis
+func (d *dao) DelCacheDemo(c context.Context, id int64) (err error) {
+	key := demoKey(id)
+	if err = d.redis.Del(c, key).Err(); err != nil {
+		log.Errorv(c, log.KV("DelCacheDemo", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	return
+}
+
+// DelCacheDemo1 delete data from redis
+func (d *dao) DelCacheDemo1(c context.Context, id int64, mid int64) (err error) {
+	key := keyMid(id, mid)
+	if err = d.redis.Del(c, key).Err(); err != nil {
+		log.Errorv(c, log.KV("DelCacheDemo1", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	return
+}
+
+// DelCacheNone delete data from redis
+func (d *dao) DelCacheNone(c context.Context) (err error) {
+	key := noneKey()
+	if err = d.redis.Del(c, key).Err(); err != nil {
+		log.Errorv(c, log.KV("DelCacheNone", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	return
+}
+
+// DelCacheHashDemo delete data from redis hash
+func (d *dao) DelCacheHashDemo(c context.Context, id int64, tp int64) (err error) {
+	key := hashKey(tp)
+	field := fmt.Sprint(id)
+	if err = d.redis.HDel(c, key, field).Err(); err != nil {
+		log.Errorv(c, log.KV("DelCacheHashDemo", fmt.Sprintf("%+v", err)), log.KV("key", key), log.KV("field", field))
+		return
+	}
+	return
+}
+
+// DelCacheHashDemos delete data from redis hash
+func (d *dao) DelCacheHashDemos(c context.Context, ids []int64, tp int64) (err error) {
+	if len(ids) == 0 {
+		return
+	}
+	key := hashKey(tp)
+	fields := make([]string, 0, len(ids))
+	for _, id := range ids {
+		fields = append(fields, fmt.Sprint(id))
+	}
+	if err = d.redis.HDel(c, key, fields...).Err(); err != nil {
+		log.Errorv(c, log.KV("DelCacheHashDemos", fmt.Sprintf("%+v", err)), log.KV("key", key), log.KV("fields", fields))
+		return
+	}
+	return
+}
+
+// DelCacheZSet delete data from redis
+func (d *dao) DelCacheZSet(c context.Context, id int64) (err error) {
+	key := zsetKey(id)
+	if err = d.redis.Del(c, key).Err(); err != nil {
+		log.Errorv(c, log.KV("DelCacheZSet", fmt.Sprintf("%+v", err)), log.KV("key", key))
+		return
+	}
+	return
+}
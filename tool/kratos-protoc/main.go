@@ -27,6 +27,11 @@ func main() {
 			Usage:       "whether to use swagger for generation",
 			Destination: &withSwagger,
 		},
+		&cli.BoolFlag{
+			Name:        "openapi",
+			Usage:       "whether to use OpenAPI 3.1 for generation",
+			Destination: &withOpenAPI,
+		},
 		&cli.BoolFlag{
 			Name:        "ecode",
 			Usage:       "whether to use ecode for generation",
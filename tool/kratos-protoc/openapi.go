@@ -0,0 +1,28 @@
+package main
+
+import (
+	"os/exec"
+)
+
+const (
+	_getOpenAPIGen = "go get -u github.com/vurtneyang/kratos/tool/protobuf/protoc-gen-bopenapi"
+	_openAPIProtoc = "protoc --proto_path=%s --proto_path=%s --proto_path=%s --bopenapi_out=" +
+		"Mgoogle/protobuf/any.proto=github.com/gogo/protobuf/types," +
+		"Mgoogle/protobuf/duration.proto=github.com/gogo/protobuf/types," +
+		"Mgoogle/protobuf/struct.proto=github.com/gogo/protobuf/types," +
+		"Mgoogle/protobuf/timestamp.proto=github.com/gogo/protobuf/types," +
+		"Mgoogle/protobuf/wrappers.proto=github.com/gogo/protobuf/types:."
+)
+
+func installOpenAPIGen() error {
+	if _, err := exec.LookPath("protoc-gen-bopenapi"); err != nil {
+		if err := goget(_getOpenAPIGen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func genOpenAPI(files []string) error {
+	return generate(_openAPIProtoc, files)
+}
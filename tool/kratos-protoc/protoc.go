@@ -20,6 +20,7 @@ var (
 	withBM      bool
 	withGRPC    bool
 	withSwagger bool
+	withOpenAPI bool
 	withEcode   bool
 )
 
@@ -31,10 +32,10 @@ func protocAction(ctx *cli.Context) (err error) {
 	if len(files) == 0 {
 		files, _ = filepath.Glob("*.proto")
 	}
-	if !withGRPC && !withBM && !withSwagger && !withEcode {
+	if !withGRPC && !withBM && !withSwagger && !withOpenAPI && !withEcode {
 		withBM = true
 		withGRPC = true
-		withSwagger = true
+		withOpenAPI = true
 		withEcode = true
 	}
 	if withBM {
@@ -61,6 +62,14 @@ func protocAction(ctx *cli.Context) (err error) {
 			return
 		}
 	}
+	if withOpenAPI {
+		if err = installOpenAPIGen(); err != nil {
+			return
+		}
+		if err = genOpenAPI(files); err != nil {
+			return
+		}
+	}
 	if withEcode {
 		if err = installEcodeGen(); err != nil {
 			return
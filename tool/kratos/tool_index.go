@@ -16,7 +16,7 @@ var toolIndexs = []*Tool{
 	{
 		Name:      "protoc",
 		Alias:     "kratos-protoc",
-		BuildTime: time.Date(2020, 3, 31, 0, 0, 0, 0, time.Local),
+		BuildTime: time.Date(2026, 10, 18, 0, 0, 0, 0, time.Local),
 		Install:   "go get -u github.com/vurtneyang/kratos/tool/kratos-protoc@" + Version,
 		Summary:   "快速方便生成pb.go的protoc封装，windows、Linux请先安装protoc工具",
 		Platform:  []string{"darwin", "linux", "windows"},
@@ -40,6 +40,15 @@ var toolIndexs = []*Tool{
 		Platform:  []string{"darwin", "linux", "windows"},
 		Author:    "kratos",
 	},
+	{
+		Name:      "genredis",
+		Alias:     "kratos-gen-redis",
+		BuildTime: time.Date(2026, 10, 18, 0, 0, 0, 0, time.Local),
+		Install:   "go get -u github.com/vurtneyang/kratos/tool/kratos-gen-redis@" + Version,
+		Summary:   "redis缓存代码生成",
+		Platform:  []string{"darwin", "linux", "windows"},
+		Author:    "kratos",
+	},
 	{
 		Name:         "genproject",
 		Alias:        "kratos-gen-project",
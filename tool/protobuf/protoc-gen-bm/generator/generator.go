@@ -54,6 +54,7 @@ func (t *bm) generateForFile(file *descriptor.FileDescriptorProto) *plugin.CodeG
 	for i, service := range file.Service {
 		count += t.generateBMInterface(file, service)
 		t.generateBMRoute(file, service, i)
+		t.generateBMClient(file, service)
 	}
 
 	resp.Name = proto.String(naming.GenFileName(file, ".bm.go"))
@@ -342,6 +343,96 @@ func (t *bm) generateBMRoute(
 	}
 }
 
+func (t *bm) generateBMClient(file *descriptor.FileDescriptorProto, service *descriptor.ServiceDescriptorProto) {
+	servName := naming.ServiceName(service)
+	clientName := servName + `BMClient`
+	structName := utils.LcFirst(servName) + `BMClient`
+
+	type methodInfo struct {
+		method      *descriptor.MethodDescriptorProto
+		comments    typemap.DefinitionComments
+		respType    string
+		respDynamic bool
+	}
+	var methList []methodInfo
+	for _, method := range service.Method {
+		if !t.ShouldGenForMethod(file, service, method) {
+			continue
+		}
+		comments, _ := t.Reg.MethodComments(file, service, method)
+		tags := tag.GetTagsInComment(comments.Leading)
+		// the client can't build multipart request or handle download for now
+		if tag.GetTagValue("dynamic", tags) == "true" ||
+			tag.GetTagValue("multipart", tags) != "" ||
+			tag.GetTagValue("download", tags) != "" {
+			continue
+		}
+		info := methodInfo{method: method, comments: comments}
+		if tag.GetTagValue("dynamic_resp", tags) == "true" {
+			info.respType = `interface{}`
+			info.respDynamic = true
+		} else {
+			info.respType = `*` + t.GoTypeName(method.GetOutputType())
+		}
+		methList = append(methList, info)
+	}
+
+	t.P()
+	t.P(`// `, clientName, ` is the client API for `, servName, ` service.`)
+	t.P(`type `, clientName, ` interface {`)
+	for _, m := range methList {
+		t.PrintComments(m.comments)
+		t.P(fmt.Sprintf(`	%s(ctx context.Context, req *%s) (resp %s, err error)`,
+			naming.MethodName(m.method), t.GoTypeName(m.method.GetInputType()), m.respType))
+		t.P()
+	}
+	t.P(`}`)
+	t.P()
+	t.P(`type `, structName, ` struct {`)
+	t.P(`	cc   *bm.Client`)
+	t.P(`	host string`)
+	t.P(`}`)
+	t.P()
+	t.P(`// New`, clientName, ` new a blademaster client of `, servName, ` service, host is like http://127.0.0.1:8000`)
+	t.P(`func New`, clientName, `(cc *bm.Client, host string) `, clientName, ` {`)
+	t.P(`	return &`, structName, `{cc: cc, host: host}`)
+	t.P(`}`)
+	for _, m := range methList {
+		methName := naming.MethodName(m.method)
+		apiInfo := t.GetHttpInfoCached(file, service, m.method)
+		fields := []string{
+			`Method: "` + apiInfo.HttpMethod + `"`,
+			`Path: Path` + servName + methName,
+		}
+		if rule, err := generator.ParseBMMethod(m.method); err == nil && rule.HTTPRule.Body != "" {
+			if rule.HTTPRule.Body == "*" {
+				fields = append(fields, `Body: "*"`)
+			} else {
+				fields = append(fields, `Body: "`+strings.TrimPrefix(apiInfo.Body, ".")+`"`)
+			}
+		}
+		if apiInfo.ResponseBody != "" {
+			fields = append(fields, `ResponseBody: "`+strings.TrimPrefix(apiInfo.ResponseBody, ".")+`"`)
+		}
+		t.P()
+		t.P(`func (c *`, structName, `) `, methName, `(ctx context.Context, req *`, t.GoTypeName(m.method.GetInputType()),
+			`) (resp `, m.respType, `, err error) {`)
+		clientMethod := `&bm.ClientMethod{` + strings.Join(fields, `, `) + `}`
+		if m.respDynamic {
+			t.P(`	err = c.cc.Invoke(ctx, c.host, `, clientMethod, `, req, &resp)`)
+			t.P(`	return`)
+		} else {
+			t.P(`	out := new(`, t.GoTypeName(m.method.GetOutputType()), `)`)
+			t.P(`	if err = c.cc.Invoke(ctx, c.host, `, clientMethod, `, req, out); err != nil {`)
+			t.P(`		return`)
+			t.P(`	}`)
+			t.P(`	resp = out`)
+			t.P(`	return`)
+		}
+		t.P(`}`)
+	}
+}
+
 func (t *bm) hasHeaderTag(md *typemap.MessageDefinition) bool {
 	if md.Descriptor.Field == nil {
 		return false
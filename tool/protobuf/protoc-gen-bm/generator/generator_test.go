@@ -3,10 +3,13 @@ package generator
 import (
 	"os"
 	"os/exec"
+	"strings"
 	"testing"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
 	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+	"google.golang.org/genproto/googleapis/api/annotations"
 )
 
 func TestGenerateParseCommandLineParamsError(t *testing.T) {
@@ -25,3 +28,57 @@ func TestGenerateParseCommandLineParamsError(t *testing.T) {
 	}
 	t.Fatalf("process ran with err %v, want exit status 1", err)
 }
+
+func TestGenerateClient(t *testing.T) {
+	opts := &descriptor.MethodOptions{}
+	rule := &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Put{Put: "/user/{mid}"},
+		Body:    "info",
+	}
+	if err := proto.SetExtension(opts, annotations.E_Http, rule); err != nil {
+		t.Fatal(err)
+	}
+	file := &descriptor.FileDescriptorProto{
+		Name:    proto.String("api.proto"),
+		Package: proto.String("user.api"),
+		Options: &descriptor.FileOptions{GoPackage: proto.String("api")},
+		MessageType: []*descriptor.DescriptorProto{
+			{Name: proto.String("UserReq")},
+			{Name: proto.String("UserReply")},
+		},
+		Service: []*descriptor.ServiceDescriptorProto{{
+			Name: proto.String("User"),
+			Method: []*descriptor.MethodDescriptorProto{
+				{
+					Name:       proto.String("Info"),
+					InputType:  proto.String(".user.api.UserReq"),
+					OutputType: proto.String(".user.api.UserReply"),
+				},
+				{
+					Name:       proto.String("Update"),
+					InputType:  proto.String(".user.api.UserReq"),
+					OutputType: proto.String(".user.api.UserReply"),
+					Options:    opts,
+				},
+			},
+		}},
+	}
+	resp := BmGenerator().Generate(&plugin.CodeGeneratorRequest{
+		FileToGenerate: []string{"api.proto"},
+		ProtoFile:      []*descriptor.FileDescriptorProto{file},
+	})
+	if len(resp.File) != 1 {
+		t.Fatalf("want 1 file, got %d", len(resp.File))
+	}
+	content := resp.File[0].GetContent()
+	for _, want := range []string{
+		"type UserBMClient interface {",
+		"func NewUserBMClient(cc *bm.Client, host string) UserBMClient {",
+		`&bm.ClientMethod{Method: "GET", Path: PathUserInfo}`,
+		`&bm.ClientMethod{Method: "PUT", Path: PathUserUpdate, Body: "Info"}`,
+	} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("generated code should contain %q, got:\n%s", want, content)
+		}
+	}
+}
@@ -0,0 +1,515 @@
+package generator
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+
+	"kratos/tool/protobuf/pkg/generator"
+	"kratos/tool/protobuf/pkg/tag"
+	"kratos/tool/protobuf/pkg/typemap"
+)
+
+const (
+	_openAPIVersion = "3.1.0"
+	_outputName     = "openapi.json"
+	_schemaPrefix   = "#/components/schemas/"
+	_errorName      = "Error"
+
+	// path of enum type and enum value in descriptor.FileDescriptorProto
+	_enumTypePath  = 5
+	_enumValuePath = 2
+)
+
+type openapi struct {
+	generator.Base
+	// schemas will fill into components.schemas
+	// key is full qualified proto name without the leading dot
+	schemas map[string]*schemaObject
+	// enums is full qualified proto name to enum of all proto files
+	enums map[string]*descriptor.EnumDescriptorProto
+	// ecodes is the values of enum ends with ErrCode in files to generate
+	ecodes []*schemaObject
+}
+
+// OpenAPIGenerator OpenAPI 3.1 generator.
+func OpenAPIGenerator() *openapi {
+	t := &openapi{}
+	return t
+}
+
+// Generate merges all services of the files to generate into one spec.
+func (t *openapi) Generate(in *plugin.CodeGeneratorRequest) *plugin.CodeGeneratorResponse {
+	t.Setup(in)
+	t.schemas = make(map[string]*schemaObject)
+	t.enums = make(map[string]*descriptor.EnumDescriptorProto)
+	t.ecodes = nil
+	for _, f := range in.ProtoFile {
+		prefix := "." + f.GetPackage()
+		if f.GetPackage() == "" {
+			prefix = ""
+		}
+		t.indexEnums(prefix, f.EnumType, f.MessageType)
+	}
+
+	resp := new(plugin.CodeGeneratorResponse)
+	doc := &openAPIObject{
+		OpenAPI: _openAPIVersion,
+		Paths:   pathsObject{},
+	}
+	var pkgs []string
+	for _, f := range t.GenFiles {
+		t.collectEcodes(f)
+		if len(f.Service) == 0 {
+			continue
+		}
+		pkgs = appendUnique(pkgs, f.GetPackage())
+		for _, svc := range f.Service {
+			t.generateService(doc, f, svc)
+		}
+	}
+	if len(doc.Paths) == 0 {
+		return resp
+	}
+	doc.Info = infoObject{
+		Title:   strings.Join(pkgs, ", "),
+		Version: version(pkgs),
+	}
+	doc.Components = componentsObject{
+		Schemas: t.schemas,
+		Responses: map[string]*responseObject{
+			_errorName: {
+				Description: "Business error, the http status is still 200 with a non-zero code.",
+				Content:     jsonContent(&schemaObject{Ref: _schemaPrefix + _errorName}),
+			},
+		},
+	}
+	t.schemas[_errorName] = t.errorSchema()
+
+	b, _ := json.MarshalIndent(doc, "", "    ")
+	name := path.Join(path.Dir(t.GenFiles[0].GetName()), _outputName)
+	str := string(b)
+	resp.File = append(resp.File, &plugin.CodeGeneratorResponse_File{
+		Name:    &name,
+		Content: &str,
+	})
+	return resp
+}
+
+func (t *openapi) indexEnums(prefix string, enums []*descriptor.EnumDescriptorProto, msgs []*descriptor.DescriptorProto) {
+	for _, e := range enums {
+		t.enums[prefix+"."+e.GetName()] = e
+	}
+	for _, m := range msgs {
+		t.indexEnums(prefix+"."+m.GetName(), m.EnumType, m.NestedType)
+	}
+}
+
+// collectEcodes collects ecodes with the same rule as protoc-gen-ecode.
+func (t *openapi) collectEcodes(file *descriptor.FileDescriptorProto) {
+	for i, enum := range file.EnumType {
+		if !strings.HasSuffix(enum.GetName(), "ErrCode") {
+			continue
+		}
+		for j, item := range enum.Value {
+			if item.GetNumber() == 0 {
+				continue
+			}
+			comment := leadingComments(file, []int32{_enumTypePath, int32(i), _enumValuePath, int32(j)})
+			t.ecodes = append(t.ecodes, &schemaObject{
+				Const:       item.GetNumber(),
+				Title:       item.GetName(),
+				Description: comment,
+			})
+		}
+	}
+}
+
+func (t *openapi) errorSchema() *schemaObject {
+	code := &schemaObject{
+		Type:        "integer",
+		Description: "ecode, common codes are defined in kratos/pkg/ecode",
+	}
+	if len(t.ecodes) > 0 {
+		sort.Slice(t.ecodes, func(i, j int) bool {
+			return t.ecodes[i].Const.(int32) < t.ecodes[j].Const.(int32)
+		})
+		code.OneOf = make([]*schemaObject, 0, len(t.ecodes)+1)
+		code.OneOf = append(code.OneOf, t.ecodes...)
+		code.OneOf = append(code.OneOf, &schemaObject{
+			Not:   &schemaObject{Enum: append(ecodeValues(t.ecodes), 0)},
+			Title: "common ecode",
+		})
+	} else {
+		code.Not = &schemaObject{Const: 0}
+	}
+	return &schemaObject{
+		Type: "object",
+		Properties: schemaProperties{
+			{Key: "code", Value: code},
+			{Key: "message", Value: &schemaObject{Type: "string"}},
+			{Key: "now", Value: &schemaObject{Type: "integer", Format: "int64"}},
+		},
+		Required: []string{"code", "message"},
+	}
+}
+
+func (t *openapi) generateService(doc *openAPIObject, file *descriptor.FileDescriptorProto, svc *descriptor.ServiceDescriptorProto) {
+	tagName := file.GetPackage() + "." + svc.GetName()
+	svcComment, _ := t.Reg.ServiceComments(file, svc)
+	doc.Tags = append(doc.Tags, tagObject{
+		Name:        tagName,
+		Description: strings.Trim(svcComment.Leading, "\n\r "),
+	})
+	for _, meth := range svc.Method {
+		if !t.ShouldGenForMethod(file, svc, meth) {
+			continue
+		}
+		comments, _ := t.Reg.MethodComments(file, svc, meth)
+		tags := tag.GetTagsInComment(comments.Leading)
+		if tag.GetTagValue("dynamic", tags) == "true" {
+			continue
+		}
+		apiInfo := t.GetHttpInfoCached(file, svc, meth)
+		p := openAPIPath(apiInfo.Path)
+		pathItem, ok := doc.Paths[p]
+		if !ok {
+			pathItem = &pathItemObject{}
+			doc.Paths[p] = pathItem
+		}
+		op := &operationObject{
+			Tags:        []string{tagName},
+			Summary:     apiInfo.Title,
+			Description: apiInfo.Description,
+			OperationID: svc.GetName() + "_" + meth.GetName(),
+		}
+		setOperation(pathItem, apiInfo.HttpMethod, op)
+		t.generateRequest(op, meth, apiInfo, tags)
+		t.generateResponse(op, meth, apiInfo, tags)
+	}
+}
+
+func setOperation(pathItem *pathItemObject, httpMethod string, op *operationObject) {
+	switch httpMethod {
+	case http.MethodPost:
+		pathItem.Post = op
+	case http.MethodPut:
+		pathItem.Put = op
+	case http.MethodDelete:
+		pathItem.Delete = op
+	case http.MethodPatch:
+		pathItem.Patch = op
+	default:
+		pathItem.Get = op
+	}
+}
+
+func (t *openapi) generateRequest(op *operationObject, meth *descriptor.MethodDescriptorProto,
+	apiInfo *generator.HTTPInfo, tags []reflect.StructTag) {
+	request := t.Reg.MessageDefinition(meth.GetInputType())
+	// fields bound from path or body are not query parameters
+	bound := make(map[*descriptor.FieldDescriptorProto]bool)
+	for _, param := range generator.GetUriParams(apiInfo.Path) {
+		name := strings.SplitN(param, "=", 2)[0]
+		p := &parameterObject{Name: name, In: "path", Required: true}
+		if field := findField(request, name); field != nil {
+			bound[field] = true
+			p.Schema, p.Description = t.fieldSchema(request, field)
+		} else {
+			p.Schema = &schemaObject{Type: "string"}
+		}
+		op.Parameters = append(op.Parameters, p)
+	}
+
+	var body string
+	if rule, err := generator.ParseBMMethod(meth); err == nil {
+		body = rule.HTTPRule.Body
+	}
+	switch {
+	case body == "*":
+		op.RequestBody = &requestBodyObject{
+			Content:  jsonContent(t.messageRef(request)),
+			Required: true,
+		}
+		return
+	case body != "":
+		if field := findField(request, body); field != nil {
+			bound[field] = true
+			schema, desc := t.fieldSchema(request, field)
+			op.RequestBody = &requestBodyObject{
+				Description: desc,
+				Content:     jsonContent(schema),
+				Required:    true,
+			}
+		}
+	case apiInfo.HttpMethod != http.MethodGet && apiInfo.HttpMethod != http.MethodDelete:
+		// without body rule blademaster binds by the Content-Type
+		content := jsonContent(t.messageRef(request))
+		if multipart := tag.GetTagValue("multipart", tags); multipart != "" {
+			content["multipart/form-data"] = &mediaTypeObject{Schema: t.messageRef(request)}
+		} else {
+			content["application/x-www-form-urlencoded"] = &mediaTypeObject{Schema: t.messageRef(request)}
+		}
+		op.RequestBody = &requestBodyObject{Content: content, Required: true}
+		return
+	}
+	for _, field := range request.Descriptor.Field {
+		if bound[field] || !generator.IsScalar(field) {
+			continue
+		}
+		p := &parameterObject{
+			Name:     generator.GetFormOrJSONName(field),
+			In:       "query",
+			Required: generator.GetFieldRequired(field, t.Reg, request),
+		}
+		p.Schema, p.Description = t.fieldSchema(request, field)
+		op.Parameters = append(op.Parameters, p)
+	}
+}
+
+func (t *openapi) generateResponse(op *operationObject, meth *descriptor.MethodDescriptorProto,
+	apiInfo *generator.HTTPInfo, tags []reflect.StructTag) {
+	op.Responses = responsesObject{
+		"default": {Ref: "#/components/responses/" + _errorName},
+	}
+	if tag.GetTagValue("download", tags) != "" {
+		op.Responses["200"] = &responseObject{
+			Description: "A successful response.",
+			Content: map[string]*mediaTypeObject{
+				"application/octet-stream": {Schema: &schemaObject{Type: "string", Format: "binary"}},
+				"application/json":         {Schema: &schemaObject{Ref: _schemaPrefix + _errorName}},
+			},
+		}
+		return
+	}
+	var data *schemaObject
+	if tag.GetTagValue("dynamic_resp", tags) == "true" {
+		data = &schemaObject{}
+	} else {
+		reply := t.Reg.MessageDefinition(meth.GetOutputType())
+		data = t.messageRef(reply)
+		if rule, err := generator.ParseBMMethod(meth); err == nil && rule.HTTPRule.ResponseBody != "" {
+			if field := findField(reply, rule.HTTPRule.ResponseBody); field != nil {
+				data, _ = t.fieldSchema(reply, field)
+			}
+		}
+	}
+	// proto 里面的response只定义data里面的
+	// 所以需要把code message data 这一级加上
+	// NOTE: bm 的业务错误也是 http 200，code 非0，所以 200 是成功或者错误的 oneOf
+	op.Responses["200"] = &responseObject{
+		Description: "A successful response, or a business error with a non-zero code.",
+		Content: jsonContent(&schemaObject{
+			OneOf: []*schemaObject{
+				{
+					Type: "object",
+					Properties: schemaProperties{
+						{Key: "code", Value: &schemaObject{Type: "integer", Const: 0}},
+						{Key: "message", Value: &schemaObject{Type: "string"}},
+						{Key: "now", Value: &schemaObject{Type: "integer", Format: "int64"}},
+						{Key: "data", Value: data},
+					},
+					Required: []string{"code", "message"},
+				},
+				{Ref: _schemaPrefix + _errorName},
+			},
+		}),
+	}
+}
+
+// messageRef returns the reference of msg and adds it to components.
+func (t *openapi) messageRef(msg *typemap.MessageDefinition) *schemaObject {
+	name := strings.TrimPrefix(msg.ProtoName(), ".")
+	if _, ok := t.schemas[name]; !ok {
+		schema := &schemaObject{
+			Type:        "object",
+			Description: strings.Trim(msg.Comments.Leading, "\n\r "),
+		}
+		// set before walking the fields to stop recursive message
+		t.schemas[name] = schema
+		for _, field := range msg.Descriptor.Field {
+			key := generator.GetJSONFieldName(field)
+			if key == "-" {
+				continue
+			}
+			if generator.GetFieldRequired(field, t.Reg, msg) {
+				schema.Required = append(schema.Required, key)
+			}
+			fs, desc := t.fieldSchema(msg, field)
+			fs.Description = desc
+			schema.Properties = append(schema.Properties, keyVal{Key: key, Value: fs})
+		}
+	}
+	return &schemaObject{Ref: _schemaPrefix + name}
+}
+
+// enumRef returns the reference of enum and adds it to components.
+func (t *openapi) enumRef(typeName string) *schemaObject {
+	name := strings.TrimPrefix(typeName, ".")
+	if _, ok := t.schemas[name]; !ok {
+		schema := &schemaObject{Type: "integer"}
+		if enum, ok := t.enums[typeName]; ok {
+			for _, v := range enum.Value {
+				schema.OneOf = append(schema.OneOf, &schemaObject{Const: v.GetNumber(), Title: v.GetName()})
+			}
+		}
+		t.schemas[name] = schema
+	}
+	return &schemaObject{Ref: _schemaPrefix + name}
+}
+
+// fieldSchema returns the schema with the validate constraints and the
+// description of field.
+func (t *openapi) fieldSchema(msg *typemap.MessageDefinition, field *descriptor.FieldDescriptorProto) (*schemaObject, string) {
+	var schema *schemaObject
+	if generator.IsMap(field, t.Reg) {
+		entry := t.Reg.MessageDefinition(field.GetTypeName())
+		value, _ := t.fieldSchema(entry, entry.Descriptor.Field[1])
+		schema = &schemaObject{Type: "object", AdditionalProperties: value}
+	} else {
+		schema = t.singularSchema(field)
+		if generator.IsRepeated(field) {
+			schema = &schemaObject{Type: "array", Items: schema}
+		}
+	}
+
+	fComment, _ := t.Reg.FieldComments(msg, field)
+	desc := strings.Trim(strings.Join(tag.GetCommentWithoutTag(fComment.Leading), "\n"), "\n\r ")
+	rest := applyValidate(schema, getValidateTag(t.Reg, msg, field))
+	if len(rest) > 0 {
+		if desc != "" {
+			desc += ","
+		}
+		desc += strings.Join(rest, ",")
+	}
+	return schema, desc
+}
+
+func (t *openapi) singularSchema(field *descriptor.FieldDescriptorProto) *schemaObject {
+	switch field.GetType() {
+	case descriptor.FieldDescriptorProto_TYPE_BOOL:
+		return &schemaObject{Type: "boolean"}
+	case descriptor.FieldDescriptorProto_TYPE_DOUBLE:
+		return &schemaObject{Type: "number", Format: "double"}
+	case descriptor.FieldDescriptorProto_TYPE_FLOAT:
+		return &schemaObject{Type: "number", Format: "float"}
+	case descriptor.FieldDescriptorProto_TYPE_INT32,
+		descriptor.FieldDescriptorProto_TYPE_SINT32,
+		descriptor.FieldDescriptorProto_TYPE_SFIXED32:
+		return &schemaObject{Type: "integer", Format: "int32"}
+	case descriptor.FieldDescriptorProto_TYPE_UINT32,
+		descriptor.FieldDescriptorProto_TYPE_FIXED32:
+		return &schemaObject{Type: "integer", Format: "uint32"}
+	case descriptor.FieldDescriptorProto_TYPE_INT64,
+		descriptor.FieldDescriptorProto_TYPE_SINT64,
+		descriptor.FieldDescriptorProto_TYPE_SFIXED64:
+		return &schemaObject{Type: "integer", Format: "int64"}
+	case descriptor.FieldDescriptorProto_TYPE_UINT64,
+		descriptor.FieldDescriptorProto_TYPE_FIXED64:
+		return &schemaObject{Type: "integer", Format: "uint64"}
+	case descriptor.FieldDescriptorProto_TYPE_STRING:
+		return &schemaObject{Type: "string"}
+	case descriptor.FieldDescriptorProto_TYPE_BYTES:
+		return &schemaObject{Type: "string", ContentEncoding: "base64"}
+	case descriptor.FieldDescriptorProto_TYPE_ENUM:
+		return t.enumRef(field.GetTypeName())
+	case descriptor.FieldDescriptorProto_TYPE_MESSAGE:
+		return t.messageRef(t.Reg.MessageDefinition(field.GetTypeName()))
+	}
+	return &schemaObject{}
+}
+
+// getValidateTag get validate tag from gogoproto.moretags then comment.
+func getValidateTag(reg *typemap.Registry, msg *typemap.MessageDefinition, field *descriptor.FieldDescriptorProto) string {
+	var tags []reflect.StructTag
+	if moretags := tag.GetMoreTags(field); moretags != nil {
+		tags = []reflect.StructTag{reflect.StructTag(*moretags)}
+	}
+	if len(tags) == 0 {
+		fComment, _ := reg.FieldComments(msg, field)
+		tags = tag.GetTagsInComment(fComment.Leading)
+	}
+	return tag.GetTagValue("validate", tags)
+}
+
+// findField finds the field by name of google.api.http rule, nested field
+// like "info.mid" is not supported.
+func findField(msg *typemap.MessageDefinition, name string) *descriptor.FieldDescriptorProto {
+	for _, field := range msg.Descriptor.Field {
+		if field.GetName() == name || field.GetJsonName() == name || generator.GetFormOrJSONName(field) == name {
+			return field
+		}
+	}
+	return nil
+}
+
+func jsonContent(schema *schemaObject) map[string]*mediaTypeObject {
+	return map[string]*mediaTypeObject{"application/json": {Schema: schema}}
+}
+
+// openAPIPath transforms /user/:id and /user/{id=*} into /user/{id}.
+func openAPIPath(p string) string {
+	segs := strings.Split(p, "/")
+	for i, seg := range segs {
+		if len(seg) > 1 && seg[0] == ':' {
+			segs[i] = "{" + seg[1:] + "}"
+		} else if len(seg) > 2 && seg[0] == '{' && seg[len(seg)-1] == '}' {
+			segs[i] = "{" + strings.SplitN(seg[1:len(seg)-1], "=", 2)[0] + "}"
+		}
+	}
+	return strings.Join(segs, "/")
+}
+
+func leadingComments(file *descriptor.FileDescriptorProto, p []int32) string {
+	for _, loc := range file.GetSourceCodeInfo().GetLocation() {
+		if len(loc.Path) != len(p) {
+			continue
+		}
+		equal := true
+		for i := range p {
+			if loc.Path[i] != p[i] {
+				equal = false
+				break
+			}
+		}
+		if equal {
+			return strings.Trim(loc.GetLeadingComments()+loc.GetTrailingComments(), "\n\r ")
+		}
+	}
+	return ""
+}
+
+func ecodeValues(ecodes []*schemaObject) []interface{} {
+	values := make([]interface{}, 0, len(ecodes))
+	for _, e := range ecodes {
+		values = append(values, e.Const)
+	}
+	return values
+}
+
+func appendUnique(ss []string, s string) []string {
+	for _, v := range ss {
+		if v == s {
+			return ss
+		}
+	}
+	return append(ss, s)
+}
+
+// version returns the version of package like v1, defaults to 1.0.
+func version(pkgs []string) string {
+	r := regexp.MustCompile(`v(\d+)$`)
+	for _, pkg := range pkgs {
+		if strs := r.FindStringSubmatch(pkg); len(strs) >= 2 {
+			return strs[1]
+		}
+	}
+	return "1.0"
+}
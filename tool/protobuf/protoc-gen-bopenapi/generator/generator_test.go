@@ -0,0 +1,217 @@
+package generator
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	plugin "github.com/golang/protobuf/protoc-gen-go/plugin"
+	"google.golang.org/genproto/googleapis/api/annotations"
+
+	"kratos/tool/protobuf/pkg/extensions/gogoproto"
+)
+
+func field(name string, number int32, typ descriptor.FieldDescriptorProto_Type, validate string) *descriptor.FieldDescriptorProto {
+	f := &descriptor.FieldDescriptorProto{
+		Name:   proto.String(name),
+		Number: proto.Int32(number),
+		Type:   typ.Enum(),
+		Label:  descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+	}
+	if validate != "" {
+		f.Options = &descriptor.FieldOptions{}
+		if err := proto.SetExtension(f.Options, gogoproto.E_Moretags, proto.String(`validate:"`+validate+`"`)); err != nil {
+			panic(err)
+		}
+	}
+	return f
+}
+
+func httpOptions(rule *annotations.HttpRule) *descriptor.MethodOptions {
+	opts := &descriptor.MethodOptions{}
+	if err := proto.SetExtension(opts, annotations.E_Http, rule); err != nil {
+		panic(err)
+	}
+	return opts
+}
+
+func testRequest() *plugin.CodeGeneratorRequest {
+	tags := field("tags", 3, descriptor.FieldDescriptorProto_TYPE_STRING, "max=10,dive,min=1")
+	tags.Label = descriptor.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	info := field("info", 2, descriptor.FieldDescriptorProto_TYPE_MESSAGE, "")
+	info.TypeName = proto.String(".user.api.Info")
+	user := &descriptor.FileDescriptorProto{
+		Name:    proto.String("user/api.proto"),
+		Package: proto.String("user.api"),
+		Options: &descriptor.FileOptions{GoPackage: proto.String("api")},
+		EnumType: []*descriptor.EnumDescriptorProto{{
+			Name: proto.String("UserErrCode"),
+			Value: []*descriptor.EnumValueDescriptorProto{
+				{Name: proto.String("OK"), Number: proto.Int32(0)},
+				{Name: proto.String("UserNotExist"), Number: proto.Int32(-404)},
+			},
+		}},
+		MessageType: []*descriptor.DescriptorProto{
+			{
+				Name: proto.String("Info"),
+				Field: []*descriptor.FieldDescriptorProto{
+					field("mid", 1, descriptor.FieldDescriptorProto_TYPE_INT64, "gt=0,required"),
+					field("name", 2, descriptor.FieldDescriptorProto_TYPE_STRING, "min=1,max=32"),
+					field("email", 3, descriptor.FieldDescriptorProto_TYPE_STRING, "omitempty,email"),
+				},
+			},
+			{
+				Name: proto.String("UserReq"),
+				Field: []*descriptor.FieldDescriptorProto{
+					field("mid", 1, descriptor.FieldDescriptorProto_TYPE_INT64, "gt=0,required"),
+					info,
+					tags,
+				},
+			},
+		},
+		Service: []*descriptor.ServiceDescriptorProto{{
+			Name: proto.String("User"),
+			Method: []*descriptor.MethodDescriptorProto{
+				{
+					Name:       proto.String("Info"),
+					InputType:  proto.String(".user.api.UserReq"),
+					OutputType: proto.String(".user.api.Info"),
+					Options:    httpOptions(&annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: "/user/{mid}"}}),
+				},
+				{
+					Name:       proto.String("Update"),
+					InputType:  proto.String(".user.api.UserReq"),
+					OutputType: proto.String(".user.api.Info"),
+					Options: httpOptions(&annotations.HttpRule{
+						Pattern: &annotations.HttpRule_Put{Put: "/user/{mid}"},
+						Body:    "info",
+					}),
+				},
+			},
+		}},
+	}
+	article := &descriptor.FileDescriptorProto{
+		Name:    proto.String("user/article.proto"),
+		Package: proto.String("user.api"),
+		Options: &descriptor.FileOptions{GoPackage: proto.String("api")},
+		Service: []*descriptor.ServiceDescriptorProto{{
+			Name: proto.String("Article"),
+			Method: []*descriptor.MethodDescriptorProto{{
+				Name:       proto.String("Add"),
+				InputType:  proto.String(".user.api.Info"),
+				OutputType: proto.String(".user.api.Info"),
+				Options: httpOptions(&annotations.HttpRule{
+					Pattern: &annotations.HttpRule_Post{Post: "/article"},
+					Body:    "*",
+				}),
+			}},
+		}},
+	}
+	return &plugin.CodeGeneratorRequest{
+		FileToGenerate: []string{"user/api.proto", "user/article.proto"},
+		ProtoFile:      []*descriptor.FileDescriptorProto{user, article},
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	resp := OpenAPIGenerator().Generate(testRequest())
+	if len(resp.File) != 1 || resp.File[0].GetName() != "user/openapi.json" {
+		t.Fatalf("want one merged spec, got %+v", resp.File)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(resp.File[0].GetContent()), &doc); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path []string
+		want interface{}
+	}{
+		{[]string{"openapi"}, "3.1.0"},
+		{[]string{"paths", "/article", "post", "requestBody", "content", "application/json", "schema", "$ref"}, "#/components/schemas/user.api.Info"},
+		{[]string{"paths", "/user/{mid}", "get", "operationId"}, "User_Info"},
+		{[]string{"paths", "/user/{mid}", "put", "requestBody", "content", "application/json", "schema", "$ref"}, "#/components/schemas/user.api.Info"},
+		{[]string{"paths", "/user/{mid}", "get", "responses", "200", "content", "application/json", "schema", "oneOf", "0", "properties", "data", "$ref"}, "#/components/schemas/user.api.Info"},
+		{[]string{"paths", "/user/{mid}", "get", "responses", "200", "content", "application/json", "schema", "oneOf", "1", "$ref"}, "#/components/schemas/Error"},
+		{[]string{"paths", "/user/{mid}", "get", "responses", "default", "$ref"}, "#/components/responses/Error"},
+		{[]string{"components", "schemas", "user.api.Info", "required"}, []interface{}{"mid"}},
+		{[]string{"components", "schemas", "user.api.Info", "properties", "mid", "exclusiveMinimum"}, float64(0)},
+		{[]string{"components", "schemas", "user.api.Info", "properties", "name", "minLength"}, float64(1)},
+		{[]string{"components", "schemas", "user.api.Info", "properties", "name", "maxLength"}, float64(32)},
+		{[]string{"components", "schemas", "user.api.Info", "properties", "email", "format"}, "email"},
+		{[]string{"components", "schemas", "Error", "properties", "code", "oneOf"}, []interface{}{
+			map[string]interface{}{"title": "UserNotExist", "const": float64(-404)},
+			map[string]interface{}{"title": "common ecode", "not": map[string]interface{}{"enum": []interface{}{float64(-404), float64(0)}}},
+		}},
+	}
+	for _, test := range tests {
+		var v interface{} = doc
+		for _, key := range test.path {
+			if a, ok := v.([]interface{}); ok {
+				i, err := strconv.Atoi(key)
+				if err != nil || i >= len(a) {
+					t.Fatalf("%v: %v is not an index", test.path, key)
+				}
+				v = a[i]
+				continue
+			}
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				t.Fatalf("%v: %v is not an object", test.path, key)
+			}
+			v = m[key]
+		}
+		if !reflect.DeepEqual(v, test.want) {
+			t.Errorf("%v: want %v, got %v", test.path, test.want, v)
+		}
+	}
+
+	// query parameters of GET without the path parameter
+	params := doc["paths"].(map[string]interface{})["/user/{mid}"].(map[string]interface{})["get"].(map[string]interface{})["parameters"].([]interface{})
+	var names []string
+	for _, p := range params {
+		names = append(names, p.(map[string]interface{})["in"].(string)+":"+p.(map[string]interface{})["name"].(string))
+	}
+	if want := []string{"path:mid", "query:tags"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("want parameters %v, got %v", want, names)
+	}
+	tagsSchema := params[1].(map[string]interface{})["schema"].(map[string]interface{})
+	if tagsSchema["maxItems"] != float64(10) || tagsSchema["items"].(map[string]interface{})["minLength"] != float64(1) {
+		t.Errorf("unexpected tags schema %v", tagsSchema)
+	}
+}
+
+func TestApplyValidate(t *testing.T) {
+	s := &schemaObject{Type: "string"}
+	rest := applyValidate(s, "required,len=6,startswith=ab,email|url,datetime=2006-01-02")
+	if *s.MinLength != 6 || *s.MaxLength != 6 || s.Pattern != "^ab" {
+		t.Errorf("unexpected schema %+v", s)
+	}
+	if want := []string{"email|url", "datetime=2006-01-02"}; !reflect.DeepEqual(rest, want) {
+		t.Errorf("want rest %v, got %v", want, rest)
+	}
+
+	n := &schemaObject{Type: "integer"}
+	if rest = applyValidate(n, "gte=1,lt=100,ne=50"); len(rest) != 0 {
+		t.Errorf("want no rest, got %v", rest)
+	}
+	if *n.Minimum != 1 || *n.ExclusiveMaximum != 100 || n.Not.Const != float64(50) {
+		t.Errorf("unexpected schema %+v", n)
+	}
+}
+
+func TestErrorSchema(t *testing.T) {
+	ecodes := make([]*schemaObject, 1, 4)
+	ecodes[0] = &schemaObject{Const: int32(-404), Title: "UserNotExist"}
+	g := &openapi{ecodes: ecodes}
+	g.errorSchema()
+	code := g.errorSchema().Properties[0].Value
+	if len(g.ecodes) != 1 || len(code.OneOf) != 2 || ecodes[:2][1] != nil {
+		t.Fatalf("ecodes should not be changed by error schema, got %+v", code.OneOf)
+	}
+	if code := (&openapi{}).errorSchema().Properties[0].Value; code.Not == nil || code.Not.Const != 0 {
+		t.Fatalf("code of error should not be 0, got %+v", code)
+	}
+}
@@ -0,0 +1,154 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// https://spec.openapis.org/oas/v3.1.0#openapi-object
+type openAPIObject struct {
+	OpenAPI    string           `json:"openapi"`
+	Info       infoObject       `json:"info"`
+	Paths      pathsObject      `json:"paths"`
+	Components componentsObject `json:"components"`
+	Tags       []tagObject      `json:"tags,omitempty"`
+}
+
+// https://spec.openapis.org/oas/v3.1.0#info-object
+type infoObject struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// https://spec.openapis.org/oas/v3.1.0#tag-object
+type tagObject struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// https://spec.openapis.org/oas/v3.1.0#paths-object
+type pathsObject map[string]*pathItemObject
+
+// https://spec.openapis.org/oas/v3.1.0#path-item-object
+type pathItemObject struct {
+	Get    *operationObject `json:"get,omitempty"`
+	Put    *operationObject `json:"put,omitempty"`
+	Post   *operationObject `json:"post,omitempty"`
+	Delete *operationObject `json:"delete,omitempty"`
+	Patch  *operationObject `json:"patch,omitempty"`
+}
+
+// https://spec.openapis.org/oas/v3.1.0#operation-object
+type operationObject struct {
+	Tags        []string           `json:"tags,omitempty"`
+	Summary     string             `json:"summary,omitempty"`
+	Description string             `json:"description,omitempty"`
+	OperationID string             `json:"operationId"`
+	Parameters  []*parameterObject `json:"parameters,omitempty"`
+	RequestBody *requestBodyObject `json:"requestBody,omitempty"`
+	Responses   responsesObject    `json:"responses"`
+}
+
+// https://spec.openapis.org/oas/v3.1.0#parameter-object
+type parameterObject struct {
+	Name        string        `json:"name"`
+	In          string        `json:"in"`
+	Description string        `json:"description,omitempty"`
+	Required    bool          `json:"required,omitempty"`
+	Schema      *schemaObject `json:"schema"`
+}
+
+// https://spec.openapis.org/oas/v3.1.0#request-body-object
+type requestBodyObject struct {
+	Description string                      `json:"description,omitempty"`
+	Content     map[string]*mediaTypeObject `json:"content"`
+	Required    bool                        `json:"required,omitempty"`
+}
+
+// https://spec.openapis.org/oas/v3.1.0#media-type-object
+type mediaTypeObject struct {
+	Schema *schemaObject `json:"schema"`
+}
+
+// https://spec.openapis.org/oas/v3.1.0#responses-object
+type responsesObject map[string]*responseObject
+
+// https://spec.openapis.org/oas/v3.1.0#response-object
+type responseObject struct {
+	Ref         string                      `json:"$ref,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	Content     map[string]*mediaTypeObject `json:"content,omitempty"`
+}
+
+// https://spec.openapis.org/oas/v3.1.0#components-object
+type componentsObject struct {
+	Schemas   map[string]*schemaObject   `json:"schemas,omitempty"`
+	Responses map[string]*responseObject `json:"responses,omitempty"`
+}
+
+type keyVal struct {
+	Key   string
+	Value *schemaObject
+}
+
+// schemaProperties keeps the order of fields defined in proto.
+type schemaProperties []keyVal
+
+func (op schemaProperties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i, kv := range op {
+		if i != 0 {
+			buf.WriteString(",")
+		}
+		key, err := json.Marshal(kv.Key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteString(":")
+		val, err := json.Marshal(kv.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(val)
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+// https://spec.openapis.org/oas/v3.1.0#schema-object
+// which is a superset of JSON Schema draft 2020-12, numeric constraints are
+// pointers since zero is a valid value.
+type schemaObject struct {
+	Ref         string `json:"$ref,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Format      string `json:"format,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	Properties           schemaProperties `json:"properties,omitempty"`
+	AdditionalProperties *schemaObject    `json:"additionalProperties,omitempty"`
+	Items                *schemaObject    `json:"items,omitempty"`
+	Required             []string         `json:"required,omitempty"`
+	OneOf                []*schemaObject  `json:"oneOf,omitempty"`
+	Not                  *schemaObject    `json:"not,omitempty"`
+
+	Const            interface{}   `json:"const,omitempty"`
+	Enum             []interface{} `json:"enum,omitempty"`
+	ContentEncoding  string        `json:"contentEncoding,omitempty"`
+	MultipleOf       *float64      `json:"multipleOf,omitempty"`
+	Maximum          *float64      `json:"maximum,omitempty"`
+	ExclusiveMaximum *float64      `json:"exclusiveMaximum,omitempty"`
+	Minimum          *float64      `json:"minimum,omitempty"`
+	ExclusiveMinimum *float64      `json:"exclusiveMinimum,omitempty"`
+	MaxLength        *uint64       `json:"maxLength,omitempty"`
+	MinLength        *uint64       `json:"minLength,omitempty"`
+	Pattern          string        `json:"pattern,omitempty"`
+	MaxItems         *uint64       `json:"maxItems,omitempty"`
+	MinItems         *uint64       `json:"minItems,omitempty"`
+	UniqueItems      bool          `json:"uniqueItems,omitempty"`
+	MaxProperties    *uint64       `json:"maxProperties,omitempty"`
+	MinProperties    *uint64       `json:"minProperties,omitempty"`
+}
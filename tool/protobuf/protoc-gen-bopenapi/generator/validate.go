@@ -0,0 +1,195 @@
+package generator
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var _stringFormats = map[string]string{
+	"email":    "email",
+	"url":      "uri",
+	"uri":      "uri",
+	"uuid":     "uuid",
+	"uuid4":    "uuid",
+	"ipv4":     "ipv4",
+	"ipv6":     "ipv6",
+	"hostname": "hostname",
+}
+
+var _stringPatterns = map[string]string{
+	"alpha":       "^[a-zA-Z]+$",
+	"alphanum":    "^[a-zA-Z0-9]+$",
+	"numeric":     "^[-+]?[0-9]+(?:\\.[0-9]+)?$",
+	"number":      "^[0-9]+$",
+	"hexadecimal": "^(0[xX])?[0-9a-fA-F]+$",
+}
+
+// applyValidate maps the validator.v9 rules in the validate tag to the json
+// schema constraints of s, rules after dive apply to the items of array or
+// the values of map. It returns the rules which can't be expressed.
+func applyValidate(s *schemaObject, validateTag string) (rest []string) {
+	if validateTag == "" {
+		return
+	}
+	rules := strings.Split(validateTag, ",")
+	target := s
+	for i := 0; i < len(rules); i++ {
+		rule := strings.TrimSpace(rules[i])
+		switch rule {
+		case "", "required", "omitempty":
+			continue
+		case "dive":
+			if target.Items != nil {
+				target = target.Items
+				continue
+			}
+			if target.AdditionalProperties != nil {
+				target = target.AdditionalProperties
+				// rules of map keys can't be expressed
+				if i+1 < len(rules) && rules[i+1] == "keys" {
+					for i < len(rules) && rules[i] != "endkeys" {
+						i++
+					}
+				}
+				continue
+			}
+			return append(rest, rules[i:]...)
+		}
+		name, param := rule, ""
+		if idx := strings.Index(rule, "="); idx > 0 {
+			name, param = rule[:idx], rule[idx+1:]
+		}
+		// or rules like "email|url" can't be expressed
+		if strings.Contains(rule, "|") || !applyRule(target, name, param) {
+			rest = append(rest, rule)
+		}
+	}
+	return
+}
+
+func applyRule(s *schemaObject, name, param string) bool {
+	switch s.Type {
+	case "string":
+		// the length of bytes is not the length of base64 string
+		if s.ContentEncoding != "" {
+			return false
+		}
+		return applyStringRule(s, name, param)
+	case "integer", "number":
+		return applyNumberRule(s, name, param)
+	case "array":
+		return applyCountRule(&s.MinItems, &s.MaxItems, name, param) ||
+			(name == "unique" && setTrue(&s.UniqueItems))
+	case "object":
+		if s.AdditionalProperties == nil {
+			return false
+		}
+		return applyCountRule(&s.MinProperties, &s.MaxProperties, name, param)
+	}
+	return false
+}
+
+func applyStringRule(s *schemaObject, name, param string) bool {
+	if applyCountRule(&s.MinLength, &s.MaxLength, name, param) {
+		return true
+	}
+	if format, ok := _stringFormats[name]; ok && s.Format == "" {
+		s.Format = format
+		return true
+	}
+	pattern, ok := _stringPatterns[name]
+	switch name {
+	case "startswith":
+		pattern, ok = "^"+regexp.QuoteMeta(param), param != ""
+	case "endswith":
+		pattern, ok = regexp.QuoteMeta(param)+"$", param != ""
+	case "contains":
+		pattern, ok = regexp.QuoteMeta(param), param != ""
+	case "eq":
+		s.Const = param
+		return true
+	case "ne":
+		s.Not = &schemaObject{Const: param}
+		return true
+	case "oneof":
+		for _, v := range strings.Fields(param) {
+			s.Enum = append(s.Enum, v)
+		}
+		return len(s.Enum) > 0
+	}
+	if ok && s.Pattern == "" {
+		s.Pattern = pattern
+		return true
+	}
+	return false
+}
+
+func applyNumberRule(s *schemaObject, name, param string) bool {
+	if name == "oneof" {
+		var enum []interface{}
+		for _, v := range strings.Fields(param) {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return false
+			}
+			enum = append(enum, f)
+		}
+		s.Enum = enum
+		return len(enum) > 0
+	}
+	f, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return false
+	}
+	switch name {
+	case "min", "gte":
+		s.Minimum = &f
+	case "max", "lte":
+		s.Maximum = &f
+	case "gt":
+		s.ExclusiveMinimum = &f
+	case "lt":
+		s.ExclusiveMaximum = &f
+	case "eq", "len":
+		s.Const = f
+	case "ne":
+		s.Not = &schemaObject{Const: f}
+	default:
+		return false
+	}
+	return true
+}
+
+// applyCountRule maps the rules of length, items count or properties count.
+func applyCountRule(min, max **uint64, name, param string) bool {
+	n, err := strconv.ParseUint(param, 10, 64)
+	if err != nil {
+		return false
+	}
+	switch name {
+	case "min", "gte":
+		*min = &n
+	case "max", "lte":
+		*max = &n
+	case "len":
+		*min, *max = &n, &n
+	case "gt":
+		n++
+		*min = &n
+	case "lt":
+		if n == 0 {
+			return false
+		}
+		n--
+		*max = &n
+	default:
+		return false
+	}
+	return true
+}
+
+func setTrue(b *bool) bool {
+	*b = true
+	return true
+}
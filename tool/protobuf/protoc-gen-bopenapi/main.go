@@ -0,0 +1,23 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"kratos/tool/protobuf/pkg/gen"
+	"kratos/tool/protobuf/pkg/generator"
+	openapigen "kratos/tool/protobuf/protoc-gen-bopenapi/generator"
+)
+
+func main() {
+	versionFlag := flag.Bool("version", false, "print version and exit")
+	flag.Parse()
+	if *versionFlag {
+		fmt.Println(generator.Version)
+		os.Exit(0)
+	}
+
+	g := openapigen.OpenAPIGenerator()
+	gen.Main(g)
+}